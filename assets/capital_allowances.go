@@ -0,0 +1,149 @@
+package assets
+
+import (
+	"math"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/tax"
+)
+
+// CapitalAllowances are deducted from the profit instead of the cost of fixed assets
+type CapitalAllowances struct {
+	PeriodStart               time.Time
+	PeriodEnd                 time.Time // exclusive
+	AnnualInvestmentAllowance float64
+	FullExpensing             float64
+	MainPoolAllowance         float64 // writing-down allowance or the small pool allowance
+	SpecialRateAllowance      float64
+	BalancingCharges          float64 // disposals for more than what is left in the pool, added to the profit
+	MainPoolCarriedForward    float64 // tax written down value
+	SpecialRateCarriedForward float64
+	Total                     float64
+}
+
+// CalculateCapitalAllowances calculates the capital allowances for the accounting period starting on
+// periodStart. The main and special rate pools are carried forward from the period of the earliest asset,
+// so all the register should be passed. Please refer to unit tests for examples
+func CalculateCapitalAllowances(fixedAssets []db.FixedAsset, periodStart time.Time) CapitalAllowances {
+	start := periodStart
+	for _, a := range fixedAssets {
+		for a.PurchaseDate.Before(start) {
+			start = start.AddDate(-1, 0, 0)
+		}
+	}
+
+	var allowances CapitalAllowances
+	for ; !start.After(periodStart); start = start.AddDate(1, 0, 0) {
+		allowances = calculatePeriod(fixedAssets, start, start.AddDate(1, 0, 0),
+			allowances.MainPoolCarriedForward, allowances.SpecialRateCarriedForward)
+	}
+	return allowances
+}
+
+func calculatePeriod(fixedAssets []db.FixedAsset, start, end time.Time, mainPool, specialPool float64) CapitalAllowances {
+	rates := tax.GetCapitalAllowanceRates(tax.GetFinYear(start))
+	allowances := CapitalAllowances{PeriodStart: start, PeriodEnd: end}
+
+	var aiaAdditions float64
+	for _, a := range fixedAssets {
+		if within(a.PurchaseDate, start, end) {
+			switch a.Pool {
+			case db.MainPool:
+				mainPool = mainPool + a.Cost
+			case db.SpecialRatePool:
+				specialPool = specialPool + a.Cost
+			case db.FullExpensing:
+				if rates.FullExpensing {
+					allowances.FullExpensing = allowances.FullExpensing + a.Cost
+				} else {
+					aiaAdditions = aiaAdditions + a.Cost
+				}
+			default:
+				aiaAdditions = aiaAdditions + a.Cost
+			}
+		}
+
+		if a.IsDisposed() && within(a.DisposalDate, start, end) {
+			// the proceeds are deducted from the pool, but never more than the cost
+			proceeds := math.Min(a.DisposalProceeds, a.Cost)
+			switch {
+			case a.Pool == db.SpecialRatePool:
+				specialPool = specialPool - proceeds
+			case isFullyExpensed(a, start):
+				allowances.BalancingCharges = allowances.BalancingCharges + proceeds
+			default:
+				mainPool = mainPool - proceeds
+			}
+		}
+	}
+
+	// the AIA above the limit goes to the main pool
+	allowances.AnnualInvestmentAllowance = math.Min(aiaAdditions, rates.AnnualInvestmentAllowance)
+	mainPool = mainPool + aiaAdditions - allowances.AnnualInvestmentAllowance
+
+	allowances.MainPoolAllowance, allowances.MainPoolCarriedForward = writeDown(mainPool, rates.MainRate, rates.SmallPoolLimit, &allowances)
+	allowances.SpecialRateAllowance, allowances.SpecialRateCarriedForward = writeDown(specialPool, rates.SpecialRate, rates.SmallPoolLimit, &allowances)
+
+	allowances.Total = round(allowances.AnnualInvestmentAllowance + allowances.FullExpensing +
+		allowances.MainPoolAllowance + allowances.SpecialRateAllowance - allowances.BalancingCharges)
+	return allowances
+}
+
+// returns the writing-down allowance and what is carried forward. A negative pool is a balancing charge
+func writeDown(pool, rate, smallPoolLimit float64, allowances *CapitalAllowances) (float64, float64) {
+	if pool < 0 {
+		allowances.BalancingCharges = allowances.BalancingCharges - pool
+		return 0, 0
+	}
+	if pool <= smallPoolLimit {
+		return pool, 0
+	}
+	allowance := round(pool * rate)
+	return allowance, round(pool - allowance)
+}
+
+// full expensing could be claimed only if it was available in the period when the asset was bought,
+// otherwise the asset got the AIA and its disposal goes to the main pool
+func isFullyExpensed(a db.FixedAsset, periodStart time.Time) bool {
+	if a.Pool != db.FullExpensing {
+		return false
+	}
+	for a.PurchaseDate.Before(periodStart) {
+		periodStart = periodStart.AddDate(-1, 0, 0)
+	}
+	return tax.GetCapitalAllowanceRates(tax.GetFinYear(periodStart)).FullExpensing
+}
+
+func within(date, start, end time.Time) bool {
+	return !date.Before(start) && date.Before(end)
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// GetRegister returns the fixed asset register. "Fixed assets purchase" transactions which are not registered yet
+// are treated as assets claiming the Annual Investment Allowance, as most small companies do
+func GetRegister(d *db.Database) ([]db.FixedAsset, error) {
+	register, err := d.GetFixedAssets()
+	if err != nil {
+		return nil, err
+	}
+
+	purchases, err := d.GetUnregisteredAssetPurchases()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range purchases {
+		register = append(register, db.FixedAsset{
+			Name:          t.Description,
+			Cost:          math.Abs(t.Debit),
+			PurchaseDate:  t.Date,
+			Pool:          db.AnnualInvestmentAllowance,
+			TransactionPk: t.Pk,
+		})
+	}
+	return register, nil
+}
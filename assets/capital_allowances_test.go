@@ -0,0 +1,92 @@
+package assets
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/w32blaster/tax-bookkeeper/conf"
+	"github.com/w32blaster/tax-bookkeeper/db"
+)
+
+func TestCalculateCapitalAllowances(t *testing.T) {
+	var tests = []struct {
+		name        string
+		assets      []db.FixedAsset
+		periodStart string
+		expected    CapitalAllowances
+	}{
+		{"AIA", []db.FixedAsset{
+			{Cost: 3000, PurchaseDate: dateOf("01-05-2024"), Pool: db.AnnualInvestmentAllowance},
+		}, "01-04-2024", CapitalAllowances{AnnualInvestmentAllowance: 3000, Total: 3000}},
+
+		// the limit was £200,000 in 2018, the rest goes to the main pool: 50000 x 18% = 9000
+		{"AIA above the limit", []db.FixedAsset{
+			{Cost: 250000, PurchaseDate: dateOf("01-05-2018"), Pool: db.AnnualInvestmentAllowance},
+		}, "01-04-2018", CapitalAllowances{AnnualInvestmentAllowance: 200000, MainPoolAllowance: 9000,
+			MainPoolCarriedForward: 41000, Total: 209000}},
+
+		// 10000 x 18% = 1800 in the first year, then (10000 - 1800) x 18% = 1476
+		{"main pool is carried forward", []db.FixedAsset{
+			{Cost: 10000, PurchaseDate: dateOf("01-06-2022"), Pool: db.MainPool},
+		}, "01-04-2023", CapitalAllowances{MainPoolAllowance: 1476, MainPoolCarriedForward: 6724, Total: 1476}},
+
+		{"special rate pool", []db.FixedAsset{
+			{Cost: 20000, PurchaseDate: dateOf("01-06-2024"), Pool: db.SpecialRatePool},
+		}, "01-04-2024", CapitalAllowances{SpecialRateAllowance: 1200, SpecialRateCarriedForward: 18800, Total: 1200}},
+
+		{"small pool is written off", []db.FixedAsset{
+			{Cost: 900, PurchaseDate: dateOf("01-06-2024"), Pool: db.MainPool},
+		}, "01-04-2024", CapitalAllowances{MainPoolAllowance: 900, Total: 900}},
+
+		{"full expensing", []db.FixedAsset{
+			{Cost: 5000, PurchaseDate: dateOf("01-06-2024"), Pool: db.FullExpensing},
+		}, "01-04-2024", CapitalAllowances{FullExpensing: 5000, Total: 5000}},
+
+		{"full expensing was not available before April 2023", []db.FixedAsset{
+			{Cost: 5000, PurchaseDate: dateOf("01-06-2022"), Pool: db.FullExpensing},
+		}, "01-04-2022", CapitalAllowances{AnnualInvestmentAllowance: 5000, Total: 5000}},
+
+		{"disposal of a fully expensed asset", []db.FixedAsset{
+			{Cost: 5000, PurchaseDate: dateOf("01-06-2023"), Pool: db.FullExpensing,
+				DisposalDate: dateOf("01-06-2024"), DisposalProceeds: 2000},
+		}, "01-04-2024", CapitalAllowances{BalancingCharges: 2000, Total: -2000}},
+
+		// the pool is 8200 after the first year, the asset is sold for more
+		{"disposal from the main pool", []db.FixedAsset{
+			{Cost: 10000, PurchaseDate: dateOf("01-06-2022"), Pool: db.MainPool,
+				DisposalDate: dateOf("01-06-2023"), DisposalProceeds: 9000},
+		}, "01-04-2023", CapitalAllowances{BalancingCharges: 800, Total: -800}},
+
+		// the asset got the AIA, so the main pool is negative
+		{"disposal of an AIA asset", []db.FixedAsset{
+			{Cost: 3000, PurchaseDate: dateOf("01-06-2022"), Pool: db.AnnualInvestmentAllowance,
+				DisposalDate: dateOf("01-06-2023"), DisposalProceeds: 500},
+			{Cost: 5000, PurchaseDate: dateOf("01-06-2023"), Pool: db.MainPool},
+		}, "01-04-2023", CapitalAllowances{MainPoolAllowance: 810, MainPoolCarriedForward: 3690, Total: 810}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// When:
+			allowances := CalculateCapitalAllowances(tt.assets, dateOf(tt.periodStart))
+
+			// Then:
+			tt.expected.PeriodStart = dateOf(tt.periodStart)
+			tt.expected.PeriodEnd = dateOf(tt.periodStart).AddDate(1, 0, 0)
+			assert.Equal(t, tt.expected, allowances)
+		})
+	}
+}
+
+// shorthand for the date creation, like "01-03-2021"
+func dateOf(date string) time.Time {
+	parts := strings.Split(date, "-")
+	year, _ := strconv.Atoi(parts[2])
+	month, _ := strconv.Atoi(parts[1])
+	day, _ := strconv.Atoi(parts[0])
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, conf.GMT)
+}
@@ -0,0 +1,189 @@
+package assets
+
+import (
+	"math"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/conf"
+	"github.com/w32blaster/tax-bookkeeper/db"
+)
+
+const (
+	defaultUsefulLifeYears = 3    // computers and office equipment
+	defaultReducingRate    = 0.25 // vans, machinery
+	monthsInYear           = 12
+)
+
+type Frequency int
+
+const (
+	Monthly Frequency = 1 + iota
+	Annual
+)
+
+type (
+	// DepreciationCharge is the depreciation of one asset for a month or a year
+	DepreciationCharge struct {
+		AssetPk      int
+		Asset        string
+		PeriodStart  time.Time
+		PeriodEnd    time.Time // exclusive
+		Amount       float64
+		NetBookValue float64 // at the end of the period
+	}
+
+	// Depreciation is the book depreciation of the whole register for an accounting period. It is an expense in the
+	// profit and loss account, but it is added back in the corporation tax computation because
+	// capital allowances are deducted instead
+	Depreciation struct {
+		PeriodStart   time.Time
+		PeriodEnd     time.Time // exclusive
+		Charge        float64
+		DisposalGains float64 // proceeds minus the net book value of assets disposed in the period, negative is a loss
+		NetBookValue  float64 // of the assets still in use at the end of the period
+	}
+)
+
+// GetDepreciationSchedule returns depreciation charges of the asset until the given date. Annual charges are aligned
+// with the accounting periods, so yearStart is the start date of any accounting period.
+// The full charge is made in the month of purchase and nothing in the month of disposal
+func GetDepreciationSchedule(a db.FixedAsset, frequency Frequency, yearStart time.Time, until time.Time) []DepreciationCharge {
+	charges := monthlyCharges(a, until)
+	if frequency == Monthly || len(charges) == 0 {
+		return charges
+	}
+
+	for yearStart.After(charges[0].PeriodStart) {
+		yearStart = yearStart.AddDate(-1, 0, 0)
+	}
+
+	var annual []DepreciationCharge
+	for _, c := range charges {
+		if len(annual) == 0 || !c.PeriodStart.Before(annual[len(annual)-1].PeriodEnd) {
+			for !c.PeriodStart.Before(yearStart.AddDate(1, 0, 0)) {
+				yearStart = yearStart.AddDate(1, 0, 0)
+			}
+			annual = append(annual, DepreciationCharge{
+				AssetPk:     a.Pk,
+				Asset:       a.Name,
+				PeriodStart: yearStart,
+				PeriodEnd:   yearStart.AddDate(1, 0, 0),
+			})
+		}
+		last := &annual[len(annual)-1]
+		last.Amount = round(last.Amount + c.Amount)
+		last.NetBookValue = c.NetBookValue
+	}
+	return annual
+}
+
+// NetBookValue is the cost minus the depreciation charged for the months ended by the date
+func NetBookValue(a db.FixedAsset, date time.Time) float64 {
+	nbv := a.Cost
+	for _, c := range monthlyCharges(a, date) {
+		if !c.PeriodEnd.After(date) {
+			nbv = c.NetBookValue
+		}
+	}
+	return nbv
+}
+
+// DisposalGainOrLoss is what the asset was sold for minus its net book value, negative is a loss
+func DisposalGainOrLoss(a db.FixedAsset) float64 {
+	if !a.IsDisposed() {
+		return 0
+	}
+	return round(a.DisposalProceeds - NetBookValue(a, a.DisposalDate))
+}
+
+// CalculateDepreciation calculates the book depreciation of the register for the accounting period starting
+// on periodStart. Please refer to unit tests for examples
+func CalculateDepreciation(fixedAssets []db.FixedAsset, periodStart time.Time) Depreciation {
+	depreciation := Depreciation{PeriodStart: periodStart, PeriodEnd: periodStart.AddDate(1, 0, 0)}
+
+	for _, a := range fixedAssets {
+		if !a.PurchaseDate.Before(depreciation.PeriodEnd) {
+			continue
+		}
+
+		for _, c := range monthlyCharges(a, depreciation.PeriodEnd) {
+			if !c.PeriodStart.Before(periodStart) {
+				depreciation.Charge = depreciation.Charge + c.Amount
+			}
+		}
+
+		switch {
+		case a.IsDisposed() && within(a.DisposalDate, periodStart, depreciation.PeriodEnd):
+			depreciation.DisposalGains = depreciation.DisposalGains + DisposalGainOrLoss(a)
+		case !a.IsDisposed() || !a.DisposalDate.Before(depreciation.PeriodEnd):
+			depreciation.NetBookValue = depreciation.NetBookValue + NetBookValue(a, depreciation.PeriodEnd)
+		}
+	}
+
+	depreciation.Charge = round(depreciation.Charge)
+	depreciation.DisposalGains = round(depreciation.DisposalGains)
+	depreciation.NetBookValue = round(depreciation.NetBookValue)
+	return depreciation
+}
+
+// monthly charges starting from the month of purchase. Yearly charges are spread over months so that
+// the pennies add up to the yearly amount
+func monthlyCharges(a db.FixedAsset, until time.Time) []DepreciationCharge {
+	month := time.Date(a.PurchaseDate.Year(), a.PurchaseDate.Month(), 1, 0, 0, 0, 0, conf.GMT)
+	var lastMonth time.Time
+	if a.IsDisposed() {
+		lastMonth = time.Date(a.DisposalDate.Year(), a.DisposalDate.Month(), 1, 0, 0, 0, 0, conf.GMT)
+	}
+
+	var charges []DepreciationCharge
+	nbv, yearCharge := a.Cost, 0.0
+	for i := 0; month.Before(until) && (lastMonth.IsZero() || month.Before(lastMonth)); i++ {
+		var amount float64
+		if a.Depreciation == db.ReducingBalance {
+			if i%monthsInYear == 0 {
+				yearCharge = math.Min(round(nbv*reducingRate(a)), nbv-a.ResidualValue)
+			}
+			amount = spread(yearCharge, i%monthsInYear, monthsInYear)
+		} else {
+			months := usefulLife(a) * monthsInYear
+			if i >= months {
+				break
+			}
+			amount = spread(a.Cost-a.ResidualValue, i, months)
+		}
+
+		if amount <= 0 {
+			break
+		}
+		nbv = round(nbv - amount)
+		charges = append(charges, DepreciationCharge{
+			AssetPk:      a.Pk,
+			Asset:        a.Name,
+			PeriodStart:  month,
+			PeriodEnd:    month.AddDate(0, 1, 0),
+			Amount:       amount,
+			NetBookValue: nbv,
+		})
+		month = month.AddDate(0, 1, 0)
+	}
+	return charges
+}
+
+// the part of the total for the n-th of the months, the rounding difference goes to the later months
+func spread(total float64, n, months int) float64 {
+	return round(round(total*float64(n+1)/float64(months)) - round(total*float64(n)/float64(months)))
+}
+
+func usefulLife(a db.FixedAsset) int {
+	if a.UsefulLifeYears > 0 {
+		return a.UsefulLifeYears
+	}
+	return defaultUsefulLifeYears
+}
+
+func reducingRate(a db.FixedAsset) float64 {
+	if a.ReducingRate > 0 {
+		return a.ReducingRate
+	}
+	return defaultReducingRate
+}
@@ -0,0 +1,96 @@
+package assets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/w32blaster/tax-bookkeeper/db"
+)
+
+func TestCalculateDepreciation(t *testing.T) {
+	var tests = []struct {
+		name        string
+		assets      []db.FixedAsset
+		periodStart string
+		expected    Depreciation
+	}{
+		// 3600 / 36 months = 100 a month, May - March
+		{"straight line, the first year", []db.FixedAsset{
+			{Cost: 3600, PurchaseDate: dateOf("15-05-2024"), Depreciation: db.StraightLine, UsefulLifeYears: 3},
+		}, "01-04-2024", Depreciation{Charge: 1100, NetBookValue: 2500}},
+
+		{"straight line, the next year", []db.FixedAsset{
+			{Cost: 3600, PurchaseDate: dateOf("15-05-2024"), Depreciation: db.StraightLine, UsefulLifeYears: 3},
+		}, "01-04-2025", Depreciation{Charge: 1200, NetBookValue: 1300}},
+
+		{"straight line stops at the residual value", []db.FixedAsset{
+			{Cost: 4000, PurchaseDate: dateOf("01-04-2022"), Depreciation: db.StraightLine, UsefulLifeYears: 2, ResidualValue: 400},
+		}, "01-04-2024", Depreciation{NetBookValue: 400}},
+
+		// 10000 x 25% = 2500, then 7500 x 25% = 1875
+		{"reducing balance", []db.FixedAsset{
+			{Cost: 10000, PurchaseDate: dateOf("01-04-2024"), Depreciation: db.ReducingBalance, ReducingRate: 0.25},
+		}, "01-04-2025", Depreciation{Charge: 1875, NetBookValue: 5625}},
+
+		{"the default is straight line over 3 years", []db.FixedAsset{
+			{Cost: 1000, PurchaseDate: dateOf("01-04-2024")},
+		}, "01-04-2024", Depreciation{Charge: 333.33, NetBookValue: 666.67}},
+
+		// charged April 2024 - May 2025, the net book value is 3600 - 1400 = 2200
+		{"disposal at a loss", []db.FixedAsset{
+			{Cost: 3600, PurchaseDate: dateOf("01-04-2024"), UsefulLifeYears: 3,
+				DisposalDate: dateOf("10-06-2025"), DisposalProceeds: 2000},
+		}, "01-04-2025", Depreciation{Charge: 200, DisposalGains: -200}},
+
+		{"disposal at a gain", []db.FixedAsset{
+			{Cost: 3600, PurchaseDate: dateOf("01-04-2024"), UsefulLifeYears: 3,
+				DisposalDate: dateOf("10-06-2025"), DisposalProceeds: 2500},
+			{Cost: 1200, PurchaseDate: dateOf("01-01-2026"), UsefulLifeYears: 1},
+		}, "01-04-2025", Depreciation{Charge: 500, DisposalGains: 300, NetBookValue: 900}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// When:
+			depreciation := CalculateDepreciation(tt.assets, dateOf(tt.periodStart))
+
+			// Then:
+			tt.expected.PeriodStart = dateOf(tt.periodStart)
+			tt.expected.PeriodEnd = dateOf(tt.periodStart).AddDate(1, 0, 0)
+			assert.Equal(t, tt.expected, depreciation)
+		})
+	}
+}
+
+func TestGetDepreciationScheduleAnnual(t *testing.T) {
+
+	// Given:
+	asset := db.FixedAsset{Pk: 1, Name: "Laptop", Cost: 3600, PurchaseDate: dateOf("15-05-2024"), UsefulLifeYears: 3}
+
+	// When:
+	charges := GetDepreciationSchedule(asset, Annual, dateOf("01-04-2020"), dateOf("01-01-2030"))
+
+	// Then:
+	assert.Equal(t, []DepreciationCharge{
+		{1, "Laptop", dateOf("01-04-2024"), dateOf("01-04-2025"), 1100, 2500},
+		{1, "Laptop", dateOf("01-04-2025"), dateOf("01-04-2026"), 1200, 1300},
+		{1, "Laptop", dateOf("01-04-2026"), dateOf("01-04-2027"), 1200, 100},
+		{1, "Laptop", dateOf("01-04-2027"), dateOf("01-04-2028"), 100, 0},
+	}, charges)
+}
+
+func TestGetDepreciationScheduleMonthly(t *testing.T) {
+
+	// Given: 1000 / 36 months doesn't divide, but the pennies add up
+	asset := db.FixedAsset{Cost: 1000, PurchaseDate: dateOf("01-04-2024")}
+
+	// When:
+	charges := GetDepreciationSchedule(asset, Monthly, dateOf("01-04-2024"), dateOf("01-01-2030"))
+
+	// Then:
+	assert.Len(t, charges, 36)
+	assert.Equal(t, 27.78, charges[0].Amount)
+	assert.Equal(t, 0.0, charges[35].NetBookValue)
+	assert.Equal(t, 972.22, NetBookValue(asset, dateOf("15-05-2024")))
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/w32blaster/tax-bookkeeper/db"
+)
+
+// "account list", "account add -code=7800 -name='Software subscriptions' -type=expense -parent=7090 -vat-rate=0.2"
+// or "account update -code=7800 -ct-allowable=false"
+func commandAccount(d *db.Database, args []string) error {
+	action := "list"
+	if len(args) > 0 {
+		action = args[0]
+		args = args[1:]
+	}
+
+	fs := flag.NewFlagSet("account "+action, flag.ContinueOnError)
+
+	switch action {
+	case "list":
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		chart, err := d.GetAccounts()
+		if err != nil {
+			return err
+		}
+		groups := map[string]bool{}
+		for _, a := range chart {
+			groups[a.Parent] = true
+		}
+		for _, a := range chart {
+			printAccount(a, groups[a.Code])
+		}
+		return nil
+
+	case "add", "update":
+		code := fs.String("code", "", "account code, like 7800")
+		name := fs.String("name", "", "account name, like 'Software subscriptions'")
+		accountType := fs.String("type", "expense", "'asset', 'liability', 'equity', 'income' or 'expense'")
+		parent := fs.String("parent", "", "code of the account it is grouped under")
+		allowable := fs.Bool("ct-allowable", true, "expenses are deductible for corporation tax")
+		vatRate := fs.Float64("vat-rate", 0, "the default VAT rate of receipts and purchases without an invoice, like 0.2")
+		capital := fs.Bool("capital", false, "purchases are fixed assets, capital allowances are claimed instead")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+
+		account := db.Account{Code: *code}
+		if action == "update" {
+			var err error
+			if account, err = d.GetAccount(*code); err != nil {
+				return errors.New("there is no account '" + *code + "' in the chart of accounts")
+			}
+		}
+
+		// when an account is updated, only the given parameters are changed
+		var err error
+		fs.Visit(func(f *flag.Flag) {
+			if action == "add" || err != nil {
+				return
+			}
+			switch f.Name {
+			case "name":
+				account.Name = *name
+			case "type":
+				account.Type, err = db.ParseAccountType(*accountType)
+			case "parent":
+				account.Parent = *parent
+			case "ct-allowable":
+				account.CTAllowable = *allowable
+			case "vat-rate":
+				account.VATRate = *vatRate
+			case "capital":
+				account.Capital = *capital
+			}
+		})
+		if action == "add" {
+			account.Name, account.Parent = *name, *parent
+			account.CTAllowable, account.VATRate, account.Capital = *allowable, *vatRate, *capital
+			account.Type, err = db.ParseAccountType(*accountType)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := d.SaveAccount(&account); err != nil {
+			return err
+		}
+		printAccount(account, false)
+		return nil
+	}
+
+	return errors.New("unknown account action '" + action + "', it should be 'list', 'add' or 'update'")
+}
+
+// the tax treatment is not shown for groups, nothing is posted to them
+func printAccount(a db.Account, isGroup bool) {
+	var treatment []string
+	if isGroup {
+		treatment = append(treatment, "group")
+	} else if a.Type == db.ExpenseAccount && !a.CTAllowable {
+		treatment = append(treatment, "disallowable")
+	}
+	if a.VATRate > 0 {
+		treatment = append(treatment, fmt.Sprintf("VAT %.0f%%", a.VATRate*100))
+	}
+	if a.Capital {
+		treatment = append(treatment, "capital")
+	}
+
+	indent := ""
+	if a.Parent != "" {
+		indent = "  "
+	}
+	fmt.Printf("%s%s   %-9s   %s   %s\n", indent, a.Code, a.Type.PrettyString(), a.Name, strings.Join(treatment, ", "))
+}
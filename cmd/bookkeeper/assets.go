@@ -0,0 +1,194 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/assets"
+	"github.com/w32blaster/tax-bookkeeper/conf"
+	"github.com/w32blaster/tax-bookkeeper/db"
+)
+
+// "asset add|dispose|list|allowances|depreciation ..."
+func commandAsset(d *db.Database, args []string) error {
+	if len(args) == 0 {
+		return errors.New("please specify the asset action: add, dispose, list, allowances or depreciation")
+	}
+
+	fs := flag.NewFlagSet("asset "+args[0], flag.ContinueOnError)
+
+	switch args[0] {
+	case "add":
+		name := fs.String("name", "", "asset name, like 'MacBook Pro'")
+		cost := fs.Float64("cost", 0, "cost in £")
+		date := fs.String("date", "", "purchase date, for example 02-01-2021")
+		pool := fs.String("pool", "aia", "capital allowance: 'aia', 'main', 'special' or 'full-expensing'")
+		transactionPk := fs.Int("transaction", 0, "ID of the 'Fixed assets purchase' transaction, the cost and the date are taken from it")
+		method := fs.String("depreciation", "straight-line", "depreciation in the accounts: 'straight-line' or 'reducing-balance'")
+		life := fs.Int("life", 3, "useful life in years, for the straight line depreciation")
+		rate := fs.Float64("rate", 0.25, "yearly rate of the reducing balance depreciation")
+		residual := fs.Float64("residual", 0, "residual value in £, the asset is not depreciated below it")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		asset := db.FixedAsset{Name: *name, Cost: *cost, TransactionPk: *transactionPk,
+			UsefulLifeYears: *life, ReducingRate: *rate, ResidualValue: *residual}
+
+		var err error
+		if asset.Pool, err = db.ParseCapitalAllowancePool(*pool); err != nil {
+			return err
+		}
+		if asset.Depreciation, err = db.ParseDepreciationMethod(*method); err != nil {
+			return err
+		}
+
+		if *transactionPk != 0 {
+			t, err := d.GetTransaction(*transactionPk)
+			if err != nil {
+				return err
+			}
+			asset.Cost, asset.PurchaseDate = math.Abs(t.Debit), t.Date
+			if asset.Name == "" {
+				asset.Name = t.Description
+			}
+		}
+		if *date != "" {
+			if asset.PurchaseDate, err = parseCommandDate(*date); err != nil {
+				return err
+			}
+		}
+		if asset.PurchaseDate.IsZero() || asset.Cost <= 0 {
+			return errors.New("please specify -cost and -date, or -transaction")
+		}
+
+		if err := d.SaveFixedAsset(&asset); err != nil {
+			return err
+		}
+		fmt.Printf("Asset %s is added with ID %d\n", asset.Name, asset.Pk)
+
+	case "dispose":
+		assetPk := fs.Int("id", 0, "asset ID")
+		date := fs.String("date", "", "disposal date, for example 02-01-2021")
+		proceeds := fs.Float64("proceeds", 0, "how much the asset was sold for, 0 if scrapped")
+		transactionPk := fs.Int("transaction", 0, "ID of the bank transaction which received the proceeds")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		disposed, err := parseCommandDate(*date)
+		if err != nil {
+			return err
+		}
+		if *transactionPk != 0 {
+			if _, err := d.GetTransaction(*transactionPk); err != nil {
+				return err
+			}
+		}
+		if err := d.DisposeFixedAsset(*assetPk, disposed, *proceeds, *transactionPk); err != nil {
+			return err
+		}
+
+		register, err := d.GetFixedAssets()
+		if err != nil {
+			return err
+		}
+		for _, a := range register {
+			if a.Pk == *assetPk {
+				fmt.Printf("Net book value £%.2f, gain on disposal £%.2f\n", assets.NetBookValue(a, a.DisposalDate), assets.DisposalGainOrLoss(a))
+			}
+		}
+
+	case "list":
+		register, err := assets.GetRegister(d)
+		if err != nil {
+			return err
+		}
+		for _, a := range register {
+			printFixedAsset(a)
+		}
+
+	case "allowances":
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		register, err := assets.GetRegister(d)
+		if err != nil {
+			return err
+		}
+		accPeriod, err := getNearestAccountingDate(accountingPeriodStartDate, time.Now().In(conf.GMT))
+		if err != nil {
+			return err
+		}
+
+		a := assets.CalculateCapitalAllowances(register, accPeriod)
+		fmt.Printf("Capital allowances for %s - %s\n\n", a.PeriodStart.Format("02 Jan 2006"),
+			a.PeriodEnd.AddDate(0, 0, -1).Format("02 Jan 2006"))
+		fmt.Printf("Annual Investment Allowance:  £%.2f\n", a.AnnualInvestmentAllowance)
+		fmt.Printf("Full expensing:               £%.2f\n", a.FullExpensing)
+		fmt.Printf("Main pool allowance:          £%.2f (carried forward £%.2f)\n", a.MainPoolAllowance, a.MainPoolCarriedForward)
+		fmt.Printf("Special rate pool allowance:  £%.2f (carried forward £%.2f)\n", a.SpecialRateAllowance, a.SpecialRateCarriedForward)
+		fmt.Printf("Balancing charges:            £%.2f\n", a.BalancingCharges)
+		fmt.Printf("Total:                        £%.2f\n", a.Total)
+
+	case "depreciation":
+		monthly := fs.Bool("monthly", false, "show monthly charges instead of yearly")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		register, err := assets.GetRegister(d)
+		if err != nil {
+			return err
+		}
+		accPeriod, err := getNearestAccountingDate(accountingPeriodStartDate, time.Now().In(conf.GMT))
+		if err != nil {
+			return err
+		}
+
+		frequency, until := assets.Annual, accPeriod.AddDate(1, 0, 0)
+		if *monthly {
+			frequency = assets.Monthly
+		}
+		for _, a := range register {
+			printFixedAsset(a)
+			for _, c := range assets.GetDepreciationSchedule(a, frequency, accPeriod, until) {
+				fmt.Printf("   %s - %s  charge £%.2f, net book value £%.2f\n", c.PeriodStart.Format("02 Jan 2006"),
+					c.PeriodEnd.AddDate(0, 0, -1).Format("02 Jan 2006"), c.Amount, c.NetBookValue)
+			}
+		}
+
+		dep := assets.CalculateDepreciation(register, accPeriod)
+		fmt.Printf("\nDepreciation for the current accounting period £%.2f, gain on disposals £%.2f, net book value £%.2f\n",
+			dep.Charge, dep.DisposalGains, dep.NetBookValue)
+
+	default:
+		return errors.New("unknown asset action '" + args[0] + "'")
+	}
+
+	return nil
+}
+
+func printFixedAsset(a db.FixedAsset) {
+	id := fmt.Sprintf("%d", a.Pk)
+	if a.Pk == 0 {
+		id = "not registered"
+	}
+
+	fmt.Printf("Asset %s: %s, £%.2f bought %s, %s, %s depreciation", id, a.Name, a.Cost, a.PurchaseDate.Format("02 Jan 2006"),
+		a.Pool.PrettyString(), strings.ToLower(depreciationMethod(a).PrettyString()))
+	if a.IsDisposed() {
+		fmt.Printf(", disposed %s for £%.2f", a.DisposalDate.Format("02 Jan 2006"), a.DisposalProceeds)
+	}
+	fmt.Println()
+}
+
+// assets registered before depreciation was supported have no method, straight line is used for them
+func depreciationMethod(a db.FixedAsset) db.DepreciationMethod {
+	if a.Depreciation == 0 {
+		return db.StraightLine
+	}
+	return a.Depreciation
+}
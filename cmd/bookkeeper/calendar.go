@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/conf"
+	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/tax"
+)
+
+const icsDateFormat = "20060102"
+
+// "calendar [-ics] [-months=12]"
+func commandCalendar(d *db.Database, args []string) error {
+	fs := flag.NewFlagSet("calendar", flag.ContinueOnError)
+	ics := fs.Bool("ics", false, "print deadlines in the iCalendar format, for example: calendar -ics > deadlines.ics")
+	months := fs.Int("months", 12, "how many months ahead")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	now := time.Now().In(conf.GMT)
+	accPeriod, err := getNearestAccountingDate(accountingPeriodStartDate, now)
+	if err != nil {
+		return err
+	}
+	calendar, err := getCompanyCalendar(d, accPeriod)
+	if err != nil {
+		return err
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, conf.GMT)
+	deadlines := tax.GetDeadlines(calendar, today, today.AddDate(0, *months, 0))
+
+	if *ics {
+		writeICS(os.Stdout, deadlines, now)
+		return nil
+	}
+
+	for _, dl := range deadlines {
+		fmt.Printf("%s  %s, %s\n", dl.Date.Format("Mon 02 Jan 2006"), dl.Type.PrettyString(), dl.Description)
+	}
+	return nil
+}
+
+// writes all-day events, https://tools.ietf.org/html/rfc5545
+func writeICS(w io.Writer, deadlines []tax.Deadline, now time.Time) {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//tax-bookkeeper//deadlines//EN",
+		"CALSCALE:GREGORIAN",
+		"X-WR-CALNAME:Tax deadlines",
+	}
+
+	for _, dl := range deadlines {
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			fmt.Sprintf("UID:%s-%d@tax-bookkeeper", dl.Date.Format(icsDateFormat), dl.Type),
+			"DTSTAMP:"+now.UTC().Format("20060102T150405Z"),
+			"DTSTART;VALUE=DATE:"+dl.Date.Format(icsDateFormat),
+			"DTEND;VALUE=DATE:"+dl.Date.AddDate(0, 0, 1).Format(icsDateFormat),
+			"SUMMARY:"+escapeICS(dl.Type.PrettyString()),
+			"DESCRIPTION:"+escapeICS(dl.Description),
+			"END:VEVENT",
+		)
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	fmt.Fprint(w, strings.Join(lines, "\r\n")+"\r\n")
+}
+
+func escapeICS(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(text)
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/conf"
+	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/tax"
+	"github.com/w32blaster/tax-bookkeeper/ui"
+)
+
+const commandDateFormat = "02-01-2006"
+
+// runs a command given after all the flags, for example "invoice -type=sales ..."
+func runCommand(d *db.Database, args []string) error {
+	switch args[0] {
+	case "invoice":
+		return commandAddInvoice(d, args[1:])
+	case "mtd":
+		return commandMTD(args[1:])
+	case "migrate-personal":
+		return commandMigratePersonal(d)
+	case "plan":
+		return commandPlan(args[1:])
+	case "payroll":
+		return commandPayroll(d, args[1:])
+	case "asset":
+		return commandAsset(d, args[1:])
+	case "losses":
+		return commandLosses(d, args[1:])
+	case "calendar":
+		return commandCalendar(d, args[1:])
+	case "expense":
+		return commandExpense(d, args[1:])
+	case "computation":
+		return commandComputation(d, args[1:])
+	case "ct600":
+		return commandCT600(d, args[1:])
+	case "penalties":
+		return commandPenalties(d, args[1:])
+	case "journal":
+		return commandJournal(d, args[1:])
+	case "account":
+		return commandAccount(d, args[1:])
+	case "report":
+		return commandReport(d, args[1:])
+	case "year-end":
+		return commandYearEnd(d, args[1:])
+	case "micro-accounts":
+		return commandMicroAccounts(d, args[1:])
+	case "forecast":
+		return commandForecast(d, args[1:])
+	case "summary":
+		return commandSummary(d, args[1:])
+	}
+	return errors.New("unknown command '" + args[0] + "', please run with -h to see the list of commands")
+}
+
+// records a sales invoice or a supplier bill, it is used for the VAT invoice accounting.
+// "invoice pay -id=3 -transaction=120" links it to the bank transaction for the VAT cash accounting
+func commandAddInvoice(d *db.Database, args []string) error {
+	if len(args) > 0 && args[0] == "pay" {
+		return commandPayInvoice(d, args[1:])
+	}
+
+	fs := flag.NewFlagSet("invoice", flag.ContinueOnError)
+	invoiceType := fs.String("type", "sales", "'sales' for a sales invoice or 'bill' for a supplier bill")
+	number := fs.String("number", "", "invoice number")
+	counterparty := fs.String("counterparty", "", "customer or supplier name")
+	date := fs.String("date", "", "invoice date, for example 02-01-2021")
+	net := fs.Float64("net", 0, "net amount in £")
+	vat := fs.Float64("vat", 0, "VAT amount in £")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	issueDate, err := parseCommandDate(*date)
+	if err != nil {
+		return err
+	}
+
+	invoice := db.Invoice{
+		Number:       *number,
+		Counterparty: *counterparty,
+		IssueDate:    issueDate,
+		Net:          *net,
+		VAT:          *vat,
+	}
+
+	switch *invoiceType {
+	case "sales":
+		invoice.Type = db.SalesInvoice
+	case "bill":
+		invoice.Type = db.SupplierBill
+	default:
+		return errors.New("invoice type must be 'sales' or 'bill'")
+	}
+
+	if err := d.SaveInvoice(&invoice); err != nil {
+		return err
+	}
+
+	fmt.Printf("Invoice %s is saved with ID %d\n", invoice.Number, invoice.Pk)
+	return nil
+}
+
+func commandPayInvoice(d *db.Database, args []string) error {
+	fs := flag.NewFlagSet("invoice pay", flag.ContinueOnError)
+	invoicePk := fs.Int("id", 0, "invoice ID")
+	transactionPk := fs.Int("transaction", 0, "ID of the bank transaction that paid the invoice")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *invoicePk == 0 || *transactionPk == 0 {
+		return errors.New("please specify the invoice with -id and the bank transaction with -transaction")
+	}
+	if err := d.LinkInvoice(*invoicePk, *transactionPk); err != nil {
+		return err
+	}
+
+	fmt.Printf("Invoice %d is paid by the transaction %d\n", *invoicePk, *transactionPk)
+	return nil
+}
+
+// moves transactions of the deprecated Personal category to Salary, Dividend or ExpenseReimbursement
+func commandMigratePersonal(d *db.Database) error {
+	migrated, unallocated, err := d.MigratePersonalTransactions()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Migrated %d transactions, %d transactions should be allocated manually, "+
+		"please run the tool without commands to allocate them\n", migrated, unallocated)
+	return nil
+}
+
+// "plan salary -profit=60000" finds the best salary and dividends split for the tax year
+func commandPlan(args []string) error {
+	if len(args) == 0 || args[0] != "salary" {
+		return errors.New("please specify what to plan, for example 'plan salary -profit=60000'")
+	}
+
+	now := time.Now().In(conf.GMT)
+	fs := flag.NewFlagSet("plan salary", flag.ContinueOnError)
+	profit := fs.Float64("profit", 0, "expected company profit before director's salary")
+	otherIncome := fs.Float64("other-income", 0, "director's other taxable income, not dividends")
+	taxYear := fs.String("tax-year", tax.GetTaxYear(now), "tax year, for example 2025-2026")
+	employmentAllowance := fs.Bool("employment-allowance", false, "company can claim the Employment Allowance (not for sole director companies)")
+	step := fs.Float64("step", 100, "salary step in £")
+	isTUI := fs.Bool("tui", false, "show the result in the terminal UI")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	taxResidency, err := tax.ParseResidency(residency)
+	if err != nil {
+		return err
+	}
+
+	// company profit is taxed in the accounting period which starts within the tax year
+	taxYearStart, err := parseCommandDate("06-04-" + strings.Split(*taxYear, "-")[0])
+	if err != nil {
+		return errors.New("the tax year should be like 2025-2026")
+	}
+	accPeriod, err := getNearestAccountingDate(accountingPeriodStartDate, taxYearStart)
+	if err != nil {
+		return err
+	}
+
+	best, splits := tax.PlanSalaryAndDividends(tax.SalaryPlannerInput{
+		CompanyProfit:         *profit,
+		OtherIncome:           *otherIncome,
+		TaxYear:               *taxYear,
+		Residency:             taxResidency,
+		AccountingPeriodStart: accPeriod,
+		EmploymentAllowance:   *employmentAllowance,
+		Step:                  *step,
+	})
+
+	if *isTUI {
+		gui := ui.TerminalUI{}
+		gui.Start()
+		gui.DrawSalaryPlan(best, splits)
+		return nil
+	}
+
+	fmt.Printf("Tax year %s, company profit £%.2f\n\n", *taxYear, *profit)
+	fmt.Printf("Recommended salary:   £%.2f\n", best.Salary)
+	fmt.Printf("Dividends:            £%.2f\n", best.Dividends)
+	fmt.Printf("Corporation tax:      £%.2f\n", best.CorporationTax)
+	fmt.Printf("Employer's NI:        £%.2f\n", best.EmployerNI)
+	fmt.Printf("Employee's NI:        £%.2f\n", best.EmployeeNI)
+	fmt.Printf("Income tax:           £%.2f\n", best.IncomeTax)
+	fmt.Printf("Dividend tax:         £%.2f\n", best.DividendTax)
+	fmt.Printf("Total tax:            £%.2f\n", best.TotalTax)
+	fmt.Printf("You take home:        £%.2f\n", best.TakeHome)
+	return nil
+}
+
+// parses dates like "02-01-2021" (2nd of January 2021) in GMT
+func parseCommandDate(date string) (time.Time, error) {
+	parsed, err := time.ParseInLocation(commandDateFormat, date, conf.GMT)
+	if err != nil {
+		return time.Time{}, errors.New("the date '" + date + "' is not valid! It should be like '02-01-2021'")
+	}
+	return parsed, nil
+}
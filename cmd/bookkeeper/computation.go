@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/conf"
+	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/ui"
+)
+
+// "expense -id=12 -ct=allowable|disallowable|category -private-use=30"
+func commandExpense(d *db.Database, args []string) error {
+	fs := flag.NewFlagSet("expense", flag.ContinueOnError)
+	transactionPk := fs.Int("id", 0, "transaction ID")
+	treatment := fs.String("ct", "category", "corporation tax treatment: 'allowable', 'disallowable' or 'category' to use the category default")
+	privateUse := fs.Float64("private-use", 0, "percent of a mixed-use cost which is not deductible, like 30")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *transactionPk == 0 {
+		return errors.New("please specify the transaction ID with -id")
+	}
+	if *privateUse < 0 || *privateUse > 100 {
+		return errors.New("-private-use should be between 0 and 100")
+	}
+	ct, err := db.ParseCTTreatment(*treatment)
+	if err != nil {
+		return err
+	}
+
+	if err := d.SetCTTreatment(*transactionPk, ct, *privateUse); err != nil {
+		return err
+	}
+
+	t, err := d.GetTransaction(*transactionPk)
+	if err != nil {
+		return err
+	}
+	account, err := d.GetAccount(t.Account)
+	if err != nil {
+		return errors.New("the transaction is not allocated to an account yet")
+	}
+	fmt.Printf("%s: £%.2f is not deductible for corporation tax\n", t.Description, t.DisallowableAmount(account))
+	return nil
+}
+
+// "computation [-previous]"
+func commandComputation(d *db.Database, args []string) error {
+	fs := flag.NewFlagSet("computation", flag.ContinueOnError)
+	previous := fs.Bool("previous", false, "show the previous accounting period instead of the current one")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	now := time.Now().In(conf.GMT)
+	start, err := getNearestAccountingDate(accountingPeriodStartDate, now)
+	if err != nil {
+		return err
+	}
+	end := now
+	if *previous {
+		start, end = start.AddDate(-1, 0, 0), start
+	}
+
+	vat, err := getRegisteredVATScheme()
+	if err != nil {
+		return err
+	}
+	ct, err := ui.CollectCorporateTax(d, vat, start, end)
+	if err != nil {
+		return err
+	}
+
+	c := ct.Computation
+	fmt.Printf("Corporation tax computation for %s - %s\n\n", ct.StartingDate.Format("02 Jan 2006"), ct.EndingDate.Format("02 Jan 2006"))
+	fmt.Printf("Profit before tax                      £%.2f\n", c.AccountingProfit)
+	for _, a := range c.AddBacks {
+		fmt.Printf("  add %-34s £%.2f\n", a.Description, a.Amount)
+	}
+	for _, a := range c.Deductions {
+		fmt.Printf("  less %-33s £%.2f\n", a.Description, a.Amount)
+	}
+	fmt.Printf("Taxable profit                         £%.2f\n", c.TaxableProfit)
+	fmt.Printf("  less losses relieved                 £%.2f\n", ct.LossesRelieved)
+	fmt.Printf("Corporation tax                        £%.2f\n", ct.CorporateTaxSoFar)
+	return nil
+}
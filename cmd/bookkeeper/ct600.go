@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/statutory"
+	"github.com/w32blaster/tax-bookkeeper/ui"
+)
+
+// "ct600 -period=01-04-2024 [-format=text|xml] [-director='Jane Smith'] [-out=ct600.xml]"
+func commandCT600(d *db.Database, args []string) error {
+	fs := flag.NewFlagSet("ct600", flag.ContinueOnError)
+	period := fs.String("period", "", "the first day of the accounting period, like 01-04-2024")
+	format := fs.String("format", "text", "'text' for the printable summary or 'xml' for the filing software")
+	director := fs.String("director", "", "the name of the director who signs the return, required for XML")
+	out := fs.String("out", "", "the file to write, by default the return is printed")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	start, err := parseAccountingPeriodStart(*period)
+	if err != nil {
+		return err
+	}
+	vat, err := getRegisteredVATScheme()
+	if err != nil {
+		return err
+	}
+	ct, err := ui.CollectCT600(d, vat, start)
+	if err != nil {
+		return err
+	}
+	r := statutory.CT600Return{CompanyName: companyName, CompanyNumber: companyNumber, UTR: companyUTR,
+		Director: *director, CT600: ct}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch *format {
+	case "xml":
+		return statutory.WriteCT600XML(w, r)
+	case "text":
+		fmt.Fprintf(w, "CT600 Company Tax Return for %s - %s\n\n", ct.PeriodStart.Format(commandDateFormat),
+			ct.PeriodEnd.Format(commandDateFormat))
+		for _, b := range r.Boxes() {
+			fmt.Fprintf(w, "%6s  %-62s %14s\n", b.Number, b.Label, b.Value)
+		}
+		if err := r.Check(); err != nil {
+			fmt.Fprintln(w, "\nThe return can't be filed yet: "+err.Error())
+		}
+		return nil
+	}
+	return errors.New("unknown format '" + *format + "', it should be 'text' or 'xml'")
+}
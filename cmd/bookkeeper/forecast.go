@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/conf"
+	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/tax"
+	"github.com/w32blaster/tax-bookkeeper/ui"
+)
+
+// the corporation tax of the next accounting period is due later than that
+const maxForecastMonths = 18
+
+// "forecast [-months=12] [-flows] [-format=text|csv|json]"
+func commandForecast(d *db.Database, args []string) error {
+	fs := flag.NewFlagSet("forecast", flag.ContinueOnError)
+	months := fs.Int("months", 12, "how many months ahead, up to "+strconv.Itoa(maxForecastMonths))
+	flows := fs.Bool("flows", false, "list every expected payment after the table")
+	format := fs.String("format", "text", "'text', 'csv' or 'json'")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *months < 1 || *months > maxForecastMonths {
+		return errors.New("the forecast should be between 1 and " + strconv.Itoa(maxForecastMonths) + " months")
+	}
+
+	now := time.Now().In(conf.GMT)
+	accPeriod, err := getNearestAccountingDate(accountingPeriodStartDate, now)
+	if err != nil {
+		return err
+	}
+	calendar, err := getCompanyCalendar(d, accPeriod)
+	if err != nil {
+		return err
+	}
+	taxResidency, err := tax.ParseResidency(residency)
+	if err != nil {
+		return err
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, conf.GMT)
+	f, err := ui.CollectForecast(d, calendar, taxResidency, today, *months)
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "text":
+		fmt.Printf("Cash flow forecast since %s, the bank balance is £%.2f\n\n", today.Format(ui.ReportDateFormat), f.OpeningBalance)
+		fmt.Printf("%-10s %12s %12s %12s %12s %12s %12s\n", "Month", "Opening", "Income", "Expenses", "Taxes", "Closing", "Lowest")
+		for _, m := range f.Months {
+			fmt.Printf("%-10s %12.2f %12.2f %12.2f %12.2f %12.2f %12.2f\n", m.Start.Format("Jan 2006"), m.Opening,
+				m.Income, m.Expenses, m.Taxes, m.Closing, m.Lowest)
+		}
+
+		if *flows {
+			fmt.Println()
+			for _, flow := range f.Flows {
+				fmt.Printf("%s  %-8s %12.2f  %s\n", flow.Date.Format("02 Jan 2006"), flow.Type.PrettyString(),
+					flow.Amount, flow.Description)
+			}
+		}
+
+		fmt.Printf("\nThe lowest balance is £%.2f on %s\n", f.LowestBalance, f.LowestDate.Format(ui.ReportDateFormat))
+		if f.IsShort(0) {
+			fmt.Println("NB! The bank account goes short, please keep money aside for the taxes")
+		}
+		return nil
+
+	case "csv":
+		cw := csv.NewWriter(os.Stdout)
+		records := [][]string{{"Month", "Opening", "Income", "Expenses", "Taxes", "Closing", "Lowest"}}
+		for _, m := range f.Months {
+			records = append(records, []string{m.Start.Format("2006-01"), formatCSVAmount(m.Opening),
+				formatCSVAmount(m.Income), formatCSVAmount(m.Expenses), formatCSVAmount(m.Taxes),
+				formatCSVAmount(m.Closing), formatCSVAmount(m.Lowest)})
+		}
+		return cw.WriteAll(records)
+
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(f)
+	}
+	return errors.New("unknown format '" + *format + "', it should be 'text', 'csv' or 'json'")
+}
+
+func formatCSVAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
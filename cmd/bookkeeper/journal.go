@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/conf"
+	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/ledger"
+	"github.com/w32blaster/tax-bookkeeper/ui"
+)
+
+// journalLines collects repeated -dr and -cr parameters like "-dr=7600:500"
+type journalLines struct {
+	lines   *[]db.JournalLine
+	isDebit bool
+}
+
+func (j journalLines) String() string {
+	return ""
+}
+
+func (j journalLines) Set(value string) error {
+	parts := strings.Split(value, ":")
+	if len(parts) != 2 {
+		return errors.New("the journal line '" + value + "' should be like 7600:500")
+	}
+	amount, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return err
+	}
+
+	line := db.JournalLine{Account: parts[0], Credit: amount}
+	if j.isDebit {
+		line = db.JournalLine{Account: parts[0], Debit: amount}
+	}
+	*j.lines = append(*j.lines, line)
+	return nil
+}
+
+// "journal add -date=31-03-2025 -description=Accountancy -dr=7600:500 -cr=2300:500", "journal list [-from= -to=]"
+// or "journal delete -id=3"
+func commandJournal(d *db.Database, args []string) error {
+	action := "list"
+	if len(args) > 0 {
+		action = args[0]
+		args = args[1:]
+	}
+
+	fs := flag.NewFlagSet("journal "+action, flag.ContinueOnError)
+
+	switch action {
+	case "add":
+		var lines []db.JournalLine
+		date := fs.String("date", "", "date of the journal, for example 31-03-2025")
+		description := fs.String("description", "", "what the journal is for, like 'Accountancy fee accrued'")
+		fs.Var(journalLines{lines: &lines, isDebit: true}, "dr", "debit line as account:amount, could be repeated")
+		fs.Var(journalLines{lines: &lines}, "cr", "credit line as account:amount, could be repeated")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+
+		journalDate, err := parseCommandDate(*date)
+		if err != nil {
+			return err
+		}
+		entry := db.JournalEntry{Date: journalDate, Description: *description, Lines: lines}
+		if err := ledger.AddJournal(d, &entry); err != nil {
+			return err
+		}
+		fmt.Printf("Journal %d is saved\n", entry.Pk)
+		return nil
+
+	case "delete":
+		pk := fs.Int("id", 0, "journal ID")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if *pk == 0 {
+			return errors.New("please specify the journal ID with -id")
+		}
+		return d.DeleteJournalEntry(*pk)
+
+	case "list":
+		now := time.Now().In(conf.GMT)
+		from := fs.String("from", "", "the first date, the current accounting period start by default")
+		to := fs.String("to", "", "the last date, today by default")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+
+		accPeriod, err := getNearestAccountingDate(accountingPeriodStartDate, now)
+		if err != nil {
+			return err
+		}
+		since := accPeriod
+		if *from != "" {
+			if since, err = parseCommandDate(*from); err != nil {
+				return err
+			}
+		}
+		until := now
+		if *to != "" {
+			if until, err = parseCommandDate(*to); err != nil {
+				return err
+			}
+		}
+		until = until.AddDate(0, 0, 1)
+
+		vat, err := getRegisteredVATScheme()
+		if err != nil {
+			return err
+		}
+		l, err := ui.CollectLedger(d, vat, accPeriod, until)
+		if err != nil {
+			return err
+		}
+		for _, e := range l.Entries {
+			if e.Date.Before(since) || !e.Date.Before(until) {
+				continue
+			}
+			id := e.Reference
+			if e.Source == db.ManualJournal {
+				id = e.Pk
+			}
+			fmt.Printf("%s   %s %d   %s\n", e.Date.Format("02 Jan 2006"), e.Source.PrettyString(), id, e.Description)
+			for _, line := range e.Lines {
+				if line.Debit > 0 {
+					fmt.Printf("    Dr %s   £%.2f\n", line.Account, line.Debit)
+				} else {
+					fmt.Printf("        Cr %s   £%.2f\n", line.Account, line.Credit)
+				}
+			}
+		}
+		return nil
+	}
+
+	return errors.New("unknown journal action '" + action + "', it should be 'add', 'list' or 'delete'")
+}
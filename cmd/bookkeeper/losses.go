@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/conf"
+	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/ui"
+)
+
+// "losses [show]" or "losses carry-back -period=01-04-2024 [-withdraw]"
+func commandLosses(d *db.Database, args []string) error {
+	action := "show"
+	if len(args) > 0 {
+		action = args[0]
+		args = args[1:]
+	}
+
+	fs := flag.NewFlagSet("losses "+action, flag.ContinueOnError)
+	now := time.Now().In(conf.GMT)
+	accPeriod, err := getNearestAccountingDate(accountingPeriodStartDate, now)
+	if err != nil {
+		return err
+	}
+	vat, err := getRegisteredVATScheme()
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "show":
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+
+	case "carry-back":
+		period := fs.String("period", "", "start date of the loss-making accounting period, for example 01-04-2024")
+		withdraw := fs.Bool("withdraw", false, "withdraw the claim, the loss is carried forward instead")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		periodStart, err := parseCommandDate(*period)
+		if err != nil {
+			return err
+		}
+
+		// the claim is kept in the loss memorandum together with the results of the periods
+		if err := ui.SaveLossMemorandum(d, vat, accPeriod, now); err != nil {
+			return err
+		}
+		if err := d.ClaimLossCarryBack(periodStart, !*withdraw); err != nil {
+			return err
+		}
+
+	default:
+		return errors.New("unknown losses action '" + action + "', it should be 'show' or 'carry-back'")
+	}
+
+	reliefs, err := ui.CollectLossMemorandum(d, vat, accPeriod, now)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Period starting   Profit/loss   Brought fwd   Relieved   Carried back   Taxable   Tax   Repayment   Carried fwd")
+	for _, r := range reliefs {
+		fmt.Printf("%s   £%.2f   £%.2f   £%.2f   £%.2f   £%.2f   £%.2f   £%.2f   £%.2f\n",
+			r.PeriodStart.Format("02 Jan 2006"), r.Profit, r.BroughtForward, r.CarriedForwardUsed+r.CarriedBackFrom,
+			r.CarriedBack, r.TaxableProfit, r.Tax, r.Repayment, r.CarriedForward)
+	}
+	return nil
+}
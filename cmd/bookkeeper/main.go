@@ -14,12 +14,17 @@ import (
 	"github.com/w32blaster/tax-bookkeeper/conf"
 	"github.com/w32blaster/tax-bookkeeper/db"
 	"github.com/w32blaster/tax-bookkeeper/importer"
+	"github.com/w32blaster/tax-bookkeeper/ledger"
+	"github.com/w32blaster/tax-bookkeeper/tax"
 	"github.com/w32blaster/tax-bookkeeper/ui"
 )
 
 var isHelp bool
-var VATRegisteredMonth int
-var importCashPlus, accountingPeriodStartDate string
+var VATRegisteredMonth, directors int
+var importCashPlus, accountingPeriodStartDate, vatAccountingBasis, vatReturnPeriod, residency, confirmationDate string
+var companyName, companyNumber, companyUTR string
+var vatQuarterlyInterim bool
+var cashBuffer float64
 var r = regexp.MustCompile("^[0-9]{2}-[0-9]{2}$")
 
 func main() {
@@ -27,23 +32,96 @@ func main() {
 	if isHelp {
 		fmt.Println("Tax Bookeeper. Helps you to analyze your taxes. Usage \n\n " +
 			"-import-cashplus=/some/path - import transactions for CashPlus bank (file or directory) \n " +
-			"-accounting-start=01-11 - set the accounting period date, if it doesn't match to financial year (1st of April)")
+			"-accounting-start=01-11 - set the accounting period date, if it doesn't match to financial year (1st of April) \n " +
+			"-vat-basis=cash - VAT accounting basis, 'cash' or 'invoice' \n " +
+			"-vat-period=quarterly - how often you submit VAT returns, 'monthly', 'quarterly' or 'annual' \n " +
+			"-residency=england - where the director pays income tax, 'england', 'wales' or 'scotland' \n " +
+			"-confirmation-date=10-02-2021 - review date of the confirmation statement, usually the incorporation date \n " +
+			"-company-name='Example Ltd' -company-number=01234567 - as registered at Companies House, used in the statutory accounts \n " +
+			"-company-utr=1234567890 - the Unique Taxpayer Reference of the company, used in the CT600 return \n " +
+			"-directors=1 - the number of directors, they are counted as employees in the statutory accounts \n " +
+			"-cash-buffer=2000 - money to keep on the bank account on top of the taxes, when the safe to withdraw amount is calculated \n\n" +
+			"Commands: \n " +
+			"invoice -type=sales -number=INV-1 -date=02-01-2021 -net=1000 -vat=200 - record a sales invoice or a supplier bill \n " +
+			"invoice pay -id=3 -transaction=120 - the bank transaction that paid the invoice, for the VAT cash accounting \n " +
+			"migrate-personal - move old 'Split between company and personal accounts' transactions to salary, dividends or expenses \n " +
+			"plan salary -profit=60000 [-tui] - find the salary and dividends split with the lowest tax \n " +
+			"payroll add-employee|run|show|link - monthly PAYE payroll, payslips and amounts due to HMRC \n " +
+			"asset add|dispose|list|allowances|depreciation - fixed asset register, capital allowances and depreciation \n " +
+			"expense -id=12 -ct=disallowable [-private-use=30] - mark an expense as (partly) not deductible for corporation tax \n " +
+			"computation [-previous] - corporation tax computation from the accounting profit to the taxable profit \n " +
+			"ct600 -period=01-04-2024 [-format=text|xml] [-director='Jane Smith'] [-out=ct600.xml] - the boxes of the " +
+			"company tax return, XML for the filing software \n " +
+			"calendar [-ics] [-months=12] - filing and payment deadlines, -ics exports them for calendar apps \n " +
+			"summary [-format=text|json] - the safe to withdraw amount and the headline figures of the dashboard \n " +
+			"forecast [-months=12] [-flows] [-format=text|csv|json] - the bank balance month by month with the recurring " +
+			"payments and the taxes on their due dates \n " +
+			"losses [show] | losses carry-back -period=01-04-2024 [-withdraw] - trading losses carried forward and back \n " +
+			"penalties [show] | penalties record -return=vat|ct600|sa -period-end=31-03-2025 [-filed=] [-paid=] - " +
+			"estimated penalties and interest for missed deadlines \n " +
+			"journal list|add|delete - the double-entry general ledger, " +
+			"add a manual journal with -date=31-03-2025 -description=Accrual -dr=7600:500 -cr=2300:500 \n " +
+			"account list|add|update -code=7800 -name='Software subscriptions' -type=expense [-parent=7090] " +
+			"[-ct-allowable=false] [-vat-rate=0.2] [-capital] - the chart of accounts \n " +
+			"report pnl [-from=01-04-2025] [-to=31-03-2026] [-format=text|csv|json] [-tui] - profit and loss " +
+			"statement compared with the previous period \n " +
+			"report balance-sheet [-date=31-03-2026] [-format=text|csv|json] [-tui] - balance sheet, retained earnings " +
+			"and dividends which exceed the distributable reserves \n " +
+			"report trial-balance [-date=31-03-2026] [-before-close] [-format=text|csv|json] - balances of all the accounts \n " +
+			"year-end close|reopen|list -period=01-04-2024 - charge the corporation tax, post the closing entries to retained earnings and lock the period \n " +
+			"micro-accounts generate -period=01-04-2024 -director='Jane Smith' [-approved=] [-employees=] [-out=accounts.html] | " +
+			"micro-accounts validate -file=accounts.html - FRS 105 micro-entity accounts in inline XBRL for Companies House \n " +
+			"mtd auth-url|token|obligations|liabilities|payments|submit -vrn=123456789 [-token= -refresh-token=] - Making Tax Digital for VAT, " +
+			"use -hmrc-url=sandbox|production|stub to choose the HMRC environment")
 		os.Exit(0)
 	}
 
+	vatScheme, err := getVATScheme()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	taxResidency, err := tax.ParseResidency(residency)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if cashBuffer < 0 {
+		log.Fatal("the cash buffer can't be negative")
+	}
+	if directors < 1 {
+		log.Fatal("the company has at least one director")
+	}
+
+	// run a command and exit
+	if flag.NArg() > 0 {
+		d := db.Init("./tax-bookkeeper.db")
+		defer d.Close()
+
+		if err := ledger.InitChartOfAccounts(d); err != nil {
+			log.Fatal(err)
+		}
+		if err := runCommand(d, flag.Args()); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	if VATRegisteredMonth == 0 && importCashPlus == "" {
 		fmt.Println("Sorry, the -v parameter is mandatory. It is the month when your company was " +
 			"registered for VAT, for example, -v=11 (meaning November). You can login to GOV.UK and see your date here:" +
 			" https://www.tax.service.gov.uk/vat-through-software/vat-certificate . Exit")
 		os.Exit(1)
 	}
-	vatMonth := time.Month(VATRegisteredMonth)
-
 	// TODO: validate date if set
 
 	d := db.Init("./tax-bookkeeper.db")
 	defer d.Close()
 
+	if err := ledger.InitChartOfAccounts(d); err != nil {
+		log.Fatal(err)
+	}
+
 	gui := ui.TerminalUI{}
 
 	// import data and exit
@@ -53,8 +131,12 @@ func main() {
 
 	// if there are unallocated transactions, show the list
 	if unallocatedTransactions, err := d.GetUnallocated(); err == nil && len(unallocatedTransactions) > 0 {
+		chart, err := d.GetAccounts()
+		if err != nil {
+			log.Fatal(err)
+		}
 		gui.Start()
-		gui.BeginDialogToAllocateTransactions(unallocatedTransactions, d.AllocateTransactions)
+		gui.BeginDialogToAllocateTransactions(unallocatedTransactions, chart, d.AllocateTransactions)
 	}
 
 	// or show the dashboards
@@ -64,7 +146,12 @@ func main() {
 		log.Fatal(err)
 	}
 
-	dashboardData, err := ui.CollectDataForDashboard(d, accPeriod, vatMonth)
+	calendar, err := getCompanyCalendar(d, accPeriod)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	dashboardData, err := ui.CollectDataForDashboard(d, accPeriod, vatScheme, taxResidency, calendar, cashBuffer)
 	if err != nil {
 		log.Fatal("Can't build the dashboard, because: " + err.Error())
 	}
@@ -91,6 +178,81 @@ func init() {
 		"you can set your date with this parameter, (example 01-11 which is 1st of November)")
 	flag.IntVar(&VATRegisteredMonth, "v", 0, "month when your company was registered for VAT"+
 		" (you can find it here: https://www.tax.service.gov.uk/vat-through-software/vat-certificate)")
+	flag.StringVar(&vatAccountingBasis, "vat-basis", "cash", "VAT accounting basis: 'cash' (tax point is the bank transaction date) "+
+		"or 'invoice' (tax point is the invoice date)")
+	flag.StringVar(&vatReturnPeriod, "vat-period", "quarterly", "how often you submit VAT returns: 'monthly', 'quarterly' "+
+		"or 'annual' (Annual Accounting Scheme, then -v is the last month of your VAT year)")
+	flag.BoolVar(&vatQuarterlyInterim, "vat-quarterly-interim", false, "under the Annual Accounting Scheme make 3 quarterly "+
+		"interim payments instead of 9 monthly ones")
+	flag.StringVar(&residency, "residency", "england", "where the director pays income tax: 'england' (and Northern Ireland), "+
+		"'wales' or 'scotland', Scottish taxpayers have their own bands and rates")
+	flag.StringVar(&confirmationDate, "confirmation-date", "", "review date of the confirmation statement "+
+		"(usually the incorporation date), for example 10-02-2021")
+	flag.StringVar(&companyName, "company-name", "", "the registered name of the company, as shown at Companies House")
+	flag.StringVar(&companyNumber, "company-number", "", "the Companies House registration number, for example 01234567")
+	flag.StringVar(&companyUTR, "company-utr", "", "the Unique Taxpayer Reference of the company for corporation tax, 10 digits")
+	flag.IntVar(&directors, "directors", 1, "the number of directors, they are counted as employees in the statutory "+
+		"accounts even when they are not on the payroll")
+	flag.Float64Var(&cashBuffer, "cash-buffer", 0, "money in £ to keep on the bank account on top of the taxes, "+
+		"it is not counted as safe to withdraw")
+}
+
+// builds the VAT scheme from the command line parameters
+func getVATScheme() (tax.VATScheme, error) {
+	vatBasis, err := tax.ParseVATAccountingBasis(vatAccountingBasis)
+	if err != nil {
+		return tax.VATScheme{}, err
+	}
+
+	vatPeriod, err := tax.ParseVATReturnPeriod(vatReturnPeriod)
+	if err != nil {
+		return tax.VATScheme{}, err
+	}
+
+	return tax.VATScheme{
+		Period:                   vatPeriod,
+		PeriodEndMonth:           time.Month(VATRegisteredMonth),
+		Basis:                    vatBasis,
+		QuarterlyInterimPayments: vatQuarterlyInterim,
+	}, nil
+}
+
+// collects what the statutory deadlines depend on from the command line parameters and the payroll
+func getCompanyCalendar(d *db.Database, accPeriod time.Time) (tax.CompanyCalendar, error) {
+	calendar := tax.CompanyCalendar{AccountingPeriodStart: accPeriod}
+
+	vatScheme, err := getRegisteredVATScheme()
+	if err != nil {
+		return calendar, err
+	}
+	calendar.VAT = vatScheme
+
+	if confirmationDate != "" {
+		date, err := parseCommandDate(confirmationDate)
+		if err != nil {
+			return calendar, err
+		}
+		calendar.ConfirmationDate = date
+	}
+
+	employees, err := d.GetEmployees()
+	if err != nil {
+		return calendar, err
+	}
+	calendar.HasPayroll = len(employees) > 0
+	return calendar, nil
+}
+
+// the VAT scheme of the company, nil if it is not registered for VAT
+func getRegisteredVATScheme() (*tax.VATScheme, error) {
+	if VATRegisteredMonth == 0 {
+		return nil, nil
+	}
+	vatScheme, err := getVATScheme()
+	if err != nil {
+		return nil, err
+	}
+	return &vatScheme, nil
 }
 
 // here we find the current account date.
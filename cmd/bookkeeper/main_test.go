@@ -1,8 +1,10 @@
 package main
 
 import (
+	"bytes"
 	"github.com/stretchr/testify/assert"
 	"github.com/w32blaster/tax-bookkeeper/conf"
+	"github.com/w32blaster/tax-bookkeeper/tax"
 	"strconv"
 	"strings"
 	"testing"
@@ -62,6 +64,27 @@ func Test_getNearestAccountingDate(t *testing.T) {
 	}
 }
 
+func Test_writeICS(t *testing.T) {
+
+	// Given:
+	deadlines := []tax.Deadline{
+		{Type: tax.VATReturn, Date: dateOf("07-02-2025"), Description: "Oct 2024 - Dec 2024"},
+		{Type: tax.AnnualAccounts, Date: dateOf("31-12-2025"), Description: "period ended 31 Mar 2025, draft"},
+	}
+
+	// When:
+	var buf bytes.Buffer
+	writeICS(&buf, deadlines, dateOf("01-01-2025"))
+
+	// Then:
+	ics := buf.String()
+	assert.True(t, strings.HasPrefix(ics, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(ics, "END:VEVENT\r\nEND:VCALENDAR\r\n"))
+	assert.Equal(t, 2, strings.Count(ics, "BEGIN:VEVENT"))
+	assert.Contains(t, ics, "DTSTART;VALUE=DATE:20251231\r\nDTEND;VALUE=DATE:20260101\r\n")
+	assert.Contains(t, ics, "DESCRIPTION:period ended 31 Mar 2025\\, draft\r\n")
+}
+
 // shorthand for the date creation, like "01-03-2021"
 func dateOf(date string) time.Time {
 	parts := strings.Split(date, "-")
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/conf"
+	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/statutory"
+	"github.com/w32blaster/tax-bookkeeper/ui"
+)
+
+// "micro-accounts generate -period=01-04-2024 -director='Jane Smith'" or "micro-accounts validate -file=accounts.html"
+func commandMicroAccounts(d *db.Database, args []string) error {
+	action := "generate"
+	if len(args) > 0 {
+		action = args[0]
+		args = args[1:]
+	}
+
+	switch action {
+	case "generate":
+		return commandGenerateMicroAccounts(d, args)
+	case "validate":
+		fs := flag.NewFlagSet("micro-accounts validate", flag.ContinueOnError)
+		file := fs.String("file", "", "the inline XBRL file to check")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if *file == "" {
+			return errors.New("please specify the file with -file")
+		}
+
+		content, err := ioutil.ReadFile(*file)
+		if err != nil {
+			return err
+		}
+		if err := printValidation(content); err != nil {
+			return err
+		}
+		fmt.Println("The accounts are valid, all the facts use the FRC taxonomy tags")
+		return nil
+	}
+	return errors.New("unknown micro-accounts action '" + action + "', it should be 'generate' or 'validate'")
+}
+
+func commandGenerateMicroAccounts(d *db.Database, args []string) error {
+	now := time.Now().In(conf.GMT)
+	fs := flag.NewFlagSet("micro-accounts generate", flag.ContinueOnError)
+	period := fs.String("period", "", "the first day of the accounting period, like 01-04-2024")
+	director := fs.String("director", "", "the name of the director who approves and signs the accounts")
+	approved := fs.String("approved", now.Format(commandDateFormat), "the date the board approved the accounts")
+	employees := fs.Int("employees", 0, "the average number of employees including directors, "+
+		"by default it is counted from the payroll and -directors")
+	out := fs.String("out", "", "the file to write, by default micro-accounts-<period end>.html")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	start, err := parseAccountingPeriodStart(*period)
+	if err != nil {
+		return err
+	}
+	calendar, err := getCompanyCalendar(d, start)
+	if err != nil {
+		return err
+	}
+
+	accounts, err := ui.CollectMicroEntityAccounts(d, calendar, start, directors)
+	if err != nil {
+		return err
+	}
+	accounts.CompanyName = companyName
+	accounts.CompanyNumber = companyNumber
+	accounts.Director = *director
+	if accounts.ApprovalDate, err = parseCommandDate(*approved); err != nil {
+		return err
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "employees" {
+			accounts.AverageEmployees = *employees
+		}
+	})
+
+	var buf bytes.Buffer
+	if err := statutory.WriteIXBRL(&buf, accounts); err != nil {
+		return err
+	}
+	if err := printValidation(buf.Bytes()); err != nil {
+		return err
+	}
+
+	file := *out
+	if file == "" {
+		file = "micro-accounts-" + accounts.PeriodEnd.Format("2006-01-02") + ".html"
+	}
+	if err := ioutil.WriteFile(file, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+
+	bs := accounts.Current
+	fmt.Printf("Fixed assets                          %10.0f\n", bs.FixedAssets)
+	fmt.Printf("Current assets                        %10.0f\n", bs.CurrentAssets)
+	fmt.Printf("Creditors due within one year         %10.0f\n", -bs.CreditorsWithinOneYear)
+	fmt.Printf("Net assets                            %10.0f\n", bs.NetAssets)
+	fmt.Printf("Capital and reserves                  %10.0f\n", bs.CapitalAndReserves)
+	fmt.Println("Average number of employees           " + fmt.Sprintf("%10s", strconv.Itoa(accounts.AverageEmployees)))
+	if accounts.DirectorAdvances != nil {
+		fmt.Printf("Advances to the director at the end   %10.0f\n", accounts.DirectorAdvances.CarriedForward)
+	}
+	fmt.Println("The micro-entity accounts are written to " + file + ", please check them before filing at Companies House")
+	return nil
+}
+
+// prints the problems found by the offline validation
+func printValidation(content []byte) error {
+	errs := statutory.Validate(bytes.NewReader(content))
+	for _, err := range errs {
+		fmt.Println("  " + err.Error())
+	}
+	if len(errs) > 0 {
+		return errors.New("the accounts are not valid, " + strconv.Itoa(len(errs)) + " problem(s) found")
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/conf"
+	"github.com/w32blaster/tax-bookkeeper/hmrc"
+)
+
+const (
+	oobRedirectURI = "urn:ietf:wg:oauth:2.0:oob"
+	vendorVersion  = "1.0"
+)
+
+var hmrcBaseURL, hmrcClientID, hmrcClientSecret, hmrcDeviceID string
+
+// Making Tax Digital for VAT, for example "mtd obligations -vrn=123456789 -token=..."
+func commandMTD(args []string) error {
+	if len(args) == 0 {
+		return errors.New("please specify the MTD action: auth-url, token, obligations, liabilities, payments or submit")
+	}
+
+	fs := flag.NewFlagSet("mtd "+args[0], flag.ContinueOnError)
+	vrn := fs.String("vrn", "", "VAT registration number")
+	accessToken := fs.String("token", "", "access token obtained with 'mtd token'")
+	refreshToken := fs.String("refresh-token", "", "refresh token obtained with 'mtd token', a new access token "+
+		"is requested with it when the access token is expired")
+	code := fs.String("code", "", "authorization code from the HMRC website")
+	from := fs.String("from", "", "start date, for example 01-01-2021")
+	to := fs.String("to", "", "end date, for example 31-12-2021")
+	vatReturn := hmrc.VATReturn{Finalised: true}
+	fs.StringVar(&vatReturn.PeriodKey, "period-key", "", "period key of the obligation")
+	fs.Float64Var(&vatReturn.VatDueSales, "box1", 0, "VAT due on sales")
+	fs.Float64Var(&vatReturn.VatDueAcquisitions, "box2", 0, "VAT due on acquisitions from EU")
+	fs.Float64Var(&vatReturn.VatReclaimedCurrPeriod, "box4", 0, "VAT reclaimed on purchases")
+	fs.Float64Var(&vatReturn.TotalValueSalesExVAT, "box6", 0, "total sales excluding VAT")
+	fs.Float64Var(&vatReturn.TotalValuePurchasesExVAT, "box7", 0, "total purchases excluding VAT")
+	fs.Float64Var(&vatReturn.TotalValueGoodsSuppliedExVAT, "box8", 0, "total supplies of goods to EU excluding VAT")
+	fs.Float64Var(&vatReturn.TotalAcquisitionsExVAT, "box9", 0, "total acquisitions of goods from EU excluding VAT")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	client, stop, err := buildMTDClient(*vrn)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	if *accessToken != "" || *refreshToken != "" {
+		client.SetToken(&hmrc.Token{AccessToken: *accessToken, RefreshToken: *refreshToken})
+	}
+
+	now := time.Now().In(conf.GMT)
+	since, until := now.AddDate(-1, 0, 0), now
+	if *from != "" {
+		if since, err = parseCommandDate(*from); err != nil {
+			return err
+		}
+	}
+	if *to != "" {
+		if until, err = parseCommandDate(*to); err != nil {
+			return err
+		}
+	}
+
+	switch args[0] {
+	case "auth-url":
+		fmt.Println("Please open this page, grant the access and then run 'mtd token -code=...':")
+		fmt.Println(client.AuthorizationURL(oobRedirectURI, "tax-bookkeeper"))
+
+	case "token":
+		token, err := client.ExchangeCode(*code, oobRedirectURI)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Access token: %s\nRefresh token: %s\nExpires at: %s\n",
+			token.AccessToken, token.RefreshToken, token.ExpiresAt.Format(time.RFC1123))
+		fmt.Println("Please pass both with -token and -refresh-token, the access token is renewed when it expires")
+
+	case "obligations":
+		obligations, err := client.GetObligations(*vrn, since, until, "")
+		if err != nil {
+			return err
+		}
+		for _, o := range obligations {
+			fmt.Printf("%s  %s - %s  due %s  status %s\n", o.PeriodKey,
+				o.Start.Format("02 Jan 2006"), o.End.Format("02 Jan 2006"), o.Due.Format("02 Jan 2006"), o.Status)
+		}
+
+	case "liabilities":
+		liabilities, err := client.GetLiabilities(*vrn, since, until)
+		if err != nil {
+			return err
+		}
+		for _, l := range liabilities {
+			fmt.Printf("%s - %s  %s  £%.2f, outstanding £%.2f, due %s\n",
+				l.TaxPeriod.From.Format("02 Jan 2006"), l.TaxPeriod.To.Format("02 Jan 2006"),
+				l.Type, l.OriginalAmount, l.OutstandingAmount, l.Due.Format("02 Jan 2006"))
+		}
+
+	case "payments":
+		payments, err := client.GetPayments(*vrn, since, until)
+		if err != nil {
+			return err
+		}
+		for _, p := range payments {
+			fmt.Printf("%s  £%.2f\n", p.Received.Format("02 Jan 2006"), p.Amount)
+		}
+
+	case "submit":
+		// HMRC accepts only whole pounds in the boxes 6 to 9, the pennies are left out
+		for _, box := range []*float64{&vatReturn.TotalValueSalesExVAT, &vatReturn.TotalValuePurchasesExVAT,
+			&vatReturn.TotalValueGoodsSuppliedExVAT, &vatReturn.TotalAcquisitionsExVAT} {
+			*box = math.Trunc(*box)
+		}
+		vatReturn.TotalVatDue = vatReturn.VatDueSales + vatReturn.VatDueAcquisitions
+		vatReturn.NetVatDue = vatReturn.TotalVatDue - vatReturn.VatReclaimedCurrPeriod
+		if vatReturn.NetVatDue < 0 {
+			vatReturn.NetVatDue = -vatReturn.NetVatDue
+		}
+		receipt, err := client.SubmitReturn(*vrn, vatReturn)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("The return is submitted, form bundle number %s\n", receipt.FormBundleNumber)
+
+	default:
+		return errors.New("unknown MTD action '" + args[0] + "'")
+	}
+
+	return nil
+}
+
+// builds the client for the configured base URL. When the URL is "stub", then
+// the in-process stub server is started and authorized, so that nothing leaves your machine
+func buildMTDClient(vrn string) (*hmrc.Client, func(), error) {
+	deviceID := hmrcDeviceID
+	if deviceID == "" {
+		deviceID, _ = os.Hostname()
+	}
+	fraud := hmrc.CollectFraudPreventionHeaders(deviceID, vendorVersion)
+
+	if hmrcBaseURL != "stub" {
+		return hmrc.NewClient(hmrc.ResolveBaseURL(hmrcBaseURL), hmrcClientID, hmrcClientSecret, fraud), func() {}, nil
+	}
+
+	stub := hmrc.NewStubServer(hmrcClientID, hmrcClientSecret)
+	now := time.Now().In(conf.GMT)
+	stub.AddObligation(vrn, time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, conf.GMT).AddDate(0, -3, 0),
+		time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, conf.GMT).AddDate(0, 0, -1), "STUB")
+
+	client := hmrc.NewClient(stub.URL, hmrcClientID, hmrcClientSecret, fraud)
+	if _, err := client.ExchangeCode(stub.IssueCode(), oobRedirectURI); err != nil {
+		stub.Close()
+		return nil, nil, err
+	}
+	return client, stub.Close, nil
+}
+
+func init() {
+	flag.StringVar(&hmrcBaseURL, "hmrc-url", "sandbox", "HMRC API base URL: 'sandbox', 'production', 'stub' "+
+		"(in-process imitation of HMRC for testing) or any URL")
+	flag.StringVar(&hmrcClientID, "hmrc-client-id", "", "client ID of your application registered on the HMRC Developer Hub")
+	flag.StringVar(&hmrcClientSecret, "hmrc-client-secret", "", "client secret of your application registered on the HMRC Developer Hub")
+	flag.StringVar(&hmrcDeviceID, "hmrc-device-id", "", "unique ID of this device for the fraud prevention headers, hostname by default")
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/conf"
+	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/payroll"
+	"github.com/w32blaster/tax-bookkeeper/tax"
+)
+
+// "payroll add-employee|run|show|link ..."
+func commandPayroll(d *db.Database, args []string) error {
+	if len(args) == 0 {
+		return errors.New("please specify the payroll action: add-employee, run, show or link")
+	}
+
+	now := time.Now().In(conf.GMT)
+	fs := flag.NewFlagSet("payroll "+args[0], flag.ContinueOnError)
+
+	switch args[0] {
+	case "add-employee":
+		employee := db.Employee{}
+		fs.StringVar(&employee.Name, "name", "", "employee name")
+		fs.StringVar(&employee.TaxCode, "tax-code", "1257L", "PAYE tax code, like 1257L, BR or 1257L M1")
+		fs.StringVar(&employee.NICategory, "ni-category", "A", "National Insurance category letter")
+		fs.BoolVar(&employee.IsDirector, "director", false, "is this employee a director")
+		fs.Float64Var(&employee.MonthlySalary, "salary", 0, "gross monthly salary")
+		fs.Float64Var(&employee.PensionRate, "pension", 0, "employee's pension contribution, like 0.05 for 5%")
+		fs.Float64Var(&employee.EmployerPensionRate, "employer-pension", 0, "employer's pension contribution, like 0.03 for 3%")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if _, err := tax.ParseTaxCode(employee.TaxCode); err != nil {
+			return err
+		}
+		if err := d.SaveEmployee(&employee); err != nil {
+			return err
+		}
+		fmt.Printf("Employee %s is added with ID %d\n", employee.Name, employee.Pk)
+
+	case "run":
+		taxYear := fs.String("tax-year", tax.GetTaxYear(now), "tax year, like 2025-2026")
+		taxMonth := fs.Int("month", payroll.GetTaxMonth(now), "tax month, 1 is 6 April - 5 May")
+		payDate := fs.String("pay-date", now.Format(commandDateFormat), "when the salary is paid, like 30-04-2025")
+		employmentAllowance := fs.Bool("employment-allowance", false, "company claims the Employment Allowance")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		paid, err := parseCommandDate(*payDate)
+		if err != nil {
+			return err
+		}
+		payslips, payment, err := payroll.RunMonth(d, *taxYear, *taxMonth, paid, *employmentAllowance)
+		if err != nil {
+			return err
+		}
+		printPayslips(payslips)
+		printPAYEPayment(payment)
+
+	case "show":
+		taxYear := fs.String("tax-year", tax.GetTaxYear(now), "tax year, like 2025-2026")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		payslips, err := d.GetPayslips(*taxYear)
+		if err != nil {
+			return err
+		}
+		printPayslips(payslips)
+		payments, err := d.GetPAYEPayments(*taxYear)
+		if err != nil {
+			return err
+		}
+		for _, p := range payments {
+			printPAYEPayment(p)
+		}
+
+	case "link":
+		payslipPk := fs.Int("payslip", 0, "payslip ID")
+		paymentPk := fs.Int("payment", 0, "ID of the amount due to HMRC")
+		transactionPk := fs.Int("transaction", 0, "ID of the bank transaction")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *payslipPk != 0 {
+			return d.LinkPayslip(*payslipPk, *transactionPk)
+		}
+		if *paymentPk != 0 {
+			return d.LinkPAYEPayment(*paymentPk, *transactionPk)
+		}
+		return errors.New("please specify -payslip or -payment to link with the -transaction")
+
+	default:
+		return errors.New("unknown payroll action '" + args[0] + "'")
+	}
+
+	return nil
+}
+
+func printPayslips(payslips []db.Payslip) {
+	for _, p := range payslips {
+		fmt.Printf("Payslip %d, employee %d, %s month %d, paid %s: gross £%.2f, pension £%.2f, "+
+			"tax £%.2f, NI £%.2f, net £%.2f (employer's NI £%.2f, pension £%.2f)\n",
+			p.Pk, p.EmployeePk, p.TaxYear, p.TaxMonth, p.PayDate.Format("02 Jan 2006"), p.Gross, p.EmployeePension,
+			p.IncomeTax, p.EmployeeNI, p.NetPay, p.EmployerNI, p.EmployerPension)
+	}
+}
+
+func printPAYEPayment(p db.PAYEPayment) {
+	fmt.Printf("Due to HMRC %d, %s month %d: tax £%.2f, employee's NI £%.2f, employer's NI £%.2f, "+
+		"Employment Allowance £%.2f, total £%.2f by %s\n",
+		p.Pk, p.TaxYear, p.TaxMonth, p.IncomeTax, p.EmployeeNI, p.EmployerNI, p.EmploymentAllowanceUsed,
+		p.Total, p.DueDate.Format("02 Jan 2006"))
+}
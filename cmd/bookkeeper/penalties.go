@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/conf"
+	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/tax"
+	"github.com/w32blaster/tax-bookkeeper/ui"
+)
+
+// "penalties [show]" or "penalties record -return=vat -period-end=31-03-2025 [-filed=10-05-2025] [-paid=20-02-2026]"
+func commandPenalties(d *db.Database, args []string) error {
+	action := "show"
+	if len(args) > 0 {
+		action = args[0]
+		args = args[1:]
+	}
+
+	fs := flag.NewFlagSet("penalties "+action, flag.ContinueOnError)
+	now := time.Now().In(conf.GMT)
+
+	switch action {
+	case "show":
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+
+	case "record":
+		returnType := fs.String("return", "", "the tax return: 'ct600', 'vat' or 'sa'")
+		periodEnd := fs.String("period-end", "", "the last day of the period or the tax year of the return, for example 31-03-2025")
+		filed := fs.String("filed", "", "when the return was filed, empty if it is not filed yet")
+		paid := fs.String("paid", "", "when the self assessment tax was paid in full, empty if it was paid on time")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+
+		record := db.FilingRecord{}
+		var err error
+		if record.Return, err = db.ParseReturnType(*returnType); err != nil {
+			return err
+		}
+		if record.PeriodEnd, err = parseCommandDate(*periodEnd); err != nil {
+			return err
+		}
+		if *filed != "" {
+			if record.Filed, err = parseCommandDate(*filed); err != nil {
+				return err
+			}
+		}
+		if *paid != "" {
+			if record.Return != db.SelfAssessmentReturn {
+				return errors.New("the payment date is recorded only for the self assessment, " +
+					"company payments are taken from the HMRC transactions")
+			}
+			if record.Paid, err = parseCommandDate(*paid); err != nil {
+				return err
+			}
+		}
+		if err := d.SaveFilingRecord(record); err != nil {
+			return err
+		}
+
+	default:
+		return errors.New("unknown penalties action '" + action + "', it should be 'show' or 'record'")
+	}
+
+	accPeriod, err := getNearestAccountingDate(accountingPeriodStartDate, now)
+	if err != nil {
+		return err
+	}
+	calendar, err := getCompanyCalendar(d, accPeriod)
+	if err != nil {
+		return err
+	}
+	taxResidency, err := tax.ParseResidency(residency)
+	if err != nil {
+		return err
+	}
+
+	penalties, err := ui.CollectPenalties(d, calendar, taxResidency, now)
+	if err != nil {
+		return err
+	}
+	if len(penalties) == 0 {
+		fmt.Println("No missed deadlines, well done")
+		return nil
+	}
+
+	fmt.Println("Deadline   Obligation   Days late   Unpaid   Filing penalty   Payment penalty   Interest   VAT points   Total")
+	for _, p := range penalties {
+		fmt.Printf("%s   %s, %s   %d   £%.2f   £%.2f   £%.2f   £%.2f   %d   £%.2f\n",
+			p.Deadline.Date.Format("02 Jan 2006"), p.Deadline.Type.PrettyString(), p.Deadline.Description, p.DaysLate,
+			p.Unpaid, p.FilingPenalty, p.PaymentPenalty, p.Interest, p.Points, p.Total())
+	}
+	fmt.Printf("Late payment interest is %.2f%% today\n", tax.GetLatePaymentInterestRate(now)*100)
+	return nil
+}
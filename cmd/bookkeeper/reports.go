@@ -0,0 +1,283 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/conf"
+	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/ui"
+)
+
+// "report pnl [-from=01-04-2025] [-to=31-03-2026] [-format=text|csv|json] [-tui]"
+// "report balance-sheet [-date=31-03-2026] [-format=text|csv|json] [-tui]"
+// or "report trial-balance [-date=31-03-2026] [-before-close] [-format=text|csv|json]"
+func commandReport(d *db.Database, args []string) error {
+	if len(args) == 0 {
+		return errors.New("please specify the report, for example 'report pnl'")
+	}
+
+	switch args[0] {
+	case "pnl":
+		return commandProfitAndLoss(d, args[1:])
+	case "balance-sheet":
+		return commandBalanceSheet(d, args[1:])
+	case "trial-balance":
+		return commandTrialBalance(d, args[1:])
+	}
+	return errors.New("unknown report '" + args[0] + "', it should be 'pnl', 'balance-sheet' or 'trial-balance'")
+}
+
+// the profit and loss statement, by default for the current accounting period so far
+func commandProfitAndLoss(d *db.Database, args []string) error {
+	now := time.Now().In(conf.GMT)
+	accPeriod, err := getNearestAccountingDate(accountingPeriodStartDate, now)
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("report pnl", flag.ContinueOnError)
+	from := fs.String("from", accPeriod.Format(commandDateFormat), "the first day of the period, like 01-04-2025")
+	to := fs.String("to", now.Format(commandDateFormat), "the last day of the period, like 31-03-2026")
+	format := fs.String("format", "text", "'text', 'csv' or 'json'")
+	isTUI := fs.Bool("tui", false, "show the report in the terminal UI")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	since, until, err := parseReportPeriod(*from, *to)
+	if err != nil {
+		return err
+	}
+
+	vat, err := getRegisteredVATScheme()
+	if err != nil {
+		return err
+	}
+	report, err := ui.CollectProfitAndLoss(d, vat, accPeriod, since, until, now)
+	if err != nil {
+		return err
+	}
+
+	headers := []string{"", ui.PeriodLabel(report.Current.From, report.Current.Until),
+		ui.PeriodLabel(report.Previous.From, report.Previous.Until)}
+	if *isTUI {
+		gui := ui.TerminalUI{}
+		gui.Start()
+		gui.DrawReport("Profit and loss", headers, report.Rows(), nil)
+		return nil
+	}
+	return writeReport(os.Stdout, *format, "Profit and loss", headers, report.Rows(), nil, report)
+}
+
+// the balance sheet as at the date compared with the year before, by default as at today
+func commandBalanceSheet(d *db.Database, args []string) error {
+	now := time.Now().In(conf.GMT)
+	fs := flag.NewFlagSet("report balance-sheet", flag.ContinueOnError)
+	date := fs.String("date", now.Format(commandDateFormat), "the balance sheet date, like 31-03-2026")
+	format := fs.String("format", "text", "'text', 'csv' or 'json'")
+	isTUI := fs.Bool("tui", false, "show the report in the terminal UI")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	asAt, err := parseCommandDate(*date)
+	if err != nil {
+		return err
+	}
+	accPeriod, err := getNearestAccountingDate(accountingPeriodStartDate, asAt)
+	if err != nil {
+		return err
+	}
+	calendar, err := getCompanyCalendar(d, accPeriod)
+	if err != nil {
+		return err
+	}
+
+	report, err := ui.CollectBalanceSheetReport(d, calendar, asAt)
+	if err != nil {
+		return err
+	}
+
+	var warnings []string
+	for _, w := range report.Current.DividendWarnings {
+		warnings = append(warnings, fmt.Sprintf("Warning! The dividend '%s' of £%.2f paid on %s exceeds "+
+			"the distributable reserves of £%.2f", w.Description, w.Amount, w.Date.Format(commandDateFormat), w.DistributableReserves))
+	}
+	if report.Current.Difference != 0 {
+		warnings = append(warnings, fmt.Sprintf("The balance sheet does not balance by £%.2f, the bank balance "+
+			"on the statement is £%.2f. Please post the opening balances as a journal", report.Current.Difference,
+			report.Current.StatementBankBalance))
+	}
+
+	headers := []string{"", "As at " + report.Current.Date.Format(ui.ReportDateFormat),
+		"As at " + report.Previous.Date.Format(ui.ReportDateFormat)}
+	if *isTUI {
+		gui := ui.TerminalUI{}
+		gui.Start()
+		gui.DrawReport("Balance sheet", headers, report.Rows(), warnings)
+		return nil
+	}
+	return writeReport(os.Stdout, *format, "Balance sheet", headers, report.Rows(), warnings, report)
+}
+
+// the trial balance as at the date, income and expenses are of the accounting period so far
+func commandTrialBalance(d *db.Database, args []string) error {
+	now := time.Now().In(conf.GMT)
+	fs := flag.NewFlagSet("report trial-balance", flag.ContinueOnError)
+	date := fs.String("date", now.Format(commandDateFormat), "the last day, like 31-03-2026")
+	beforeClose := fs.Bool("before-close", false, "leave out the year-end closing entries")
+	format := fs.String("format", "text", "'text', 'csv' or 'json'")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	asAt, err := parseCommandDate(*date)
+	if err != nil {
+		return err
+	}
+	accPeriod, err := getNearestAccountingDate(accountingPeriodStartDate, asAt)
+	if err != nil {
+		return err
+	}
+
+	vat, err := getRegisteredVATScheme()
+	if err != nil {
+		return err
+	}
+
+	until := asAt.AddDate(0, 0, 1)
+	l, err := ui.CollectLedger(d, vat, accPeriod, until)
+	if err != nil {
+		return err
+	}
+	if *beforeClose {
+		l = l.WithoutClosingEntries()
+	}
+	lines := l.TrialBalance(accPeriod, until)
+
+	var debit, credit float64
+	for _, line := range lines {
+		debit = debit + line.Debit
+		credit = credit + line.Credit
+	}
+
+	switch *format {
+	case "text":
+		fmt.Printf("Trial balance as at %s\n\n", asAt.Format(ui.ReportDateFormat))
+		fmt.Printf("%-6s %-50s %12s %12s\n", "Code", "Account", "Debit", "Credit")
+		for _, line := range lines {
+			fmt.Printf("%-6s %-50s %12s %12s\n", line.Code, line.Name, formatTrialBalanceAmount(line.Debit),
+				formatTrialBalanceAmount(line.Credit))
+		}
+		fmt.Printf("%-57s %12.2f %12.2f\n", "Total", debit, credit)
+		return nil
+
+	case "csv":
+		cw := csv.NewWriter(os.Stdout)
+		records := [][]string{{"Code", "Account", "Debit", "Credit"}}
+		for _, line := range lines {
+			records = append(records, []string{line.Code, line.Name, strconv.FormatFloat(line.Debit, 'f', 2, 64),
+				strconv.FormatFloat(line.Credit, 'f', 2, 64)})
+		}
+		records = append(records, []string{"", "Total", strconv.FormatFloat(debit, 'f', 2, 64),
+			strconv.FormatFloat(credit, 'f', 2, 64)})
+		return cw.WriteAll(records)
+
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(lines)
+	}
+	return errors.New("unknown format '" + *format + "', it should be 'text', 'csv' or 'json'")
+}
+
+// zero amounts are left empty
+func formatTrialBalanceAmount(amount float64) string {
+	if amount == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+// both dates are inclusive, the returned end date is exclusive
+func parseReportPeriod(from, to string) (time.Time, time.Time, error) {
+	since, err := parseCommandDate(from)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	until, err := parseCommandDate(to)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if until.Before(since) {
+		return time.Time{}, time.Time{}, errors.New("the end of the period is before its start")
+	}
+	return since, until.AddDate(0, 0, 1), nil
+}
+
+// writes the rows of the report as a text table or CSV, and the report itself as JSON.
+// Warnings are printed after the text table only, JSON has them in the report
+func writeReport(w io.Writer, format, title string, headers []string, rows []ui.ReportRow, warnings []string,
+	report interface{}) error {
+
+	switch format {
+	case "text":
+		fmt.Fprintf(w, "%s\n\n", title)
+		fmt.Fprintf(w, "%-40s %26s %26s\n", headers[0], headers[1], headers[2])
+		for _, row := range rows {
+			if row.IsHeader {
+				fmt.Fprintf(w, "%s\n", row.Label)
+				continue
+			}
+			if row.IsTotal {
+				fmt.Fprintf(w, "%-40s %26s %26s\n", "", "----------", "----------")
+			}
+			fmt.Fprintf(w, "%-40s %26s %26s\n", row.Label, formatAmount(row.Current), formatAmount(row.Previous))
+		}
+		for _, warning := range warnings {
+			fmt.Fprintf(w, "\n%s\n", warning)
+		}
+		return nil
+
+	case "csv":
+		cw := csv.NewWriter(w)
+		if err := cw.Write(headers); err != nil {
+			return err
+		}
+		for _, row := range rows {
+			record := []string{strings.TrimSpace(row.Label), "", ""}
+			if !row.IsHeader {
+				record[1] = strconv.FormatFloat(row.Current, 'f', 2, 64)
+				record[2] = strconv.FormatFloat(row.Previous, 'f', 2, 64)
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	return errors.New("unknown format '" + format + "', it should be 'text', 'csv' or 'json'")
+}
+
+// negative amounts are in brackets, as accountants write them
+func formatAmount(amount float64) string {
+	if amount < 0 {
+		return fmt.Sprintf("(£%.2f)", -amount)
+	}
+	return fmt.Sprintf("£%.2f", amount)
+}
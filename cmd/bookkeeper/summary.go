@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/conf"
+	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/tax"
+	"github.com/w32blaster/tax-bookkeeper/ui"
+)
+
+// "summary [-format=text|json]", the dashboard without the terminal UI
+func commandSummary(d *db.Database, args []string) error {
+	fs := flag.NewFlagSet("summary", flag.ContinueOnError)
+	format := fs.String("format", "text", "'text' or 'json'")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	now := time.Now().In(conf.GMT)
+	accPeriod, err := getNearestAccountingDate(accountingPeriodStartDate, now)
+	if err != nil {
+		return err
+	}
+	calendar, err := getCompanyCalendar(d, accPeriod)
+	if err != nil {
+		return err
+	}
+	vatScheme, err := getVATScheme()
+	if err != nil {
+		return err
+	}
+	taxResidency, err := tax.ParseResidency(residency)
+	if err != nil {
+		return err
+	}
+
+	data, err := ui.CollectDataForDashboard(d, accPeriod, vatScheme, taxResidency, calendar, cashBuffer)
+	if err != nil {
+		return err
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, conf.GMT)
+	summary := ui.NewSummary(data, today)
+
+	switch *format {
+	case "text":
+		fmt.Printf("Safe to withdraw today: £%.2f\n\n", summary.SafeToWithdraw)
+		fmt.Printf("%-40s %14s\n", "Bank balance", formatAmount(summary.BankBalance))
+		fmt.Printf("%-40s %14s\n", "Corporation tax accrued, not paid", formatReserved(summary.Reserve.CorporationTax))
+		fmt.Printf("%-40s %14s\n", "VAT owed", formatReserved(summary.Reserve.VAT))
+		fmt.Printf("%-40s %14s\n", "PAYE due", formatReserved(summary.Reserve.PAYE))
+		fmt.Printf("%-40s %14s\n", "s455 on the director's loan", formatReserved(summary.Reserve.S455))
+		fmt.Printf("%-40s %14s\n", "Buffer", formatReserved(summary.Reserve.Buffer))
+
+		fmt.Println()
+		fmt.Printf("%-40s %14s\n", "Corporation tax so far", formatAmount(summary.CorporationTaxSoFar))
+		fmt.Printf("%-40s %14s\n", "Self assessment so far", formatAmount(summary.SelfAssessmentSoFar))
+		fmt.Printf("%-40s %14s\n", "Director's loan", formatAmount(summary.DirectorsLoan))
+		fmt.Printf("%-40s %14s on %s\n", "Lowest forecast balance", formatAmount(summary.LowestForecastBalance),
+			summary.LowestForecastDate.Format(ui.ReportDateFormat))
+
+		if len(summary.Deadlines) > 0 {
+			fmt.Println("\nUpcoming deadlines:")
+			for _, dl := range summary.Deadlines {
+				fmt.Printf("%s  %s, %s\n", dl.Date.Format("Mon 02 Jan 2006"), dl.Type, dl.Description)
+			}
+		}
+		return nil
+
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(summary)
+	}
+	return errors.New("unknown format '" + *format + "', it should be 'text' or 'json'")
+}
+
+// the reserved amounts are taken from the bank balance
+func formatReserved(amount float64) string {
+	if amount == 0 {
+		return formatAmount(0)
+	}
+	return formatAmount(-amount)
+}
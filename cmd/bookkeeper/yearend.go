@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/conf"
+	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/ledger"
+	"github.com/w32blaster/tax-bookkeeper/ui"
+)
+
+// "year-end close -period=01-04-2024", "year-end reopen -period=01-04-2024" or "year-end list"
+func commandYearEnd(d *db.Database, args []string) error {
+	action := "list"
+	if len(args) > 0 {
+		action = args[0]
+		args = args[1:]
+	}
+
+	fs := flag.NewFlagSet("year-end "+action, flag.ContinueOnError)
+	period := fs.String("period", "", "the first day of the accounting period, like 01-04-2024")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	switch action {
+	case "list":
+		locks, err := d.GetLockedPeriods()
+		if err != nil {
+			return err
+		}
+		if len(locks) == 0 {
+			fmt.Println("No accounting periods are closed yet")
+		}
+		for _, l := range locks {
+			fmt.Printf("%s - %s   closed on %s\n", l.Start.Format(commandDateFormat),
+				l.End.AddDate(0, 0, -1).Format(commandDateFormat), l.ClosedAt.Format(commandDateFormat))
+		}
+		return nil
+
+	case "close", "reopen":
+		start, err := parseAccountingPeriodStart(*period)
+		if err != nil {
+			return err
+		}
+
+		if action == "reopen" {
+			if err := ledger.ReopenPeriod(d, start); err != nil {
+				return err
+			}
+			fmt.Printf("The accounting period %s is reopened, its closing entry and the corporation tax charge are deleted\n", start.Format(commandDateFormat))
+			return nil
+		}
+
+		vat, err := getRegisteredVATScheme()
+		if err != nil {
+			return err
+		}
+		now := time.Now().In(conf.GMT)
+
+		// the results are kept as they are filed, the later periods take the losses from them
+		if err := ui.SaveLossMemorandum(d, vat, start, now); err != nil {
+			return err
+		}
+		ct, err := ui.CollectCorporateTax(d, vat, start, start.AddDate(1, 0, 0))
+		if err != nil {
+			return err
+		}
+		entry, err := ledger.ClosePeriod(d, vat, start, now, ct.CorporateTaxSoFar)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Corporation tax of the period: %.2f\n", ct.CorporateTaxSoFar)
+		for _, line := range entry.Lines {
+			fmt.Printf("  %s   Dr %10.2f   Cr %10.2f\n", line.Account, line.Debit, line.Credit)
+		}
+		fmt.Printf("The accounting period %s is closed, transactions and journals of the period can't be changed "+
+			"until it is reopened\n", start.Format(commandDateFormat))
+		return nil
+	}
+
+	return errors.New("unknown year-end action '" + action + "', it should be 'close', 'reopen' or 'list'")
+}
+
+// the date must be the first day of an accounting period
+func parseAccountingPeriodStart(period string) (time.Time, error) {
+	if period == "" {
+		return time.Time{}, errors.New("please specify the accounting period with -period, like -period=01-04-2024")
+	}
+	start, err := parseCommandDate(period)
+	if err != nil {
+		return time.Time{}, err
+	}
+	accPeriod, err := getNearestAccountingDate(accountingPeriodStartDate, start)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !accPeriod.Equal(start) {
+		return time.Time{}, errors.New("the accounting period starts on " + accPeriod.Format(commandDateFormat) +
+			", not on " + period)
+	}
+	return start, nil
+}
@@ -4,15 +4,250 @@ import "time"
 
 // historical Corporation Tax Rates
 // https://www.gov.uk/corporation-tax-rates
+// since 2023 it is the main rate, see CorporationTaxSmallProfits
 var CorporationTaxRates = map[string]float64{
 	"2015-2016": 0.2,
 	"2016-2017": 0.2,
 	"2017-2018": 0.19,
+	"2018-2019": 0.19,
 	"2019-2020": 0.19,
 	"2020-2021": 0.19,
+	"2021-2022": 0.19,
+	"2022-2023": 0.19,
+	"2023-2024": 0.25,
+	"2024-2025": 0.25,
+	"2025-2026": 0.25,
+	"2026-2027": 0.25,
+}
+
+type SmallProfits struct {
+	Rate                   float64 // small profits rate for profits up to the lower limit
+	LowerLimit             float64
+	UpperLimit             float64 // profits above it are taxed at the main rate
+	MarginalReliefFraction float64 // standard fraction for profits between limits
+}
+
+// small profits rate and marginal relief, since 1 April 2023
+// https://www.gov.uk/guidance/corporation-tax-marginal-relief
+var CorporationTaxSmallProfits = map[string]SmallProfits{
+	"2023-2024": {0.19, 50000, 250000, 3.0 / 200},
+	"2024-2025": {0.19, 50000, 250000, 3.0 / 200},
+	"2025-2026": {0.19, 50000, 250000, 3.0 / 200},
+	"2026-2027": {0.19, 50000, 250000, 3.0 / 200},
+}
+
+type NationalInsurance struct {
+	PrimaryThreshold    float64 // employee starts paying Class 1 NI
+	SecondaryThreshold  float64 // employer starts paying Class 1 NI
+	UpperEarningsLimit  float64
+	EmployeeRate        float64 // between the primary threshold and the upper earnings limit
+	EmployeeUpperRate   float64 // above the upper earnings limit
+	EmployerRate        float64 // above the secondary threshold
+	EmploymentAllowance float64 // yearly reduction of the employer NI, not available for a sole director company
+}
+
+// yearly Class 1 National Insurance thresholds and rates by tax year (6 April - 5 April)
+// https://www.gov.uk/guidance/rates-and-thresholds-for-employers-2025-to-2026
+var NationalInsuranceRates = map[string]NationalInsurance{
+	"2019-2020": {8632, 8632, 50000, 0.12, 0.02, 0.138, 3000},
+	"2020-2021": {9500, 8788, 50000, 0.12, 0.02, 0.138, 4000},
+	"2021-2022": {9568, 8840, 50270, 0.12, 0.02, 0.138, 4000},
+	"2022-2023": {11908, 9100, 50270, 0.1273, 0.0273, 0.1453, 5000}, // annual equivalents of the Health and Social Care levy year
+	"2023-2024": {12570, 9100, 50270, 0.115, 0.02, 0.138, 5000},     // annual equivalent of 12% cut to 10% in January 2024
+	"2024-2025": {12570, 9100, 50270, 0.08, 0.02, 0.138, 5000},
+	"2025-2026": {12570, 5000, 50270, 0.08, 0.02, 0.15, 10500},
+	"2026-2027": {12570, 5000, 50270, 0.08, 0.02, 0.15, 10500},
+}
+
+type DividendTax struct {
+	Allowance      float64 // tax-free dividend allowance
+	OrdinaryRate   float64 // within the basic rate band
+	UpperRate      float64 // within the higher rate band
+	AdditionalRate float64 // within the additional rate band
+}
+
+// historical dividend tax rates and allowances by tax year (6 April - 5 April)
+// https://www.gov.uk/tax-on-dividends
+var DividendTaxRates = map[string]DividendTax{
+	"2016-2017": {5000, 0.075, 0.325, 0.381},
+	"2017-2018": {5000, 0.075, 0.325, 0.381},
+	"2018-2019": {2000, 0.075, 0.325, 0.381},
+	"2019-2020": {2000, 0.075, 0.325, 0.381},
+	"2020-2021": {2000, 0.075, 0.325, 0.381},
+	"2021-2022": {2000, 0.075, 0.325, 0.381},
+	"2022-2023": {2000, 0.0875, 0.3375, 0.3935},
+	"2023-2024": {1000, 0.0875, 0.3375, 0.3935},
+	"2024-2025": {500, 0.0875, 0.3375, 0.3935},
+	"2025-2026": {500, 0.0875, 0.3375, 0.3935},
+	"2026-2027": {500, 0.1075, 0.3575, 0.3935},
+}
+
+// Personal Allowance by tax year (6 April - 5 April), it is the same across the UK
+// https://www.gov.uk/government/publications/rates-and-allowances-income-tax/income-tax-rates-and-allowances-current-and-past
+var PersonalAllowances = map[string]float64{
+	"2018-2019": 11850,
+	"2019-2020": 12500,
+	"2020-2021": 12500,
+	"2021-2022": 12570,
+	"2022-2023": 12570,
+	"2023-2024": 12570,
+	"2024-2025": 12570,
+	"2025-2026": 12570,
+	"2026-2027": 12570,
+}
+
+type IncomeTaxBand struct {
+	Rate float64
+	UpTo float64 // upper limit of the taxable income (above the personal allowance), 0 for the top band
+}
+
+// income tax bands in England and Northern Ireland: basic, higher and additional rates.
+// Welsh rates of income tax are the same so far, so they are used for Wales too
+// https://www.gov.uk/income-tax-rates
+var IncomeTaxBands = map[string][]IncomeTaxBand{
+	"2018-2019": {{0.2, 34500}, {0.4, 150000}, {0.45, 0}},
+	"2019-2020": {{0.2, 37500}, {0.4, 150000}, {0.45, 0}},
+	"2020-2021": {{0.2, 37500}, {0.4, 150000}, {0.45, 0}},
+	"2021-2022": {{0.2, 37700}, {0.4, 150000}, {0.45, 0}},
+	"2022-2023": {{0.2, 37700}, {0.4, 150000}, {0.45, 0}},
+	"2023-2024": {{0.2, 37700}, {0.4, 125140}, {0.45, 0}},
+	"2024-2025": {{0.2, 37700}, {0.4, 125140}, {0.45, 0}},
+	"2025-2026": {{0.2, 37700}, {0.4, 125140}, {0.45, 0}},
+	"2026-2027": {{0.2, 37700}, {0.4, 125140}, {0.45, 0}},
+}
+
+// Scottish income tax bands: starter, basic, intermediate, higher, advanced and top rates.
+// There was no advanced rate before 2024, so its band is empty
+// https://www.gov.uk/scottish-income-tax
+var ScottishIncomeTaxBands = map[string][]IncomeTaxBand{
+	"2018-2019": {{0.19, 2000}, {0.2, 12150}, {0.21, 31580}, {0.41, 150000}, {0.41, 150000}, {0.46, 0}},
+	"2019-2020": {{0.19, 2049}, {0.2, 12444}, {0.21, 30930}, {0.41, 150000}, {0.41, 150000}, {0.46, 0}},
+	"2020-2021": {{0.19, 2085}, {0.2, 12658}, {0.21, 30930}, {0.41, 150000}, {0.41, 150000}, {0.46, 0}},
+	"2021-2022": {{0.19, 2097}, {0.2, 12726}, {0.21, 31092}, {0.41, 150000}, {0.41, 150000}, {0.46, 0}},
+	"2022-2023": {{0.19, 2162}, {0.2, 13118}, {0.21, 31092}, {0.41, 150000}, {0.41, 150000}, {0.46, 0}},
+	"2023-2024": {{0.19, 2162}, {0.2, 13118}, {0.21, 31092}, {0.42, 125140}, {0.42, 125140}, {0.47, 0}},
+	"2024-2025": {{0.19, 2306}, {0.2, 13991}, {0.21, 31092}, {0.42, 62430}, {0.45, 125140}, {0.48, 0}},
+	"2025-2026": {{0.19, 2827}, {0.2, 14921}, {0.21, 31092}, {0.42, 62430}, {0.45, 125140}, {0.48, 0}},
+}
+
+// s455 tax on director's loans not repaid within 9 months after the end of the accounting period,
+// it is the same as the dividend upper rate
+// https://www.gov.uk/directors-loans/if-you-owe-your-company-money
+var S455Rates = map[string]float64{
+	"2016-2017": 0.325,
+	"2017-2018": 0.325,
+	"2018-2019": 0.325,
+	"2019-2020": 0.325,
+	"2020-2021": 0.325,
+	"2021-2022": 0.325,
+	"2022-2023": 0.3375,
+	"2023-2024": 0.3375,
+	"2024-2025": 0.3375,
+	"2025-2026": 0.3375,
+	"2026-2027": 0.3375,
+}
+
+// HMRC official rate of interest for beneficial loans by tax year
+// https://www.gov.uk/government/publications/rates-and-allowances-beneficial-loan-arrangements-hmrc-official-rates
+var OfficialInterestRates = map[string]float64{
+	"2017-2018": 0.025,
+	"2018-2019": 0.025,
+	"2019-2020": 0.025,
+	"2020-2021": 0.0225,
+	"2021-2022": 0.02,
+	"2022-2023": 0.02,
+	"2023-2024": 0.0225,
+	"2024-2025": 0.0225,
+	"2025-2026": 0.0375,
+}
+
+type CapitalAllowanceRates struct {
+	AnnualInvestmentAllowance float64 // yearly limit
+	MainRate                  float64 // writing-down allowance of the main pool
+	SpecialRate               float64 // writing-down allowance of the special rate pool
+	SmallPoolLimit            float64 // a pool below this can be written off at once
+	FullExpensing             bool    // 100% first year allowance for the main pool assets, since 1 April 2023
+}
+
+// capital allowances for plant and machinery by financial year (1 April - 31 March).
+// The AIA limit went up from £200,000 to £1,000,000 on 1 January 2019, the transitional rules are not supported
+// https://www.gov.uk/capital-allowances/annual-investment-allowance
+// https://www.gov.uk/work-out-capital-allowances/rates-and-pools
+var CapitalAllowances = map[string]CapitalAllowanceRates{
+	"2016-2017": {200000, 0.18, 0.08, 1000, false},
+	"2017-2018": {200000, 0.18, 0.08, 1000, false},
+	"2018-2019": {200000, 0.18, 0.08, 1000, false},
+	"2019-2020": {1000000, 0.18, 0.06, 1000, false},
+	"2020-2021": {1000000, 0.18, 0.06, 1000, false},
+	"2021-2022": {1000000, 0.18, 0.06, 1000, false},
+	"2022-2023": {1000000, 0.18, 0.06, 1000, false},
+	"2023-2024": {1000000, 0.18, 0.06, 1000, true},
+	"2024-2025": {1000000, 0.18, 0.06, 1000, true},
+	"2025-2026": {1000000, 0.18, 0.06, 1000, true},
+	"2026-2027": {1000000, 0.18, 0.06, 1000, true},
 }
 
 var GMT, _ = time.LoadLocation("GMT")
 
+// RateChange is a rate effective since the date until the next change
+type RateChange struct {
+	Since time.Time
+	Rate  float64
+}
+
+// Bank of England base rate changes, HMRC late payment interest is the base rate plus a spread
+// https://www.bankofengland.co.uk/boeapps/database/Bank-Rate.asp
+var BankBaseRates = []RateChange{
+	{dateOf(2016, time.August, 4), 0.0025},
+	{dateOf(2017, time.November, 2), 0.005},
+	{dateOf(2018, time.August, 2), 0.0075},
+	{dateOf(2020, time.March, 11), 0.0025},
+	{dateOf(2020, time.March, 19), 0.001},
+	{dateOf(2021, time.December, 16), 0.0025},
+	{dateOf(2022, time.February, 3), 0.005},
+	{dateOf(2022, time.March, 17), 0.0075},
+	{dateOf(2022, time.May, 5), 0.01},
+	{dateOf(2022, time.June, 16), 0.0125},
+	{dateOf(2022, time.August, 4), 0.0175},
+	{dateOf(2022, time.September, 22), 0.0225},
+	{dateOf(2022, time.November, 3), 0.03},
+	{dateOf(2022, time.December, 15), 0.035},
+	{dateOf(2023, time.February, 2), 0.04},
+	{dateOf(2023, time.March, 23), 0.0425},
+	{dateOf(2023, time.May, 11), 0.045},
+	{dateOf(2023, time.June, 22), 0.05},
+	{dateOf(2023, time.August, 3), 0.0525},
+	{dateOf(2024, time.August, 1), 0.05},
+	{dateOf(2024, time.November, 7), 0.0475},
+	{dateOf(2025, time.February, 6), 0.045},
+	{dateOf(2025, time.May, 8), 0.0425},
+	{dateOf(2025, time.August, 7), 0.04},
+}
+
+// HMRC late payment interest spread above the base rate
+// https://www.gov.uk/government/publications/rates-and-allowances-hmrc-interest-rates-for-late-and-early-payments
+var LatePaymentInterestSpreads = []RateChange{
+	{dateOf(2009, time.September, 29), 0.025},
+	{dateOf(2025, time.April, 6), 0.04},
+}
+
+// VATLatePaymentPenalty is the MTD VAT late payment penalty regime since January 2023
+type VATLatePaymentPenalty struct {
+	Since      time.Time
+	Day15Rate  float64 // of the tax still unpaid on day 15
+	Day30Rate  float64 // of the tax still unpaid on day 30
+	YearlyRate float64 // daily second penalty from day 31 until paid
+}
+
+// https://www.gov.uk/guidance/how-late-payment-penalties-work-if-you-pay-vat-late
+var VATLatePaymentPenalties = []VATLatePaymentPenalty{
+	{dateOf(2023, time.January, 1), 0.02, 0.02, 0.04},
+	{dateOf(2025, time.April, 1), 0.03, 0.03, 0.10},
+}
+
+func dateOf(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, GMT)
+}
+
 type App struct {
 }
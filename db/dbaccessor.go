@@ -1,12 +1,14 @@
 package db
 
 import (
+	"errors"
 	"github.com/asdine/storm/v3"
 	"github.com/asdine/storm/v3/codec/msgpack"
 	"github.com/asdine/storm/v3/q"
 	"go.etcd.io/bbolt"
 	"log"
 	"math"
+	"sort"
 	"time"
 )
 
@@ -14,6 +16,9 @@ type Database struct {
 	db *storm.DB
 }
 
+// expenses in the profit and loss account, VAT could be reclaimed on them
+var expenseCategories = []TransactionCategory{Legal, Travel, Office, EquipmentExpenses, Premises, ExpenseReimbursement}
+
 func Init(dbPathFile string) *Database {
 
 	// Open Storm DB
@@ -23,6 +28,16 @@ func Init(dbPathFile string) *Database {
 	}
 
 	boltdb.Init(&Transaction{})
+	boltdb.Init(&Invoice{})
+	boltdb.Init(&Employee{})
+	boltdb.Init(&Payslip{})
+	boltdb.Init(&PAYEPayment{})
+	boltdb.Init(&FixedAsset{})
+	boltdb.Init(&LossMemorandum{})
+	boltdb.Init(&FilingRecord{})
+	boltdb.Init(&JournalEntry{})
+	boltdb.Init(&Account{})
+	boltdb.Init(&LockedPeriod{})
 
 	return &Database{
 		db: boltdb,
@@ -75,18 +90,56 @@ func (d Database) GetTransactionsByCategories(categories ...TransactionCategory)
 	return transactions, nil
 }
 
-func (d Database) AllocateTransactions(cats map[int]TransactionCategory) error {
+// GetTransactionsByAccounts returns transactions allocated to the accounts ordered by date
+func (d Database) GetTransactionsByAccounts(accounts ...string) ([]Transaction, error) {
+	if len(accounts) == 0 {
+		return []Transaction{}, nil
+	}
+
+	var transactions []Transaction
+	if err := d.db.Select(q.In("Account", accounts)).OrderBy("Date").Find(&transactions); err != nil {
+		if err == storm.ErrNotFound {
+			return []Transaction{}, nil
+		}
+		return []Transaction{}, err
+	}
+	return transactions, nil
+}
+
+// AllocateTransactions allocates transactions to the accounts of the chart, the map is transaction ID to account code
+func (d Database) AllocateTransactions(accounts map[int]string) error {
+	chart, err := d.getChart()
+	if err != nil {
+		return err
+	}
+	locks, err := d.GetLockedPeriods()
+	if err != nil {
+		return err
+	}
+
 	tx, err := d.db.Begin(true)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	for pk, cat := range cats {
-		if err := tx.UpdateField(&Transaction{Pk: pk}, "ToBeAllocated", false); err != nil {
+	for pk, code := range accounts {
+		account, ok := chart[code]
+		if !ok {
+			return errors.New("there is no account '" + code + "' in the chart of accounts")
+		}
+
+		var t Transaction
+		if err := tx.One("Pk", pk, &t); err != nil {
 			return err
 		}
-		if err := tx.UpdateField(&Transaction{Pk: pk}, "Category", cat); err != nil {
+		if err := checkNotLocked(locks, t.Date); err != nil {
+			return err
+		}
+		t.ToBeAllocated = false
+		t.Account = code
+		t.Category = account.CategoryOf(t)
+		if err := tx.Update(&t); err != nil {
 			return err
 		}
 	}
@@ -101,6 +154,10 @@ func (d Database) GetUnallocated() ([]Transaction, error) {
 }
 
 func (d Database) ImportTransactions(transactions []Transaction) (int, error) {
+	locks, err := d.GetLockedPeriods()
+	if err != nil {
+		return 0, err
+	}
 
 	tx, err := d.db.Begin(true)
 	if err != nil {
@@ -113,6 +170,9 @@ func (d Database) ImportTransactions(transactions []Transaction) (int, error) {
 		if len(v.Description) == 0 && v.Balance == 0.0 {
 			continue
 		}
+		if err := checkNotLocked(locks, v.Date); err != nil {
+			return 0, err
+		}
 
 		if err := tx.Save(&v); err != nil {
 			log.Fatalf("Can't save transaction, because %s", err.Error())
@@ -122,6 +182,618 @@ func (d Database) ImportTransactions(transactions []Transaction) (int, error) {
 	return len(transactions), tx.Commit()
 }
 
+func (d Database) SaveInvoice(invoice *Invoice) error {
+	dates := []time.Time{invoice.IssueDate}
+	if invoice.Pk != 0 {
+		var old Invoice
+		if err := d.db.One("Pk", invoice.Pk, &old); err != nil {
+			return err
+		}
+		dates = append(dates, old.IssueDate)
+	}
+	if err := d.checkNotLocked(dates...); err != nil {
+		return err
+	}
+	return d.db.Save(invoice)
+}
+
+// GetInvoices returns all sales invoices and supplier bills ordered by the issue date
+func (d Database) GetInvoices() ([]Invoice, error) {
+	var invoices []Invoice
+	if err := d.db.AllByIndex("IssueDate", &invoices); err != nil {
+		if err == storm.ErrNotFound {
+			return []Invoice{}, nil
+		}
+		return []Invoice{}, err
+	}
+	return invoices, nil
+}
+
+// LinkInvoice marks the invoice as paid by the bank transaction, the cash accounting takes VAT on it that day
+func (d Database) LinkInvoice(invoicePk, transactionPk int) error {
+	t, err := d.GetTransaction(transactionPk)
+	if err != nil {
+		return err
+	}
+	var invoice Invoice
+	if err := d.db.One("Pk", invoicePk, &invoice); err != nil {
+		return err
+	}
+	if err := d.checkNotLocked(t.Date, invoice.IssueDate); err != nil {
+		return err
+	}
+	return d.db.UpdateField(&Invoice{Pk: invoicePk}, "TransactionPk", transactionPk)
+}
+
+func (d Database) SaveEmployee(employee *Employee) error {
+	return d.db.Save(employee)
+}
+
+// GetEmployees returns employees who didn't leave
+func (d Database) GetEmployees() ([]Employee, error) {
+	var employees []Employee
+	if err := d.db.Find("Left", false, &employees); err != nil {
+		if err == storm.ErrNotFound {
+			return []Employee{}, nil
+		}
+		return []Employee{}, err
+	}
+	return employees, nil
+}
+
+// GetPayslips returns all payslips of the tax year ordered by the tax month
+func (d Database) GetPayslips(taxYear string) ([]Payslip, error) {
+	var payslips []Payslip
+	if err := d.db.Select(q.Eq("TaxYear", taxYear)).OrderBy("TaxMonth").Find(&payslips); err != nil {
+		if err == storm.ErrNotFound {
+			return []Payslip{}, nil
+		}
+		return []Payslip{}, err
+	}
+	return payslips, nil
+}
+
+// GetPAYEPayments returns amounts due to HMRC for the tax year ordered by the tax month
+func (d Database) GetPAYEPayments(taxYear string) ([]PAYEPayment, error) {
+	var payments []PAYEPayment
+	if err := d.db.Select(q.Eq("TaxYear", taxYear)).OrderBy("TaxMonth").Find(&payments); err != nil {
+		if err == storm.ErrNotFound {
+			return []PAYEPayment{}, nil
+		}
+		return []PAYEPayment{}, err
+	}
+	return payments, nil
+}
+
+// GetAllEmployees returns everyone who has ever been on the payroll, including the ones who left
+func (d Database) GetAllEmployees() ([]Employee, error) {
+	var employees []Employee
+	if err := d.db.All(&employees); err != nil {
+		return []Employee{}, err
+	}
+	return employees, nil
+}
+
+// GetAllPayslips returns the payslips of all the tax years
+func (d Database) GetAllPayslips() ([]Payslip, error) {
+	var payslips []Payslip
+	if err := d.db.All(&payslips); err != nil {
+		return []Payslip{}, err
+	}
+	return payslips, nil
+}
+
+// GetAllPAYEPayments returns amounts due to HMRC of all the tax years
+func (d Database) GetAllPAYEPayments() ([]PAYEPayment, error) {
+	var payments []PAYEPayment
+	if err := d.db.All(&payments); err != nil {
+		return []PAYEPayment{}, err
+	}
+	return payments, nil
+}
+
+// GetDirectorsPAYE returns the income tax deducted from directors' salaries through the payroll in the tax year
+func (d Database) GetDirectorsPAYE(taxYear string) (float64, error) {
+	employees, err := d.GetAllEmployees()
+	if err != nil {
+		return 0, err
+	}
+
+	directors := map[int]bool{}
+	for _, e := range employees {
+		directors[e.Pk] = e.IsDirector
+	}
+
+	payslips, err := d.GetPayslips(taxYear)
+	if err != nil {
+		return 0, err
+	}
+
+	var paye float64
+	for _, p := range payslips {
+		if directors[p.EmployeePk] {
+			paye = paye + p.IncomeTax
+		}
+	}
+	return paye, nil
+}
+
+// SavePayroll saves payslips of one month together with the amount due to HMRC
+func (d Database) SavePayroll(payslips []Payslip, payment *PAYEPayment) error {
+	// the payroll is posted on the pay dates, or on the due date if nobody was paid
+	dates := []time.Time{payment.DueDate}
+	if len(payslips) > 0 {
+		dates = dates[:0]
+		for _, p := range payslips {
+			dates = append(dates, p.PayDate)
+		}
+	}
+	if err := d.checkNotLocked(dates...); err != nil {
+		return err
+	}
+
+	tx, err := d.db.Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i := range payslips {
+		if err := tx.Save(&payslips[i]); err != nil {
+			return err
+		}
+	}
+	if err := tx.Save(payment); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// LinkPayslip links the payslip to the bank transaction that paid the salary
+func (d Database) LinkPayslip(payslipPk, transactionPk int) error {
+	t, err := d.GetTransaction(transactionPk)
+	if err != nil {
+		return err
+	}
+	var payslip Payslip
+	if err := d.db.One("Pk", payslipPk, &payslip); err != nil {
+		return err
+	}
+	if err := d.checkNotLocked(t.Date, payslip.PayDate); err != nil {
+		return err
+	}
+	return d.db.UpdateField(&Payslip{Pk: payslipPk}, "TransactionPk", transactionPk)
+}
+
+// LinkPAYEPayment links the amount due to HMRC to the bank transaction that paid it
+func (d Database) LinkPAYEPayment(paymentPk, transactionPk int) error {
+	t, err := d.GetTransaction(transactionPk)
+	if err != nil {
+		return err
+	}
+	var payment PAYEPayment
+	if err := d.db.One("Pk", paymentPk, &payment); err != nil {
+		return err
+	}
+	if err := d.checkNotLocked(t.Date, payment.DueDate); err != nil {
+		return err
+	}
+	return d.db.UpdateField(&PAYEPayment{Pk: paymentPk}, "TransactionPk", transactionPk)
+}
+
+func (d Database) SaveFixedAsset(asset *FixedAsset) error {
+	if err := d.checkNotLocked(asset.PurchaseDate); err != nil {
+		return err
+	}
+	return d.db.Save(asset)
+}
+
+// GetFixedAssets returns the whole fixed asset register ordered by the purchase date
+func (d Database) GetFixedAssets() ([]FixedAsset, error) {
+	var assets []FixedAsset
+	if err := d.db.AllByIndex("PurchaseDate", &assets); err != nil {
+		if err == storm.ErrNotFound {
+			return []FixedAsset{}, nil
+		}
+		return []FixedAsset{}, err
+	}
+	return assets, nil
+}
+
+// DisposeFixedAsset marks the asset as sold or scrapped, transactionPk is the bank transaction of the proceeds
+// or 0 if they are not received yet
+func (d Database) DisposeFixedAsset(assetPk int, date time.Time, proceeds float64, transactionPk int) error {
+	if err := d.checkNotLocked(date); err != nil {
+		return err
+	}
+	return d.db.Update(&FixedAsset{Pk: assetPk, DisposalDate: date, DisposalProceeds: proceeds,
+		DisposalTransactionPk: transactionPk})
+}
+
+// GetUnregisteredAssetPurchases returns "Fixed assets purchase" transactions that are not
+// in the fixed asset register yet
+func (d Database) GetUnregisteredAssetPurchases() ([]Transaction, error) {
+	assets, err := d.GetFixedAssets()
+	if err != nil {
+		return []Transaction{}, err
+	}
+
+	registered := map[int]bool{}
+	for _, a := range assets {
+		registered[a.TransactionPk] = true
+	}
+
+	chart, err := d.getChart()
+	if err != nil {
+		return []Transaction{}, err
+	}
+	var capitalAccounts []string
+	for code, a := range chart {
+		if a.Capital {
+			capitalAccounts = append(capitalAccounts, code)
+		}
+	}
+
+	purchases, err := d.GetTransactionsByAccounts(capitalAccounts...)
+	if err != nil {
+		return []Transaction{}, err
+	}
+
+	unregistered := []Transaction{}
+	for _, t := range purchases {
+		if !registered[t.Pk] {
+			unregistered = append(unregistered, t)
+		}
+	}
+	return unregistered, nil
+}
+
+// GetTransaction returns one transaction by its ID
+func (d Database) GetTransaction(pk int) (Transaction, error) {
+	var transaction Transaction
+	err := d.db.One("Pk", pk, &transaction)
+	return transaction, err
+}
+
+// SaveLossMemorandum saves the taxable result of the accounting period, the carry back claim is kept
+func (d Database) SaveLossMemorandum(periodStart time.Time, profit float64) error {
+	memorandum, err := d.getLossMemorandum(periodStart)
+	if err != nil {
+		return err
+	}
+	memorandum.PeriodStart = periodStart
+	memorandum.Profit = profit
+	return d.db.Save(&memorandum)
+}
+
+// ClaimLossCarryBack claims (or withdraws the claim) to carry the loss of the period back to the previous 12 months
+func (d Database) ClaimLossCarryBack(periodStart time.Time, claim bool) error {
+	memorandum, err := d.getLossMemorandum(periodStart)
+	if err != nil {
+		return err
+	}
+	if memorandum.Pk == 0 {
+		return errors.New("there is no result for the accounting period starting " + periodStart.Format("02-01-2006") +
+			", please run the 'losses' command first")
+	}
+	return d.db.UpdateField(&LossMemorandum{Pk: memorandum.Pk}, "CarryBack", claim)
+}
+
+// GetLossMemoranda returns results of all the accounting periods ordered by the period start
+func (d Database) GetLossMemoranda() ([]LossMemorandum, error) {
+	var memoranda []LossMemorandum
+	if err := d.db.AllByIndex("PeriodStart", &memoranda); err != nil {
+		if err == storm.ErrNotFound {
+			return []LossMemorandum{}, nil
+		}
+		return []LossMemorandum{}, err
+	}
+	return memoranda, nil
+}
+
+// returns an empty memorandum if the period is not saved yet
+func (d Database) getLossMemorandum(periodStart time.Time) (LossMemorandum, error) {
+	memoranda, err := d.GetLossMemoranda()
+	if err != nil {
+		return LossMemorandum{}, err
+	}
+	for _, m := range memoranda {
+		if m.PeriodStart.Equal(periodStart) {
+			return m, nil
+		}
+	}
+	return LossMemorandum{}, nil
+}
+
+// SaveFilingRecord saves when the return for the period was filed, replacing the previous record of the same return
+func (d Database) SaveFilingRecord(record FilingRecord) error {
+	records, err := d.GetFilingRecords()
+	if err != nil {
+		return err
+	}
+	// what happened in a closed period can't be changed, the filing of its own returns can be recorded later
+	dates := []time.Time{record.Filed, record.Paid}
+	for _, r := range records {
+		if r.Return == record.Return && r.PeriodEnd.Equal(record.PeriodEnd) {
+			record.Pk = r.Pk
+			dates = append(dates, r.Filed, r.Paid)
+		}
+	}
+	if err := d.checkNotLocked(dates...); err != nil {
+		return err
+	}
+	return d.db.Save(&record)
+}
+
+// GetFilingRecords returns all the filing records
+func (d Database) GetFilingRecords() ([]FilingRecord, error) {
+	var records []FilingRecord
+	if err := d.db.All(&records); err != nil {
+		if err == storm.ErrNotFound {
+			return []FilingRecord{}, nil
+		}
+		return []FilingRecord{}, err
+	}
+	return records, nil
+}
+
+func (d Database) SaveJournalEntry(entry *JournalEntry) error {
+	dates := []time.Time{entry.Date}
+	if entry.Pk != 0 {
+		var old JournalEntry
+		if err := d.db.One("Pk", entry.Pk, &old); err != nil {
+			return err
+		}
+		dates = append(dates, old.Date)
+	}
+	if err := d.checkNotLocked(dates...); err != nil {
+		return err
+	}
+	return d.db.Save(entry)
+}
+
+// GetJournalEntries returns all the manual journals ordered by date
+func (d Database) GetJournalEntries() ([]JournalEntry, error) {
+	var entries []JournalEntry
+	if err := d.db.AllByIndex("Date", &entries); err != nil {
+		if err == storm.ErrNotFound {
+			return []JournalEntry{}, nil
+		}
+		return []JournalEntry{}, err
+	}
+	return entries, nil
+}
+
+func (d Database) DeleteJournalEntry(pk int) error {
+	var entry JournalEntry
+	if err := d.db.One("Pk", pk, &entry); err != nil {
+		return err
+	}
+	if err := d.checkNotLocked(entry.Date); err != nil {
+		return err
+	}
+	return d.db.DeleteStruct(&entry)
+}
+
+// LockPeriod closes the accounting period, so that its transactions and journals can't be changed
+func (d Database) LockPeriod(period LockedPeriod) error {
+	locks, err := d.GetLockedPeriods()
+	if err != nil {
+		return err
+	}
+	for _, l := range locks {
+		if period.Start.Before(l.End) && l.Start.Before(period.End) {
+			return errors.New("the period " + l.Start.Format("02-01-2006") + " is closed already")
+		}
+	}
+	return d.db.Save(&period)
+}
+
+// UnlockPeriod reopens the accounting period starting on the date
+func (d Database) UnlockPeriod(start time.Time) error {
+	var period LockedPeriod
+	if err := d.db.One("Start", start, &period); err != nil {
+		if err == storm.ErrNotFound {
+			return errors.New("the period " + start.Format("02-01-2006") + " is not closed")
+		}
+		return err
+	}
+	return d.db.DeleteStruct(&period)
+}
+
+// GetLockedPeriods returns the closed accounting periods
+func (d Database) GetLockedPeriods() ([]LockedPeriod, error) {
+	var locks []LockedPeriod
+	if err := d.db.AllByIndex("Start", &locks); err != nil {
+		if err == storm.ErrNotFound {
+			return []LockedPeriod{}, nil
+		}
+		return []LockedPeriod{}, err
+	}
+	return locks, nil
+}
+
+func (d Database) checkNotLocked(dates ...time.Time) error {
+	locks, err := d.GetLockedPeriods()
+	if err != nil {
+		return err
+	}
+	return checkNotLocked(locks, dates...)
+}
+
+func checkNotLocked(locks []LockedPeriod, dates ...time.Time) error {
+	for _, l := range locks {
+		for _, date := range dates {
+			if l.Contains(date) {
+				return errors.New("the date " + date.Format("02-01-2006") + " is in the closed accounting period " +
+					l.Start.Format("02-01-2006") + " - " + l.End.AddDate(0, 0, -1).Format("02-01-2006") +
+					", please reopen it first")
+			}
+		}
+	}
+	return nil
+}
+
+// SaveAccount adds the account to the chart of accounts, or updates the account with the same code
+func (d Database) SaveAccount(account *Account) error {
+	if account.Code == "" || account.Name == "" {
+		return errors.New("the account needs a code and a name")
+	}
+	if account.Type < AssetAccount || account.Type > ExpenseAccount {
+		return errors.New("unknown type of the account " + account.Code)
+	}
+
+	chart, err := d.getChart()
+	if err != nil {
+		return err
+	}
+	if account.Parent != "" {
+		if _, ok := chart[account.Parent]; !ok || account.Parent == account.Code {
+			return errors.New("there is no parent account '" + account.Parent + "' in the chart of accounts")
+		}
+	}
+
+	// the link to the old category is kept, it is used for the transactions which are not migrated yet
+	existing := chart[account.Code]
+	account.Pk = existing.Pk
+	if account.Category == 0 {
+		account.Category = existing.Category
+	}
+	return d.db.Save(account)
+}
+
+// GetAccounts returns the chart of accounts ordered by code
+func (d Database) GetAccounts() ([]Account, error) {
+	var accounts []Account
+	if err := d.db.All(&accounts); err != nil {
+		if err == storm.ErrNotFound {
+			return []Account{}, nil
+		}
+		return []Account{}, err
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Code < accounts[j].Code
+	})
+	return accounts, nil
+}
+
+// GetAccount returns the account of the chart by its code
+func (d Database) GetAccount(code string) (Account, error) {
+	var account Account
+	err := d.db.One("Code", code, &account)
+	return account, err
+}
+
+// SeedAccounts saves the accounts of the chart which are not in the database yet, so that a database created by
+// an older version gets the new accounts. The accounts already saved are kept as they are, even if the user
+// changed them
+func (d Database) SeedAccounts(chart []Account) error {
+	existing, err := d.getChart()
+	if err != nil {
+		return err
+	}
+
+	tx, err := d.db.Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i := range chart {
+		account := chart[i]
+		if _, ok := existing[account.Code]; ok {
+			continue
+		}
+		if err := tx.Save(&account); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// AssignAccounts allocates transactions which have a category, but no account yet, to the account of
+// their category. Transactions of the deprecated Personal category are recognised by the description,
+// everything that is not a salary is a dividend. Closed periods are skipped. Returns how many transactions
+// were migrated
+func (d Database) AssignAccounts() (int, error) {
+	var accounts []Account
+	if err := d.db.All(&accounts); err != nil {
+		return 0, err
+	}
+	byCategory := map[TransactionCategory]string{}
+	for _, a := range accounts {
+		if a.Category != 0 {
+			byCategory[a.Category] = a.Code
+		}
+	}
+	byCategory[LoansReturn] = byCategory[Loan]
+
+	var transactions []Transaction
+	if err := d.db.Select(q.Eq("Account", "")).Find(&transactions); err != nil && err != storm.ErrNotFound {
+		return 0, err
+	}
+	locks, err := d.GetLockedPeriods()
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := d.db.Begin(true)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var migrated int
+	for _, t := range transactions {
+		if t.ToBeAllocated || checkNotLocked(locks, t.Date) != nil {
+			continue
+		}
+
+		category := t.Category
+		if category == Personal {
+			if category = GuessPersonalCategory(t.Description); category == Personal {
+				category = Dividend
+			}
+		}
+		code, ok := byCategory[category]
+		if !ok {
+			continue
+		}
+
+		if err := tx.UpdateField(&Transaction{Pk: t.Pk}, "Account", code); err != nil {
+			return 0, err
+		}
+		migrated++
+	}
+	return migrated, tx.Commit()
+}
+
+// the chart of accounts by code
+func (d Database) getChart() (map[string]Account, error) {
+	accounts, err := d.GetAccounts()
+	if err != nil {
+		return nil, err
+	}
+	chart := make(map[string]Account, len(accounts))
+	for _, a := range accounts {
+		chart[a.Code] = a
+	}
+	return chart, nil
+}
+
+// GetFirstTransactionDate returns the date of the earliest imported transaction, zero if nothing is imported yet
+func (d Database) GetFirstTransactionDate() (time.Time, error) {
+	var transactions []Transaction
+	if err := d.db.AllByIndex("Date", &transactions, storm.Limit(1)); err != nil && err != storm.ErrNotFound {
+		return time.Time{}, err
+	}
+	if len(transactions) == 0 {
+		return time.Time{}, nil
+	}
+	return transactions[0].Date, nil
+}
+
 func (d Database) GetRevenueSince(accountingDateStart time.Time, accountingDateEnd time.Time) (float64, error) {
 
 	var transactions []Transaction
@@ -149,7 +821,67 @@ func (d Database) GetRevenueSince(accountingDateStart time.Time, accountingDateE
 }
 
 func (d Database) GetExpensesSince(accountingDateStart time.Time, accountingDateEnd time.Time) (float64, error) {
-	return _calculateExpensesByType(d.db, accountingDateStart, accountingDateEnd, Legal, Travel, Office, EquipmentExpenses, Premises, FixedAssetPurchase)
+	return _calculateExpensesByType(d.db, accountingDateStart, accountingDateEnd, expenseCategories...)
+}
+
+// GetDisallowableSince returns the disallowable part of expenses by account code, it is added back
+// in the corporation tax computation
+func (d Database) GetDisallowableSince(accountingDateStart time.Time, accountingDateEnd time.Time) (map[string]float64, error) {
+	chart, err := d.getChart()
+	if err != nil {
+		return nil, err
+	}
+
+	var expenseAccounts []string
+	for code, a := range chart {
+		if a.Type == ExpenseAccount {
+			expenseAccounts = append(expenseAccounts, code)
+		}
+	}
+
+	transactions, err := _findDebitTransactionsOfAccounts(d.db, accountingDateStart, accountingDateEnd, expenseAccounts...)
+	if err != nil {
+		return nil, err
+	}
+
+	disallowable := map[string]float64{}
+	for _, t := range transactions {
+		if amount := t.DisallowableAmount(chart[t.Account]); amount > 0 {
+			disallowable[t.Account] = disallowable[t.Account] + amount
+		}
+	}
+	return disallowable, nil
+}
+
+// SetCTTreatment marks the expense as allowable or disallowable for corporation tax, privateUse is the percent
+// of a mixed-use cost which is not deductible
+func (d Database) SetCTTreatment(transactionPk int, treatment CTTreatment, privateUse float64) error {
+	t, err := d.GetTransaction(transactionPk)
+	if err != nil {
+		return err
+	}
+	if err := d.checkNotLocked(t.Date); err != nil {
+		return err
+	}
+
+	tx, err := d.db.Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := tx.UpdateField(&Transaction{Pk: transactionPk}, "CTTreatment", treatment); err != nil {
+		return err
+	}
+	if err := tx.UpdateField(&Transaction{Pk: transactionPk}, "PrivateUse", privateUse); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetSalariesSince returns director's salaries and wages of employees, they are deductible for corporation tax
+func (d Database) GetSalariesSince(accountingDateStart time.Time, accountingDateEnd time.Time) (float64, error) {
+	return _calculateExpensesByType(d.db, accountingDateStart, accountingDateEnd, Salary, WagesPayment)
 }
 
 func (d Database) GetPensionSince(accountingDateStart time.Time, accountingDateEnd time.Time) (float64, error) {
@@ -157,11 +889,105 @@ func (d Database) GetPensionSince(accountingDateStart time.Time, accountingDateE
 }
 
 func (d Database) GetMovedOut(since time.Time, until time.Time) (float64, error) {
-	return _calculateExpensesByType(d.db, since, until, Personal)
+	return _calculateExpensesByType(d.db, since, until, Personal, Salary, Dividend)
+}
+
+// MigratePersonalTransactions moves transactions of the deprecated Personal category to Salary, Dividend
+// or ExpenseReimbursement guessing by the description. Transactions that can't be recognised are
+// marked as unallocated, so that user is asked to choose the category again. Closed periods are skipped.
+// Returns how many transactions were migrated and how many should be allocated manually
+func (d Database) MigratePersonalTransactions() (int, int, error) {
+	var transactions []Transaction
+	if err := d.db.Find("Category", Personal, &transactions); err != nil {
+		if err == storm.ErrNotFound {
+			return 0, 0, nil
+		}
+		return 0, 0, err
+	}
+	locks, err := d.GetLockedPeriods()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	tx, err := d.db.Begin(true)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	var migrated, unallocated int
+	for _, t := range transactions {
+		if checkNotLocked(locks, t.Date) != nil {
+			continue
+		}
+
+		cat := GuessPersonalCategory(t.Description)
+		if cat == Personal {
+			if err := tx.UpdateField(&Transaction{Pk: t.Pk}, "ToBeAllocated", true); err != nil {
+				return 0, 0, err
+			}
+			unallocated++
+			continue
+		}
+
+		if err := tx.UpdateField(&Transaction{Pk: t.Pk}, "Category", cat); err != nil {
+			return 0, 0, err
+		}
+		migrated++
+	}
+
+	return migrated, unallocated, tx.Commit()
+}
+
+// GetDebitTransactionsSince returns allocated debit transactions of given categories within the period
+func (d Database) GetDebitTransactionsSince(since time.Time, until time.Time, categories ...TransactionCategory) ([]Transaction, error) {
+	return _findDebitTransactions(d.db, since, until, categories...)
 }
 
 func _calculateExpensesByType(db *storm.DB, since time.Time, until time.Time, categories ...TransactionCategory) (float64, error) {
 
+	transactions, err := _findDebitTransactions(db, since, until, categories...)
+	if err != nil {
+		return 0, err
+	}
+
+	var total float64
+	for _, idx := range transactions {
+		total = total + idx.Debit
+	}
+	return math.Abs(total), nil
+
+}
+
+func _findDebitTransactionsOfAccounts(db *storm.DB, since time.Time, until time.Time, accounts ...string) ([]Transaction, error) {
+	if len(accounts) == 0 {
+		return []Transaction{}, nil
+	}
+
+	// the same range as the ledger, the first day of the period is included
+	query := db.Select(
+		q.And(
+			q.Gte("Date", since),
+			q.Lt("Date", until),
+			q.Eq("Type", Debit),
+			q.Eq("ToBeAllocated", false),
+			q.In("Account", accounts),
+		),
+	).OrderBy("Date")
+
+	var transactions []Transaction
+	if err := query.Find(&transactions); err != nil {
+		if err == storm.ErrNotFound {
+			return []Transaction{}, nil
+		}
+		return []Transaction{}, err
+	}
+
+	return transactions, nil
+}
+
+func _findDebitTransactions(db *storm.DB, since time.Time, until time.Time, categories ...TransactionCategory) ([]Transaction, error) {
+
 	// prepare the query
 	var catMatcher q.Matcher
 	if len(categories) == 1 {
@@ -182,20 +1008,15 @@ func _calculateExpensesByType(db *storm.DB, since time.Time, until time.Time, ca
 			q.Eq("ToBeAllocated", false),
 			catMatcher,
 		),
-	)
+	).OrderBy("Date")
 
 	var transactions []Transaction
 	if err := query.Find(&transactions); err != nil {
 		if err == storm.ErrNotFound {
-			return 0, nil
+			return []Transaction{}, nil
 		}
-		return 0, err
-	}
-
-	var total float64
-	for _, idx := range transactions {
-		total = total + idx.Debit
+		return []Transaction{}, err
 	}
-	return math.Abs(total), nil
 
+	return transactions, nil
 }
@@ -176,6 +176,371 @@ func TestCalculateExpensesNegativeNumbers(t *testing.T) {
 	assert.Equal(t, 260.0, total) // still positive number
 }
 
+func TestCalculateSalaries(t *testing.T) {
+
+	// create real DB
+	const dbFile = "/tmp/tax-bookkeeper-salaries.db"
+	db := Init(dbFile)
+	defer func() {
+		db.Close()
+		os.Remove(dbFile)
+	}()
+
+	// Dates:
+	recently := dateOf("20-12-2019")
+
+	// Populate with data:
+	inserted, err := db.ImportTransactions([]Transaction{
+		_debitTransaction(Salary, 732.0, "Director's salary", recently),
+		_debitTransaction(WagesPayment, 1000.0, "Employee", recently),
+
+		// ignored, dividends are paid from the profit after tax
+		_debitTransaction(Dividend, 5000.0, "Dividends", recently),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, inserted)
+
+	// When:
+	total, err := db.GetSalariesSince(dateOf("01-12-2019"), now)
+
+	// Then:
+	assert.Nil(t, err)
+	assert.Equal(t, 1732.0, total)
+}
+
+func TestMigratePersonalTransactions(t *testing.T) {
+
+	// create real DB
+	const dbFile = "/tmp/tax-bookkeeper-migrate.db"
+	db := Init(dbFile)
+	defer func() {
+		db.Close()
+		os.Remove(dbFile)
+	}()
+
+	// Dates:
+	recently := dateOf("20-12-2019")
+
+	// Populate with data:
+	_, err := db.ImportTransactions([]Transaction{
+		_debitTransaction(Personal, 732.0, "Salary December", recently),
+		_debitTransaction(Personal, 5000.0, "Interim dividend", recently),
+		_debitTransaction(Personal, 50.0, "Expenses for November", recently),
+		_debitTransaction(Personal, 100.0, "Transfer", recently),
+		_debitTransaction(Legal, 100.0, "Accountant", recently),
+	})
+	assert.Nil(t, err)
+
+	// When:
+	migrated, unallocated, err := db.MigratePersonalTransactions()
+
+	// Then:
+	assert.Nil(t, err)
+	assert.Equal(t, 3, migrated)
+	assert.Equal(t, 1, unallocated)
+
+	salaries, _ := db.GetTransactionsByCategories(Salary)
+	assert.Len(t, salaries, 1)
+	dividends, _ := db.GetTransactionsByCategories(Dividend)
+	assert.Len(t, dividends, 1)
+	reimbursements, _ := db.GetTransactionsByCategories(ExpenseReimbursement)
+	assert.Len(t, reimbursements, 1)
+
+	toAllocate, _ := db.GetUnallocated()
+	assert.Len(t, toAllocate, 1)
+	assert.Equal(t, "Transfer", toAllocate[0].Description)
+}
+
+func TestGetInvoices(t *testing.T) {
+
+	// create real DB
+	const dbFile = "/tmp/tax-bookkeeper-invoices.db"
+	db := Init(dbFile)
+	defer func() {
+		db.Close()
+		os.Remove(dbFile)
+	}()
+
+	// Populate with data:
+	for _, inv := range []Invoice{
+		{Type: SalesInvoice, Number: "INV-2", IssueDate: dateOf("20-12-2019"), Net: 250, VAT: 50},
+		{Type: SupplierBill, Number: "B-1", IssueDate: dateOf("05-12-2019"), Net: 50, VAT: 10},
+		{Type: SalesInvoice, Number: "INV-1", IssueDate: dateOf("01-12-2019"), Net: 500, VAT: 100},
+	} {
+		assert.Nil(t, db.SaveInvoice(&inv))
+	}
+
+	// When:
+	invoices, err := db.GetInvoices()
+
+	// Then: both types, ordered by the issue date
+	assert.Nil(t, err)
+	assert.Len(t, invoices, 3)
+	assert.Equal(t, "INV-1", invoices[0].Number)
+	assert.Equal(t, "B-1", invoices[1].Number)
+	assert.Equal(t, "INV-2", invoices[2].Number)
+}
+
+func TestSaveLossMemorandumKeepsCarryBack(t *testing.T) {
+
+	// create real DB
+	const dbFile = "/tmp/tax-bookkeeper-losses.db"
+	db := Init(dbFile)
+	defer func() {
+		db.Close()
+		os.Remove(dbFile)
+	}()
+
+	// Given:
+	assert.Nil(t, db.SaveLossMemorandum(dateOf("01-04-2023"), 40000))
+	assert.Nil(t, db.SaveLossMemorandum(dateOf("01-04-2024"), -10000))
+	assert.Nil(t, db.ClaimLossCarryBack(dateOf("01-04-2024"), true))
+
+	// When: the result is recalculated
+	assert.Nil(t, db.SaveLossMemorandum(dateOf("01-04-2024"), -12000))
+
+	// Then:
+	memoranda, err := db.GetLossMemoranda()
+	assert.Nil(t, err)
+	assert.Len(t, memoranda, 2)
+	assert.Equal(t, -12000.0, memoranda[1].Profit)
+	assert.True(t, memoranda[1].CarryBack)
+	assert.NotNil(t, db.ClaimLossCarryBack(dateOf("01-04-2025"), true))
+}
+
+func TestGetDisallowableSince(t *testing.T) {
+
+	// create real DB
+	const dbFile = "/tmp/tax-bookkeeper-disallowable.db"
+	db := Init(dbFile)
+	defer func() {
+		db.Close()
+		os.Remove(dbFile)
+	}()
+
+	// Populate with data:
+	recently := dateOf("20-12-2019")
+	_, err := db.ImportTransactions([]Transaction{
+		_debitTransaction(Penalties, 100.0, "Late filing penalty", recently),
+		_debitTransaction(Entertaining, 80.0, "Dinner with a client", recently),
+		_debitTransaction(Travel, 200.0, "Car, 30% private use", recently),
+		_debitTransaction(Office, 50.0, "Allowable", recently),
+		_debitTransaction(Penalties, 25.0, "On the first day of the period", dateOf("01-12-2019")),
+		_debitTransaction(Penalties, 40.0, "Next period", dateOf("01-01-2020")),
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, db.SeedAccounts(_chart()))
+	_, err = db.AssignAccounts()
+	assert.Nil(t, err)
+
+	transactions, err := db.GetDebitTransactionsSince(dateOf("01-12-2019"), dateOf("01-01-2020"), Travel, Entertaining)
+	assert.Nil(t, err)
+	for _, tx := range transactions {
+		if tx.Category == Travel {
+			assert.Nil(t, db.SetCTTreatment(tx.Pk, CategoryDefault, 30))
+		} else {
+			assert.Nil(t, db.SetCTTreatment(tx.Pk, Allowable, 0)) // staff entertaining is allowable
+		}
+	}
+
+	// When:
+	disallowable, err := db.GetDisallowableSince(dateOf("01-12-2019"), dateOf("01-01-2020"))
+
+	// Then: the period includes its first day, but not the next one
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]float64{"8200": 125, "7400": 60}, disallowable)
+}
+
+func TestSeedAccountsAddsNewAccounts(t *testing.T) {
+
+	// create real DB
+	const dbFile = "/tmp/tax-bookkeeper-seed.db"
+	db := Init(dbFile)
+	defer func() {
+		db.Close()
+		os.Remove(dbFile)
+	}()
+
+	// Given: the database was seeded by an older version and the user renamed an account
+	chart := _chart()
+	assert.Nil(t, db.SeedAccounts(chart[:3]))
+	office, err := db.GetAccount("7100")
+	assert.Nil(t, err)
+	office.Name = "Stationery"
+	assert.Nil(t, db.SaveAccount(&office))
+
+	// When:
+	err = db.SeedAccounts(chart)
+
+	// Then:
+	assert.Nil(t, err)
+	accounts, err := db.GetAccounts()
+	assert.Nil(t, err)
+	assert.Len(t, accounts, len(chart))
+	office, err = db.GetAccount("7100")
+	assert.Nil(t, err)
+	assert.Equal(t, "Stationery", office.Name)
+}
+
+func TestAssignAccounts(t *testing.T) {
+
+	// create real DB
+	const dbFile = "/tmp/tax-bookkeeper-accounts.db"
+	db := Init(dbFile)
+	defer func() {
+		db.Close()
+		os.Remove(dbFile)
+	}()
+
+	// Populate with data:
+	recently := dateOf("20-12-2019")
+	_, err := db.ImportTransactions([]Transaction{
+		_debitTransaction(Travel, 200.0, "Train", recently),
+		_debitTransaction(Personal, 1000.0, "Dividends", recently),
+		_debitTransaction(Personal, 500.0, "Cash withdrawal", recently),
+		_debitTransaction(Premises, 70.0, "Not in the chart", recently),
+		{Date: recently, Type: Debit, Debit: 30, Description: "Uber", ToBeAllocated: true},
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, db.SeedAccounts(_chart()))
+
+	// When:
+	migrated, err := db.AssignAccounts()
+
+	// Then:
+	assert.Nil(t, err)
+	assert.Equal(t, 3, migrated)
+
+	travel, err := db.GetTransactionsByAccounts("7400")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(travel))
+
+	dividends, err := db.GetTransactionsByAccounts("3300")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(dividends), "the Personal transactions which are not salaries are dividends")
+
+	// and Then: the second run has nothing to migrate
+	migrated, err = db.AssignAccounts()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, migrated)
+}
+
+func TestSaveAccount(t *testing.T) {
+
+	// create real DB
+	const dbFile = "/tmp/tax-bookkeeper-chart.db"
+	db := Init(dbFile)
+	defer func() {
+		db.Close()
+		os.Remove(dbFile)
+	}()
+	assert.Nil(t, db.SeedAccounts(_chart()))
+
+	var tests = []struct {
+		name    string
+		account Account
+		isValid bool
+	}{
+		{"new account", Account{Code: "7800", Name: "Software", Type: ExpenseAccount, Parent: "7090", CTAllowable: true}, true},
+		{"update of the existing", Account{Code: "8205", Name: "Staff entertaining", Type: ExpenseAccount, CTAllowable: true}, true},
+		{"unknown parent", Account{Code: "7810", Name: "Hosting", Type: ExpenseAccount, Parent: "7095"}, false},
+		{"parent of itself", Account{Code: "7820", Name: "Domains", Type: ExpenseAccount, Parent: "7820"}, false},
+		{"no name", Account{Code: "7830", Type: ExpenseAccount}, false},
+		{"no type", Account{Code: "7840", Name: "Books"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// When:
+			err := db.SaveAccount(&tt.account)
+
+			// Then:
+			assert.Equal(t, tt.isValid, err == nil)
+		})
+	}
+
+	accounts, err := db.GetAccounts()
+	assert.Nil(t, err)
+	assert.Equal(t, len(_chart())+1, len(accounts))
+
+	entertaining, err := db.GetAccount("8205")
+	assert.Nil(t, err)
+	assert.True(t, entertaining.CTAllowable)
+	assert.Equal(t, Entertaining, entertaining.Category, "the link to the category is kept")
+}
+
+func TestLockedPeriodRefusesChanges(t *testing.T) {
+
+	// create real DB
+	const dbFile = "/tmp/tax-bookkeeper-locks.db"
+	db := Init(dbFile)
+	defer func() {
+		db.Close()
+		os.Remove(dbFile)
+	}()
+	assert.Nil(t, db.SeedAccounts(_chart()))
+
+	_, err := db.ImportTransactions([]Transaction{
+		{Date: dateOf("31-03-2025"), Type: Debit, Debit: 30, Description: "Train", ToBeAllocated: true},
+		{Date: dateOf("01-04-2025"), Type: Debit, Debit: 40, Description: "Taxi", ToBeAllocated: true},
+	})
+	assert.Nil(t, err)
+	transactions, err := db.GetUnallocated()
+	assert.Nil(t, err)
+	journal := JournalEntry{Date: dateOf("15-03-2025"), Source: ManualJournal,
+		Lines: []JournalLine{{Account: "7400", Debit: 10}, {Account: "3300", Credit: 10}}}
+	assert.Nil(t, db.SaveJournalEntry(&journal))
+	invoice := Invoice{Type: SalesInvoice, Number: "INV-1", IssueDate: dateOf("10-03-2025"), Net: 100}
+	assert.Nil(t, db.SaveInvoice(&invoice))
+	payslips := []Payslip{{EmployeePk: 1, TaxYear: "2024-2025", TaxMonth: 12, PayDate: dateOf("25-03-2025")}}
+	payment := PAYEPayment{TaxYear: "2024-2025", TaxMonth: 12, DueDate: dateOf("22-04-2025")}
+	assert.Nil(t, db.SavePayroll(payslips, &payment))
+
+	// When:
+	assert.Nil(t, db.LockPeriod(LockedPeriod{Start: dateOf("01-04-2024"), End: dateOf("01-04-2025")}))
+
+	// Then: the last day of the period is locked, the next one is not
+	for _, tx := range transactions {
+		err := db.AllocateTransactions(map[int]string{tx.Pk: "7400"})
+		assert.Equal(t, tx.Date.Before(dateOf("01-04-2025")), err != nil, tx.Description)
+	}
+	_, err = db.ImportTransactions([]Transaction{{Date: dateOf("01-06-2024"), Type: Debit, Debit: 5, Description: "Late"}})
+	assert.NotNil(t, err)
+	assert.NotNil(t, db.DeleteJournalEntry(journal.Pk))
+	journal.Date = dateOf("01-05-2025")
+	assert.NotNil(t, db.SaveJournalEntry(&journal), "a journal can't be moved out of the closed period")
+	invoice.IssueDate = dateOf("10-04-2025")
+	assert.NotNil(t, db.SaveInvoice(&invoice), "an invoice can't be moved out of the closed period")
+	assert.NotNil(t, db.LinkInvoice(invoice.Pk, transactions[1].Pk))
+	assert.NotNil(t, db.LinkPayslip(payslips[0].Pk, transactions[1].Pk), "the salary was paid in the closed period")
+	assert.NotNil(t, db.LinkPAYEPayment(payment.Pk, transactions[0].Pk), "the bank transaction is in the closed period")
+	assert.Nil(t, db.LinkPAYEPayment(payment.Pk, transactions[1].Pk))
+	assert.NotNil(t, db.SavePayroll([]Payslip{{EmployeePk: 1, TaxYear: "2024-2025", TaxMonth: 12, PayDate: dateOf("25-03-2025")}},
+		&PAYEPayment{TaxYear: "2024-2025", TaxMonth: 12, DueDate: dateOf("22-04-2025")}))
+	assert.NotNil(t, db.SaveFilingRecord(FilingRecord{Return: VATReturn, PeriodEnd: dateOf("31-12-2024"), Filed: dateOf("01-02-2025")}))
+	assert.Nil(t, db.SaveFilingRecord(FilingRecord{Return: CT600Return, PeriodEnd: dateOf("31-03-2025"), Filed: dateOf("01-12-2025")}),
+		"the return of the closed period is filed later")
+	assert.NotNil(t, db.LockPeriod(LockedPeriod{Start: dateOf("01-10-2024"), End: dateOf("01-10-2025")}), "overlaps")
+
+	// and Then: the period is reopened
+	assert.Nil(t, db.UnlockPeriod(dateOf("01-04-2024")))
+	assert.Nil(t, db.DeleteJournalEntry(journal.Pk))
+	assert.NotNil(t, db.UnlockPeriod(dateOf("01-04-2024")))
+}
+
+// a small chart of accounts, the default one lives in the ledger
+func _chart() []Account {
+	return []Account{
+		{Code: "3300", Name: "Dividends", Type: EquityAccount, Category: Dividend},
+		{Code: "7090", Name: "Administrative expenses", Type: ExpenseAccount},
+		{Code: "7100", Name: "Office", Type: ExpenseAccount, Parent: "7090", CTAllowable: true, Category: Office},
+		{Code: "7400", Name: "Travel", Type: ExpenseAccount, Parent: "7090", CTAllowable: true, Category: Travel},
+		{Code: "8200", Name: "Penalties", Type: ExpenseAccount, Parent: "7090", Category: Penalties},
+		{Code: "8205", Name: "Entertaining", Type: ExpenseAccount, Parent: "7090", Category: Entertaining},
+	}
+}
+
 func _debitTransaction(cat TransactionCategory, debit float64, description string, txDate time.Time) Transaction {
 	return Transaction{
 		Date:          txDate,
@@ -198,3 +563,31 @@ func dateOf(date string) time.Time {
 	day, _ := strconv.Atoi(parts[0])
 	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, conf.GMT)
 }
+
+func TestSaveFilingRecordReplacesTheSameReturn(t *testing.T) {
+
+	// create real DB
+	const dbFile = "/tmp/tax-bookkeeper-filings.db"
+	db := Init(dbFile)
+	defer func() {
+		db.Close()
+		os.Remove(dbFile)
+	}()
+
+	// Given:
+	assert.Nil(t, db.SaveFilingRecord(FilingRecord{Return: VATReturn, PeriodEnd: dateOf("31-03-2025")}))
+	assert.Nil(t, db.SaveFilingRecord(FilingRecord{Return: CT600Return, PeriodEnd: dateOf("31-03-2025"), Filed: dateOf("02-04-2026")}))
+
+	// When: the VAT return is filed later
+	assert.Nil(t, db.SaveFilingRecord(FilingRecord{Return: VATReturn, PeriodEnd: dateOf("31-03-2025"), Filed: dateOf("10-05-2025")}))
+
+	// Then:
+	records, err := db.GetFilingRecords()
+	assert.Nil(t, err)
+	assert.Len(t, records, 2)
+	for _, r := range records {
+		if r.Return == VATReturn {
+			assert.True(t, dateOf("10-05-2025").Equal(r.Filed))
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package db
 
 import (
+	"errors"
 	"fmt"
-	"sort"
+	"math"
+	"strings"
 	"time"
 )
 
@@ -17,7 +19,7 @@ type TransactionCategory int
 
 const (
 	Unknown  TransactionCategory = 1 + iota
-	Personal                     // split between company and personal accounts (salary and dividends)
+	Personal                     // Deprecated: split between company and personal accounts, use Salary, Dividend or ExpenseReimbursement
 	Legal                        // accountancy, advertising
 	Travel
 	Office            // rent
@@ -32,109 +34,452 @@ const (
 	FixedAssetPurchase
 	Income
 	LoansReturn
-	Loan // when you borrow some money from your company and have to return it back
+	Loan                 // when you borrow some money from your company and have to return it back
+	Salary               // director's salary, deductible for corporation tax
+	Dividend             // paid from the profit after corporation tax
+	ExpenseReimbursement // company expenses paid by director personally and returned back
+	Entertaining         // client entertaining
 )
 
-var TransactionDebitLabelMap = map[string]TransactionCategory{
-	"Unknown": Unknown,
-	"Split between company and personal accounts":                Personal,
-	"Legal (accountancy, advertising)":                           Legal,
-	"Travel expenses":                                            Travel,
-	"Office expenses":                                            Office,
-	"Equipment expenses":                                         EquipmentExpenses,
-	"Premises (heat, water, electricity)":                        Premises,
-	"Cost of Sales (goods purchased for resale, subcontractors)": CostOfSales,
-	"Wage payments, non-director salaries":                       WagesPayment,
-	"Penalties and fines":                                        Penalties,
-	"Bank charges":                                               BankCharges,
-	"Pension":                                                    Pension,
-	"HMRC (VAT payment, Corp Tax etc)":                           HMRC,
-	"Fixed assets purchase":                                      FixedAssetPurchase,
-	"Loan":                                                       Loan,
+// CTTreatment says whether a transaction is deductible for corporation tax
+type CTTreatment int
+
+const (
+	CategoryDefault CTTreatment = iota // as the account of the transaction says
+	Allowable
+	Disallowable
+)
+
+// ParseCTTreatment parses the value of a command line parameter
+func ParseCTTreatment(treatment string) (CTTreatment, error) {
+	switch strings.ToLower(treatment) {
+	case "", "category":
+		return CategoryDefault, nil
+	case "allowable":
+		return Allowable, nil
+	case "disallowable":
+		return Disallowable, nil
+	}
+	return 0, errors.New("unknown treatment '" + treatment + "', it should be 'category', 'allowable' or 'disallowable'")
 }
 
-var TransactionCreditLabelMap = map[string]TransactionCategory{
-	"income":      Income,
-	"loan Return": LoansReturn,
+// GuessPersonalCategory recognises money moved out from the company to director by the transaction description.
+// It returns Personal if the description doesn't say what it is
+func GuessPersonalCategory(description string) TransactionCategory {
+	descr := strings.ToLower(description)
+	switch {
+	case strings.Contains(descr, "salary"):
+		return Salary
+	case strings.Contains(descr, "dividend"):
+		return Dividend
+	case strings.Contains(descr, "expense"), strings.Contains(descr, "reimburs"):
+		return ExpenseReimbursement
+	}
+	return Personal
 }
 
-type TransactionUi struct {
-	labels                  []string
-	categoryToLabelPosition map[TransactionCategory]int
+type (
+	Transaction struct {
+		Pk            int             `storm:"id,increment"` // primary key with auto increment
+		Date          time.Time       `storm:"index"`        // midnight, GMT
+		Type          TransactionType `storm:"index"`
+		Card          string          // last 4 digits
+		Description   string
+		Credit        float64
+		Debit         float64
+		Balance       float64
+		ToBeAllocated bool                `storm:"index"` // when category of this transaction is specified, it is "allocated"
+		Category      TransactionCategory `storm:"index"`
+		CTTreatment   CTTreatment         // overrides the tax treatment of the account for this transaction
+		PrivateUse    float64             // percent of a mixed-use cost which is not deductible, like 30 for 30%
+		Account       string              `storm:"index"` // code in the chart of accounts, empty if not allocated yet
+	}
+)
+
+// IsAllowableForCT tells whether the expense posted to the account is deductible for corporation tax, at least partly
+func (s Transaction) IsAllowableForCT(account Account) bool {
+	switch s.CTTreatment {
+	case Allowable:
+		return true
+	case Disallowable:
+		return false
+	}
+	return account.CTAllowable
 }
 
-func (d TransactionUi) GetLabels() []string {
-	return d.labels
+// DisallowableAmount is the part of the expense which is added back in the corporation tax computation
+func (s Transaction) DisallowableAmount(account Account) float64 {
+	if !s.IsAllowableForCT(account) {
+		return math.Abs(s.Debit)
+	}
+	return math.Round(math.Abs(s.Debit)*s.PrivateUse) / 100
 }
 
-func (d TransactionUi) GetPositionFor(category TransactionCategory) int {
-	return d.categoryToLabelPosition[category]
+func (s *Transaction) PrettyPrint() string {
+	txAmount := s.Debit
+	if s.Type == Credit {
+		txAmount = s.Credit
+	}
+	return fmt.Sprintf("%s  %.2f  %s", s.Date.Format("2 Jan 06"), txAmount, s.Description)
 }
 
-var DebitTransactionUI TransactionUi
-var CreditTransactionUI TransactionUi
+type InvoiceType int
+
+const (
+	SalesInvoice InvoiceType = 1 + iota // issued by us to a customer, output VAT
+	SupplierBill                        // received from a supplier, input VAT
+)
+
+type (
+	// Invoice is a sales invoice or a supplier bill. It is needed for the VAT invoice (standard)
+	// accounting, when the tax point is the invoice date rather than the date of the bank transaction
+	Invoice struct {
+		Pk            int         `storm:"id,increment"` // primary key with auto increment
+		Type          InvoiceType `storm:"index"`
+		Number        string
+		Counterparty  string    // customer or supplier name
+		IssueDate     time.Time `storm:"index"` // midnight, GMT
+		Net           float64
+		VAT           float64
+		TransactionPk int // bank transaction that paid this invoice, 0 if not paid yet
+	}
+)
+
+type (
+	// Employee is a director or an employee on the payroll
+	Employee struct {
+		Pk                  int `storm:"id,increment"`
+		Name                string
+		TaxCode             string // like "1257L" or "BR"
+		NICategory          string // like "A", "C", "H", "M" or "X"
+		IsDirector          bool   // directors have the annual earnings period for NI
+		MonthlySalary       float64
+		PensionRate         float64 // employee's contribution, net pay arrangement, like 0.05
+		EmployerPensionRate float64
+		Left                bool
+	}
+
+	// Payslip is one month salary of an employee
+	Payslip struct {
+		Pk              int    `storm:"id,increment"`
+		EmployeePk      int    `storm:"index"`
+		TaxYear         string `storm:"index"` // like "2025-2026"
+		TaxMonth        int    // 1 is 6 April - 5 May
+		PayDate         time.Time
+		Gross           float64
+		EmployeePension float64
+		EmployerPension float64
+		TaxablePay      float64 // gross minus pension under the net pay arrangement
+		IncomeTax       float64
+		EmployeeNI      float64
+		EmployerNI      float64
+		NetPay          float64
+		TransactionPk   int // bank transaction that paid the net salary, 0 if not linked yet
+	}
+
+	// PAYEPayment is what the company owes HMRC for one tax month
+	PAYEPayment struct {
+		Pk                      int    `storm:"id,increment"`
+		TaxYear                 string `storm:"index"`
+		TaxMonth                int
+		IncomeTax               float64
+		EmployeeNI              float64
+		EmployerNI              float64
+		EmploymentAllowanceUsed float64
+		Total                   float64
+		DueDate                 time.Time
+		TransactionPk           int // bank transaction that paid HMRC, 0 if not linked yet
+	}
+)
+
+type CapitalAllowancePool int
 
-func init() {
+const (
+	AnnualInvestmentAllowance CapitalAllowancePool = 1 + iota // 100% up to the yearly limit, the rest goes to the main pool
+	MainPool                                                  // writing-down allowance at the main rate
+	SpecialRatePool                                           // integral features, long life assets, cars with high emissions
+	FullExpensing                                             // 100% for new main pool plant and machinery, since April 2023
+)
 
-	// Debit labels
-	DebitTransactionUI = createUiElementFrom(TransactionDebitLabelMap)
+func (p CapitalAllowancePool) PrettyString() string {
+	switch p {
+	case AnnualInvestmentAllowance:
+		return "Annual Investment Allowance"
+	case MainPool:
+		return "Main pool"
+	case SpecialRatePool:
+		return "Special rate pool"
+	case FullExpensing:
+		return "Full expensing"
+	}
+	return ""
+}
 
-	// Credit labels
-	CreditTransactionUI = createUiElementFrom(TransactionCreditLabelMap)
+// ParseCapitalAllowancePool parses the value of a command line parameter
+func ParseCapitalAllowancePool(pool string) (CapitalAllowancePool, error) {
+	switch strings.ToLower(pool) {
+	case "", "aia":
+		return AnnualInvestmentAllowance, nil
+	case "main":
+		return MainPool, nil
+	case "special":
+		return SpecialRatePool, nil
+	case "full-expensing":
+		return FullExpensing, nil
+	}
+	return 0, errors.New("unknown capital allowance pool '" + pool + "', it should be 'aia', 'main', 'special' or 'full-expensing'")
 }
 
-// creates one element to me used for UI, to draw a Dropdown List
-func createUiElementFrom(categories map[string]TransactionCategory) TransactionUi {
+// DepreciationMethod is how the cost of a fixed asset is spread in the statutory accounts
+type DepreciationMethod int
+
+const (
+	StraightLine    DepreciationMethod = 1 + iota // the same charge every year over the useful life
+	ReducingBalance                               // a fixed percentage of the net book value every year
+)
 
-	// make array of labels to be displayed in dropdown list
-	var labels = make([]string, len(categories))
-	i := 0
-	for k := range categories {
-		labels[i] = k
-		i++
+func (m DepreciationMethod) PrettyString() string {
+	switch m {
+	case StraightLine:
+		return "Straight line"
+	case ReducingBalance:
+		return "Reducing balance"
 	}
-	sort.Strings(labels)
+	return ""
+}
 
-	// build map category <=> position in an array
-	var positions = make(map[TransactionCategory]int, len(categories))
-	for k, v := range categories {
-		positions[v] = getPosition(k, labels)
+// ParseDepreciationMethod parses the value of a command line parameter
+func ParseDepreciationMethod(method string) (DepreciationMethod, error) {
+	switch strings.ToLower(method) {
+	case "", "straight-line":
+		return StraightLine, nil
+	case "reducing-balance":
+		return ReducingBalance, nil
 	}
+	return 0, errors.New("unknown depreciation method '" + method + "', it should be 'straight-line' or 'reducing-balance'")
+}
+
+type (
+	// FixedAsset is an item of the fixed asset register, like a computer or a van
+	FixedAsset struct {
+		Pk               int `storm:"id,increment"`
+		Name             string
+		Cost             float64
+		PurchaseDate     time.Time `storm:"index"` // midnight, GMT
+		Pool             CapitalAllowancePool
+		DisposalDate     time.Time // zero if the asset is still in use
+		DisposalProceeds float64
+		TransactionPk    int // bank transaction of the purchase, 0 if not linked
 
-	return TransactionUi{
-		labels:                  labels,
-		categoryToLabelPosition: positions,
+		DisposalTransactionPk int // bank transaction of the disposal proceeds, 0 if not linked
+
+		// book depreciation, it doesn't affect the corporation tax
+		Depreciation    DepreciationMethod
+		UsefulLifeYears int     // for the straight line method
+		ReducingRate    float64 // for the reducing balance method, like 0.25 for 25%
+		ResidualValue   float64 // the asset is not depreciated below this value
 	}
+)
+
+type (
+	// LossMemorandum is the taxable result of an accounting period before the loss relief, it is kept
+	// to carry trading losses forward and back between the years
+	LossMemorandum struct {
+		Pk          int       `storm:"id,increment"`
+		PeriodStart time.Time `storm:"index"` // midnight, GMT
+		Profit      float64   // negative is a loss
+		CarryBack   bool      // the loss is claimed against the profits of the previous 12 months
+	}
+)
+
+// ReturnType is a tax return filed with HMRC
+type ReturnType int
+
+const (
+	CT600Return          ReturnType = 1 + iota // company tax return
+	VATReturn                                  // MTD VAT return
+	SelfAssessmentReturn                       // director's personal tax return
+)
+
+func (r ReturnType) PrettyString() string {
+	switch r {
+	case CT600Return:
+		return "CT600"
+	case VATReturn:
+		return "VAT return"
+	case SelfAssessmentReturn:
+		return "Self assessment"
+	}
+	return ""
 }
 
-func getPosition(label string, arr []string) int {
-	for i, l := range arr {
-		if l == label {
-			return i
-		}
+// ParseReturnType parses the value of a command line parameter
+func ParseReturnType(returnType string) (ReturnType, error) {
+	switch strings.ToLower(returnType) {
+	case "ct600":
+		return CT600Return, nil
+	case "vat":
+		return VATReturn, nil
+	case "sa":
+		return SelfAssessmentReturn, nil
 	}
-	return 0
+	return 0, errors.New("unknown return '" + returnType + "', it should be 'ct600', 'vat' or 'sa'")
 }
 
 type (
-	Transaction struct {
-		Pk            int             `storm:"id,increment"` // primary key with auto increment
-		Date          time.Time       `storm:"index"`        // midnight, GMT
-		Type          TransactionType `storm:"index"`
-		Card          string          // last 4 digits
-		Description   string
-		Credit        float64
-		Debit         float64
-		Balance       float64
-		ToBeAllocated bool                `storm:"index"` // when category of this transaction is specified, it is "allocated"
-		Category      TransactionCategory `storm:"index"`
+	// FilingRecord is when a tax return was actually filed. Returns without a record are treated as filed on time
+	FilingRecord struct {
+		Pk        int        `storm:"id,increment"`
+		Return    ReturnType `storm:"index"`
+		PeriodEnd time.Time  // the last day of the accounting period, the VAT period or the tax year, midnight GMT
+		Filed     time.Time  // zero if it is not filed yet
+		Paid      time.Time  // when the personal tax was paid in full, only for self assessment, zero means on time
 	}
 )
 
-func (s *Transaction) PrettyPrint() string {
-	txAmount := s.Debit
-	if s.Type == Credit {
-		txAmount = s.Credit
+// AccountType says where the account goes in the accounts, a debit balance is normal for assets and expenses
+type AccountType int
+
+const (
+	AssetAccount AccountType = 1 + iota
+	LiabilityAccount
+	EquityAccount
+	IncomeAccount
+	ExpenseAccount
+)
+
+func (t AccountType) PrettyString() string {
+	switch t {
+	case AssetAccount:
+		return "Asset"
+	case LiabilityAccount:
+		return "Liability"
+	case EquityAccount:
+		return "Equity"
+	case IncomeAccount:
+		return "Income"
+	case ExpenseAccount:
+		return "Expense"
 	}
-	return fmt.Sprintf("%s  %.2f  %s", s.Date.Format("2 Jan 06"), txAmount, s.Description)
+	return ""
+}
+
+// ParseAccountType parses the value of a command line parameter
+func ParseAccountType(accountType string) (AccountType, error) {
+	switch strings.ToLower(accountType) {
+	case "asset":
+		return AssetAccount, nil
+	case "liability":
+		return LiabilityAccount, nil
+	case "equity":
+		return EquityAccount, nil
+	case "income":
+		return IncomeAccount, nil
+	case "expense":
+		return ExpenseAccount, nil
+	}
+	return 0, errors.New("unknown account type '" + accountType + "', it should be 'asset', 'liability', 'equity', " +
+		"'income' or 'expense'")
+}
+
+type (
+	// Account is an account of the chart of accounts
+	Account struct {
+		Pk          int    `storm:"id,increment"`
+		Code        string `storm:"unique"` // like "7600"
+		Name        string
+		Type        AccountType
+		Parent      string              // code of the account it is grouped under, empty for the top level
+		CTAllowable bool                // expenses are deductible for corporation tax, unless a transaction says otherwise
+		VATRate     float64             // the default VAT rate of receipts and purchases without an invoice, like 0.2, zero if there is no VAT
+		Capital     bool                // purchases are fixed assets, capital allowances are claimed instead
+		Category    TransactionCategory // the category transactions of this account had before the chart of accounts
+	}
+)
+
+// CategoryOf returns the category of the transaction allocated to the account. The director's loan account
+// is shared by loans and their returns
+func (a Account) CategoryOf(t Transaction) TransactionCategory {
+	if a.Category == Loan && t.Type == Credit {
+		return LoansReturn
+	}
+	if a.Category == 0 {
+		return Unknown
+	}
+	return a.Category
+}
+
+// JournalSource says where a journal entry comes from
+type JournalSource int
+
+const (
+	ManualJournal       JournalSource = 1 + iota // entered by user, like accruals or dividends declared but unpaid
+	BankPosting                                  // posted from a bank transaction by its category
+	DepreciationPosting                          // posted from the fixed asset register
+	ClosingEntry                                 // moves the result of the year to retained earnings
+	InvoicePosting                               // VAT of an invoice on its issue date, the VAT invoice accounting
+	PayrollPosting                               // income tax and NI of the payroll month owed to HMRC
+	VATReturnPosting                             // VAT of the finished VAT period owed to HMRC
+	TaxAccrual                                   // corporation tax charge of the accounting period
+)
+
+func (s JournalSource) PrettyString() string {
+	switch s {
+	case ManualJournal:
+		return "Manual"
+	case BankPosting:
+		return "Bank"
+	case DepreciationPosting:
+		return "Fixed assets"
+	case ClosingEntry:
+		return "Year-end close"
+	case InvoicePosting:
+		return "Invoice"
+	case PayrollPosting:
+		return "Payroll"
+	case VATReturnPosting:
+		return "VAT return"
+	case TaxAccrual:
+		return "Tax accrual"
+	}
+	return ""
+}
+
+type (
+	// JournalEntry is a balanced double-entry posting to the general ledger.
+	// Only manual journals are stored, other entries are posted when the ledger is built
+	JournalEntry struct {
+		Pk          int       `storm:"id,increment"`
+		Date        time.Time `storm:"index"` // midnight, GMT
+		Description string
+		Source      JournalSource
+		Reference   int // the bank transaction, the fixed asset, the invoice or the PAYE payment, 0 for manual journals
+		Lines       []JournalLine
+	}
+
+	// JournalLine is one side of a journal entry, either Debit or Credit is set
+	JournalLine struct {
+		Account string // code in the chart of accounts, like "1200"
+		Debit   float64
+		Credit  float64
+	}
+)
+
+// LockedPeriod is a closed accounting period, transactions and journals dated within it can't be changed
+// until the period is reopened
+type LockedPeriod struct {
+	Pk       int       `storm:"id,increment"`
+	Start    time.Time `storm:"unique"` // the first day of the period
+	End      time.Time // the day after the last one
+	ClosedAt time.Time
+}
+
+// Contains tells whether the date is within the period
+func (p LockedPeriod) Contains(date time.Time) bool {
+	return !date.Before(p.Start) && date.Before(p.End)
+}
+
+// IsDisposed tells whether the asset was sold or scrapped
+func (a FixedAsset) IsDisposed() bool {
+	return !a.DisposalDate.IsZero()
 }
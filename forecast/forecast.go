@@ -0,0 +1,264 @@
+package forecast
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/conf"
+	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/ledger"
+)
+
+// how recurring payments are detected in the bank history
+const (
+	historyMonths   = 12 // older transactions are not used
+	minMonthsSeen   = 3  // a payment is recurring when it was seen in at least 3 different months
+	activeMonths    = 2  // and at least once within the last 2 months, otherwise it is stopped
+	monthsToAverage = 3  // the expected amount is the average of the latest 3 months
+)
+
+type FlowType int
+
+const (
+	Income FlowType = 1 + iota
+	Expense
+	Tax
+)
+
+func (t FlowType) PrettyString() string {
+	switch t {
+	case Income:
+		return "Income"
+	case Expense:
+		return "Expense"
+	case Tax:
+		return "Tax"
+	default:
+		return "Unknown"
+	}
+}
+
+type (
+	// Pattern is an income or an expense repeated every month in the bank history
+	Pattern struct {
+		Description string  `json:"description"`
+		Amount      float64 `json:"amount"` // positive is money in, negative is money out
+		Day         int     `json:"day"`    // day of the month of the latest payment
+		MonthsSeen  int     `json:"monthsSeen"`
+	}
+
+	// Flow is an expected movement of money on the bank account
+	Flow struct {
+		Date        time.Time `json:"date"`
+		Description string    `json:"description"`
+		Amount      float64   `json:"amount"` // positive is money in, negative is money out
+		Type        FlowType  `json:"type"`
+	}
+
+	// Month is one line of the forecast
+	Month struct {
+		Start    time.Time `json:"start"`
+		Opening  float64   `json:"opening"`
+		Income   float64   `json:"income"`
+		Expenses float64   `json:"expenses"` // negative
+		Taxes    float64   `json:"taxes"`    // negative, a refund makes it positive
+		Closing  float64   `json:"closing"`
+		Lowest   float64   `json:"lowest"` // the lowest balance within the month
+	}
+
+	// Forecast is the projected bank balance month by month
+	Forecast struct {
+		From           time.Time `json:"from"`
+		OpeningBalance float64   `json:"openingBalance"`
+		Months         []Month   `json:"months"`
+		Flows          []Flow    `json:"flows"` // ordered by date
+		LowestBalance  float64   `json:"lowestBalance"`
+		LowestDate     time.Time `json:"lowestDate"`
+	}
+)
+
+var digits = regexp.MustCompile(`[0-9]+`)
+
+// DetectPatterns finds the monthly incomes and expenses in the bank history before "now". The transactions are
+// grouped by their description without digits (references and dates of bank statements change every month)
+// and direction. Payments to HMRC are not patterns, the tax liabilities are forecast from the tax package
+// Please refer to unit tests for examples
+func DetectPatterns(history []db.Transaction, now time.Time) []Pattern {
+	type group struct {
+		description string
+		months      map[time.Time]float64
+		latest      time.Time
+	}
+
+	since := monthOf(now).AddDate(0, -historyMonths, 0)
+	groups := make(map[string]*group)
+	for _, t := range history {
+		if t.Date.Before(since) || !t.Date.Before(now) || isTaxPayment(t) {
+			continue
+		}
+
+		amount := signedAmount(t)
+		key := strings.Join(strings.Fields(digits.ReplaceAllString(strings.ToLower(t.Description), " ")), " ")
+		if amount > 0 {
+			key = "+" + key
+		} else {
+			key = "-" + key
+		}
+
+		g, ok := groups[key]
+		if !ok {
+			g = &group{description: strings.TrimSpace(t.Description), months: make(map[time.Time]float64)}
+			groups[key] = g
+		}
+		g.months[monthOf(t.Date)] = g.months[monthOf(t.Date)] + amount
+		if !t.Date.Before(g.latest) {
+			g.latest = t.Date
+			g.description = strings.TrimSpace(t.Description)
+		}
+	}
+
+	activeSince := monthOf(now).AddDate(0, -activeMonths, 0)
+	var patterns []Pattern
+	for _, g := range groups {
+		if len(g.months) < minMonthsSeen || g.latest.Before(activeSince) {
+			continue
+		}
+
+		months := make([]time.Time, 0, len(g.months))
+		for m := range g.months {
+			months = append(months, m)
+		}
+		sort.Slice(months, func(i, j int) bool { return months[i].After(months[j]) })
+		if len(months) > monthsToAverage {
+			months = months[:monthsToAverage]
+		}
+
+		var total float64
+		for _, m := range months {
+			total = total + g.months[m]
+		}
+		patterns = append(patterns, Pattern{
+			Description: g.description,
+			Amount:      roundPennies(total / float64(len(months))),
+			Day:         g.latest.Day(),
+			MonthsSeen:  len(g.months),
+		})
+	}
+
+	sort.Slice(patterns, func(i, j int) bool {
+		if patterns[i].Day != patterns[j].Day {
+			return patterns[i].Day < patterns[j].Day
+		}
+		return patterns[i].Description < patterns[j].Description
+	})
+	return patterns
+}
+
+// Flows repeats the pattern every month since "from" (inclusive) until "until" (exclusive). In shorter months
+// a payment of the 31st comes on the last day
+func (p Pattern) Flows(from, until time.Time) []Flow {
+	flowType := Income
+	if p.Amount < 0 {
+		flowType = Expense
+	}
+
+	var flows []Flow
+	for month := monthOf(from); month.Before(until); month = month.AddDate(0, 1, 0) {
+		lastDay := month.AddDate(0, 1, -1).Day()
+		date := month.AddDate(0, 0, int(math.Min(float64(p.Day), float64(lastDay)))-1)
+		if !date.Before(from) && date.Before(until) {
+			flows = append(flows, Flow{Date: date, Description: p.Description, Amount: p.Amount, Type: flowType})
+		}
+	}
+	return flows
+}
+
+// Project moves the bank balance forward month by month since "from", starting with the opening balance.
+// Flows outside of the months are ignored
+// Please refer to unit tests for examples
+func Project(openingBalance float64, from time.Time, months int, flows []Flow) Forecast {
+	until := monthOf(from).AddDate(0, months, 0)
+
+	var inRange []Flow
+	for _, f := range flows {
+		if !f.Date.Before(from) && f.Date.Before(until) {
+			inRange = append(inRange, f)
+		}
+	}
+	sort.SliceStable(inRange, func(i, j int) bool { return inRange[i].Date.Before(inRange[j].Date) })
+
+	forecast := Forecast{
+		From:           from,
+		OpeningBalance: openingBalance,
+		Flows:          inRange,
+		LowestBalance:  roundPennies(openingBalance),
+		LowestDate:     from,
+	}
+
+	balance := openingBalance
+	next := 0
+	for i := 0; i < months; i++ {
+		start := monthOf(from).AddDate(0, i, 0)
+		month := Month{Start: start, Opening: roundPennies(balance), Lowest: roundPennies(balance)}
+
+		// the balance is checked at the end of the day, so the money in and out of the same day are netted
+		for ; next < len(inRange) && inRange[next].Date.Before(start.AddDate(0, 1, 0)); next++ {
+			f := inRange[next]
+			switch f.Type {
+			case Income:
+				month.Income = month.Income + f.Amount
+			case Expense:
+				month.Expenses = month.Expenses + f.Amount
+			case Tax:
+				month.Taxes = month.Taxes + f.Amount
+			}
+			balance = balance + f.Amount
+
+			if next+1 < len(inRange) && inRange[next+1].Date.Equal(f.Date) {
+				continue
+			}
+			if roundPennies(balance) < month.Lowest {
+				month.Lowest = roundPennies(balance)
+			}
+			if roundPennies(balance) < forecast.LowestBalance {
+				forecast.LowestBalance, forecast.LowestDate = roundPennies(balance), f.Date
+			}
+		}
+
+		month.Income = roundPennies(month.Income)
+		month.Expenses = roundPennies(month.Expenses)
+		month.Taxes = roundPennies(month.Taxes)
+		month.Closing = roundPennies(balance)
+		forecast.Months = append(forecast.Months, month)
+	}
+	return forecast
+}
+
+// IsShort tells whether the bank balance is expected to go below the threshold
+func (f Forecast) IsShort(threshold float64) bool {
+	return f.LowestBalance < threshold
+}
+
+// HMRC is paid either from the category or the account of tax liabilities
+func isTaxPayment(t db.Transaction) bool {
+	return t.Category == db.HMRC || t.Account == ledger.TaxLiabilities
+}
+
+// NB! Debits can be negative in the bank statement
+func signedAmount(t db.Transaction) float64 {
+	if t.Type == db.Credit {
+		return math.Abs(t.Credit)
+	}
+	return -math.Abs(t.Debit)
+}
+
+func monthOf(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, conf.GMT)
+}
+
+func roundPennies(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
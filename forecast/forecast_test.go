@@ -0,0 +1,125 @@
+package forecast
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/w32blaster/tax-bookkeeper/conf"
+	"github.com/w32blaster/tax-bookkeeper/db"
+)
+
+func TestDetectPatterns(t *testing.T) {
+
+	// Given:
+	history := []db.Transaction{
+		// monthly invoice paid by the client, the reference changes
+		income("25-06-2025", "ACME LTD INV 101", 5000),
+		income("24-07-2025", "ACME LTD INV 102", 5000),
+		income("25-08-2025", "ACME LTD INV 103", 6000),
+		income("25-09-2025", "ACME LTD INV 104", 7000),
+
+		// monthly rent, debits can be negative in the bank statement
+		expense("01-07-2025", "Office rent", -1000),
+		expense("01-08-2025", "Office rent", 1000),
+		expense("01-09-2025", "Office rent", 1000),
+
+		// stopped in the summer
+		expense("10-03-2025", "Old hosting", 50),
+		expense("10-04-2025", "Old hosting", 50),
+		expense("10-05-2025", "Old hosting", 50),
+
+		// only twice
+		expense("15-08-2025", "Train tickets", 80),
+		expense("15-09-2025", "Train tickets", 80),
+
+		// taxes are forecast separately
+		taxPayment("22-07-2025", "HMRC PAYE"),
+		taxPayment("22-08-2025", "HMRC PAYE"),
+		taxPayment("22-09-2025", "HMRC PAYE"),
+
+		// too old
+		expense("05-06-2024", "Subscription", 10),
+		expense("05-07-2024", "Subscription", 10),
+		expense("05-08-2024", "Subscription", 10),
+	}
+
+	// When:
+	patterns := DetectPatterns(history, dateOf("10-10-2025"))
+
+	// Then:
+	assert.Equal(t, []Pattern{
+		{Description: "Office rent", Amount: -1000, Day: 1, MonthsSeen: 3},
+		{Description: "ACME LTD INV 104", Amount: 6000, Day: 25, MonthsSeen: 4},
+	}, patterns)
+}
+
+func TestPatternFlows(t *testing.T) {
+
+	// Given:
+	p := Pattern{Description: "Salary", Amount: -2000, Day: 31}
+
+	// When:
+	flows := p.Flows(dateOf("19-01-2026"), dateOf("01-04-2026"))
+
+	// Then:
+	assert.Equal(t, []Flow{
+		{dateOf("31-01-2026"), "Salary", -2000, Expense},
+		{dateOf("28-02-2026"), "Salary", -2000, Expense},
+		{dateOf("31-03-2026"), "Salary", -2000, Expense},
+	}, flows)
+}
+
+func TestProject(t *testing.T) {
+
+	// Given:
+	flows := []Flow{
+		{dateOf("25-11-2025"), "Client", 6000, Income},
+		{dateOf("01-12-2025"), "Rent", -1000, Expense},
+		{dateOf("25-12-2025"), "Client", 6000, Income},
+		{dateOf("07-01-2026"), "VAT", -3000, Tax},
+		{dateOf("31-01-2026"), "Self assessment", -4000, Tax},
+		{dateOf("31-01-2026"), "Client", 6000, Income}, // the same day, it is netted
+		{dateOf("01-01-2026"), "Corporation tax", -9000, Tax},
+		{dateOf("01-01-2026"), "Rent", -1000, Expense},
+		{dateOf("01-06-2026"), "Too late", -100000, Expense},
+		{dateOf("01-10-2025"), "Too early", -100000, Expense},
+	}
+
+	// When:
+	f := Project(5000, dateOf("19-11-2025"), 3, flows)
+
+	// Then:
+	assert.Len(t, f.Flows, 8)
+	assert.Equal(t, []Month{
+		{Start: dateOf("01-11-2025"), Opening: 5000, Income: 6000, Closing: 11000, Lowest: 5000},
+		{Start: dateOf("01-12-2025"), Opening: 11000, Income: 6000, Expenses: -1000, Closing: 16000, Lowest: 10000},
+		{Start: dateOf("01-01-2026"), Opening: 16000, Income: 6000, Expenses: -1000, Taxes: -16000, Closing: 5000, Lowest: 3000},
+	}, f.Months)
+	assert.Equal(t, 3000.0, f.LowestBalance)
+	assert.Equal(t, dateOf("07-01-2026"), f.LowestDate)
+	assert.True(t, f.IsShort(5000))
+	assert.False(t, f.IsShort(0))
+}
+
+func income(date, description string, amount float64) db.Transaction {
+	return db.Transaction{Date: dateOf(date), Type: db.Credit, Description: description, Credit: amount, Category: db.Income}
+}
+
+func expense(date, description string, amount float64) db.Transaction {
+	return db.Transaction{Date: dateOf(date), Type: db.Debit, Description: description, Debit: amount, Category: db.Office}
+}
+
+func taxPayment(date, description string) db.Transaction {
+	return db.Transaction{Date: dateOf(date), Type: db.Debit, Description: description, Debit: -500, Category: db.HMRC}
+}
+
+func dateOf(date string) time.Time {
+	parts := strings.Split(date, "-")
+	year, _ := strconv.Atoi(parts[2])
+	month, _ := strconv.Atoi(parts[1])
+	day, _ := strconv.Atoi(parts[0])
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, conf.GMT)
+}
@@ -0,0 +1,242 @@
+package hmrc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Making Tax Digital VAT API
+// https://developer.service.hmrc.gov.uk/api-documentation/docs/api/service/vat-api/1.0
+const (
+	SandboxBaseURL    = "https://test-api.service.hmrc.gov.uk"
+	ProductionBaseURL = "https://api.service.hmrc.gov.uk"
+
+	acceptHeader = "application/vnd.hmrc.1.0+json"
+	vatScope     = "read:vat write:vat"
+)
+
+// ObligationStatus filters obligations, empty means all of them
+const (
+	ObligationOpen      = "O"
+	ObligationFulfilled = "F"
+)
+
+type Client struct {
+	baseURL      string
+	clientID     string
+	clientSecret string
+	fraud        FraudPreventionHeaders
+	httpClient   *http.Client
+	token        *Token
+}
+
+// NewClient creates a client for the given base URL, it could be SandboxBaseURL, ProductionBaseURL
+// or the URL of a StubServer
+func NewClient(baseURL, clientID, clientSecret string, fraud FraudPreventionHeaders) *Client {
+	return &Client{
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		fraud:        fraud,
+		httpClient:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// ResolveBaseURL converts a command line value ("sandbox", "production" or any URL) to the base URL
+func ResolveBaseURL(value string) string {
+	switch value {
+	case "", "sandbox":
+		return SandboxBaseURL
+	case "production":
+		return ProductionBaseURL
+	}
+	return value
+}
+
+// SetToken sets previously obtained token, so that user doesn't have to authorize every time
+func (c *Client) SetToken(token *Token) {
+	c.token = token
+}
+
+// AuthorizationURL is the page where a user grants the access to their VAT account. After that HMRC redirects
+// to the redirectURI with the "code" parameter that should be exchanged for the token with ExchangeCode
+func (c *Client) AuthorizationURL(redirectURI, state string) string {
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", c.clientID)
+	params.Set("scope", vatScope)
+	params.Set("redirect_uri", redirectURI)
+	params.Set("state", state)
+	return c.baseURL + "/oauth/authorize?" + params.Encode()
+}
+
+// ExchangeCode exchanges the authorization code for the access token
+func (c *Client) ExchangeCode(code, redirectURI string) (*Token, error) {
+	params := url.Values{}
+	params.Set("grant_type", "authorization_code")
+	params.Set("code", code)
+	params.Set("redirect_uri", redirectURI)
+	return c.requestToken(params)
+}
+
+// RefreshToken obtains a new access token using the refresh token, the access token lives only 4 hours
+func (c *Client) RefreshToken() (*Token, error) {
+	if c.token == nil || c.token.RefreshToken == "" {
+		return nil, errors.New("there is no refresh token, please authorize first")
+	}
+	params := url.Values{}
+	params.Set("grant_type", "refresh_token")
+	params.Set("refresh_token", c.token.RefreshToken)
+	return c.requestToken(params)
+}
+
+func (c *Client) requestToken(params url.Values) (*Token, error) {
+	params.Set("client_id", c.clientID)
+	params.Set("client_secret", c.clientSecret)
+
+	resp, err := c.httpClient.PostForm(c.baseURL+"/oauth/token", params)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, readAPIError(resp)
+	}
+
+	var token Token
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, err
+	}
+	token.ExpiresAt = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+
+	c.token = &token
+	return &token, nil
+}
+
+// GetObligations returns VAT periods between the dates, status can be ObligationOpen, ObligationFulfilled or empty
+func (c *Client) GetObligations(vrn string, from, to time.Time, status string) ([]Obligation, error) {
+	params := dateRange(from, to)
+	if status != "" {
+		params.Set("status", status)
+	}
+
+	var resp struct {
+		Obligations []Obligation `json:"obligations"`
+	}
+	err := c.call(http.MethodGet, "/organisations/vat/"+vrn+"/obligations?"+params.Encode(), nil, &resp)
+	return resp.Obligations, err
+}
+
+// SubmitReturn submits the nine-box VAT return for an open obligation
+func (c *Client) SubmitReturn(vrn string, vatReturn VATReturn) (*SubmissionReceipt, error) {
+	if !vatReturn.ValidateBoxes() {
+		return nil, errors.New("box 3 must be box 1 + box 2 and box 5 must be the difference between box 3 and box 4")
+	}
+	if !vatReturn.HasWholePounds() {
+		return nil, errors.New("boxes 6 to 9 must be whole pounds, without pennies")
+	}
+
+	var receipt SubmissionReceipt
+	if err := c.call(http.MethodPost, "/organisations/vat/"+vrn+"/returns", vatReturn, &receipt); err != nil {
+		return nil, err
+	}
+	return &receipt, nil
+}
+
+// GetLiabilities returns amounts charged by HMRC between the dates
+func (c *Client) GetLiabilities(vrn string, from, to time.Time) ([]Liability, error) {
+	var resp struct {
+		Liabilities []Liability `json:"liabilities"`
+	}
+	err := c.call(http.MethodGet, "/organisations/vat/"+vrn+"/liabilities?"+dateRange(from, to).Encode(), nil, &resp)
+	return resp.Liabilities, err
+}
+
+// GetPayments returns payments received by HMRC between the dates
+func (c *Client) GetPayments(vrn string, from, to time.Time) ([]Payment, error) {
+	var resp struct {
+		Payments []Payment `json:"payments"`
+	}
+	err := c.call(http.MethodGet, "/organisations/vat/"+vrn+"/payments?"+dateRange(from, to).Encode(), nil, &resp)
+	return resp.Payments, err
+}
+
+// makes a call to a user-restricted endpoint, refreshing the token if it is expired. The expiry of a token
+// set with SetToken could be unknown, so the call is repeated with the refreshed token when HMRC rejects it
+func (c *Client) call(method, path string, body interface{}, result interface{}) error {
+	if c.token == nil {
+		return errors.New("not authorized, please obtain the token first")
+	}
+
+	if !c.token.ExpiresAt.IsZero() && time.Now().After(c.token.ExpiresAt) {
+		if _, err := c.RefreshToken(); err != nil {
+			return err
+		}
+	}
+
+	err := c.do(method, path, body, result)
+	if apiErr, ok := err.(*APIError); ok && apiErr.StatusCode == http.StatusUnauthorized && c.token.RefreshToken != "" {
+		if _, err := c.RefreshToken(); err != nil {
+			return err
+		}
+		return c.do(method, path, body, result)
+	}
+	return err
+}
+
+func (c *Client) do(method, path string, body interface{}, result interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", acceptHeader)
+	req.Header.Set("Authorization", "Bearer "+c.token.AccessToken)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	c.fraud.Apply(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return readAPIError(resp)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func readAPIError(resp *http.Response) error {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	if err := json.NewDecoder(resp.Body).Decode(apiErr); err != nil || apiErr.Code == "" {
+		apiErr.Code = http.StatusText(resp.StatusCode)
+		apiErr.Message = "unexpected response"
+	}
+	return apiErr
+}
+
+func dateRange(from, to time.Time) url.Values {
+	params := url.Values{}
+	params.Set("from", from.Format(dateFormat))
+	params.Set("to", to.Format(dateFormat))
+	return params
+}
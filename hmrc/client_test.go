@@ -0,0 +1,251 @@
+package hmrc
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/w32blaster/tax-bookkeeper/conf"
+)
+
+const (
+	testVRN         = "123456789"
+	testRedirectURI = "urn:ietf:wg:oauth:2.0:oob"
+)
+
+var testFraudHeaders = FraudPreventionHeaders{
+	DeviceID:          "beec798b-b366-47fa-b1f8-92cede14a1ce",
+	UserID:            "director",
+	Timezone:          "UTC+00:00",
+	LocalIPs:          []string{"192.168.1.10"},
+	LocalIPsTimestamp: time.Date(2020, time.September, 21, 14, 30, 5, 123000000, time.UTC),
+	UserAgent:         "os-family=linux",
+	VendorVersion:     "1.0",
+	VendorProduct:     "tax-bookkeeper",
+}
+
+func TestFullVATReturnFlow(t *testing.T) {
+
+	// Given:
+	stub := NewStubServer("client", "secret")
+	defer stub.Close()
+	stub.AddObligation(testVRN, dateOf("01-01-2020"), dateOf("31-03-2020"), "20A1")
+	stub.AddObligation(testVRN, dateOf("01-04-2020"), dateOf("30-06-2020"), "20A2")
+
+	client := NewClient(stub.URL, "client", "secret", testFraudHeaders)
+
+	// When: authorize
+	token, err := client.ExchangeCode(stub.IssueCode(), testRedirectURI)
+
+	// Then:
+	assert.Nil(t, err)
+	assert.NotEmpty(t, token.AccessToken)
+
+	// When: fetch open obligations
+	obligations, err := client.GetObligations(testVRN, dateOf("01-01-2020"), dateOf("31-12-2020"), ObligationOpen)
+
+	// Then:
+	assert.Nil(t, err)
+	assert.Len(t, obligations, 2)
+	assert.Equal(t, "20A1", obligations[0].PeriodKey)
+	assert.Equal(t, dateOf("07-05-2020"), obligations[0].Due.Time)
+
+	// When: submit the return
+	receipt, err := client.SubmitReturn(testVRN, VATReturn{
+		PeriodKey:                "20A1",
+		VatDueSales:              1000,
+		TotalVatDue:              1000,
+		VatReclaimedCurrPeriod:   200,
+		NetVatDue:                800,
+		TotalValueSalesExVAT:     5000,
+		TotalValuePurchasesExVAT: 1000,
+		Finalised:                true,
+	})
+
+	// Then:
+	assert.Nil(t, err)
+	assert.NotEmpty(t, receipt.FormBundleNumber)
+
+	// the obligation is fulfilled and the liability appeared
+	open, err := client.GetObligations(testVRN, dateOf("01-01-2020"), dateOf("31-12-2020"), ObligationOpen)
+	assert.Nil(t, err)
+	assert.Len(t, open, 1)
+	assert.Equal(t, "20A2", open[0].PeriodKey)
+
+	liabilities, err := client.GetLiabilities(testVRN, dateOf("01-01-2020"), dateOf("31-12-2020"))
+	assert.Nil(t, err)
+	assert.Len(t, liabilities, 1)
+	assert.Equal(t, 800.0, liabilities[0].OutstandingAmount)
+
+	payments, err := client.GetPayments(testVRN, dateOf("01-01-2020"), dateOf("31-12-2020"))
+	assert.Nil(t, err)
+	assert.Len(t, payments, 0)
+}
+
+func TestSubmitReturnTwice(t *testing.T) {
+
+	// Given:
+	stub := NewStubServer("client", "secret")
+	defer stub.Close()
+	stub.AddObligation(testVRN, dateOf("01-01-2020"), dateOf("31-03-2020"), "20A1")
+
+	client := NewClient(stub.URL, "client", "secret", testFraudHeaders)
+	_, err := client.ExchangeCode(stub.IssueCode(), testRedirectURI)
+	assert.Nil(t, err)
+
+	vatReturn := VATReturn{PeriodKey: "20A1", VatDueSales: 100, TotalVatDue: 100, NetVatDue: 100, Finalised: true}
+	_, err = client.SubmitReturn(testVRN, vatReturn)
+	assert.Nil(t, err)
+
+	// When:
+	_, err = client.SubmitReturn(testVRN, vatReturn)
+
+	// Then:
+	apiErr, ok := err.(*APIError)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+	assert.Equal(t, "DUPLICATE_SUBMISSION", apiErr.Code)
+}
+
+func TestSubmitReturnBoxesDontAddUp(t *testing.T) {
+
+	// Given:
+	client := NewClient("http://localhost", "client", "secret", testFraudHeaders)
+	client.SetToken(&Token{AccessToken: "token"})
+
+	// When:
+	_, err := client.SubmitReturn(testVRN, VATReturn{PeriodKey: "20A1", VatDueSales: 100, TotalVatDue: 50, Finalised: true})
+
+	// Then:
+	assert.NotNil(t, err)
+}
+
+func TestSubmitReturnPenniesInWholePoundBoxes(t *testing.T) {
+
+	// Given:
+	client := NewClient("http://localhost", "client", "secret", testFraudHeaders)
+	client.SetToken(&Token{AccessToken: "token"})
+
+	// When:
+	_, err := client.SubmitReturn(testVRN, VATReturn{PeriodKey: "20A1", VatDueSales: 100, TotalVatDue: 100, NetVatDue: 100,
+		TotalValueSalesExVAT: 500.40, Finalised: true})
+
+	// Then:
+	assert.NotNil(t, err)
+}
+
+func TestMissingFraudPreventionHeaders(t *testing.T) {
+
+	// Given:
+	stub := NewStubServer("client", "secret")
+	defer stub.Close()
+
+	client := NewClient(stub.URL, "client", "secret", FraudPreventionHeaders{})
+	_, err := client.ExchangeCode(stub.IssueCode(), testRedirectURI)
+	assert.Nil(t, err)
+
+	// When:
+	_, err = client.GetObligations(testVRN, dateOf("01-01-2020"), dateOf("31-12-2020"), "")
+
+	// Then:
+	apiErr, ok := err.(*APIError)
+	assert.True(t, ok)
+	assert.Equal(t, "INVALID_HEADER", apiErr.Code)
+}
+
+func TestExpiredTokenIsRefreshed(t *testing.T) {
+
+	// Given:
+	stub := NewStubServer("client", "secret")
+	defer stub.Close()
+
+	client := NewClient(stub.URL, "client", "secret", testFraudHeaders)
+	token, err := client.ExchangeCode(stub.IssueCode(), testRedirectURI)
+	assert.Nil(t, err)
+	token.ExpiresAt = time.Now().Add(-time.Minute)
+
+	// When:
+	_, err = client.GetPayments(testVRN, dateOf("01-01-2020"), dateOf("31-12-2020"))
+
+	// Then:
+	assert.Nil(t, err)
+}
+
+func TestTokenWithoutExpiryIsRefreshed(t *testing.T) {
+
+	// Given: the token is passed on the command line, so its expiry is unknown
+	stub := NewStubServer("client", "secret")
+	defer stub.Close()
+
+	token, err := NewClient(stub.URL, "client", "secret", testFraudHeaders).ExchangeCode(stub.IssueCode(), testRedirectURI)
+	assert.Nil(t, err)
+	client := NewClient(stub.URL, "client", "secret", testFraudHeaders)
+	client.SetToken(&Token{AccessToken: "expired", RefreshToken: token.RefreshToken})
+
+	// When:
+	_, err = client.GetPayments(testVRN, dateOf("01-01-2020"), dateOf("31-12-2020"))
+
+	// Then:
+	assert.Nil(t, err)
+}
+
+func TestNotAuthorized(t *testing.T) {
+
+	// Given:
+	stub := NewStubServer("client", "secret")
+	defer stub.Close()
+	client := NewClient(stub.URL, "client", "wrong secret", testFraudHeaders)
+
+	// When:
+	_, err := client.ExchangeCode(stub.IssueCode(), testRedirectURI)
+
+	// Then:
+	apiErr, ok := err.(*APIError)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+}
+
+func TestResolveBaseURL(t *testing.T) {
+	assert.Equal(t, SandboxBaseURL, ResolveBaseURL(""))
+	assert.Equal(t, SandboxBaseURL, ResolveBaseURL("sandbox"))
+	assert.Equal(t, ProductionBaseURL, ResolveBaseURL("production"))
+	assert.Equal(t, "http://localhost:8080", ResolveBaseURL("http://localhost:8080"))
+}
+
+func TestApplyFraudPreventionHeaders(t *testing.T) {
+
+	// Given:
+	headers := testFraudHeaders
+	headers.Screens = []Screen{{Width: 1920, Height: 1080, ScalingFactor: 1.25, ColourDepth: 24}}
+	headers.WindowWidth = 1256
+	headers.WindowHeight = 803
+	headers.MultiFactor = []MultiFactor{{Type: "TOTP", Timestamp: time.Date(2017, time.April, 21, 13, 23, 0, 0, time.UTC),
+		UniqueReference: "fc4b5fd6"}}
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost", nil)
+
+	// When:
+	headers.Apply(req)
+
+	// Then:
+	for _, h := range MandatoryFraudPreventionHeaders {
+		_, ok := req.Header[http.CanonicalHeaderKey(h)]
+		assert.True(t, ok, h)
+	}
+	assert.Equal(t, "width=1920&height=1080&scaling-factor=1.25&colour-depth=24", req.Header.Get("Gov-Client-Screens"))
+	assert.Equal(t, "width=1256&height=803", req.Header.Get("Gov-Client-Window-Size"))
+	assert.Equal(t, "type=TOTP&timestamp=2017-04-21T13%3A23Z&unique-reference=fc4b5fd6", req.Header.Get("Gov-Client-Multi-Factor"))
+	assert.Equal(t, "2020-09-21T14:30:05.123Z", req.Header.Get("Gov-Client-Local-IPs-Timestamp"))
+	assert.Equal(t, "", req.Header.Get("Gov-Vendor-License-IDs"))
+}
+
+// shorthand for the date creation, like "01-03-2021"
+func dateOf(date string) time.Time {
+	parts := strings.Split(date, "-")
+	year, _ := strconv.Atoi(parts[2])
+	month, _ := strconv.Atoi(parts[1])
+	day, _ := strconv.Atoi(parts[0])
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, conf.GMT)
+}
@@ -0,0 +1,194 @@
+package hmrc
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/user"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FraudPreventionHeaders are mandatory for every call to the MTD APIs.
+// The tool runs on the user's machine and talks to HMRC directly, so the connection method is DESKTOP_APP_DIRECT
+// https://developer.service.hmrc.gov.uk/guides/fraud-prevention/connection-method/desktop-app-direct/
+type FraudPreventionHeaders struct {
+	DeviceID          string // should be generated once and persisted
+	UserID            string
+	Timezone          string // like "UTC+00:00"
+	LocalIPs          []string
+	LocalIPsTimestamp time.Time // when the local IPs were collected
+	MACAddresses      []string
+	Screens           []Screen // unknown for a terminal, the header is sent empty then
+	WindowWidth       int      // in pixels, zero when unknown
+	WindowHeight      int
+	MultiFactor       []MultiFactor // empty when the user didn't pass any multi factor authentication
+	UserAgent         string
+	VendorVersion     string
+	VendorProduct     string
+	VendorLicenseIDs  []string // hashed license keys, the tool is free and has none
+	ConnectionTime    time.Time
+}
+
+// Screen is a monitor connected to the device
+type Screen struct {
+	Width         int
+	Height        int
+	ScalingFactor float64
+	ColourDepth   int
+}
+
+// MultiFactor is a multi factor authentication the user passed, like "TOTP"
+type MultiFactor struct {
+	Type            string
+	Timestamp       time.Time
+	UniqueReference string
+}
+
+// CollectFraudPreventionHeaders collects what we can about the current device
+func CollectFraudPreventionHeaders(deviceID, vendorVersion string) FraudPreventionHeaders {
+	headers := FraudPreventionHeaders{
+		DeviceID:      deviceID,
+		Timezone:      formatTimezone(time.Now()),
+		UserAgent:     "os-family=" + runtime.GOOS + "&os-version=unknown&device-manufacturer=unknown&device-model=" + runtime.GOARCH,
+		VendorVersion: vendorVersion,
+		VendorProduct: "tax-bookkeeper",
+	}
+
+	if u, err := user.Current(); err == nil {
+		headers.UserID = u.Username
+	} else {
+		headers.UserID = os.Getenv("USER")
+	}
+
+	headers.LocalIPsTimestamp = time.Now()
+	if interfaces, err := net.Interfaces(); err == nil {
+		for _, i := range interfaces {
+			if i.Flags&net.FlagLoopback != 0 {
+				continue
+			}
+			if mac := i.HardwareAddr.String(); mac != "" {
+				headers.MACAddresses = append(headers.MACAddresses, mac)
+			}
+			addrs, err := i.Addrs()
+			if err != nil {
+				continue
+			}
+			for _, a := range addrs {
+				if ipNet, ok := a.(*net.IPNet); ok {
+					headers.LocalIPs = append(headers.LocalIPs, ipNet.IP.String())
+				}
+			}
+		}
+	}
+
+	return headers
+}
+
+// Apply sets all the headers to the request
+func (f FraudPreventionHeaders) Apply(req *http.Request) {
+	req.Header.Set("Gov-Client-Connection-Method", "DESKTOP_APP_DIRECT")
+	req.Header.Set("Gov-Client-Device-ID", f.DeviceID)
+	req.Header.Set("Gov-Client-User-IDs", "os="+url.QueryEscape(f.UserID))
+	req.Header.Set("Gov-Client-Timezone", f.Timezone)
+	req.Header.Set("Gov-Client-Local-IPs", encodeList(f.LocalIPs))
+	req.Header.Set("Gov-Client-Local-IPs-Timestamp", formatTimestamp(f.LocalIPsTimestamp))
+	req.Header.Set("Gov-Client-MAC-Addresses", encodeList(f.MACAddresses))
+	req.Header.Set("Gov-Client-Screens", encodeScreens(f.Screens))
+	req.Header.Set("Gov-Client-Window-Size", encodeWindowSize(f.WindowWidth, f.WindowHeight))
+	req.Header.Set("Gov-Client-Multi-Factor", encodeMultiFactor(f.MultiFactor))
+	req.Header.Set("Gov-Client-User-Agent", f.UserAgent)
+	req.Header.Set("Gov-Vendor-Version", "tax-bookkeeper="+url.QueryEscape(f.VendorVersion))
+	req.Header.Set("Gov-Vendor-License-IDs", encodeLicenseIDs(f.VendorLicenseIDs))
+	req.Header.Set("Gov-Vendor-Product-Name", url.QueryEscape(f.VendorProduct))
+}
+
+// MandatoryFraudPreventionHeaders must be present in every request for DESKTOP_APP_DIRECT
+var MandatoryFraudPreventionHeaders = []string{
+	"Gov-Client-Connection-Method",
+	"Gov-Client-Device-ID",
+	"Gov-Client-User-IDs",
+	"Gov-Client-Timezone",
+	"Gov-Client-Local-IPs",
+	"Gov-Client-Local-IPs-Timestamp",
+	"Gov-Client-MAC-Addresses",
+	"Gov-Client-Screens",
+	"Gov-Client-Window-Size",
+	"Gov-Client-Multi-Factor",
+	"Gov-Client-User-Agent",
+	"Gov-Vendor-Version",
+	"Gov-Vendor-License-IDs",
+	"Gov-Vendor-Product-Name",
+}
+
+// the mandatory headers which are sent empty when the value can't be collected,
+// the rest of them must have a value
+var emptyFraudPreventionHeaders = map[string]bool{
+	"Gov-Client-Local-IPs":     true,
+	"Gov-Client-MAC-Addresses": true,
+	"Gov-Client-Screens":       true,
+	"Gov-Client-Window-Size":   true,
+	"Gov-Client-Multi-Factor":  true,
+	"Gov-Vendor-License-IDs":   true,
+}
+
+func encodeList(values []string) string {
+	encoded := make([]string, len(values))
+	for i, v := range values {
+		encoded[i] = url.QueryEscape(v)
+	}
+	return strings.Join(encoded, ",")
+}
+
+// like "width=1920&height=1080&scaling-factor=1.25&colour-depth=24", comma separated for every screen
+func encodeScreens(screens []Screen) string {
+	encoded := make([]string, len(screens))
+	for i, s := range screens {
+		encoded[i] = "width=" + strconv.Itoa(s.Width) + "&height=" + strconv.Itoa(s.Height) +
+			"&scaling-factor=" + strconv.FormatFloat(s.ScalingFactor, 'f', -1, 64) +
+			"&colour-depth=" + strconv.Itoa(s.ColourDepth)
+	}
+	return strings.Join(encoded, ",")
+}
+
+func encodeWindowSize(width, height int) string {
+	if width == 0 || height == 0 {
+		return ""
+	}
+	return "width=" + strconv.Itoa(width) + "&height=" + strconv.Itoa(height)
+}
+
+// like "type=TOTP&timestamp=2017-04-21T13%3A23Z&unique-reference=fc4b5fd6", comma separated
+func encodeMultiFactor(factors []MultiFactor) string {
+	encoded := make([]string, len(factors))
+	for i, m := range factors {
+		encoded[i] = "type=" + url.QueryEscape(m.Type) +
+			"&timestamp=" + url.QueryEscape(m.Timestamp.UTC().Format("2006-01-02T15:04Z")) +
+			"&unique-reference=" + url.QueryEscape(m.UniqueReference)
+	}
+	return strings.Join(encoded, ",")
+}
+
+func encodeLicenseIDs(ids []string) string {
+	encoded := make([]string, len(ids))
+	for i, id := range ids {
+		encoded[i] = "tax-bookkeeper=" + url.QueryEscape(id)
+	}
+	return strings.Join(encoded, ",")
+}
+
+// formats the time in UTC with milliseconds, like "2020-09-21T14:30:05.123Z"
+func formatTimestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+// formats the timezone as HMRC expects, like "UTC+01:00"
+func formatTimezone(now time.Time) string {
+	return "UTC" + now.Format("-07:00")
+}
@@ -0,0 +1,130 @@
+package hmrc
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/conf"
+)
+
+const dateFormat = "2006-01-02"
+
+// Date is a calendar date as HMRC sends it, like "2019-03-31"
+type Date struct {
+	time.Time
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(`"` + d.Format(dateFormat) + `"`), nil
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	str := strings.Trim(string(data), `"`)
+	if str == "null" || str == "" {
+		return nil
+	}
+	parsed, err := time.ParseInLocation(dateFormat, str, conf.GMT)
+	if err != nil {
+		return err
+	}
+	d.Time = parsed
+	return nil
+}
+
+type (
+	// Token is an OAuth2 access token issued for the user-restricted endpoints
+	Token struct {
+		AccessToken  string    `json:"access_token"`
+		RefreshToken string    `json:"refresh_token"`
+		ExpiresIn    int       `json:"expires_in"`
+		Scope        string    `json:"scope"`
+		TokenType    string    `json:"token_type"`
+		ExpiresAt    time.Time `json:"-"`
+	}
+
+	// Obligation is a VAT period for which a return is expected
+	Obligation struct {
+		Start     Date   `json:"start"`
+		End       Date   `json:"end"`
+		Due       Date   `json:"due"`
+		Status    string `json:"status"` // "O" - open, "F" - fulfilled
+		PeriodKey string `json:"periodKey"`
+		Received  Date   `json:"received"`
+	}
+
+	// VATReturn is a nine-box VAT return
+	VATReturn struct {
+		PeriodKey                    string  `json:"periodKey"`
+		VatDueSales                  float64 `json:"vatDueSales"`                  // box 1
+		VatDueAcquisitions           float64 `json:"vatDueAcquisitions"`           // box 2
+		TotalVatDue                  float64 `json:"totalVatDue"`                  // box 3
+		VatReclaimedCurrPeriod       float64 `json:"vatReclaimedCurrPeriod"`       // box 4
+		NetVatDue                    float64 `json:"netVatDue"`                    // box 5
+		TotalValueSalesExVAT         float64 `json:"totalValueSalesExVAT"`         // box 6
+		TotalValuePurchasesExVAT     float64 `json:"totalValuePurchasesExVAT"`     // box 7
+		TotalValueGoodsSuppliedExVAT float64 `json:"totalValueGoodsSuppliedExVAT"` // box 8
+		TotalAcquisitionsExVAT       float64 `json:"totalAcquisitionsExVAT"`       // box 9
+		Finalised                    bool    `json:"finalised"`
+	}
+
+	// SubmissionReceipt is returned by HMRC when a return is accepted
+	SubmissionReceipt struct {
+		ProcessingDate   time.Time `json:"processingDate"`
+		PaymentIndicator string    `json:"paymentIndicator,omitempty"`
+		FormBundleNumber string    `json:"formBundleNumber"`
+		ChargeRefNumber  string    `json:"chargeRefNumber,omitempty"`
+	}
+
+	TaxPeriod struct {
+		From Date `json:"from"`
+		To   Date `json:"to"`
+	}
+
+	// Liability is an amount charged by HMRC
+	Liability struct {
+		TaxPeriod         TaxPeriod `json:"taxPeriod"`
+		Type              string    `json:"type"`
+		OriginalAmount    float64   `json:"originalAmount"`
+		OutstandingAmount float64   `json:"outstandingAmount"`
+		Due               Date      `json:"due"`
+	}
+
+	// Payment is an amount received by HMRC
+	Payment struct {
+		Amount   float64 `json:"amount"`
+		Received Date    `json:"received"`
+	}
+
+	// APIError is the error body HMRC returns for 4xx and 5xx responses
+	APIError struct {
+		StatusCode int    `json:"-"`
+		Code       string `json:"code"`
+		Message    string `json:"message"`
+	}
+)
+
+func (e *APIError) Error() string {
+	return "HMRC responded " + e.Code + ": " + e.Message
+}
+
+// HasWholePounds checks that the boxes 6 to 9 have no pennies, HMRC rejects them otherwise
+func (r VATReturn) HasWholePounds() bool {
+	for _, box := range []float64{r.TotalValueSalesExVAT, r.TotalValuePurchasesExVAT, r.TotalValueGoodsSuppliedExVAT,
+		r.TotalAcquisitionsExVAT} {
+		if box != math.Trunc(box) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateBoxes checks the arithmetic between the boxes of a VAT return the same way HMRC does
+func (r VATReturn) ValidateBoxes() bool {
+	const penny = 0.005
+	return math.Abs(r.TotalVatDue-(r.VatDueSales+r.VatDueAcquisitions)) < penny &&
+		math.Abs(r.NetVatDue-math.Abs(r.TotalVatDue-r.VatReclaimedCurrPeriod)) < penny
+}
@@ -0,0 +1,294 @@
+package hmrc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/conf"
+)
+
+// StubServer is an in-process imitation of the HMRC MTD VAT API. It auto-approves authorization requests,
+// checks the bearer token and fraud prevention headers and keeps obligations, returns, liabilities and payments
+// in memory, so that the whole flow can be tested offline
+type StubServer struct {
+	*httptest.Server
+
+	ClientID     string
+	ClientSecret string
+
+	mu            sync.Mutex
+	codes         map[string]bool
+	tokens        map[string]bool
+	refreshTokens map[string]bool
+	counter       int
+
+	Obligations map[string][]Obligation // VRN => obligations
+	Returns     map[string][]VATReturn  // VRN => submitted returns
+	Liabilities map[string][]Liability
+	Payments    map[string][]Payment
+}
+
+// NewStubServer starts the stub, don't forget to Close it
+func NewStubServer(clientID, clientSecret string) *StubServer {
+	s := &StubServer{
+		ClientID:      clientID,
+		ClientSecret:  clientSecret,
+		codes:         make(map[string]bool),
+		tokens:        make(map[string]bool),
+		refreshTokens: make(map[string]bool),
+		Obligations:   make(map[string][]Obligation),
+		Returns:       make(map[string][]VATReturn),
+		Liabilities:   make(map[string][]Liability),
+		Payments:      make(map[string][]Payment),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth/authorize", s.handleAuthorize)
+	mux.HandleFunc("/oauth/token", s.handleToken)
+	mux.HandleFunc("/organisations/vat/", s.handleVAT)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// AddObligation adds an open obligation for the VRN
+func (s *StubServer) AddObligation(vrn string, start, end time.Time, periodKey string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Obligations[vrn] = append(s.Obligations[vrn], Obligation{
+		Start:     Date{start},
+		End:       Date{end},
+		Due:       Date{end.AddDate(0, 0, 1).AddDate(0, 1, 6)}, // 1 month and 7 days after the end
+		Status:    ObligationOpen,
+		PeriodKey: periodKey,
+	})
+}
+
+// IssueCode imitates the user approving the access on the HMRC website
+func (s *StubServer) IssueCode() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	code := s.nextID("code")
+	s.codes[code] = true
+	return code
+}
+
+func (s *StubServer) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("client_id") != s.ClientID {
+		writeError(w, http.StatusBadRequest, "INVALID_CLIENT", "unknown client_id")
+		return
+	}
+
+	redirect, err := url.Parse(r.URL.Query().Get("redirect_uri"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "redirect_uri is not valid")
+		return
+	}
+
+	params := redirect.Query()
+	params.Set("code", s.IssueCode())
+	params.Set("state", r.URL.Query().Get("state"))
+	redirect.RawQuery = params.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusSeeOther)
+}
+
+func (s *StubServer) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if r.PostForm.Get("client_id") != s.ClientID || r.PostForm.Get("client_secret") != s.ClientSecret {
+		writeError(w, http.StatusUnauthorized, "INVALID_CLIENT", "client credentials are not valid")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		code := r.PostForm.Get("code")
+		if !s.codes[code] {
+			writeError(w, http.StatusBadRequest, "INVALID_GRANT", "authorization code is not valid")
+			return
+		}
+		delete(s.codes, code)
+	case "refresh_token":
+		refresh := r.PostForm.Get("refresh_token")
+		if !s.refreshTokens[refresh] {
+			writeError(w, http.StatusBadRequest, "INVALID_GRANT", "refresh token is not valid")
+			return
+		}
+		delete(s.refreshTokens, refresh)
+	default:
+		writeError(w, http.StatusBadRequest, "UNSUPPORTED_GRANT_TYPE", "grant_type is not supported")
+		return
+	}
+
+	token := Token{
+		AccessToken:  s.nextID("access"),
+		RefreshToken: s.nextID("refresh"),
+		ExpiresIn:    14400,
+		Scope:        vatScope,
+		TokenType:    "bearer",
+	}
+	s.tokens[token.AccessToken] = true
+	s.refreshTokens[token.RefreshToken] = true
+	writeJSON(w, http.StatusOK, token)
+}
+
+// handles /organisations/vat/{vrn}/{obligations|returns|liabilities|payments}
+func (s *StubServer) handleVAT(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/organisations/vat/"), "/")
+	if len(parts) != 2 {
+		writeError(w, http.StatusNotFound, "MATCHING_RESOURCE_NOT_FOUND", "unknown endpoint")
+		return
+	}
+	vrn, resource := parts[0], parts[1]
+
+	if r.Header.Get("Accept") != acceptHeader {
+		writeError(w, http.StatusNotAcceptable, "ACCEPT_HEADER_INVALID", "the accept header is missing or invalid")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")] {
+		writeError(w, http.StatusUnauthorized, "INVALID_CREDENTIALS", "invalid authentication credentials")
+		return
+	}
+
+	for _, h := range MandatoryFraudPreventionHeaders {
+		if _, ok := r.Header[http.CanonicalHeaderKey(h)]; !ok || (r.Header.Get(h) == "" && !emptyFraudPreventionHeaders[h]) {
+			writeError(w, http.StatusBadRequest, "INVALID_HEADER", "fraud prevention header "+h+" is missing")
+			return
+		}
+	}
+
+	switch {
+	case resource == "obligations" && r.Method == http.MethodGet:
+		from, to, ok := parseDateRange(w, r)
+		if !ok {
+			return
+		}
+		status := r.URL.Query().Get("status")
+		var obligations []Obligation
+		for _, o := range s.Obligations[vrn] {
+			if (status == "" || status == o.Status) && !o.End.Before(from) && !o.Start.After(to) {
+				obligations = append(obligations, o)
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"obligations": obligations})
+
+	case resource == "returns" && r.Method == http.MethodPost:
+		var vatReturn VATReturn
+		if err := json.NewDecoder(r.Body).Decode(&vatReturn); err != nil {
+			writeError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
+		s.submitReturn(w, vrn, vatReturn)
+
+	case resource == "liabilities" && r.Method == http.MethodGet:
+		from, to, ok := parseDateRange(w, r)
+		if !ok {
+			return
+		}
+		var liabilities []Liability
+		for _, l := range s.Liabilities[vrn] {
+			if !l.TaxPeriod.To.Before(from) && !l.TaxPeriod.From.After(to) {
+				liabilities = append(liabilities, l)
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"liabilities": liabilities})
+
+	case resource == "payments" && r.Method == http.MethodGet:
+		from, to, ok := parseDateRange(w, r)
+		if !ok {
+			return
+		}
+		var payments []Payment
+		for _, p := range s.Payments[vrn] {
+			if !p.Received.Before(from) && !p.Received.After(to) {
+				payments = append(payments, p)
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"payments": payments})
+
+	default:
+		writeError(w, http.StatusNotFound, "MATCHING_RESOURCE_NOT_FOUND", "unknown endpoint")
+	}
+}
+
+// fulfils the obligation and creates the liability, as HMRC does
+func (s *StubServer) submitReturn(w http.ResponseWriter, vrn string, vatReturn VATReturn) {
+	if !vatReturn.Finalised {
+		writeError(w, http.StatusForbidden, "NOT_FINALISED", "the return must be finalised")
+		return
+	}
+	if !vatReturn.ValidateBoxes() {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "the boxes of the return don't add up")
+		return
+	}
+
+	for i, o := range s.Obligations[vrn] {
+		if o.PeriodKey != vatReturn.PeriodKey {
+			continue
+		}
+		if o.Status == ObligationFulfilled {
+			writeError(w, http.StatusForbidden, "DUPLICATE_SUBMISSION", "the return was already submitted")
+			return
+		}
+
+		now := time.Now().In(conf.GMT)
+		s.Obligations[vrn][i].Status = ObligationFulfilled
+		s.Obligations[vrn][i].Received = Date{now}
+		s.Returns[vrn] = append(s.Returns[vrn], vatReturn)
+		s.Liabilities[vrn] = append(s.Liabilities[vrn], Liability{
+			TaxPeriod:         TaxPeriod{From: o.Start, To: o.End},
+			Type:              "VAT Return Debit Charge",
+			OriginalAmount:    vatReturn.NetVatDue,
+			OutstandingAmount: vatReturn.NetVatDue,
+			Due:               o.Due,
+		})
+
+		writeJSON(w, http.StatusCreated, SubmissionReceipt{
+			ProcessingDate:   now,
+			PaymentIndicator: "BANK",
+			FormBundleNumber: s.nextID("bundle"),
+		})
+		return
+	}
+
+	writeError(w, http.StatusForbidden, "TAX_PERIOD_NOT_ENDED", "there is no obligation for the period key")
+}
+
+func (s *StubServer) nextID(prefix string) string {
+	s.counter++
+	return prefix + "-" + strconv.Itoa(s.counter)
+}
+
+func parseDateRange(w http.ResponseWriter, r *http.Request) (time.Time, time.Time, bool) {
+	from, errFrom := time.ParseInLocation(dateFormat, r.URL.Query().Get("from"), conf.GMT)
+	to, errTo := time.ParseInLocation(dateFormat, r.URL.Query().Get("to"), conf.GMT)
+	if errFrom != nil || errTo != nil || to.Before(from) {
+		writeError(w, http.StatusBadRequest, "INVALID_DATE_RANGE", "invalid date range")
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, APIError{Code: code, Message: message})
+}
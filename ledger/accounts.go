@@ -0,0 +1,118 @@
+package ledger
+
+import (
+	"github.com/w32blaster/tax-bookkeeper/db"
+)
+
+// codes of the groups of the default chart
+const (
+	FixedAssets            = "0010"
+	CurrentAssets          = "1000"
+	CurrentLiabilities     = "2000"
+	StaffCosts             = "6900"
+	AdministrativeExpenses = "7090"
+)
+
+// codes of the accounts used by the automatic postings
+const (
+	FixedAssetsCost         = "0030"
+	AccumulatedDepreciation = "0031"
+	Debtors                 = "1100"
+	BankAccount             = "1200"
+	DirectorsLoan           = "1250" // a debit balance is an overdrawn loan account
+	TaxLiabilities          = "2200"
+	VATControl              = "2202" // VAT of the current VAT period, it moves to 2200 when the period ends
+	Accruals                = "2300"
+	ShareCapital            = "3000"
+	RetainedEarnings        = "3200"
+	Dividends               = "3300"
+	Sales                   = "4000"
+	CostOfSales             = "5000"
+	DirectorsSalaries       = "7000"
+	Wages                   = "7002"
+	EmployersNI             = "7004"
+	Pension                 = "7006"
+	Office                  = "7100"
+	Premises                = "7200"
+	Travel                  = "7400"
+	Equipment               = "7500"
+	LegalAndProfessional    = "7600"
+	ExpensesReimbursed      = "7700"
+	BankCharges             = "7900"
+	Depreciation            = "8000"
+	DisposalLoss            = "8110" // a credit balance is a gain
+	Penalties               = "8200"
+	Entertaining            = "8205"
+	CorporationTaxCharge    = "9100" // it is not an overhead, the profit and loss is before tax
+	Suspense                = "9999" // bank transactions not allocated yet
+)
+
+// DefaultChart is the chart of accounts a new database starts with, it is seeded from the transaction categories
+var DefaultChart = []db.Account{
+	{Code: FixedAssets, Name: "Fixed assets", Type: db.AssetAccount},
+	{Code: FixedAssetsCost, Name: "Fixed assets at cost", Type: db.AssetAccount, Parent: FixedAssets, Capital: true,
+		Category: db.FixedAssetPurchase},
+	{Code: AccumulatedDepreciation, Name: "Fixed assets accumulated depreciation", Type: db.AssetAccount, Parent: FixedAssets},
+	{Code: CurrentAssets, Name: "Current assets", Type: db.AssetAccount},
+	{Code: Debtors, Name: "Debtors", Type: db.AssetAccount, Parent: CurrentAssets},
+	{Code: BankAccount, Name: "Bank current account", Type: db.AssetAccount, Parent: CurrentAssets},
+	{Code: DirectorsLoan, Name: "Director's loan account", Type: db.AssetAccount, Parent: CurrentAssets, Category: db.Loan},
+	{Code: CurrentLiabilities, Name: "Current liabilities", Type: db.LiabilityAccount},
+	{Code: TaxLiabilities, Name: "HMRC (VAT, PAYE, corporation tax)", Type: db.LiabilityAccount, Parent: CurrentLiabilities,
+		Category: db.HMRC},
+	{Code: VATControl, Name: "VAT", Type: db.LiabilityAccount, Parent: CurrentLiabilities},
+	{Code: Accruals, Name: "Accruals", Type: db.LiabilityAccount, Parent: CurrentLiabilities},
+	{Code: ShareCapital, Name: "Share capital", Type: db.EquityAccount},
+	{Code: RetainedEarnings, Name: "Retained earnings", Type: db.EquityAccount},
+	{Code: Dividends, Name: "Dividends", Type: db.EquityAccount, Category: db.Dividend},
+	{Code: Sales, Name: "Sales", Type: db.IncomeAccount, VATRate: 0.2, Category: db.Income},
+	{Code: CostOfSales, Name: "Cost of sales", Type: db.ExpenseAccount, CTAllowable: true, Category: db.CostOfSales},
+	{Code: StaffCosts, Name: "Staff costs", Type: db.ExpenseAccount},
+	{Code: DirectorsSalaries, Name: "Directors' salaries", Type: db.ExpenseAccount, Parent: StaffCosts, CTAllowable: true,
+		Category: db.Salary},
+	{Code: Wages, Name: "Wages", Type: db.ExpenseAccount, Parent: StaffCosts, CTAllowable: true, Category: db.WagesPayment},
+	{Code: EmployersNI, Name: "Employer's National Insurance", Type: db.ExpenseAccount, Parent: StaffCosts, CTAllowable: true},
+	{Code: Pension, Name: "Pension contributions", Type: db.ExpenseAccount, Parent: StaffCosts, CTAllowable: true,
+		Category: db.Pension},
+	{Code: AdministrativeExpenses, Name: "Administrative expenses", Type: db.ExpenseAccount},
+	{Code: Office, Name: "Office expenses", Type: db.ExpenseAccount, Parent: AdministrativeExpenses, CTAllowable: true,
+		VATRate: 0.2, Category: db.Office},
+	{Code: Premises, Name: "Premises (heat, water, electricity)", Type: db.ExpenseAccount, Parent: AdministrativeExpenses,
+		CTAllowable: true, VATRate: 0.2, Category: db.Premises},
+	{Code: Travel, Name: "Travel expenses", Type: db.ExpenseAccount, Parent: AdministrativeExpenses, CTAllowable: true,
+		VATRate: 0.2, Category: db.Travel},
+	{Code: Equipment, Name: "Equipment expenses", Type: db.ExpenseAccount, Parent: AdministrativeExpenses, CTAllowable: true,
+		VATRate: 0.2, Category: db.EquipmentExpenses},
+	{Code: LegalAndProfessional, Name: "Legal and professional (accountancy, advertising)", Type: db.ExpenseAccount,
+		Parent: AdministrativeExpenses, CTAllowable: true, VATRate: 0.2, Category: db.Legal},
+	{Code: ExpensesReimbursed, Name: "Expenses reimbursed to director", Type: db.ExpenseAccount, Parent: AdministrativeExpenses,
+		CTAllowable: true, VATRate: 0.2, Category: db.ExpenseReimbursement},
+	{Code: BankCharges, Name: "Bank charges", Type: db.ExpenseAccount, Parent: AdministrativeExpenses, CTAllowable: true,
+		Category: db.BankCharges},
+	{Code: Depreciation, Name: "Depreciation", Type: db.ExpenseAccount, Parent: AdministrativeExpenses},
+	{Code: DisposalLoss, Name: "Loss (gain) on disposal of fixed assets", Type: db.ExpenseAccount, Parent: AdministrativeExpenses},
+	{Code: Penalties, Name: "Penalties and fines", Type: db.ExpenseAccount, Parent: AdministrativeExpenses,
+		Category: db.Penalties},
+	{Code: Entertaining, Name: "Client entertaining", Type: db.ExpenseAccount, Parent: AdministrativeExpenses,
+		Category: db.Entertaining},
+	{Code: CorporationTaxCharge, Name: "Corporation tax", Type: db.ExpenseAccount},
+	{Code: Suspense, Name: "Suspense", Type: db.AssetAccount, Category: db.Unknown},
+}
+
+// InitChartOfAccounts seeds the chart of accounts of a new database, or adds the accounts which are new to the chart
+// of an existing one, and allocates transactions of the old categories to the accounts
+func InitChartOfAccounts(d *db.Database) error {
+	if err := d.SeedAccounts(DefaultChart); err != nil {
+		return err
+	}
+	_, err := d.AssignAccounts()
+	return err
+}
+
+// AccountForTransaction returns the account the bank transaction is posted to
+func AccountForTransaction(t db.Transaction) string {
+	if t.ToBeAllocated || t.Account == "" {
+		return Suspense
+	}
+	return t.Account
+}
@@ -0,0 +1,206 @@
+package ledger
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/tax"
+)
+
+// TrialBalanceLine is the balance of one account, either Debit or Credit is set
+type TrialBalanceLine struct {
+	Code   string  `json:"code"`
+	Name   string  `json:"name"`
+	Debit  float64 `json:"debit"`
+	Credit float64 `json:"credit"`
+}
+
+// TrialBalance returns balances of all the accounts at the end of the accounting period starting on periodStart,
+// "until" is exclusive. Income, expenses and dividends of the previous periods, which are not closed, are
+// brought forward to retained earnings
+func (l Ledger) TrialBalance(periodStart, until time.Time) []TrialBalanceLine {
+	balances := l.Balances(periodStart, until)
+	for code, balance := range l.Balances(time.Time{}, periodStart) {
+		if a, ok := l.Account(code); ok && isClosedToRetainedEarnings(a) {
+			code = RetainedEarnings
+		}
+		balances[code] = round(balances[code] + balance)
+	}
+
+	var lines []TrialBalanceLine
+	for code, balance := range balances {
+		if balance == 0 {
+			continue
+		}
+		line := TrialBalanceLine{Code: code}
+		if a, ok := l.Account(code); ok {
+			line.Name = a.Name
+		}
+		if balance > 0 {
+			line.Debit = balance
+		} else {
+			line.Credit = -balance
+		}
+		lines = append(lines, line)
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		return lines[i].Code < lines[j].Code
+	})
+	return lines
+}
+
+// ClosingEntry moves balances of income, expenses and dividends of the period to retained earnings,
+// it is dated on the last day of the period. The entry has no lines if there is nothing to close
+func (l Ledger) ClosingEntry(start, end time.Time) db.JournalEntry {
+	entry := db.JournalEntry{
+		Date:        end.AddDate(0, 0, -1),
+		Description: "Year-end close of " + start.Format("02 Jan 2006") + " - " + end.AddDate(0, 0, -1).Format("02 Jan 2006"),
+		Source:      db.ClosingEntry,
+	}
+
+	balances := l.Balances(start, end)
+	codes := make([]string, 0, len(balances))
+	for code := range balances {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	var result float64
+	for _, code := range codes {
+		a, ok := l.Account(code)
+		balance := balances[code]
+		if !ok || !isClosedToRetainedEarnings(a) || balance == 0 {
+			continue
+		}
+		if balance > 0 {
+			entry.Lines = append(entry.Lines, db.JournalLine{Account: code, Credit: balance})
+		} else {
+			entry.Lines = append(entry.Lines, db.JournalLine{Account: code, Debit: -balance})
+		}
+		result = result + balance
+	}
+
+	result = round(result)
+	if result > 0 {
+		entry.Lines = append(entry.Lines, db.JournalLine{Account: RetainedEarnings, Debit: result})
+	} else if result < 0 {
+		entry.Lines = append(entry.Lines, db.JournalLine{Account: RetainedEarnings, Credit: -result})
+	}
+	return entry
+}
+
+// WithoutClosingEntries returns the ledger without the year-end closing entries, it is used for the profit
+// and loss of closed periods
+func (l Ledger) WithoutClosingEntries() Ledger {
+	return l.Without(db.ClosingEntry)
+}
+
+// Without returns the ledger without the entries of the sources
+func (l Ledger) Without(sources ...db.JournalSource) Ledger {
+	skipped := map[db.JournalSource]bool{}
+	for _, s := range sources {
+		skipped[s] = true
+	}
+
+	filtered := Ledger{Accounts: l.Accounts}
+	for _, e := range l.Entries {
+		if !skipped[e.Source] {
+			filtered.Entries = append(filtered.Entries, e)
+		}
+	}
+	return filtered
+}
+
+// ClosePeriod posts the corporation tax charge and the closing entry of the accounting period starting on the date
+// and locks the period, so that its transactions and journals can't be changed anymore. The tax is charged first,
+// so the retained earnings are after tax
+func ClosePeriod(d *db.Database, vat *tax.VATScheme, start, now time.Time, corporationTax float64) (db.JournalEntry, error) {
+	end := start.AddDate(1, 0, 0)
+	if end.After(now) {
+		return db.JournalEntry{}, errors.New("the accounting period is not over yet, it ends on " +
+			end.AddDate(0, 0, -1).Format("02-01-2006"))
+	}
+
+	locks, err := d.GetLockedPeriods()
+	if err != nil {
+		return db.JournalEntry{}, err
+	}
+	for _, lock := range locks {
+		if lock.Start.Equal(start) {
+			return db.JournalEntry{}, errors.New("the accounting period " + start.Format("02-01-2006") + " is closed already")
+		}
+	}
+
+	// the accrual of the previous close is replaced, the tax could change since the period was reopened
+	if err := deletePeriodEntries(d, start, db.TaxAccrual); err != nil {
+		return db.JournalEntry{}, err
+	}
+	accrual := PostCorporationTax(start, corporationTax)
+	if corporationTax != 0 {
+		if err := d.SaveJournalEntry(&accrual); err != nil {
+			return db.JournalEntry{}, err
+		}
+	}
+
+	l, err := Build(d, vat, end)
+	if err != nil {
+		return db.JournalEntry{}, err
+	}
+	entry := l.WithoutClosingEntries().ClosingEntry(start, end)
+
+	if len(entry.Lines) > 0 {
+		err = ValidateEntry(entry, l.Accounts)
+		if err == nil {
+			err = d.SaveJournalEntry(&entry)
+		}
+	}
+	if err == nil {
+		err = d.LockPeriod(db.LockedPeriod{Start: start, End: end, ClosedAt: now})
+	}
+	if err != nil {
+		for _, pk := range []int{entry.Pk, accrual.Pk} {
+			if pk != 0 {
+				d.DeleteJournalEntry(pk)
+			}
+		}
+		return db.JournalEntry{}, err
+	}
+	return entry, nil
+}
+
+// ReopenPeriod unlocks the accounting period starting on the date and deletes its closing entry and the
+// corporation tax charge, they are posted again when the period is closed
+func ReopenPeriod(d *db.Database, start time.Time) error {
+	if err := d.UnlockPeriod(start); err != nil {
+		return err
+	}
+	return deletePeriodEntries(d, start, db.ClosingEntry, db.TaxAccrual)
+}
+
+// deletes the journals of the sources dated in the accounting period starting on the date
+func deletePeriodEntries(d *db.Database, start time.Time, sources ...db.JournalSource) error {
+	journals, err := d.GetJournalEntries()
+	if err != nil {
+		return err
+	}
+	end := start.AddDate(1, 0, 0)
+	deleted := map[db.JournalSource]bool{}
+	for _, s := range sources {
+		deleted[s] = true
+	}
+	for _, e := range journals {
+		if deleted[e.Source] && !e.Date.Before(start) && e.Date.Before(end) {
+			if err := d.DeleteJournalEntry(e.Pk); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// income, expenses and dividends start every accounting period from zero
+func isClosedToRetainedEarnings(a db.Account) bool {
+	return a.Type == db.IncomeAccount || a.Type == db.ExpenseAccount || a.Code == Dividends
+}
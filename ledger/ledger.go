@@ -0,0 +1,324 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/assets"
+	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/tax"
+)
+
+// Ledger is the double-entry general ledger of the company
+type Ledger struct {
+	Entries  []db.JournalEntry // ordered by date
+	Accounts []db.Account      // the chart of accounts
+}
+
+// Build builds the general ledger from the bank transactions posted by their category, the depreciation of
+// the fixed asset register until the given date, the payroll and the manual journals. Bank transactions are posted
+// every time the ledger is built, so that re-allocating a transaction never leaves a stale entry behind.
+// VAT is posted by the scheme, which is nil if the company is not registered for VAT
+func Build(d *db.Database, vat *tax.VATScheme, until time.Time) (Ledger, error) {
+	transactions, err := d.GetAll(0, 0)
+	if err != nil {
+		return Ledger{}, err
+	}
+
+	register, err := assets.GetRegister(d)
+	if err != nil {
+		return Ledger{}, err
+	}
+
+	journals, err := d.GetJournalEntries()
+	if err != nil {
+		return Ledger{}, err
+	}
+
+	chart, err := getChart(d)
+	if err != nil {
+		return Ledger{}, err
+	}
+
+	invoices, err := d.GetInvoices()
+	if err != nil {
+		return Ledger{}, err
+	}
+
+	employees, err := d.GetAllEmployees()
+	if err != nil {
+		return Ledger{}, err
+	}
+	payslips, err := d.GetAllPayslips()
+	if err != nil {
+		return Ledger{}, err
+	}
+	payments, err := d.GetAllPAYEPayments()
+	if err != nil {
+		return Ledger{}, err
+	}
+
+	// bank transactions which received the disposal proceeds or paid the invoices
+	disposals := map[int]bool{}
+	for _, a := range register {
+		if a.DisposalTransactionPk != 0 {
+			disposals[a.DisposalTransactionPk] = true
+		}
+	}
+	paid := map[int]db.Invoice{}
+	for _, inv := range invoices {
+		if inv.TransactionPk != 0 {
+			paid[inv.TransactionPk] = inv
+		}
+	}
+
+	l := Ledger{Accounts: chart}
+	for _, t := range transactions {
+		if disposals[t.Pk] {
+			// the proceeds clear what the buyer owes since the disposal
+			t.Account, t.ToBeAllocated = Debtors, false
+		}
+		l.Entries = append(l.Entries, postTransactionWithVAT(t, vat, chart, paid))
+	}
+	for _, a := range register {
+		l.Entries = append(l.Entries, PostFixedAsset(a, until)...)
+	}
+	if vat != nil && vat.Basis == tax.InvoiceAccounting {
+		for _, inv := range invoices {
+			if inv.VAT != 0 {
+				l.Entries = append(l.Entries, PostInvoice(inv))
+			}
+		}
+	}
+	l.Entries = append(l.Entries, PostPayroll(employees, payslips, payments)...)
+	l.Entries = append(l.Entries, journals...)
+	if vat != nil {
+		l.Entries = append(l.Entries, l.postVATReturns(*vat, until)...)
+	}
+
+	sort.SliceStable(l.Entries, func(i, j int) bool {
+		return l.Entries[i].Date.Before(l.Entries[j].Date)
+	})
+	return l, nil
+}
+
+// PostTransaction posts the bank transaction to the account of its category, the other side is the bank account
+func PostTransaction(t db.Transaction) db.JournalEntry {
+	return postTransaction(t, 0, "")
+}
+
+// the amount net of VAT is posted to the account of the transaction category, VAT goes to the given account
+func postTransaction(t db.Transaction, vat float64, vatAccount string) db.JournalEntry {
+	entry := db.JournalEntry{
+		Date:        t.Date,
+		Description: t.Description,
+		Source:      db.BankPosting,
+		Reference:   t.Pk,
+	}
+
+	account := AccountForTransaction(t)
+	if t.Type == db.Credit {
+		amount := math.Abs(t.Credit)
+		entry.Lines = []db.JournalLine{{Account: BankAccount, Debit: amount}, {Account: account, Credit: round(amount - vat)}}
+		if vat != 0 {
+			entry.Lines = append(entry.Lines, db.JournalLine{Account: vatAccount, Credit: vat})
+		}
+	} else {
+		amount := math.Abs(t.Debit)
+		entry.Lines = []db.JournalLine{{Account: account, Debit: round(amount - vat)}}
+		if vat != 0 {
+			entry.Lines = append(entry.Lines, db.JournalLine{Account: vatAccount, Debit: vat})
+		}
+		entry.Lines = append(entry.Lines, db.JournalLine{Account: BankAccount, Credit: amount})
+	}
+	return entry
+}
+
+// Under the VAT cash accounting the tax point is the bank transaction: VAT of a paid invoice is taken from
+// the invoice, a receipt or a purchase without an invoice is taken at the default VAT rate of its account. Under the invoice
+// accounting VAT was posted on the issue date, so the payment of the invoice clears what the customer owes
+// or what the supplier is owed. The whole amount is posted if the company is not registered for VAT
+func postTransactionWithVAT(t db.Transaction, vat *tax.VATScheme, chart []db.Account, paid map[int]db.Invoice) db.JournalEntry {
+	if vat == nil {
+		return PostTransaction(t)
+	}
+
+	if inv, ok := paid[t.Pk]; ok {
+		switch {
+		case vat.Basis == tax.CashAccounting:
+			return postTransaction(t, inv.VAT, VATControl)
+		case inv.Type == db.SalesInvoice:
+			return postTransaction(t, inv.VAT, Debtors)
+		default:
+			return postTransaction(t, inv.VAT, Accruals)
+		}
+	}
+
+	if vat.Basis == tax.CashAccounting {
+		if a, ok := findAccount(chart, AccountForTransaction(t)); ok && a.VATRate > 0 {
+			return postTransaction(t, round(math.Abs(t.Debit+t.Credit)*a.VATRate/(1+a.VATRate)), VATControl)
+		}
+	}
+	return PostTransaction(t)
+}
+
+// PostFixedAsset posts the monthly depreciation of the asset until the given date, and its disposal.
+// Depreciation is posted on the last day of the month. The cost of assets bought with a bank transaction
+// is posted with the transaction, otherwise it goes to the suspense account to be reviewed
+func PostFixedAsset(a db.FixedAsset, until time.Time) []db.JournalEntry {
+	var entries []db.JournalEntry
+
+	if a.TransactionPk == 0 && a.PurchaseDate.Before(until) {
+		entries = append(entries, db.JournalEntry{
+			Date:        a.PurchaseDate,
+			Description: a.Name + ", not linked to a bank transaction",
+			Source:      db.DepreciationPosting,
+			Reference:   a.Pk,
+			Lines:       []db.JournalLine{{Account: FixedAssetsCost, Debit: a.Cost}, {Account: Suspense, Credit: a.Cost}},
+		})
+	}
+
+	for _, c := range assets.GetDepreciationSchedule(a, assets.Monthly, a.PurchaseDate, until) {
+		entries = append(entries, db.JournalEntry{
+			Date:        c.PeriodEnd.AddDate(0, 0, -1),
+			Description: "Depreciation of " + a.Name,
+			Source:      db.DepreciationPosting,
+			Reference:   a.Pk,
+			Lines: []db.JournalLine{
+				{Account: Depreciation, Debit: c.Amount},
+				{Account: AccumulatedDepreciation, Credit: c.Amount},
+			},
+		})
+	}
+
+	if a.IsDisposed() && a.DisposalDate.Before(until) {
+		accumulated := round(a.Cost - assets.NetBookValue(a, a.DisposalDate))
+		gain := assets.DisposalGainOrLoss(a)
+
+		lines := []db.JournalLine{{Account: FixedAssetsCost, Credit: a.Cost}}
+		if accumulated > 0 {
+			lines = append(lines, db.JournalLine{Account: AccumulatedDepreciation, Debit: accumulated})
+		}
+		if a.DisposalProceeds > 0 {
+			lines = append(lines, db.JournalLine{Account: Debtors, Debit: a.DisposalProceeds})
+		}
+		if gain > 0 {
+			lines = append(lines, db.JournalLine{Account: DisposalLoss, Credit: gain})
+		} else if gain < 0 {
+			lines = append(lines, db.JournalLine{Account: DisposalLoss, Debit: -gain})
+		}
+
+		entries = append(entries, db.JournalEntry{
+			Date:        a.DisposalDate,
+			Description: "Disposal of " + a.Name,
+			Source:      db.DepreciationPosting,
+			Reference:   a.Pk,
+			Lines:       lines,
+		})
+	}
+	return entries
+}
+
+// ValidateEntry checks that the journal entry is balanced and it is posted to the accounts of the chart
+func ValidateEntry(entry db.JournalEntry, chart []db.Account) error {
+	if len(entry.Lines) < 2 {
+		return errors.New("a journal entry needs at least two lines")
+	}
+
+	var debit, credit float64
+	for _, line := range entry.Lines {
+		if _, ok := findAccount(chart, line.Account); !ok {
+			return errors.New("there is no account '" + line.Account + "' in the chart of accounts")
+		}
+		if line.Debit < 0 || line.Credit < 0 || (line.Debit > 0) == (line.Credit > 0) {
+			return errors.New("the line of the account " + line.Account + " should have either a debit or a credit amount")
+		}
+		debit = debit + line.Debit
+		credit = credit + line.Credit
+	}
+
+	if round(debit) != round(credit) {
+		return fmt.Errorf("the journal entry is not balanced, debit is £%.2f and credit is £%.2f", debit, credit)
+	}
+	return nil
+}
+
+// AddJournal validates and saves the manual journal
+func AddJournal(d *db.Database, entry *db.JournalEntry) error {
+	chart, err := getChart(d)
+	if err != nil {
+		return err
+	}
+
+	entry.Source = db.ManualJournal
+	if err := ValidateEntry(*entry, chart); err != nil {
+		return err
+	}
+	return d.SaveJournalEntry(entry)
+}
+
+// Balances returns debit minus credit of every account for the entries since "from" (inclusive)
+// until "until" (exclusive)
+func (l Ledger) Balances(from, until time.Time) map[string]float64 {
+	balances := map[string]float64{}
+	for _, e := range l.Entries {
+		if e.Date.Before(from) || !e.Date.Before(until) {
+			continue
+		}
+		for _, line := range e.Lines {
+			balances[line.Account] = round(balances[line.Account] + line.Debit - line.Credit)
+		}
+	}
+	return balances
+}
+
+// Balance returns debit minus credit of the accounts together
+func (l Ledger) Balance(from, until time.Time, codes ...string) float64 {
+	balances := l.Balances(from, until)
+	var total float64
+	for _, code := range codes {
+		total = total + balances[code]
+	}
+	return round(total)
+}
+
+// BalanceOfType returns debit minus credit of all the accounts of the type together
+func (l Ledger) BalanceOfType(from, until time.Time, accountType db.AccountType) float64 {
+	var total float64
+	for code, balance := range l.Balances(from, until) {
+		if a, ok := findAccount(l.Accounts, code); ok && a.Type == accountType {
+			total = total + balance
+		}
+	}
+	return round(total)
+}
+
+// Account finds the account of the chart by its code
+func (l Ledger) Account(code string) (db.Account, bool) {
+	return findAccount(l.Accounts, code)
+}
+
+// the chart of accounts of the database, or the default one if it is not seeded yet
+func getChart(d *db.Database) ([]db.Account, error) {
+	chart, err := d.GetAccounts()
+	if err != nil || len(chart) > 0 {
+		return chart, err
+	}
+	return DefaultChart, nil
+}
+
+func findAccount(chart []db.Account, code string) (db.Account, bool) {
+	for _, a := range chart {
+		if a.Code == code {
+			return a, true
+		}
+	}
+	return db.Account{}, false
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,287 @@
+package ledger
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/w32blaster/tax-bookkeeper/conf"
+	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/tax"
+)
+
+func TestPostTransaction(t *testing.T) {
+	var tests = []struct {
+		name        string
+		transaction db.Transaction
+		expected    []db.JournalLine
+	}{
+		{"expense", db.Transaction{Type: db.Debit, Debit: 120, Account: Travel},
+			[]db.JournalLine{{Account: Travel, Debit: 120}, {Account: BankAccount, Credit: 120}}},
+
+		{"income", db.Transaction{Type: db.Credit, Credit: 1000, Account: Sales},
+			[]db.JournalLine{{Account: BankAccount, Debit: 1000}, {Account: Sales, Credit: 1000}}},
+
+		{"negative amounts of the import", db.Transaction{Type: db.Debit, Debit: -50, Account: BankCharges},
+			[]db.JournalLine{{Account: BankCharges, Debit: 50}, {Account: BankAccount, Credit: 50}}},
+
+		{"not allocated yet", db.Transaction{Type: db.Debit, Debit: 10, Category: db.Travel, ToBeAllocated: true},
+			[]db.JournalLine{{Account: Suspense, Debit: 10}, {Account: BankAccount, Credit: 10}}},
+
+		{"not migrated to the chart of accounts", db.Transaction{Type: db.Debit, Debit: 700, Category: db.Salary},
+			[]db.JournalLine{{Account: Suspense, Debit: 700}, {Account: BankAccount, Credit: 700}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// When:
+			entry := PostTransaction(tt.transaction)
+
+			// Then:
+			assert.Equal(t, db.BankPosting, entry.Source)
+			assert.Equal(t, tt.expected, entry.Lines)
+			assert.Nil(t, ValidateEntry(entry, DefaultChart))
+		})
+	}
+}
+
+func TestValidateEntry(t *testing.T) {
+	var tests = []struct {
+		name    string
+		lines   []db.JournalLine
+		isValid bool
+	}{
+		{"balanced", []db.JournalLine{{Account: LegalAndProfessional, Debit: 500}, {Account: Accruals, Credit: 500}}, true},
+		{"split", []db.JournalLine{{Account: Dividends, Debit: 3000}, {Account: DirectorsLoan, Credit: 1000},
+			{Account: Accruals, Credit: 2000}}, true},
+		{"not balanced", []db.JournalLine{{Account: LegalAndProfessional, Debit: 500}, {Account: Accruals, Credit: 400}}, false},
+		{"one line", []db.JournalLine{{Account: LegalAndProfessional, Debit: 0}}, false},
+		{"unknown account", []db.JournalLine{{Account: "1234", Debit: 500}, {Account: Accruals, Credit: 500}}, false},
+		{"both sides in one line", []db.JournalLine{{Account: Travel, Debit: 500, Credit: 500},
+			{Account: Accruals, Debit: 100}, {Account: BankAccount, Credit: 100}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// When:
+			err := ValidateEntry(db.JournalEntry{Lines: tt.lines}, DefaultChart)
+
+			// Then:
+			assert.Equal(t, tt.isValid, err == nil)
+		})
+	}
+}
+
+func TestPostFixedAssetDisposal(t *testing.T) {
+
+	// Given: charged April 2024 - May 2025, the net book value is 3600 - 1400 = 2200
+	asset := db.FixedAsset{Name: "Laptop", Cost: 3600, PurchaseDate: dateOf("01-04-2024"), UsefulLifeYears: 3,
+		DisposalDate: dateOf("10-06-2025"), DisposalProceeds: 2000, TransactionPk: 1}
+
+	// When:
+	l := Ledger{Entries: PostFixedAsset(asset, dateOf("01-04-2026"))}
+
+	// Then:
+	for _, e := range l.Entries {
+		assert.Nil(t, ValidateEntry(e, DefaultChart))
+	}
+	assert.Equal(t, 1200.0, l.Balance(dateOf("01-04-2024"), dateOf("01-04-2025"), Depreciation))
+	assert.Equal(t, 200.0, l.Balance(dateOf("01-04-2025"), dateOf("01-04-2026"), Depreciation))
+	assert.Equal(t, 200.0, l.Balance(dateOf("01-04-2025"), dateOf("01-04-2026"), DisposalLoss))
+
+	// the asset is gone, only the proceeds are left
+	all := l.Balances(dateOf("01-04-2024"), dateOf("01-04-2026"))
+	assert.Equal(t, 0.0, all[AccumulatedDepreciation])
+	assert.Equal(t, 2000.0, all[Debtors])
+	assert.Equal(t, -3600.0, all[FixedAssetsCost]) // the cost was posted with the bank transaction
+}
+
+func TestBuild(t *testing.T) {
+
+	// create real DB
+	const dbFile = "/tmp/tax-bookkeeper-ledger-build.db"
+	d := db.Init(dbFile)
+	defer func() {
+		d.Close()
+		os.Remove(dbFile)
+	}()
+
+	// Populate with data: the sale of the first quarter is paid to HMRC after the quarter, the laptop is sold
+	// and the buyer pays later
+	_, err := d.ImportTransactions([]db.Transaction{
+		{Date: dateOf("10-02-2021"), Type: db.Debit, Description: "Laptop", Debit: 1200, Category: db.FixedAssetPurchase},
+		{Date: dateOf("15-02-2021"), Type: db.Credit, Description: "ACME", Credit: 1200, Category: db.Income},
+		{Date: dateOf("07-05-2021"), Type: db.Debit, Description: "HMRC VAT", Debit: 200, Category: db.HMRC},
+		{Date: dateOf("20-06-2021"), Type: db.Credit, Description: "Laptop buyer", Credit: 800, Category: db.Income},
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, InitChartOfAccounts(d))
+
+	transactions, err := d.GetAll(0, 0)
+	assert.Nil(t, err)
+	pks := map[string]int{}
+	for _, tx := range transactions {
+		pks[tx.Description] = tx.Pk
+	}
+
+	invoice := db.Invoice{Type: db.SalesInvoice, Number: "INV-1", IssueDate: dateOf("01-02-2021"), Net: 1000, VAT: 200}
+	assert.Nil(t, d.SaveInvoice(&invoice))
+	assert.Nil(t, d.LinkInvoice(invoice.Pk, pks["ACME"]))
+
+	laptop := db.FixedAsset{Name: "Laptop", Cost: 1200, PurchaseDate: dateOf("10-02-2021"), UsefulLifeYears: 3,
+		TransactionPk: pks["Laptop"]}
+	assert.Nil(t, d.SaveFixedAsset(&laptop))
+	assert.Nil(t, d.DisposeFixedAsset(laptop.Pk, dateOf("01-06-2021"), 800, pks["Laptop buyer"]))
+
+	scheme := tax.VATScheme{Period: tax.QuarterlyReturns, PeriodEndMonth: time.March, Basis: tax.CashAccounting}
+
+	// When:
+	l, err := Build(d, &scheme, dateOf("01-07-2021"))
+
+	// Then:
+	assert.Nil(t, err)
+	for _, e := range l.Entries {
+		assert.Nil(t, ValidateEntry(e, l.Accounts))
+	}
+	assert.Equal(t, 1000.0, l.ProfitAndLoss(dateOf("01-01-2021"), dateOf("01-04-2021")).Turnover) // net of VAT
+
+	balances := l.Balances(time.Time{}, dateOf("01-07-2021"))
+	assert.Equal(t, 0.0, balances[VATControl])
+	assert.Equal(t, 0.0, balances[TaxLiabilities]) // the VAT return is paid
+	assert.Equal(t, 0.0, balances[Debtors])        // the buyer paid the proceeds
+	assert.Equal(t, 0.0, balances[Suspense])
+}
+
+func TestBalanceOfType(t *testing.T) {
+
+	// Given:
+	l := Ledger{Accounts: DefaultChart, Entries: []db.JournalEntry{
+		PostTransaction(db.Transaction{Date: dateOf("31-03-2025"), Type: db.Credit, Credit: 500, Account: Sales}),
+		PostTransaction(db.Transaction{Date: dateOf("01-04-2025"), Type: db.Credit, Credit: 1000, Account: Sales}),
+		PostTransaction(db.Transaction{Date: dateOf("02-04-2025"), Type: db.Debit, Debit: 100, Account: Travel}),
+		{Date: dateOf("31-03-2026"), Lines: []db.JournalLine{{Account: LegalAndProfessional, Debit: 300}, {Account: Accruals, Credit: 300}}},
+		PostTransaction(db.Transaction{Date: dateOf("01-04-2026"), Type: db.Debit, Debit: 300, Account: LegalAndProfessional}),
+	}}
+
+	// When: the start date is inclusive, the end date is exclusive
+	income := l.BalanceOfType(dateOf("01-04-2025"), dateOf("01-04-2026"), db.IncomeAccount)
+	expenses := l.BalanceOfType(dateOf("01-04-2025"), dateOf("01-04-2026"), db.ExpenseAccount)
+
+	// Then:
+	assert.Equal(t, -1000.0, income)
+	assert.Equal(t, 400.0, expenses)
+}
+
+func TestProfitAndLoss(t *testing.T) {
+
+	// Given:
+	l := Ledger{Accounts: DefaultChart, Entries: []db.JournalEntry{
+		PostTransaction(db.Transaction{Date: dateOf("01-04-2025"), Type: db.Credit, Credit: 10000, Account: Sales}),
+		PostTransaction(db.Transaction{Date: dateOf("02-04-2025"), Type: db.Debit, Debit: 1000, Account: CostOfSales}),
+		PostTransaction(db.Transaction{Date: dateOf("03-04-2025"), Type: db.Debit, Debit: 2000, Account: DirectorsSalaries}),
+		PostTransaction(db.Transaction{Date: dateOf("04-04-2025"), Type: db.Debit, Debit: 300, Account: Travel}),
+		PostTransaction(db.Transaction{Date: dateOf("05-04-2025"), Type: db.Debit, Debit: 5000, Account: Dividends}),
+		PostTransaction(db.Transaction{Date: dateOf("01-05-2025"), Type: db.Debit, Debit: 400, Account: Travel}),
+	}}
+
+	// When:
+	pnl := l.ProfitAndLoss(dateOf("01-04-2025"), dateOf("01-05-2025"))
+
+	// Then: dividends are paid from the profit, they are not expenses
+	assert.Equal(t, 10000.0, pnl.Turnover)
+	assert.Equal(t, 1000.0, pnl.CostOfSales)
+	assert.Equal(t, 9000.0, pnl.GrossProfit)
+	assert.Equal(t, []ReportLine{
+		{Code: DirectorsSalaries, Name: "Directors' salaries", Group: "Staff costs", Amount: 2000},
+		{Code: Travel, Name: "Travel expenses", Group: "Administrative expenses", Amount: 300},
+	}, pnl.Overheads)
+	assert.Equal(t, 2300.0, pnl.TotalOverheads)
+	assert.Equal(t, 6700.0, pnl.OperatingProfit)
+}
+
+func TestClosingEntryAndTrialBalance(t *testing.T) {
+
+	// Given:
+	l := Ledger{Accounts: DefaultChart, Entries: []db.JournalEntry{
+		PostTransaction(db.Transaction{Date: dateOf("01-05-2024"), Type: db.Credit, Credit: 10000, Account: Sales}),
+		PostTransaction(db.Transaction{Date: dateOf("02-05-2024"), Type: db.Debit, Debit: 1500, Account: Travel}),
+		PostTransaction(db.Transaction{Date: dateOf("03-05-2024"), Type: db.Debit, Debit: 5000, Account: Dividends}),
+		PostTransaction(db.Transaction{Date: dateOf("01-05-2025"), Type: db.Credit, Credit: 2000, Account: Sales}),
+	}}
+	expectedTrialBalance := []TrialBalanceLine{
+		{Code: BankAccount, Name: "Bank current account", Debit: 5500},
+		{Code: RetainedEarnings, Name: "Retained earnings", Credit: 3500},
+		{Code: Sales, Name: "Sales", Credit: 2000},
+	}
+
+	// When:
+	closing := l.ClosingEntry(dateOf("01-04-2024"), dateOf("01-04-2025"))
+
+	// Then:
+	assert.Nil(t, ValidateEntry(closing, DefaultChart))
+	assert.Equal(t, dateOf("31-03-2025"), closing.Date)
+	assert.Equal(t, []db.JournalLine{
+		{Account: Dividends, Credit: 5000},
+		{Account: Sales, Debit: 10000},
+		{Account: Travel, Credit: 1500},
+		{Account: RetainedEarnings, Credit: 3500},
+	}, closing.Lines)
+
+	// and Then: the previous period is brought forward to retained earnings whether it is closed or not
+	assert.Equal(t, expectedTrialBalance, l.TrialBalance(dateOf("01-04-2025"), dateOf("01-06-2025")))
+
+	l.Entries = append(l.Entries, closing)
+	assert.Equal(t, expectedTrialBalance, l.TrialBalance(dateOf("01-04-2025"), dateOf("01-06-2025")))
+	assert.Equal(t, 8500.0, l.WithoutClosingEntries().ProfitAndLoss(dateOf("01-04-2024"), dateOf("01-04-2025")).OperatingProfit)
+}
+
+func TestClosePeriod(t *testing.T) {
+
+	// create real DB
+	const dbFile = "/tmp/tax-bookkeeper-ledger-close.db"
+	d := db.Init(dbFile)
+	defer func() {
+		d.Close()
+		os.Remove(dbFile)
+	}()
+
+	// Populate with data:
+	_, err := d.ImportTransactions([]db.Transaction{
+		{Date: dateOf("10-05-2024"), Type: db.Credit, Description: "ACME", Credit: 10000, Category: db.Income},
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, InitChartOfAccounts(d))
+
+	// When:
+	closing, err := ClosePeriod(d, nil, dateOf("01-04-2024"), dateOf("01-05-2025"), 1900)
+
+	// Then: the tax is charged before the profit is closed to the retained earnings
+	assert.Nil(t, err)
+	assert.Equal(t, []db.JournalLine{
+		{Account: Sales, Debit: 10000},
+		{Account: CorporationTaxCharge, Credit: 1900},
+		{Account: RetainedEarnings, Credit: 8100},
+	}, closing.Lines)
+	l, err := Build(d, nil, dateOf("01-05-2025"))
+	assert.Nil(t, err)
+	assert.Equal(t, -1900.0, l.Balance(time.Time{}, dateOf("01-05-2025"), TaxLiabilities))
+
+	// and Then: reopening deletes both, they are posted again on the next close
+	assert.Nil(t, ReopenPeriod(d, dateOf("01-04-2024")))
+	journals, err := d.GetJournalEntries()
+	assert.Nil(t, err)
+	assert.Empty(t, journals)
+}
+
+func dateOf(date string) time.Time {
+	parts := strings.Split(date, "-")
+	year, _ := strconv.Atoi(parts[2])
+	month, _ := strconv.Atoi(parts[1])
+	day, _ := strconv.Atoi(parts[0])
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, conf.GMT)
+}
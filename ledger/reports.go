@@ -0,0 +1,65 @@
+package ledger
+
+import (
+	"sort"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/db"
+)
+
+type (
+	// ReportLine is the balance of one account in a report
+	ReportLine struct {
+		Code   string  `json:"code"`
+		Name   string  `json:"name"`
+		Group  string  `json:"group,omitempty"` // name of the parent account
+		Amount float64 `json:"amount"`
+	}
+
+	// ProfitAndLoss is the profit and loss statement of the date range before tax, expenses are positive
+	ProfitAndLoss struct {
+		From            time.Time    `json:"from"`
+		Until           time.Time    `json:"until"` // exclusive
+		Turnover        float64      `json:"turnover"`
+		CostOfSales     float64      `json:"costOfSales"`
+		GrossProfit     float64      `json:"grossProfit"`
+		Overheads       []ReportLine `json:"overheads"` // ordered by code
+		TotalOverheads  float64      `json:"totalOverheads"`
+		OperatingProfit float64      `json:"operatingProfit"`
+	}
+)
+
+// ProfitAndLoss returns the profit and loss statement since "from" (inclusive) until "until" (exclusive).
+// Cost of sales is the account 5000 with its sub-accounts, every other expense account but the corporation tax
+// is an overhead
+func (l Ledger) ProfitAndLoss(from, until time.Time) ProfitAndLoss {
+	pnl := ProfitAndLoss{From: from, Until: until}
+
+	for code, balance := range l.Balances(from, until) {
+		a, ok := l.Account(code)
+		if !ok || balance == 0 || a.Code == CorporationTaxCharge {
+			continue
+		}
+
+		switch {
+		case a.Type == db.IncomeAccount:
+			pnl.Turnover = round(pnl.Turnover - balance)
+		case a.Type == db.ExpenseAccount && (a.Code == CostOfSales || a.Parent == CostOfSales):
+			pnl.CostOfSales = round(pnl.CostOfSales + balance)
+		case a.Type == db.ExpenseAccount:
+			line := ReportLine{Code: a.Code, Name: a.Name, Amount: balance}
+			if parent, ok := l.Account(a.Parent); ok {
+				line.Group = parent.Name
+			}
+			pnl.Overheads = append(pnl.Overheads, line)
+			pnl.TotalOverheads = round(pnl.TotalOverheads + balance)
+		}
+	}
+	sort.Slice(pnl.Overheads, func(i, j int) bool {
+		return pnl.Overheads[i].Code < pnl.Overheads[j].Code
+	})
+
+	pnl.GrossProfit = round(pnl.Turnover - pnl.CostOfSales)
+	pnl.OperatingProfit = round(pnl.GrossProfit - pnl.TotalOverheads)
+	return pnl
+}
@@ -0,0 +1,137 @@
+package ledger
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/tax"
+)
+
+// PostInvoice posts VAT of the invoice on its issue date, which is the tax point of the VAT invoice accounting.
+// The customer owes VAT of a sales invoice and the supplier is owed VAT of a bill until the invoice is paid,
+// the bank transaction of the payment posts the net amount
+func PostInvoice(inv db.Invoice) db.JournalEntry {
+	entry := db.JournalEntry{
+		Date:        inv.IssueDate,
+		Description: "VAT of " + inv.Number + ", " + inv.Counterparty,
+		Source:      db.InvoicePosting,
+		Reference:   inv.Pk,
+	}
+
+	if inv.Type == db.SalesInvoice {
+		entry.Lines = []db.JournalLine{debitOrCredit(Debtors, inv.VAT), debitOrCredit(VATControl, -inv.VAT)}
+	} else {
+		entry.Lines = []db.JournalLine{debitOrCredit(VATControl, inv.VAT), debitOrCredit(Accruals, -inv.VAT)}
+	}
+	return entry
+}
+
+// PostPayroll posts income tax and NI of every payroll month owed to HMRC on the latest pay date of the month.
+// The bank transactions of the salaries are the net pay, so the deductions make them gross. The Employment
+// Allowance is taken off the employer's NI
+func PostPayroll(employees []db.Employee, payslips []db.Payslip, payments []db.PAYEPayment) []db.JournalEntry {
+	directors := map[int]bool{}
+	for _, e := range employees {
+		directors[e.Pk] = e.IsDirector
+	}
+
+	var entries []db.JournalEntry
+	for _, p := range payments {
+		var date time.Time
+		var directorsDeductions, wagesDeductions, employerNI float64
+		for _, s := range payslips {
+			if s.TaxYear != p.TaxYear || s.TaxMonth != p.TaxMonth {
+				continue
+			}
+			if s.PayDate.After(date) {
+				date = s.PayDate
+			}
+			if directors[s.EmployeePk] {
+				directorsDeductions = directorsDeductions + s.IncomeTax + s.EmployeeNI
+			} else {
+				wagesDeductions = wagesDeductions + s.IncomeTax + s.EmployeeNI
+			}
+			employerNI = employerNI + s.EmployerNI
+		}
+		if date.IsZero() {
+			date = p.DueDate
+		}
+
+		entry := db.JournalEntry{
+			Date:        date,
+			Description: "PAYE " + p.TaxYear + ", month " + strconv.Itoa(p.TaxMonth),
+			Source:      db.PayrollPosting,
+			Reference:   p.Pk,
+		}
+		for _, line := range []db.JournalLine{
+			debitOrCredit(DirectorsSalaries, directorsDeductions),
+			debitOrCredit(Wages, wagesDeductions),
+			debitOrCredit(EmployersNI, employerNI-p.EmploymentAllowanceUsed),
+			debitOrCredit(TaxLiabilities, -p.Total),
+		} {
+			if line.Debit != 0 || line.Credit != 0 {
+				entry.Lines = append(entry.Lines, line)
+			}
+		}
+		if len(entry.Lines) > 0 {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// PostCorporationTax posts the corporation tax charge of the accounting period starting on the date, it is owed
+// to HMRC until it is paid. A negative amount is a repayment, like the loss carried back
+func PostCorporationTax(periodStart time.Time, amount float64) db.JournalEntry {
+	end := periodStart.AddDate(1, 0, -1)
+	return db.JournalEntry{
+		Date:        end,
+		Description: "Corporation tax " + tax.GetFinYear(periodStart),
+		Source:      db.TaxAccrual,
+		Lines:       []db.JournalLine{debitOrCredit(CorporationTaxCharge, amount), debitOrCredit(TaxLiabilities, -amount)},
+	}
+}
+
+// VAT of every VAT period which ended before the date moves from the VAT control account to the amount owed
+// to HMRC on the last day of the period, so that the payments to HMRC clear it
+func (l Ledger) postVATReturns(scheme tax.VATScheme, until time.Time) []db.JournalEntry {
+	var periods []tax.VATPeriod
+	owed := map[time.Time]float64{} // by the end of the period
+	for _, e := range l.Entries {
+		for _, line := range e.Lines {
+			if line.Account != VATControl {
+				continue
+			}
+			period := scheme.GetVATPeriod(e.Date)
+			if !period.End.Before(until) {
+				continue
+			}
+			if _, ok := owed[period.End]; !ok {
+				periods = append(periods, period)
+			}
+			owed[period.End] = owed[period.End] + line.Credit - line.Debit
+		}
+	}
+
+	var entries []db.JournalEntry
+	for _, period := range periods {
+		if amount := round(owed[period.End]); amount != 0 {
+			entries = append(entries, db.JournalEntry{
+				Date:        period.End,
+				Description: "VAT for " + period.EndingMonth.String() + " " + strconv.Itoa(period.End.Year()),
+				Source:      db.VATReturnPosting,
+				Lines:       []db.JournalLine{debitOrCredit(VATControl, amount), debitOrCredit(TaxLiabilities, -amount)},
+			})
+		}
+	}
+	return entries
+}
+
+// a positive amount is a debit, a negative one is a credit
+func debitOrCredit(account string, amount float64) db.JournalLine {
+	if amount < 0 {
+		return db.JournalLine{Account: account, Credit: round(-amount)}
+	}
+	return db.JournalLine{Account: account, Debit: round(amount)}
+}
@@ -0,0 +1,166 @@
+package ledger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/tax"
+)
+
+func TestPostTransactionWithVAT(t *testing.T) {
+	paid := map[int]db.Invoice{
+		1: {Type: db.SalesInvoice, Number: "INV-1", Net: 1000, VAT: 200, TransactionPk: 1},
+		2: {Type: db.SupplierBill, Number: "B-1", Net: 500, VAT: 100, TransactionPk: 2},
+	}
+	sale := db.Transaction{Pk: 1, Type: db.Credit, Credit: 1200, Account: Sales}
+	bill := db.Transaction{Pk: 2, Type: db.Debit, Debit: 600, Account: LegalAndProfessional}
+	stationery := db.Transaction{Pk: 3, Type: db.Debit, Debit: 120, Account: Office}
+	receipt := db.Transaction{Pk: 4, Type: db.Credit, Credit: 600, Account: Sales}
+	cash := &tax.VATScheme{Basis: tax.CashAccounting}
+	invoice := &tax.VATScheme{Basis: tax.InvoiceAccounting}
+
+	var tests = []struct {
+		name        string
+		transaction db.Transaction
+		scheme      *tax.VATScheme
+		expected    []db.JournalLine
+	}{
+		{"not registered", sale, nil,
+			[]db.JournalLine{{Account: BankAccount, Debit: 1200}, {Account: Sales, Credit: 1200}}},
+
+		{"cash accounting, paid invoice", sale, cash,
+			[]db.JournalLine{{Account: BankAccount, Debit: 1200}, {Account: Sales, Credit: 1000}, {Account: VATControl, Credit: 200}}},
+
+		{"cash accounting, paid bill", bill, cash,
+			[]db.JournalLine{{Account: LegalAndProfessional, Debit: 500}, {Account: VATControl, Debit: 100}, {Account: BankAccount, Credit: 600}}},
+
+		{"cash accounting, purchase without a bill at the default rate of the account", stationery, cash,
+			[]db.JournalLine{{Account: Office, Debit: 100}, {Account: VATControl, Debit: 20}, {Account: BankAccount, Credit: 120}}},
+
+		{"cash accounting, receipt without an invoice at the default rate of the account", receipt, cash,
+			[]db.JournalLine{{Account: BankAccount, Debit: 600}, {Account: Sales, Credit: 500}, {Account: VATControl, Credit: 100}}},
+
+		{"invoice accounting, the customer pays what it owes", sale, invoice,
+			[]db.JournalLine{{Account: BankAccount, Debit: 1200}, {Account: Sales, Credit: 1000}, {Account: Debtors, Credit: 200}}},
+
+		{"invoice accounting, the supplier is paid", bill, invoice,
+			[]db.JournalLine{{Account: LegalAndProfessional, Debit: 500}, {Account: Accruals, Debit: 100}, {Account: BankAccount, Credit: 600}}},
+
+		{"invoice accounting, purchase without a bill", stationery, invoice,
+			[]db.JournalLine{{Account: Office, Debit: 120}, {Account: BankAccount, Credit: 120}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// When:
+			entry := postTransactionWithVAT(tt.transaction, tt.scheme, DefaultChart, paid)
+
+			// Then:
+			assert.Equal(t, tt.expected, entry.Lines)
+			assert.Nil(t, ValidateEntry(entry, DefaultChart))
+		})
+	}
+}
+
+func TestPostInvoice(t *testing.T) {
+
+	// When:
+	sale := PostInvoice(db.Invoice{Pk: 1, Type: db.SalesInvoice, Number: "INV-1", Counterparty: "ACME",
+		IssueDate: dateOf("20-12-2020"), Net: 1000, VAT: 200})
+	bill := PostInvoice(db.Invoice{Pk: 2, Type: db.SupplierBill, Number: "B-1", Counterparty: "Accountant",
+		IssueDate: dateOf("05-01-2021"), Net: 500, VAT: 100})
+
+	// Then: VAT is posted on the issue date, the net amount waits for the payment
+	assert.Equal(t, dateOf("20-12-2020"), sale.Date)
+	assert.Equal(t, []db.JournalLine{{Account: Debtors, Debit: 200}, {Account: VATControl, Credit: 200}}, sale.Lines)
+	assert.Equal(t, []db.JournalLine{{Account: VATControl, Debit: 100}, {Account: Accruals, Credit: 100}}, bill.Lines)
+	assert.Nil(t, ValidateEntry(sale, DefaultChart))
+	assert.Nil(t, ValidateEntry(bill, DefaultChart))
+}
+
+func TestPostVATReturns(t *testing.T) {
+
+	// Given: the quarters end in March, June, September and December
+	scheme := tax.VATScheme{Period: tax.QuarterlyReturns, PeriodEndMonth: time.March, Basis: tax.CashAccounting}
+	l := Ledger{Accounts: DefaultChart, Entries: []db.JournalEntry{
+		postTransaction(db.Transaction{Date: dateOf("10-02-2021"), Type: db.Credit, Credit: 1200, Account: Sales}, 200, VATControl),
+		postTransaction(db.Transaction{Date: dateOf("15-03-2021"), Type: db.Debit, Debit: 120, Account: Office}, 20, VATControl),
+		postTransaction(db.Transaction{Date: dateOf("10-04-2021"), Type: db.Credit, Credit: 600, Account: Sales}, 100, VATControl),
+	}}
+
+	// When:
+	returns := l.postVATReturns(scheme, dateOf("20-05-2021"))
+
+	// Then: only the first quarter is over
+	assert.Len(t, returns, 1)
+	assert.Equal(t, dateOf("31-03-2021"), returns[0].Date)
+	assert.Equal(t, db.VATReturnPosting, returns[0].Source)
+	assert.Equal(t, []db.JournalLine{{Account: VATControl, Debit: 180}, {Account: TaxLiabilities, Credit: 180}}, returns[0].Lines)
+	assert.Nil(t, ValidateEntry(returns[0], DefaultChart))
+
+	// and Then: the VAT control account is left with the current quarter
+	l.Entries = append(l.Entries, returns...)
+	assert.Equal(t, -100.0, l.Balance(time.Time{}, dateOf("20-05-2021"), VATControl))
+}
+
+func TestPostPayroll(t *testing.T) {
+
+	// Given:
+	employees := []db.Employee{{Pk: 1, Name: "Director", IsDirector: true}, {Pk: 2, Name: "Assistant"}}
+	payslips := []db.Payslip{
+		{EmployeePk: 1, TaxYear: "2025-2026", TaxMonth: 1, PayDate: dateOf("25-04-2025"), IncomeTax: 300, EmployeeNI: 150, EmployerNI: 200},
+		{EmployeePk: 2, TaxYear: "2025-2026", TaxMonth: 1, PayDate: dateOf("28-04-2025"), IncomeTax: 100, EmployeeNI: 50, EmployerNI: 80},
+		{EmployeePk: 1, TaxYear: "2025-2026", TaxMonth: 2, PayDate: dateOf("25-05-2025"), IncomeTax: 300, EmployeeNI: 150, EmployerNI: 200},
+	}
+	payments := []db.PAYEPayment{
+		{Pk: 7, TaxYear: "2025-2026", TaxMonth: 1, IncomeTax: 400, EmployeeNI: 200, EmployerNI: 280,
+			EmploymentAllowanceUsed: 280, Total: 600, DueDate: dateOf("22-05-2025")},
+	}
+
+	// When:
+	entries := PostPayroll(employees, payslips, payments)
+
+	// Then: the Employment Allowance covers the employer's NI, the month without a payment is not posted
+	assert.Len(t, entries, 1)
+	assert.Equal(t, dateOf("28-04-2025"), entries[0].Date)
+	assert.Equal(t, db.PayrollPosting, entries[0].Source)
+	assert.Equal(t, 7, entries[0].Reference)
+	assert.Equal(t, []db.JournalLine{
+		{Account: DirectorsSalaries, Debit: 450},
+		{Account: Wages, Debit: 150},
+		{Account: TaxLiabilities, Credit: 600},
+	}, entries[0].Lines)
+	assert.Nil(t, ValidateEntry(entries[0], DefaultChart))
+}
+
+func TestPostCorporationTax(t *testing.T) {
+	var tests = []struct {
+		name     string
+		amount   float64
+		expected []db.JournalLine
+	}{
+		{"charge", 4750, []db.JournalLine{{Account: CorporationTaxCharge, Debit: 4750}, {Account: TaxLiabilities, Credit: 4750}}},
+		{"repayment of the loss carried back", -1900, []db.JournalLine{{Account: CorporationTaxCharge, Credit: 1900},
+			{Account: TaxLiabilities, Debit: 1900}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// When:
+			entry := PostCorporationTax(dateOf("01-04-2025"), tt.amount)
+
+			// Then:
+			assert.Equal(t, dateOf("31-03-2026"), entry.Date)
+			assert.Equal(t, tt.expected, entry.Lines)
+			assert.Nil(t, ValidateEntry(entry, DefaultChart))
+
+			// the profit and loss is before tax
+			l := Ledger{Accounts: DefaultChart, Entries: []db.JournalEntry{entry}}
+			assert.Equal(t, 0.0, l.ProfitAndLoss(dateOf("01-04-2025"), dateOf("01-04-2026")).OperatingProfit)
+		})
+	}
+}
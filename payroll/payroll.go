@@ -0,0 +1,158 @@
+package payroll
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/conf"
+	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/tax"
+)
+
+// RunMonth calculates payslips of all the employees for the tax month (1 is 6 April - 5 May)
+// and saves them together with the amount due to HMRC
+func RunMonth(d *db.Database, taxYear string, taxMonth int, payDate time.Time, employmentAllowance bool) ([]db.Payslip, db.PAYEPayment, error) {
+	employees, err := d.GetEmployees()
+	if err != nil {
+		return nil, db.PAYEPayment{}, err
+	}
+
+	previousPayslips, err := d.GetPayslips(taxYear)
+	if err != nil {
+		return nil, db.PAYEPayment{}, err
+	}
+
+	previousPayments, err := d.GetPAYEPayments(taxYear)
+	if err != nil {
+		return nil, db.PAYEPayment{}, err
+	}
+
+	payslips, payment, err := CalculateMonth(employees, previousPayslips, previousPayments, taxYear, taxMonth, payDate, employmentAllowance)
+	if err != nil {
+		return nil, db.PAYEPayment{}, err
+	}
+
+	return payslips, payment, d.SavePayroll(payslips, &payment)
+}
+
+// CalculateMonth calculates payslips for the tax month using the payslips of previous months of the tax year,
+// which are needed for the cumulative PAYE and directors' NI. The Employment Allowance (if the company
+// can claim it) is used against the employer's NI until it is exhausted
+func CalculateMonth(employees []db.Employee, previousPayslips []db.Payslip, previousPayments []db.PAYEPayment,
+	taxYear string, taxMonth int, payDate time.Time, employmentAllowance bool) ([]db.Payslip, db.PAYEPayment, error) {
+
+	if taxMonth < 1 || taxMonth > 12 {
+		return nil, db.PAYEPayment{}, errors.New("tax month must be between 1 and 12")
+	}
+
+	var allowanceUsed float64
+	for _, p := range previousPayments {
+		if p.TaxMonth == taxMonth {
+			return nil, db.PAYEPayment{}, errors.New("payroll for the tax month " + strconv.Itoa(taxMonth) + " was already run")
+		}
+		allowanceUsed = allowanceUsed + p.EmploymentAllowanceUsed
+	}
+
+	payment := db.PAYEPayment{
+		TaxYear:  taxYear,
+		TaxMonth: taxMonth,
+	}
+
+	payslips := make([]db.Payslip, 0, len(employees))
+	for _, e := range employees {
+		payslip, err := calculatePayslip(e, previousPayslips, taxYear, taxMonth, payDate)
+		if err != nil {
+			return nil, db.PAYEPayment{}, err
+		}
+		payslips = append(payslips, payslip)
+
+		payment.IncomeTax = payment.IncomeTax + payslip.IncomeTax
+		payment.EmployeeNI = payment.EmployeeNI + payslip.EmployeeNI
+		payment.EmployerNI = payment.EmployerNI + payslip.EmployerNI
+	}
+
+	if employmentAllowance {
+		payment.EmploymentAllowanceUsed = tax.EmploymentAllowanceLeft(taxYear, allowanceUsed, payment.EmployerNI)
+	}
+
+	payment.Total = round(payment.IncomeTax + payment.EmployeeNI + payment.EmployerNI - payment.EmploymentAllowanceUsed)
+	dueDate, err := GetPAYEDueDate(taxYear, taxMonth)
+	if err != nil {
+		return nil, db.PAYEPayment{}, err
+	}
+	payment.DueDate = dueDate
+
+	return payslips, payment, nil
+}
+
+func calculatePayslip(e db.Employee, previousPayslips []db.Payslip, taxYear string, taxMonth int, payDate time.Time) (db.Payslip, error) {
+	code, err := tax.ParseTaxCode(e.TaxCode)
+	if err != nil {
+		return db.Payslip{}, errors.New(e.Name + ": " + err.Error())
+	}
+
+	// the totals of this employee since the beginning of the tax year. The pension is deducted from the pay
+	// for the tax, but not for NI, so that they are accumulated separately
+	var payToDate, niablePayToDate, taxToDate, employeeNIToDate, employerNIToDate float64
+	for _, p := range previousPayslips {
+		if p.EmployeePk == e.Pk && p.TaxMonth < taxMonth {
+			payToDate = payToDate + p.TaxablePay
+			niablePayToDate = niablePayToDate + p.Gross
+			taxToDate = taxToDate + p.IncomeTax
+			employeeNIToDate = employeeNIToDate + p.EmployeeNI
+			employerNIToDate = employerNIToDate + p.EmployerNI
+		}
+	}
+
+	gross := e.MonthlySalary
+	employeePension := round(gross * e.PensionRate)
+	employerPension := round(gross * e.EmployerPensionRate)
+
+	// under the net pay arrangement the pension is deducted before the tax, but not before NI
+	taxablePay := gross - employeePension
+	incomeTax := tax.CalculatePAYE(code, taxYear, taxMonth, taxablePay, payToDate, taxToDate)
+	employeeNI, employerNI := tax.CalculateMonthlyNI(tax.NICategory(strings.ToUpper(e.NICategory)), e.IsDirector, taxYear,
+		gross, niablePayToDate, employeeNIToDate, employerNIToDate)
+
+	return db.Payslip{
+		EmployeePk:      e.Pk,
+		TaxYear:         taxYear,
+		TaxMonth:        taxMonth,
+		PayDate:         payDate,
+		Gross:           gross,
+		EmployeePension: employeePension,
+		EmployerPension: employerPension,
+		TaxablePay:      taxablePay,
+		IncomeTax:       incomeTax,
+		EmployeeNI:      employeeNI,
+		EmployerNI:      employerNI,
+		NetPay:          round(taxablePay - incomeTax - employeeNI),
+	}, nil
+}
+
+// GetPAYEDueDate returns when PAYE for the tax month should be paid to HMRC. The tax month ends on the 5th
+// and the electronic payment is due by the 22nd of the same calendar month
+// https://www.gov.uk/pay-paye-tax
+func GetPAYEDueDate(taxYear string, taxMonth int) (time.Time, error) {
+	startYear, err := strconv.Atoi(strings.Split(taxYear, "-")[0])
+	if err != nil {
+		return time.Time{}, errors.New("the tax year should be like 2025-2026")
+	}
+	return time.Date(startYear, time.April+time.Month(taxMonth), 22, 0, 0, 0, 0, conf.GMT), nil
+}
+
+// GetTaxMonth returns the tax month for the date, 1 is 6 April - 5 May
+func GetTaxMonth(date time.Time) int {
+	month := int(date.Month()) - int(time.April)
+	if date.Day() < 6 {
+		month = month - 1
+	}
+	return (month+12)%12 + 1
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
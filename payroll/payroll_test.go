@@ -0,0 +1,116 @@
+package payroll
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/w32blaster/tax-bookkeeper/conf"
+	"github.com/w32blaster/tax-bookkeeper/db"
+)
+
+var director = db.Employee{Pk: 1, Name: "Director", TaxCode: "1257L", NICategory: "A", IsDirector: true, MonthlySalary: 1047.50}
+var employee = db.Employee{Pk: 2, Name: "Employee", TaxCode: "1257L", NICategory: "A", MonthlySalary: 3000, PensionRate: 0.05, EmployerPensionRate: 0.03}
+
+func TestCalculateMonth(t *testing.T) {
+
+	// When:
+	payslips, payment, err := CalculateMonth([]db.Employee{director, employee}, nil, nil, "2025-2026", 1, dateOf("30-04-2025"), false)
+
+	// Then:
+	assert.Nil(t, err)
+	assert.Len(t, payslips, 2)
+
+	// director's salary is below the personal allowance and the primary threshold,
+	// and employer's NI is calculated on the annual basis: 1047.50 is below the yearly secondary threshold
+	assert.Equal(t, 0.0, payslips[0].IncomeTax)
+	assert.Equal(t, 0.0, payslips[0].EmployeeNI)
+	assert.Equal(t, 0.0, payslips[0].EmployerNI)
+	assert.Equal(t, 1047.50, payslips[0].NetPay)
+
+	// employee pays 5% pension before tax: 3000 - 150 = 2850 taxable, tax (2850 - 1048.25) x 20% = 360.20,
+	// NI on the whole 3000: (3000 - 1048) x 8% = 156.16, employer (3000 - 417) x 15% = 387.45
+	assert.Equal(t, 150.0, payslips[1].EmployeePension)
+	assert.Equal(t, 90.0, payslips[1].EmployerPension)
+	assert.Equal(t, 360.20, payslips[1].IncomeTax)
+	assert.Equal(t, 156.16, payslips[1].EmployeeNI)
+	assert.Equal(t, 387.45, payslips[1].EmployerNI)
+	assert.Equal(t, 2333.64, payslips[1].NetPay)
+
+	assert.Equal(t, 360.20+156.16+387.45, payment.Total)
+	assert.Equal(t, dateOf("22-05-2025"), payment.DueDate)
+}
+
+func TestCalculateMonthWithEmploymentAllowance(t *testing.T) {
+
+	// Given: almost all the allowance was used in previous months
+	previous := []db.PAYEPayment{{TaxYear: "2025-2026", TaxMonth: 1, EmploymentAllowanceUsed: 10300}}
+
+	// When:
+	_, payment, err := CalculateMonth([]db.Employee{employee}, nil, previous, "2025-2026", 2, dateOf("31-05-2025"), true)
+
+	// Then: there were no payslips in the first month, so the tax is (2850 - 2096.50) x 20%
+	assert.Nil(t, err)
+	assert.Equal(t, 200.0, payment.EmploymentAllowanceUsed)
+	assert.Equal(t, 150.60+156.16+387.45-200, payment.Total)
+}
+
+func TestCalculateMonthCumulative(t *testing.T) {
+
+	// Given: nothing was paid in the first month, so the second month gets two months of tax-free pay
+	previous := []db.Payslip{{EmployeePk: 2, TaxYear: "2025-2026", TaxMonth: 1}}
+
+	// When:
+	payslips, _, err := CalculateMonth([]db.Employee{employee}, previous, nil, "2025-2026", 2, dateOf("31-05-2025"), false)
+
+	// Then: (2850 - 2096.50) x 20%
+	assert.Nil(t, err)
+	assert.Equal(t, 150.60, payslips[0].IncomeTax)
+}
+
+func TestCalculateMonthDirectorWithPension(t *testing.T) {
+
+	// Given: director's pension is deducted for the tax, but the annual NI is calculated on the gross pay
+	pensioner := db.Employee{Pk: 3, Name: "Director", TaxCode: "1257L", NICategory: "A", IsDirector: true,
+		MonthlySalary: 6000, PensionRate: 0.1}
+	previous := []db.Payslip{{EmployeePk: 3, TaxYear: "2025-2026", TaxMonth: 1, Gross: 6000, TaxablePay: 5400, EmployerNI: 150}}
+
+	// When:
+	payslips, _, err := CalculateMonth([]db.Employee{pensioner}, previous, nil, "2025-2026", 2, dateOf("31-05-2025"), false)
+
+	// Then: 12000 is below the yearly primary threshold, employer's (12000 - 5000) x 15% - 150 paid in the first month
+	assert.Nil(t, err)
+	assert.Equal(t, 0.0, payslips[0].EmployeeNI)
+	assert.Equal(t, 900.0, payslips[0].EmployerNI)
+}
+
+func TestCalculateMonthTwice(t *testing.T) {
+
+	// Given:
+	previous := []db.PAYEPayment{{TaxYear: "2025-2026", TaxMonth: 1}}
+
+	// When:
+	_, _, err := CalculateMonth([]db.Employee{employee}, nil, previous, "2025-2026", 1, dateOf("30-04-2025"), false)
+
+	// Then:
+	assert.NotNil(t, err)
+}
+
+func TestGetTaxMonth(t *testing.T) {
+	assert.Equal(t, 1, GetTaxMonth(dateOf("06-04-2025")))
+	assert.Equal(t, 1, GetTaxMonth(dateOf("05-05-2025")))
+	assert.Equal(t, 2, GetTaxMonth(dateOf("06-05-2025")))
+	assert.Equal(t, 10, GetTaxMonth(dateOf("31-01-2026")))
+	assert.Equal(t, 12, GetTaxMonth(dateOf("05-04-2026")))
+}
+
+// shorthand for the date creation, like "01-03-2021"
+func dateOf(date string) time.Time {
+	parts := strings.Split(date, "-")
+	year, _ := strconv.Atoi(parts[2])
+	month, _ := strconv.Atoi(parts[1])
+	day, _ := strconv.Atoi(parts[0])
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, conf.GMT)
+}
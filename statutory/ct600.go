@@ -0,0 +1,306 @@
+package statutory
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+
+	"github.com/w32blaster/tax-bookkeeper/tax"
+)
+
+const ct600Namespace = "http://www.govtalk.gov.uk/taxation/CT/5"
+
+var utrPattern = regexp.MustCompile("^[0-9]{10}$")
+
+type (
+	// CT600Return is the company tax return with the details of the company and the director who signs it
+	CT600Return struct {
+		CompanyName   string
+		CompanyNumber string
+		UTR           string // the Unique Taxpayer Reference of the company, 10 digits
+		Director      string
+		tax.CT600
+	}
+
+	// Box is one filled in box of the return as it is printed
+	Box struct {
+		Number string
+		Label  string
+		Value  string
+	}
+
+	// money is written with pennies, like "1234.50"
+	money float64
+
+	ct600FinancialYear struct {
+		Year    int   `xml:"Year"`
+		Profit  money `xml:"Details>Profit"`
+		TaxRate money `xml:"Details>TaxRate"` // in percent
+		Tax     money `xml:"Details>Tax"`
+	}
+
+	// the body of the return, the GovTalk envelope and the IRmark are added by the filing software
+	ct600XML struct {
+		XMLName            xml.Name `xml:"CompanyTaxReturn"`
+		Namespace          string   `xml:"xmlns,attr"`
+		ReturnType         string   `xml:"ReturnType,attr"`
+		CompanyInformation struct {
+			CompanyName        string `xml:"CompanyName"`
+			RegistrationNumber string `xml:"RegistrationNumber"`
+			Reference          string `xml:"Reference"`
+			From               string `xml:"PeriodCovered>From"`
+			To                 string `xml:"PeriodCovered>To"`
+		} `xml:"CompanyInformation"`
+		ReturnInfoSummary struct {
+			Accounts      string              `xml:"Accounts>ThisPeriodAccounts"`
+			Computations  string              `xml:"Computations>ThisPeriodComputations"`
+			Supplementary *ct600Supplementary `xml:"SupplementaryPages,omitempty"`
+		} `xml:"ReturnInfoSummary"`
+		Turnover              money `xml:"Turnover>Total"`
+		CompanyTaxCalculation struct {
+			TradingProfits           money               `xml:"Income>Trading>Profits"`
+			LossesBroughtForward     money               `xml:"Income>Trading>LossesBroughtForward,omitempty"`
+			NetTradingProfits        money               `xml:"Income>Trading>NetProfits"`
+			ProfitsBeforeDeductions  money               `xml:"ProfitsBeforeOtherDeductions"`
+			ChargesAndReliefs        *ct600Reliefs       `xml:"ChargesAndReliefs,omitempty"`
+			ChargeableProfits        money               `xml:"ChargeableProfits"`
+			SmallProfitsRate         string              `xml:"CorporationTaxChargeable>SmallProfitsRateOrMarginalRelief,omitempty"`
+			FinancialYearOne         *ct600FinancialYear `xml:"CorporationTaxChargeable>FinancialYearOne,omitempty"`
+			FinancialYearTwo         *ct600FinancialYear `xml:"CorporationTaxChargeable>FinancialYearTwo,omitempty"`
+			CorporationTax           money               `xml:"CorporationTax"`
+			MarginalRelief           money               `xml:"MarginalRelief,omitempty"`
+			CorporationTaxChargeable money               `xml:"NetCorporationTaxChargeable"`
+			NetLiability             money               `xml:"NetCorporationTaxLiability"`
+			S455Tax                  money               `xml:"LoansToParticipators,omitempty"`
+			TaxChargeable            money               `xml:"TaxChargeable"`
+			TaxPayable               money               `xml:"TaxPayable"`
+		} `xml:"CompanyTaxCalculation"`
+		AllowancesAndCharges    *ct600Allowances `xml:"AllowancesAndCharges,omitempty"`
+		LossesDeficitsAndExcess *ct600Losses     `xml:"LossesDeficitsAndExcess,omitempty"`
+		LoansToParticipators    *ct600AXML       `xml:"LoansToParticipators,omitempty"`
+		Declaration             struct {
+			AcceptDeclaration string `xml:"AcceptDeclaration"`
+			Name              string `xml:"Name"`
+			Status            string `xml:"Status"`
+		} `xml:"Declaration"`
+	}
+
+	ct600Supplementary struct {
+		CT600A string `xml:"CT600A"`
+	}
+
+	ct600Reliefs struct {
+		TradingLosses money `xml:"TradingLosses"`
+	}
+
+	ct600Allowances struct {
+		AnnualInvestmentAllowance money      `xml:"AIACapitalAllowancesInc,omitempty"`
+		SpecialRatePool           *ct600Pool `xml:"MachineryAndPlantSpecialRatePool,omitempty"`
+		MainPool                  *ct600Pool `xml:"MachineryAndPlantMainPool,omitempty"`
+	}
+
+	ct600Pool struct {
+		CapitalAllowances money `xml:"CapitalAllowances,omitempty"`
+		BalancingCharges  money `xml:"BalancingCharges,omitempty"`
+	}
+
+	ct600Losses struct {
+		TradingLosses money `xml:"AmountArising>TradingLosses"`
+	}
+
+	// the supplementary page CT600A of loans to the director
+	ct600AXML struct {
+		LoansInPeriod    money             `xml:"LoansInPeriod"`
+		OutstandingAtEnd money             `xml:"OutstandingAtEnd"`
+		Relief           *ct600ARepayments `xml:"ReliefEarlierThan,omitempty"`
+		Chargeable       money             `xml:"ChargeableAmount"`
+		TaxChargeable    money             `xml:"TaxChargeable"`
+	}
+
+	// repayments within nine months after the period end which reduce the charge
+	ct600ARepayments struct {
+		AmountRepaid money  `xml:"AmountRepaid"`
+		RepaidBy     string `xml:"RepaidBy"`
+	}
+)
+
+// MarshalText writes the amount with pennies
+func (m money) MarshalText() ([]byte, error) {
+	return []byte(strconv.FormatFloat(float64(m), 'f', 2, 64)), nil
+}
+
+// Check returns an error if the return can't be filed
+func (r CT600Return) Check() error {
+	if r.CompanyName == "" || r.CompanyNumber == "" {
+		return errors.New("the company name and the company number are required, please set -company-name and -company-number")
+	}
+	if !utrPattern.MatchString(r.UTR) {
+		return errors.New("the Unique Taxpayer Reference of the company should be 10 digits, please set -company-utr")
+	}
+	if r.Director == "" {
+		return errors.New("the name of the director who signs the return is required")
+	}
+	return nil
+}
+
+// WriteCT600XML writes the return as the body of the CT600 submission to HMRC
+func WriteCT600XML(w io.Writer, r CT600Return) error {
+	if err := r.Check(); err != nil {
+		return err
+	}
+
+	var doc ct600XML
+	doc.Namespace = ct600Namespace
+	doc.ReturnType = "new"
+
+	info := &doc.CompanyInformation
+	info.CompanyName = r.CompanyName
+	info.RegistrationNumber = r.CompanyNumber
+	info.Reference = r.UTR
+	info.From = r.PeriodStart.Format(xbrlDateFormat)
+	info.To = r.PeriodEnd.Format(xbrlDateFormat)
+
+	doc.ReturnInfoSummary.Accounts = "yes"
+	doc.ReturnInfoSummary.Computations = "yes"
+	doc.Turnover = money(r.Turnover)
+
+	calc := &doc.CompanyTaxCalculation
+	calc.TradingProfits = money(r.TradingProfits)
+	calc.LossesBroughtForward = money(r.LossesBroughtForward)
+	calc.NetTradingProfits = money(r.NetTradingProfits)
+	calc.ProfitsBeforeDeductions = money(r.ProfitsBeforeDeductions)
+	if r.LossesCarriedBack != 0 {
+		calc.ChargesAndReliefs = &ct600Reliefs{TradingLosses: money(r.LossesCarriedBack)}
+	}
+	calc.ChargeableProfits = money(r.ChargeableProfits)
+	if r.ClaimsSmallProfitsRate {
+		calc.SmallProfitsRate = "yes"
+	}
+	for i, fy := range r.FinancialYears {
+		year := &ct600FinancialYear{Year: fy.Year, Profit: money(fy.Profit), TaxRate: money(fy.Rate * 100), Tax: money(fy.Tax)}
+		if i == 0 {
+			calc.FinancialYearOne = year
+		} else {
+			calc.FinancialYearTwo = year
+		}
+	}
+	calc.CorporationTax = money(r.CorporationTax)
+	calc.MarginalRelief = money(r.MarginalRelief)
+	calc.CorporationTaxChargeable = money(r.CorporationTaxChargeable)
+	calc.NetLiability = money(r.CorporationTaxChargeable)
+	calc.S455Tax = money(r.S455Tax)
+	calc.TaxChargeable = money(r.TaxChargeable)
+	calc.TaxPayable = money(r.TaxChargeable)
+
+	if a := r.Allowances; a != (tax.CT600Allowances{}) {
+		allowances := &ct600Allowances{AnnualInvestmentAllowance: money(a.AnnualInvestmentAllowance)}
+		if a.SpecialRatePool != 0 {
+			allowances.SpecialRatePool = &ct600Pool{CapitalAllowances: money(a.SpecialRatePool)}
+		}
+		if a.MainPool != 0 || a.BalancingCharges != 0 {
+			allowances.MainPool = &ct600Pool{CapitalAllowances: money(a.MainPool), BalancingCharges: money(a.BalancingCharges)}
+		}
+		doc.AllowancesAndCharges = allowances
+	}
+	if r.LossArising != 0 {
+		doc.LossesDeficitsAndExcess = &ct600Losses{TradingLosses: money(r.LossArising)}
+	}
+
+	if r.Loans.LoansInPeriod > 0 || r.Loans.OutstandingAtEnd > 0 {
+		doc.ReturnInfoSummary.Supplementary = &ct600Supplementary{CT600A: "yes"}
+		doc.LoansToParticipators = &ct600AXML{
+			LoansInPeriod:    money(r.Loans.LoansInPeriod),
+			OutstandingAtEnd: money(r.Loans.OutstandingAtEnd),
+			Chargeable:       money(r.Loans.Chargeable),
+			TaxChargeable:    money(r.Loans.Tax),
+		}
+		if r.Loans.RepaidInNineMonths > 0 {
+			doc.LoansToParticipators.Relief = &ct600ARepayments{AmountRepaid: money(r.Loans.RepaidInNineMonths),
+				RepaidBy: r.Loans.RepayBy.Format(xbrlDateFormat)}
+		}
+	}
+
+	doc.Declaration.AcceptDeclaration = "yes"
+	doc.Declaration.Name = r.Director
+	doc.Declaration.Status = "Director"
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// Boxes returns the filled in boxes of the return in the order of the form, boxes of zero are left out
+// except the totals
+func (r CT600Return) Boxes() []Box {
+	boxes := []Box{
+		{"1", "Company name", r.CompanyName},
+		{"2", "Company registration number", r.CompanyNumber},
+		{"3", "Tax reference", r.UTR},
+		{"30", "Period from", r.PeriodStart.Format(displayDateFormat)},
+		{"35", "Period to", r.PeriodEnd.Format(displayDateFormat)},
+	}
+	add := func(number, label string, amount float64, isTotal bool) {
+		if amount != 0 || isTotal {
+			boxes = append(boxes, Box{number, label, formatMoney(amount)})
+		}
+	}
+
+	add("145", "Total turnover from trade", r.Turnover, true)
+	add("155", "Trading profits", r.TradingProfits, true)
+	add("160", "Trading losses brought forward set against trading profits", r.LossesBroughtForward, false)
+	add("165", "Net trading profits", r.NetTradingProfits, true)
+	add("235", "Profits before other deductions and reliefs", r.ProfitsBeforeDeductions, true)
+	add("275", "Trading losses of a later accounting period", r.LossesCarriedBack, false)
+	add("280", "Trading losses of this period carried back to the previous one", r.LossCarriedBack, false)
+	add("315", "Profits chargeable to corporation tax", r.ChargeableProfits, true)
+	if r.ClaimsSmallProfitsRate {
+		boxes = append(boxes, Box{"329", "Claiming the small profits rate or the marginal relief", "X"})
+	}
+	for i, fy := range r.FinancialYears {
+		numbers := []string{"330", "335", "340", "345"}
+		if i > 0 {
+			numbers = []string{"380", "385", "390", "395"}
+		}
+		boxes = append(boxes,
+			Box{numbers[0], "Financial year", strconv.Itoa(fy.Year)},
+			Box{numbers[1], "Amount of profit", formatMoney(fy.Profit)},
+			Box{numbers[2], "Rate of tax", strconv.FormatFloat(fy.Rate*100, 'f', 2, 64) + "%"},
+			Box{numbers[3], "Tax", formatMoney(fy.Tax)})
+	}
+	add("430", "Corporation tax", r.CorporationTax, true)
+	add("435", "Marginal relief", r.MarginalRelief, false)
+	add("440", "Corporation tax chargeable", r.CorporationTaxChargeable, true)
+	add("475", "Net corporation tax liability", r.CorporationTaxChargeable, true)
+	add("480", "Tax payable on loans to participators (s455)", r.S455Tax, false)
+	add("510", "Tax chargeable", r.TaxChargeable, true)
+	add("525", "Self-assessment of tax payable", r.TaxChargeable, true)
+	add("690", "Annual investment allowance", r.Allowances.AnnualInvestmentAllowance, false)
+	add("695", "Special rate pool allowances", r.Allowances.SpecialRatePool, false)
+	add("705", "Main pool allowances including full expensing", r.Allowances.MainPool, false)
+	add("710", "Main pool balancing charges", r.Allowances.BalancingCharges, false)
+	add("780", "Trading losses arising", r.LossArising, false)
+	if r.LossCarriedForward != 0 {
+		boxes = append(boxes, Box{"", "Trading losses carried forward", formatMoney(r.LossCarriedForward)})
+	}
+
+	if r.Loans.LoansInPeriod > 0 || r.Loans.OutstandingAtEnd > 0 {
+		add("CT600A", "Loans to the director made in the period", r.Loans.LoansInPeriod, true)
+		add("CT600A", "Outstanding at the end of the period", r.Loans.OutstandingAtEnd, true)
+		add("CT600A", "Repaid within nine months after the period", r.Loans.RepaidInNineMonths, false)
+		add("CT600A", "Tax chargeable on loans", r.Loans.Tax, true)
+	}
+	return boxes
+}
+
+func formatMoney(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
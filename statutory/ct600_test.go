@@ -0,0 +1,68 @@
+package statutory
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/w32blaster/tax-bookkeeper/tax"
+)
+
+func TestWriteCT600XML(t *testing.T) {
+
+	// Given:
+	r := CT600Return{
+		CompanyName:   "Smith & Sons Ltd",
+		CompanyNumber: "01234567",
+		UTR:           "1234567890",
+		Director:      "John Smith",
+		CT600: tax.CalculateCT600(dateOf("01-04-2024"), 120000, tax.TaxComputation{TaxableProfit: 100000},
+			tax.LossRelief{}, tax.CT600Allowances{AnnualInvestmentAllowance: 3000},
+			tax.S455Charge{LoansInPeriod: 8000, OutstandingAtEnd: 8000, Chargeable: 8000, Tax: 2700}),
+	}
+
+	// When:
+	var buf bytes.Buffer
+	err := WriteCT600XML(&buf, r)
+
+	// Then:
+	assert.Nil(t, err)
+	assert.Nil(t, xml.Unmarshal(buf.Bytes(), &struct{}{}))
+
+	document := buf.String()
+	assert.Contains(t, document, `<CompanyTaxReturn xmlns="http://www.govtalk.gov.uk/taxation/CT/5" ReturnType="new">`)
+	assert.Contains(t, document, `<CompanyName>Smith &amp; Sons Ltd</CompanyName>`)
+	assert.Contains(t, document, `<TaxRate>25.00</TaxRate>`)
+	assert.Contains(t, document, `<MarginalRelief>2250.00</MarginalRelief>`)
+	assert.Contains(t, document, `<LoansToParticipators>2700.00</LoansToParticipators>`)
+	assert.Contains(t, document, `<TaxPayable>25450.00</TaxPayable>`)
+	assert.Contains(t, document, `<AIACapitalAllowancesInc>3000.00</AIACapitalAllowancesInc>`)
+	assert.Contains(t, document, `<CT600A>yes</CT600A>`)
+	assert.NotContains(t, document, `<MachineryAndPlantMainPool>`)
+	assert.NotContains(t, document, `<LossesDeficitsAndExcess>`)
+}
+
+func TestCT600ReturnCheck(t *testing.T) {
+	var tests = []struct {
+		name    string
+		r       CT600Return
+		isValid bool
+	}{
+		{"complete", CT600Return{CompanyName: "Ltd", CompanyNumber: "01234567", UTR: "1234567890", Director: "J"}, true},
+		{"no UTR", CT600Return{CompanyName: "Ltd", CompanyNumber: "01234567", Director: "J"}, false},
+		{"UTR is too short", CT600Return{CompanyName: "Ltd", CompanyNumber: "01234567", UTR: "12345", Director: "J"}, false},
+		{"no director", CT600Return{CompanyName: "Ltd", CompanyNumber: "01234567", UTR: "1234567890"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// When:
+			err := tt.r.Check()
+
+			// Then:
+			assert.Equal(t, tt.isValid, err == nil)
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package statutory
+
+import (
+	"bufio"
+	"html"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+const (
+	xbrlDateFormat    = "2006-01-02"
+	displayDateFormat = "2 January 2006"
+
+	currentPeriod   = "cur-period"
+	previousPeriod  = "prev-period"
+	currentInstant  = "cur-instant"
+	previousInstant = "prev-instant"
+)
+
+// statements required on the balance sheet of a company exempt from audit
+var auditExemptionStatements = []struct{ Concept, Text string }{
+	{"direp:StatementThatCompanyEntitledToExemptionFromAuditUnderSection477CompaniesAct2006RelatingToSmallCompanies",
+		"For the year ending on the balance sheet date the company was entitled to exemption from audit under section 477 of the Companies Act 2006 relating to small companies."},
+	{"direp:StatementThatMembersHaveNotRequiredCompanyToObtainAnAudit",
+		"The members have not required the company to obtain an audit in accordance with section 476 of the Companies Act 2006."},
+	{"direp:StatementThatDirectorsAcknowledgeTheirResponsibilitiesUnderCompaniesAct",
+		"The directors acknowledge their responsibilities for complying with the requirements of the Companies Act 2006 with respect to accounting records and the preparation of accounts."},
+	{"direp:StatementThatAccountsHaveBeenPreparedInAccordanceWithProvisionsSmallCompaniesRegime",
+		"These accounts have been prepared in accordance with the provisions applicable to companies subject to the small companies regime and in accordance with FRS 105 The Financial Reporting Standard applicable to the Micro-entities Regime."},
+}
+
+// WriteIXBRL writes the accounts as an inline XBRL document, which is an XHTML page with the figures tagged
+// by the FRC taxonomy, the format Companies House accepts for the online filing
+func WriteIXBRL(w io.Writer, a MicroEntityAccounts) error {
+	if err := a.Check(); err != nil {
+		return err
+	}
+
+	doc := bufio.NewWriter(w)
+	title := a.CompanyName + " - Micro-entity accounts for the period ended " + a.PeriodEnd.Format(displayDateFormat)
+
+	doc.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	doc.WriteString(`<html xmlns="` + xhtmlNamespace + `" xmlns:ix="` + ixNamespace + `" xmlns:ixt="` + ixtNamespace +
+		`" xmlns:xbrli="` + xbrliNamespace + `" xmlns:xbrldi="` + xbrldiNamespace + `" xmlns:link="` + linkNamespace +
+		`" xmlns:xlink="` + xlinkNamespace + `" xmlns:iso4217="` + iso4217 + `" xmlns:core="` + coreNamespace +
+		`" xmlns:bus="` + busNamespace + `" xmlns:direp="` + direpNamespace + `" xml:lang="en">` + "\n")
+	doc.WriteString("<head>\n<meta http-equiv=\"Content-Type\" content=\"text/html; charset=UTF-8\"/>\n")
+	doc.WriteString("<title>" + escape(title) + "</title>\n")
+	doc.WriteString("<style>body{font-family:sans-serif;max-width:50em;margin:auto} td{padding:0.2em 1em} " +
+		"td.amount{text-align:right} tr.total td{font-weight:bold;border-top:1px solid}</style>\n</head>\n<body>\n")
+
+	writeHeader(doc, a)
+
+	doc.WriteString("<h1>" + nonNumeric("bus:EntityCurrentLegalOrRegisteredName", currentPeriod, "", a.CompanyName) + "</h1>\n")
+	doc.WriteString("<p>Company registration number " +
+		nonNumeric("bus:UKCompaniesHouseRegisteredNumber", currentPeriod, "", a.CompanyNumber) + "</p>\n")
+	doc.WriteString("<p>Micro-entity accounts for the period from " +
+		date("bus:StartDateForPeriodCoveredByReport", currentPeriod, a.PeriodStart) + " to " +
+		date("bus:EndDateForPeriodCoveredByReport", currentPeriod, a.PeriodEnd) + "</p>\n")
+
+	writeBalanceSheet(doc, a)
+	writeNotes(doc, a)
+
+	doc.WriteString("</body>\n</html>\n")
+	return doc.Flush()
+}
+
+// the hidden facts and the contexts and units which all the facts refer to
+func writeHeader(doc *bufio.Writer, a MicroEntityAccounts) {
+	previousStart, previousEnd := a.PeriodStart.AddDate(-1, 0, 0), a.PeriodStart.AddDate(0, 0, -1)
+
+	doc.WriteString("<div style=\"display:none\">\n<ix:header>\n<ix:hidden>\n")
+	doc.WriteString(nonNumeric("bus:EntityDormantTruefalse", currentPeriod, "", "false") + "\n")
+	doc.WriteString("</ix:hidden>\n<ix:references>\n")
+	doc.WriteString(`<link:schemaRef xlink:type="simple" xlink:href="` + taxonomySchema + `"/>` + "\n")
+	doc.WriteString("</ix:references>\n<ix:resources>\n")
+
+	director := dimension("bus:EntityOfficersDimension", "bus:Director1")
+	withinOneYear := dimension("core:MaturitiesOrExpirationPeriodsDimension", "core:WithinOneYear")
+	for _, c := range []struct {
+		id, segment string
+		start, end  time.Time
+		instant     bool
+	}{
+		{id: currentPeriod, start: a.PeriodStart, end: a.PeriodEnd},
+		{id: previousPeriod, start: previousStart, end: previousEnd},
+		{id: currentInstant, end: a.PeriodEnd, instant: true},
+		{id: previousInstant, end: previousEnd, instant: true},
+		{id: currentInstant + "-wioy", segment: withinOneYear, end: a.PeriodEnd, instant: true},
+		{id: previousInstant + "-wioy", segment: withinOneYear, end: previousEnd, instant: true},
+		{id: currentPeriod + "-director1", segment: director, start: a.PeriodStart, end: a.PeriodEnd},
+		{id: currentInstant + "-director1", segment: director, end: a.PeriodEnd, instant: true},
+		{id: previousInstant + "-director1", segment: director, end: previousEnd, instant: true},
+	} {
+		doc.WriteString(`<xbrli:context id="` + c.id + `"><xbrli:entity><xbrli:identifier scheme="` +
+			companiesHouseScheme + `">` + escape(a.CompanyNumber) + "</xbrli:identifier>")
+		if c.segment != "" {
+			doc.WriteString("<xbrli:segment>" + c.segment + "</xbrli:segment>")
+		}
+		doc.WriteString("</xbrli:entity><xbrli:period>")
+		if c.instant {
+			doc.WriteString("<xbrli:instant>" + c.end.Format(xbrlDateFormat) + "</xbrli:instant>")
+		} else {
+			doc.WriteString("<xbrli:startDate>" + c.start.Format(xbrlDateFormat) + "</xbrli:startDate>" +
+				"<xbrli:endDate>" + c.end.Format(xbrlDateFormat) + "</xbrli:endDate>")
+		}
+		doc.WriteString("</xbrli:period></xbrli:context>\n")
+	}
+
+	doc.WriteString(`<xbrli:unit id="GBP"><xbrli:measure>iso4217:GBP</xbrli:measure></xbrli:unit>` + "\n")
+	doc.WriteString(`<xbrli:unit id="pure"><xbrli:measure>xbrli:pure</xbrli:measure></xbrli:unit>` + "\n")
+	doc.WriteString("</ix:resources>\n</ix:header>\n</div>\n")
+}
+
+func writeBalanceSheet(doc *bufio.Writer, a MicroEntityAccounts) {
+	doc.WriteString("<h2>Balance sheet as at " + date("bus:BalanceSheetDate", currentInstant, a.PeriodEnd) + "</h2>\n")
+	doc.WriteString("<table>\n<tr><td></td><td class=\"amount\">" + a.PeriodEnd.Format("2006") + "<br/>£</td>")
+	if !a.FirstPeriod {
+		doc.WriteString("<td class=\"amount\">" + a.PeriodStart.AddDate(0, 0, -1).Format("2006") + "<br/>£</td>")
+	}
+	doc.WriteString("</tr>\n")
+
+	for _, row := range []struct {
+		label, concept, suffix string
+		current, previous      float64
+		isTotal, isDeducted    bool
+	}{
+		{label: "Fixed assets", concept: "core:FixedAssets", current: a.Current.FixedAssets, previous: a.Previous.FixedAssets},
+		{label: "Current assets", concept: "core:CurrentAssets", current: a.Current.CurrentAssets, previous: a.Previous.CurrentAssets},
+		{label: "Creditors: amounts falling due within one year", concept: "core:Creditors", suffix: "-wioy",
+			current: a.Current.CreditorsWithinOneYear, previous: a.Previous.CreditorsWithinOneYear, isDeducted: true},
+		{label: "Net current assets (liabilities)", concept: "core:NetCurrentAssetsLiabilities",
+			current: a.Current.NetCurrentAssets, previous: a.Previous.NetCurrentAssets, isTotal: true},
+		{label: "Total assets less current liabilities", concept: "core:TotalAssetsLessCurrentLiabilities",
+			current: a.Current.TotalAssetsLessCurrentLiabilities, previous: a.Previous.TotalAssetsLessCurrentLiabilities, isTotal: true},
+		{label: "Net assets (liabilities)", concept: "core:NetAssetsLiabilities",
+			current: a.Current.NetAssets, previous: a.Previous.NetAssets, isTotal: true},
+		{label: "Capital and reserves", concept: "core:Equity",
+			current: a.Current.CapitalAndReserves, previous: a.Previous.CapitalAndReserves, isTotal: true},
+	} {
+		if row.isTotal {
+			doc.WriteString("<tr class=\"total\">")
+		} else {
+			doc.WriteString("<tr>")
+		}
+		doc.WriteString("<td>" + escape(row.label) + "</td>")
+		doc.WriteString("<td class=\"amount\">" + amount(row.concept, currentInstant+row.suffix, row.current, row.isDeducted) + "</td>")
+		if !a.FirstPeriod {
+			doc.WriteString("<td class=\"amount\">" + amount(row.concept, previousInstant+row.suffix, row.previous, row.isDeducted) + "</td>")
+		}
+		doc.WriteString("</tr>\n")
+	}
+	doc.WriteString("</table>\n")
+
+	for _, s := range auditExemptionStatements {
+		doc.WriteString("<p>" + nonNumeric(s.Concept, currentPeriod, "", s.Text) + "</p>\n")
+	}
+	doc.WriteString("<p>The financial statements were approved by the board of directors on " +
+		date("core:DateAuthorisationFinancialStatementsForIssue", currentPeriod, a.ApprovalDate) +
+		" and signed on its behalf by " +
+		nonNumeric("bus:NameEntityOfficer", currentPeriod+"-director1", "", a.Director) + ", Director.</p>\n")
+}
+
+func writeNotes(doc *bufio.Writer, a MicroEntityAccounts) {
+	doc.WriteString("<h2>Notes to the financial statements</h2>\n")
+	doc.WriteString("<h3>1. Employees</h3>\n<p>The average number of persons employed by the company during the period, " +
+		"including directors, was " + number("core:AverageNumberEmployeesDuringPeriod", currentPeriod, "pure", float64(a.AverageEmployees)))
+	if !a.FirstPeriod {
+		doc.WriteString(" (previous period: " +
+			number("core:AverageNumberEmployeesDuringPeriod", previousPeriod, "pure", float64(a.PreviousAverageEmployees)) + ")")
+	}
+	doc.WriteString(".</p>\n")
+
+	if adv := a.DirectorAdvances; adv != nil {
+		doc.WriteString("<h3>2. Advances to the director</h3>\n<table>\n")
+		for _, row := range []struct {
+			label, concept, context string
+			amount                  float64
+			isDeducted              bool
+		}{
+			{"Balance brought forward", "core:AdvancesCreditsDirectors", previousInstant + "-director1", adv.BroughtForward, false},
+			{"Advanced in the period", "core:AdvancesCreditsMadeInPeriodDirectors", currentPeriod + "-director1", adv.Advanced, false},
+			{"Repaid in the period", "core:AdvancesCreditsRepaidInPeriodDirectors", currentPeriod + "-director1", adv.Repaid, true},
+			{"Balance carried forward", "core:AdvancesCreditsDirectors", currentInstant + "-director1", adv.CarriedForward, false},
+		} {
+			doc.WriteString("<tr><td>" + row.label + "</td><td class=\"amount\">" +
+				amount(row.concept, row.context, row.amount, row.isDeducted) + "</td></tr>\n")
+		}
+		doc.WriteString("</table>\n<p>The advances are unsecured, interest free and repayable on demand.</p>\n")
+	}
+}
+
+// amount tags the monetary fact in pounds. Deducted amounts, like creditors, are positive and shown in brackets
+func amount(concept, context string, value float64, isDeducted bool) string {
+	tagged := number(concept, context, "GBP", value)
+	if isDeducted != (value < 0) {
+		return "(" + tagged + ")"
+	}
+	return tagged
+}
+
+// number tags the numeric fact, negative values are shown without the minus which goes to the sign attribute
+func number(concept, context, unit string, value float64) string {
+	attrs := `name="` + concept + `" contextRef="` + context + `" unitRef="` + unit + `" decimals="0"`
+	if value < 0 {
+		attrs = attrs + ` sign="-"`
+	}
+	if math.Round(value) == 0 {
+		return `<ix:nonFraction ` + attrs + ` format="ixt:fixed-zero">-</ix:nonFraction>`
+	}
+	return `<ix:nonFraction ` + attrs + ` format="ixt:num-dot-decimal">` + withThousands(math.Abs(value)) + `</ix:nonFraction>`
+}
+
+func date(concept, context string, d time.Time) string {
+	return nonNumeric(concept, context, "ixt:date-day-monthname-year-en", d.Format(displayDateFormat))
+}
+
+func nonNumeric(concept, context, format, text string) string {
+	attrs := `name="` + concept + `" contextRef="` + context + `"`
+	if format != "" {
+		attrs = attrs + ` format="` + format + `"`
+	}
+	return `<ix:nonNumeric ` + attrs + `>` + escape(text) + `</ix:nonNumeric>`
+}
+
+func dimension(dimension, member string) string {
+	return `<xbrldi:explicitMember dimension="` + dimension + `">` + member + `</xbrldi:explicitMember>`
+}
+
+// withThousands formats whole pounds like "12,345"
+func withThousands(value float64) string {
+	s := strconv.FormatFloat(math.Round(value), 'f', 0, 64)
+	for i := len(s) - 3; i > 0; i = i - 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+func escape(s string) string {
+	return html.EscapeString(s)
+}
@@ -0,0 +1,88 @@
+package statutory
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+type (
+	// BalanceSheet is the micro-entity balance sheet of the Companies Act format 1, rounded to whole pounds.
+	// Creditors and capital and reserves are positive
+	BalanceSheet struct {
+		FixedAssets                       float64
+		CurrentAssets                     float64
+		CreditorsWithinOneYear            float64
+		NetCurrentAssets                  float64
+		TotalAssetsLessCurrentLiabilities float64
+		NetAssets                         float64
+		CapitalAndReserves                float64
+	}
+
+	// DirectorAdvances is the note of advances to the director required by s413 Companies Act 2006,
+	// it is the overdrawn director's loan account
+	DirectorAdvances struct {
+		BroughtForward float64
+		Advanced       float64
+		Repaid         float64
+		CarriedForward float64
+	}
+
+	// MicroEntityAccounts are the annual accounts of a micro-entity prepared under FRS 105,
+	// the balance sheet with notes filed at Companies House
+	MicroEntityAccounts struct {
+		CompanyName              string
+		CompanyNumber            string
+		PeriodStart              time.Time
+		PeriodEnd                time.Time // the balance sheet date, the last day of the period
+		Current                  BalanceSheet
+		Previous                 BalanceSheet
+		FirstPeriod              bool // the first accounting period of the company has no comparatives
+		AverageEmployees         int
+		PreviousAverageEmployees int
+		DirectorAdvances         *DirectorAdvances // nil if the director's loan account was never overdrawn
+		Director                 string            // who approves and signs the balance sheet
+		ApprovalDate             time.Time
+	}
+)
+
+// NewBalanceSheet rounds the figures to whole pounds and calculates the totals. Micro-entities have no
+// creditors due after more than one year or provisions here, so the net assets are total assets
+// less current liabilities. The rounding difference of a balanced sheet goes to current assets
+func NewBalanceSheet(fixedAssets, currentAssets, creditorsWithinOneYear, capitalAndReserves float64) BalanceSheet {
+	bs := BalanceSheet{
+		FixedAssets:            math.Round(fixedAssets),
+		CurrentAssets:          math.Round(currentAssets),
+		CreditorsWithinOneYear: math.Round(creditorsWithinOneYear),
+		CapitalAndReserves:     math.Round(capitalAndReserves),
+	}
+	if math.Abs(fixedAssets+currentAssets-creditorsWithinOneYear-capitalAndReserves) < 0.005 {
+		bs.CurrentAssets = bs.CapitalAndReserves + bs.CreditorsWithinOneYear - bs.FixedAssets
+	}
+	bs.NetCurrentAssets = bs.CurrentAssets - bs.CreditorsWithinOneYear
+	bs.TotalAssetsLessCurrentLiabilities = bs.FixedAssets + bs.NetCurrentAssets
+	bs.NetAssets = bs.TotalAssetsLessCurrentLiabilities
+	return bs
+}
+
+// Check returns an error if the accounts can't be filed, like when the balance sheet does not balance
+func (a MicroEntityAccounts) Check() error {
+	if a.CompanyName == "" || a.CompanyNumber == "" {
+		return errors.New("the company name and the company number are required, please set -company-name and -company-number")
+	}
+	if a.Director == "" {
+		return errors.New("the name of the director who approves the accounts is required")
+	}
+	if a.ApprovalDate.Before(a.PeriodEnd) {
+		return errors.New("the accounts can't be approved before the end of the period")
+	}
+	for i, bs := range []BalanceSheet{a.Current, a.Previous} {
+		if i == 1 && a.FirstPeriod {
+			break
+		}
+		if bs.NetAssets != bs.CapitalAndReserves {
+			return errors.New("the balance sheet does not balance, please post the opening balances as journals first")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package statutory
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/w32blaster/tax-bookkeeper/conf"
+)
+
+func TestNewBalanceSheet(t *testing.T) {
+	var tests = []struct {
+		name                                     string
+		fixed, current, creditors, capital       float64
+		expectedCurrent, expectedNet, expectedCR float64
+	}{
+		{"whole pounds", 1000, 5000, 1500, 4500, 5000, 4500, 4500},
+		{"rounding difference goes to current assets", 1000.4, 5000.4, 1500.3, 4500.5, 5001, 4501, 4501},
+		{"net liabilities", 0, 200, 1200.2, -1000.2, 200, -1000, -1000},
+		{"not balanced", 0, 5000, 1000, 3000, 5000, 4000, 3000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// When:
+			bs := NewBalanceSheet(tt.fixed, tt.current, tt.creditors, tt.capital)
+
+			// Then:
+			assert.Equal(t, tt.expectedCurrent, bs.CurrentAssets)
+			assert.Equal(t, tt.expectedNet, bs.NetAssets)
+			assert.Equal(t, tt.expectedCR, bs.CapitalAndReserves)
+			assert.Equal(t, bs.FixedAssets+bs.NetCurrentAssets, bs.TotalAssetsLessCurrentLiabilities)
+		})
+	}
+}
+
+func TestWriteIXBRLIsValid(t *testing.T) {
+
+	// Given:
+	accounts := _accounts()
+
+	// When:
+	var buf bytes.Buffer
+	err := WriteIXBRL(&buf, accounts)
+
+	// Then:
+	assert.Nil(t, err)
+	assert.Empty(t, Validate(bytes.NewReader(buf.Bytes())))
+	assert.Contains(t, buf.String(), `<ix:nonFraction name="core:Creditors" contextRef="cur-instant-wioy" unitRef="GBP" `+
+		`decimals="0" format="ixt:num-dot-decimal">1,450</ix:nonFraction>`)
+	assert.Contains(t, buf.String(), `>Smith &amp; Sons Ltd</ix:nonNumeric>`)
+	assert.Contains(t, buf.String(), `format="ixt:date-day-monthname-year-en">31 March 2025</ix:nonNumeric>`)
+}
+
+func TestWriteIXBRLRefusesUnbalancedAccounts(t *testing.T) {
+
+	// Given:
+	accounts := _accounts()
+	accounts.Current = NewBalanceSheet(0, 5000, 1000, 3000)
+
+	// When:
+	err := WriteIXBRL(&bytes.Buffer{}, accounts)
+
+	// Then:
+	assert.NotNil(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	var tests = []struct {
+		name        string
+		from, to    string
+		expectedErr string
+	}{
+		{"unknown concept", `name="core:Equity"`, `name="core:Equities"`,
+			"the concept core:Equities is not in the taxonomy of the micro-entity accounts"},
+		{"unknown context", `contextRef="prev-instant-wioy"`, `contextRef="last-year"`,
+			"the fact core:Creditors refers to the unknown context 'last-year'"},
+		{"wrong unit", `name="core:FixedAssets" contextRef="cur-instant" unitRef="GBP"`,
+			`name="core:FixedAssets" contextRef="cur-instant" unitRef="pure"`,
+			"the fact core:FixedAssets has the wrong unit xbrli:pure"},
+		{"creditors without the maturity", `name="core:Creditors" contextRef="cur-instant-wioy"`,
+			`name="core:Creditors" contextRef="cur-instant"`,
+			"the fact core:Creditors can't have the dimension '' of the context cur-instant"},
+		{"totals don't add up", `>3,550</ix:nonFraction>`, `>3,500</ix:nonFraction>`,
+			"the total assets less current liabilities don't add up in the context cur-instant"},
+		{"invalid date", `>31 March 2025<`, `>31/03/2025<`,
+			"the fact bus:EndDateForPeriodCoveredByReport has the invalid date '31/03/2025'"},
+		{"other taxonomy", `FRS-102-2023-01-01.xsd`, `FRS-102-2019-01-01.xsd`,
+			"the document must refer to the taxonomy " + taxonomySchema +
+				", but refers to 'https://xbrl.frc.org.uk/FRS-102/2023-01-01/FRS-102-2019-01-01.xsd'"},
+		{"not well-formed", `</body>`, `</bod>`,
+			"the document is not well-formed: XML syntax error"},
+	}
+
+	var buf bytes.Buffer
+	assert.Nil(t, WriteIXBRL(&buf, _accounts()))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// Given:
+			document := strings.Replace(buf.String(), tt.from, tt.to, 1)
+			assert.NotEqual(t, buf.String(), document)
+
+			// When:
+			errs := Validate(strings.NewReader(document))
+
+			// Then:
+			found := false
+			for _, err := range errs {
+				found = found || strings.HasPrefix(err.Error(), tt.expectedErr)
+			}
+			assert.True(t, found, "'%s' is not found in %v", tt.expectedErr, errs)
+		})
+	}
+}
+
+func TestValidateWithOtherPrefixes(t *testing.T) {
+
+	// Given:
+	var buf bytes.Buffer
+	assert.Nil(t, WriteIXBRL(&buf, _accounts()))
+	document := strings.Replace(buf.String(), `xmlns:core=`, `xmlns:frc=`, 1)
+	document = strings.Replace(document, `"core:`, `"frc:`, -1)
+	document = strings.Replace(document, `>core:`, `>frc:`, -1)
+
+	// When:
+	errs := Validate(strings.NewReader(document))
+
+	// Then:
+	assert.Empty(t, errs)
+}
+
+func _accounts() MicroEntityAccounts {
+	return MicroEntityAccounts{
+		CompanyName:              "Smith & Sons Ltd",
+		CompanyNumber:            "01234567",
+		PeriodStart:              dateOf("01-04-2024"),
+		PeriodEnd:                dateOf("31-03-2025"),
+		Current:                  NewBalanceSheet(1200, 3800, 1450, 3550),
+		Previous:                 NewBalanceSheet(0, 2100, 700, 1400),
+		AverageEmployees:         1,
+		PreviousAverageEmployees: 1,
+		DirectorAdvances:         &DirectorAdvances{Advanced: 800, Repaid: 300, CarriedForward: 500},
+		Director:                 "John Smith",
+		ApprovalDate:             dateOf("15-06-2025"),
+	}
+}
+
+func dateOf(strDate string) time.Time {
+	d, _ := time.ParseInLocation("02-01-2006", strDate, conf.GMT)
+	return d
+}
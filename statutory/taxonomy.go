@@ -0,0 +1,96 @@
+package statutory
+
+// the FRC taxonomy suite which covers FRS 105, Companies House accepts it for micro-entity accounts
+// https://www.frc.org.uk/accountants/taxonomies
+const (
+	taxonomySchema  = "https://xbrl.frc.org.uk/FRS-102/2023-01-01/FRS-102-2023-01-01.xsd"
+	coreNamespace   = "http://xbrl.frc.org.uk/fr/2023-01-01/core"
+	busNamespace    = "http://xbrl.frc.org.uk/cd/2023-01-01/business"
+	direpNamespace  = "http://xbrl.frc.org.uk/reports/2023-01-01/direp"
+	ixNamespace     = "http://www.xbrl.org/2013/inlineXBRL"
+	ixtNamespace    = "http://www.xbrl.org/inlineXBRL/transformation/2020-02-12"
+	xbrliNamespace  = "http://www.xbrl.org/2003/instance"
+	xbrldiNamespace = "http://xbrl.org/2006/xbrldi"
+	linkNamespace   = "http://www.xbrl.org/2003/linkbase"
+	xlinkNamespace  = "http://www.w3.org/1999/xlink"
+	iso4217         = "http://www.xbrl.org/2003/iso4217"
+	xhtmlNamespace  = "http://www.w3.org/1999/xhtml"
+
+	companiesHouseScheme = "http://www.companieshouse.gov.uk/"
+)
+
+// conceptType is the XBRL item type of a concept, it defines how the fact is tagged
+type conceptType int
+
+const (
+	monetaryItem conceptType = 1 + iota // ix:nonFraction in GBP
+	integerItem                         // ix:nonFraction without a currency, like the number of employees
+	dateItem                            // ix:nonNumeric with a date transformation
+	stringItem                          // ix:nonNumeric
+	booleanItem                         // ix:nonNumeric, "true" or "false"
+)
+
+// concept is one element of the taxonomy and the dimensions its facts could have
+type concept struct {
+	Type      conceptType
+	Instant   bool     // the period type, duration otherwise
+	Dimension string   // the only allowed dimension, empty if the fact has no dimensions
+	Members   []string // allowed members of the dimension
+}
+
+// the part of the taxonomy used by the micro-entity accounts, the offline validation accepts only these concepts
+var concepts = map[string]concept{
+	"bus:EntityCurrentLegalOrRegisteredName": {Type: stringItem},
+	"bus:UKCompaniesHouseRegisteredNumber":   {Type: stringItem},
+	"bus:StartDateForPeriodCoveredByReport":  {Type: dateItem},
+	"bus:EndDateForPeriodCoveredByReport":    {Type: dateItem},
+	"bus:BalanceSheetDate":                   {Type: dateItem, Instant: true},
+	"bus:EntityDormantTruefalse":             {Type: booleanItem},
+	"bus:NameEntityOfficer": {Type: stringItem, Dimension: "bus:EntityOfficersDimension",
+		Members: []string{"bus:Director1"}},
+
+	"core:FixedAssets":                       {Type: monetaryItem, Instant: true},
+	"core:CurrentAssets":                     {Type: monetaryItem, Instant: true},
+	"core:NetCurrentAssetsLiabilities":       {Type: monetaryItem, Instant: true},
+	"core:TotalAssetsLessCurrentLiabilities": {Type: monetaryItem, Instant: true},
+	"core:NetAssetsLiabilities":              {Type: monetaryItem, Instant: true},
+	"core:Equity":                            {Type: monetaryItem, Instant: true},
+	"core:Creditors": {Type: monetaryItem, Instant: true, Dimension: "core:MaturitiesOrExpirationPeriodsDimension",
+		Members: []string{"core:WithinOneYear", "core:AfterOneYear"}},
+	"core:AverageNumberEmployeesDuringPeriod":           {Type: integerItem},
+	"core:DateAuthorisationFinancialStatementsForIssue": {Type: dateItem},
+	"core:AdvancesCreditsDirectors": {Type: monetaryItem, Instant: true, Dimension: "bus:EntityOfficersDimension",
+		Members: []string{"bus:Director1"}},
+	"core:AdvancesCreditsMadeInPeriodDirectors": {Type: monetaryItem, Dimension: "bus:EntityOfficersDimension",
+		Members: []string{"bus:Director1"}},
+	"core:AdvancesCreditsRepaidInPeriodDirectors": {Type: monetaryItem, Dimension: "bus:EntityOfficersDimension",
+		Members: []string{"bus:Director1"}},
+
+	"direp:StatementThatCompanyEntitledToExemptionFromAuditUnderSection477CompaniesAct2006RelatingToSmallCompanies": {Type: stringItem},
+	"direp:StatementThatMembersHaveNotRequiredCompanyToObtainAnAudit":                                               {Type: stringItem},
+	"direp:StatementThatDirectorsAcknowledgeTheirResponsibilitiesUnderCompaniesAct":                                 {Type: stringItem},
+	"direp:StatementThatAccountsHaveBeenPreparedInAccordanceWithProvisionsSmallCompaniesRegime":                     {Type: stringItem},
+}
+
+// Companies House rejects accounts without these facts for the current period
+var requiredConcepts = []string{
+	"bus:EntityCurrentLegalOrRegisteredName",
+	"bus:UKCompaniesHouseRegisteredNumber",
+	"bus:StartDateForPeriodCoveredByReport",
+	"bus:EndDateForPeriodCoveredByReport",
+	"bus:BalanceSheetDate",
+	"bus:EntityDormantTruefalse",
+	"bus:NameEntityOfficer",
+	"core:NetAssetsLiabilities",
+	"core:Equity",
+	"core:AverageNumberEmployeesDuringPeriod",
+	"core:DateAuthorisationFinancialStatementsForIssue",
+	"direp:StatementThatCompanyEntitledToExemptionFromAuditUnderSection477CompaniesAct2006RelatingToSmallCompanies",
+	"direp:StatementThatMembersHaveNotRequiredCompanyToObtainAnAudit",
+	"direp:StatementThatDirectorsAcknowledgeTheirResponsibilitiesUnderCompaniesAct",
+	"direp:StatementThatAccountsHaveBeenPreparedInAccordanceWithProvisionsSmallCompaniesRegime",
+}
+
+// transformations of the displayed values, from the Inline XBRL Transformation Registry 4
+var numberFormats = map[string]bool{"ixt:num-dot-decimal": true, "ixt:fixed-zero": true}
+var dateFormats = map[string]bool{"ixt:date-day-monthname-year-en": true}
@@ -0,0 +1,320 @@
+package statutory
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// fact is a tagged value of the inline XBRL document
+	fact struct {
+		Concept  string // with the prefix used by the taxonomy subset, like "core:Equity"
+		Numeric  bool   // ix:nonFraction, ix:nonNumeric otherwise
+		Context  string
+		Unit     string
+		Format   string
+		Sign     string
+		Decimals string
+		Text     string
+	}
+
+	context struct {
+		Scheme    string
+		Instant   bool
+		Start     time.Time
+		End       time.Time
+		Dimension string
+		Member    string
+	}
+)
+
+// namespaces of the prefixes used in the taxonomy subset, the document might use other prefixes
+var knownPrefixes = map[string]string{
+	coreNamespace:   "core",
+	busNamespace:    "bus",
+	direpNamespace:  "direp",
+	ixtNamespace:    "ixt",
+	iso4217:         "iso4217",
+	xbrliNamespace:  "xbrli",
+	xbrldiNamespace: "xbrldi",
+}
+
+// Validate checks the inline XBRL document offline: it must be well-formed XML referring to the FRC taxonomy,
+// use only the concepts of the micro-entity accounts with the right contexts, units and formats, contain
+// the facts required by Companies House and its balance sheet totals must add up
+func Validate(r io.Reader) []error {
+	facts, contexts, units, schemaRef, err := parseIXBRL(r)
+	if err != nil {
+		return []error{errors.New("the document is not well-formed: " + err.Error())}
+	}
+
+	var errs []error
+	if schemaRef != taxonomySchema {
+		errs = append(errs, errors.New("the document must refer to the taxonomy "+taxonomySchema+", but refers to '"+schemaRef+"'"))
+	}
+	for id, c := range contexts {
+		if c.Scheme != companiesHouseScheme {
+			errs = append(errs, errors.New("the context "+id+" must identify the company by the Companies House number"))
+		}
+		if !c.Instant && c.End.Before(c.Start) {
+			errs = append(errs, errors.New("the context "+id+" ends before it starts"))
+		}
+	}
+
+	values := map[string]string{}
+	present := map[string]bool{}
+	amounts := map[string]map[string]float64{}
+	for _, f := range facts {
+		value, err := checkFact(f, contexts, units)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		key := f.Concept + " " + f.Context
+		if previous, ok := values[key]; ok && previous != value {
+			errs = append(errs, errors.New("the fact "+f.Concept+" is inconsistent in the context "+f.Context+
+				": '"+previous+"' and '"+value+"'"))
+		}
+		values[key] = value
+		present[f.Concept] = true
+
+		if f.Numeric && contexts[f.Context].Dimension == "" {
+			if amounts[f.Context] == nil {
+				amounts[f.Context] = map[string]float64{}
+			}
+			amounts[f.Context][f.Concept], _ = strconv.ParseFloat(value, 64)
+		}
+	}
+
+	for _, concept := range requiredConcepts {
+		if !present[concept] {
+			errs = append(errs, errors.New("the required fact "+concept+" is missing"))
+		}
+	}
+
+	for id, a := range amounts {
+		netAssets, hasNetAssets := a["core:NetAssetsLiabilities"]
+		equity, hasEquity := a["core:Equity"]
+		if hasNetAssets && hasEquity && netAssets != equity {
+			errs = append(errs, errors.New("the net assets are not equal to the capital and reserves in the context "+id))
+		}
+		if total, ok := a["core:TotalAssetsLessCurrentLiabilities"]; ok &&
+			a["core:FixedAssets"]+a["core:NetCurrentAssetsLiabilities"] != total {
+			errs = append(errs, errors.New("the total assets less current liabilities don't add up in the context "+id))
+		}
+	}
+	return errs
+}
+
+// checkFact returns the value of the fact if the concept, the context, the unit and the format are valid
+func checkFact(f fact, contexts map[string]context, units map[string]string) (string, error) {
+	c, ok := concepts[f.Concept]
+	if !ok {
+		return "", errors.New("the concept " + f.Concept + " is not in the taxonomy of the micro-entity accounts")
+	}
+	ctx, ok := contexts[f.Context]
+	if !ok {
+		return "", errors.New("the fact " + f.Concept + " refers to the unknown context '" + f.Context + "'")
+	}
+	if ctx.Instant != c.Instant {
+		return "", errors.New("the fact " + f.Concept + " has the wrong period type in the context " + f.Context)
+	}
+	if ctx.Dimension != c.Dimension {
+		return "", errors.New("the fact " + f.Concept + " can't have the dimension '" + ctx.Dimension + "' of the context " + f.Context)
+	}
+	if c.Dimension != "" && !contains(c.Members, ctx.Member) {
+		return "", errors.New("the fact " + f.Concept + " can't have the member " + ctx.Member + " of the context " + f.Context)
+	}
+
+	isNumeric := c.Type == monetaryItem || c.Type == integerItem
+	if f.Numeric != isNumeric {
+		return "", errors.New("the fact " + f.Concept + " is tagged by the wrong element")
+	}
+	text := strings.TrimSpace(f.Text)
+
+	switch c.Type {
+	case monetaryItem, integerItem:
+		measure, ok := units[f.Unit]
+		if !ok {
+			return "", errors.New("the fact " + f.Concept + " refers to the unknown unit '" + f.Unit + "'")
+		}
+		if (c.Type == monetaryItem && measure != "iso4217:GBP") || (c.Type == integerItem && measure != "xbrli:pure") {
+			return "", errors.New("the fact " + f.Concept + " has the wrong unit " + measure)
+		}
+		if f.Decimals == "" {
+			return "", errors.New("the fact " + f.Concept + " has no decimals")
+		}
+		if !numberFormats[f.Format] {
+			return "", errors.New("the fact " + f.Concept + " has the unknown format '" + f.Format + "'")
+		}
+
+		var value float64
+		if f.Format != "ixt:fixed-zero" {
+			var err error
+			if value, err = strconv.ParseFloat(strings.Replace(text, ",", "", -1), 64); err != nil || value < 0 {
+				return "", errors.New("the fact " + f.Concept + " has the invalid number '" + text + "'")
+			}
+		}
+		if c.Type == integerItem && value != math.Trunc(value) {
+			return "", errors.New("the fact " + f.Concept + " must be a whole number")
+		}
+		if f.Sign == "-" {
+			value = -value
+		}
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+
+	case dateItem:
+		if !dateFormats[f.Format] {
+			return "", errors.New("the fact " + f.Concept + " has the unknown format '" + f.Format + "'")
+		}
+		d, err := time.Parse(displayDateFormat, text)
+		if err != nil {
+			return "", errors.New("the fact " + f.Concept + " has the invalid date '" + text + "'")
+		}
+		return d.Format(xbrlDateFormat), nil
+
+	case booleanItem:
+		if text != "true" && text != "false" {
+			return "", errors.New("the fact " + f.Concept + " must be true or false")
+		}
+	default:
+		if text == "" {
+			return "", errors.New("the fact " + f.Concept + " is empty")
+		}
+	}
+	return text, nil
+}
+
+// parseIXBRL reads the facts, the contexts and the units of the document
+func parseIXBRL(r io.Reader) ([]fact, map[string]context, map[string]string, string, error) {
+	var (
+		facts     []fact
+		open      []*fact // the text of a nested fact is a part of its parent too
+		contexts  = map[string]context{}
+		units     = map[string]string{}
+		schemaRef string
+		prefixes  = map[string]string{}
+		ctxID     string
+		ctx       context
+		unitID    string
+		text      strings.Builder
+		dim       string
+	)
+
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, nil, "", err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			text.Reset()
+			for _, a := range t.Attr {
+				if a.Name.Space == "xmlns" {
+					prefixes[a.Name.Local] = a.Value
+				}
+			}
+
+			switch {
+			case t.Name.Space == ixNamespace && (t.Name.Local == "nonFraction" || t.Name.Local == "nonNumeric"):
+				f := &fact{
+					Concept:  qualified(prefixes, attr(t, "name")),
+					Numeric:  t.Name.Local == "nonFraction",
+					Context:  attr(t, "contextRef"),
+					Unit:     attr(t, "unitRef"),
+					Format:   qualified(prefixes, attr(t, "format")),
+					Sign:     attr(t, "sign"),
+					Decimals: attr(t, "decimals"),
+				}
+				open = append(open, f)
+			case t.Name.Space == linkNamespace && t.Name.Local == "schemaRef":
+				for _, a := range t.Attr {
+					if a.Name.Space == xlinkNamespace && a.Name.Local == "href" {
+						schemaRef = a.Value
+					}
+				}
+			case t.Name.Space == xbrliNamespace && t.Name.Local == "context":
+				ctxID, ctx = attr(t, "id"), context{}
+			case t.Name.Space == xbrliNamespace && t.Name.Local == "identifier":
+				ctx.Scheme = attr(t, "scheme")
+			case t.Name.Space == xbrldiNamespace && t.Name.Local == "explicitMember":
+				dim = qualified(prefixes, attr(t, "dimension"))
+			case t.Name.Space == xbrliNamespace && t.Name.Local == "unit":
+				unitID = attr(t, "id")
+			}
+
+		case xml.CharData:
+			text.Write(t)
+			for _, f := range open {
+				f.Text = f.Text + string(t)
+			}
+
+		case xml.EndElement:
+			value := strings.TrimSpace(text.String())
+			switch {
+			case t.Name.Space == ixNamespace && (t.Name.Local == "nonFraction" || t.Name.Local == "nonNumeric"):
+				facts = append(facts, *open[len(open)-1])
+				open = open[:len(open)-1]
+			case t.Name.Space == xbrliNamespace && t.Name.Local == "context":
+				contexts[ctxID] = ctx
+			case t.Name.Space == xbrliNamespace && t.Name.Local == "instant":
+				ctx.Instant = true
+				ctx.End, err = time.Parse(xbrlDateFormat, value)
+			case t.Name.Space == xbrliNamespace && t.Name.Local == "startDate":
+				ctx.Start, err = time.Parse(xbrlDateFormat, value)
+			case t.Name.Space == xbrliNamespace && t.Name.Local == "endDate":
+				ctx.End, err = time.Parse(xbrlDateFormat, value)
+			case t.Name.Space == xbrldiNamespace && t.Name.Local == "explicitMember":
+				ctx.Dimension, ctx.Member = dim, qualified(prefixes, value)
+			case t.Name.Space == xbrliNamespace && t.Name.Local == "measure":
+				units[unitID] = qualified(prefixes, value)
+			}
+			if err != nil {
+				return nil, nil, nil, "", errors.New("the context " + ctxID + " has the invalid date '" + value + "'")
+			}
+		}
+	}
+	return facts, contexts, units, schemaRef, nil
+}
+
+// qualified replaces the prefix of the name with the one used in the taxonomy subset, so that "c:Equity"
+// becomes "core:Equity" if the document declared the prefix "c" for the core namespace
+func qualified(prefixes map[string]string, name string) string {
+	i := strings.Index(name, ":")
+	if i < 0 {
+		return name
+	}
+	if prefix, ok := knownPrefixes[prefixes[name[:i]]]; ok {
+		return prefix + name[i:]
+	}
+	return name
+}
+
+func attr(e xml.StartElement, name string) string {
+	for _, a := range e.Attr {
+		if a.Name.Space == "" && a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
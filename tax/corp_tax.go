@@ -3,6 +3,7 @@ package tax
 import (
 	"github.com/w32blaster/tax-bookkeeper/conf"
 	"math"
+	"sort"
 	"strconv"
 	"time"
 )
@@ -21,17 +22,22 @@ const (
 //                           See more: https://www.gov.uk/corporation-tax-accounting-period
 func CalculateCorporateTax(yearProfit float64, accountingPeriodStartDate time.Time) float64 {
 
+	// there is no tax on a loss, please refer to ApplyTradingLosses
+	if yearProfit <= 0 {
+		return 0
+	}
+
 	// simply multiply profit by rate
 	if isMatchingFinYear(accountingPeriodStartDate) {
 		finYear := GetFinYear(accountingPeriodStartDate)
-		rate := conf.CorporationTaxRates[finYear]
+		rate := getCorporationTaxRate(yearProfit, finYear)
 		return yearProfit * rate
 	}
 
 	// if both periods has the same rate, then calculate as in previous step
 	prevPeriod, nextPeriod := getTwoPeriods(accountingPeriodStartDate)
-	ratePrev := conf.CorporationTaxRates[prevPeriod]
-	rateNext := conf.CorporationTaxRates[nextPeriod]
+	ratePrev := getCorporationTaxRate(yearProfit, prevPeriod)
+	rateNext := getCorporationTaxRate(yearProfit, nextPeriod)
 	if ratePrev == rateNext {
 		return yearProfit * ratePrev
 	}
@@ -84,6 +90,16 @@ func isMatchingFinYear(accPeriodStartDate time.Time) bool {
 		accPeriodStartDate.Month() == financialYearStartMonth
 }
 
+// GetCapitalAllowanceRates returns capital allowances for the financial year, like "2025-2026".
+// If the year is not known, then the closest known year is used
+func GetCapitalAllowanceRates(finYear string) conf.CapitalAllowanceRates {
+	years := make([]string, 0, len(conf.CapitalAllowances))
+	for y := range conf.CapitalAllowances {
+		years = append(years, y)
+	}
+	return conf.CapitalAllowances[findTaxYear(finYear, years)]
+}
+
 // returns year period for the giving accounting period
 func GetFinYear(accPeriodStartDate time.Time) string {
 	// TODO: THIS CAN BE INCORRECT, especially when this is the last month of the year, then it could be year-1
@@ -131,3 +147,78 @@ func calculateTwoPeriodsDifferentRate(daysOne int, rateOne float64, daysTwo int,
 
 	return math.Round(tax*100) / 100 // round for two decimal places
 }
+
+// returns the effective rate for the financial year. Since 1 April 2023 the main rate applies only to profits
+// above the upper limit, small profits are taxed at the small profits rate and between the limits
+// the main rate is reduced by the marginal relief:
+//
+//    relief = standard fraction x (upper limit - profit)
+//
+// https://www.gov.uk/guidance/corporation-tax-marginal-relief
+func getCorporationTaxRate(profit float64, finYear string) float64 {
+	mainRate := getMainRate(finYear)
+
+	smallProfits, ok := getSmallProfits(finYear)
+	if !ok {
+		return mainRate
+	}
+
+	if profit <= smallProfits.LowerLimit {
+		return smallProfits.Rate
+	}
+	if profit >= smallProfits.UpperLimit {
+		return mainRate
+	}
+
+	relief := smallProfits.MarginalReliefFraction * (smallProfits.UpperLimit - profit)
+	return (profit*mainRate - relief) / profit
+}
+
+func getMainRate(finYear string) float64 {
+	return conf.CorporationTaxRates[findTaxYear(finYear, corporationTaxYears())]
+}
+
+// returns the small profits rate and the limits of the marginal relief, false before they were introduced in 2023
+func getSmallProfits(finYear string) (conf.SmallProfits, bool) {
+	if smallProfits, ok := conf.CorporationTaxSmallProfits[finYear]; ok {
+		return smallProfits, true
+	}
+	years := smallProfitsYears()
+	if finYear < years[0] {
+		return conf.SmallProfits{}, false
+	}
+	return conf.CorporationTaxSmallProfits[findTaxYear(finYear, years)], true
+}
+
+func corporationTaxYears() []string {
+	years := make([]string, 0, len(conf.CorporationTaxRates))
+	for y := range conf.CorporationTaxRates {
+		years = append(years, y)
+	}
+	return years
+}
+
+func smallProfitsYears() []string {
+	years := make([]string, 0, len(conf.CorporationTaxSmallProfits))
+	for y := range conf.CorporationTaxSmallProfits {
+		years = append(years, y)
+	}
+	sort.Strings(years)
+	return years
+}
+
+// finds the year in the list of known years. If the year is not known (too old or is not published yet),
+// then the closest known year is returned
+func findTaxYear(year string, knownYears []string) string {
+	sort.Strings(knownYears)
+	for _, y := range knownYears {
+		if y == year {
+			return y
+		}
+	}
+
+	if year < knownYears[0] {
+		return knownYears[0]
+	}
+	return knownYears[len(knownYears)-1]
+}
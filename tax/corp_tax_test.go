@@ -32,6 +32,19 @@ func Test_calculateCorporateTax(t *testing.T) {
 
 		{"accounting date splits year with two slices with different rates 20% old one and 19% new",
 			60000.00, dateOf("01-11-2016"), 11648.22},
+
+		// since 1st of April 2023 small profits rate is 19%, main rate is 25%
+		// and profits between £50.000 and £250.000 get marginal relief
+		{"small profits rate", 40000.00, dateOf("01-04-2023"), 7600.00},
+		{"main rate", 300000.00, dateOf("01-04-2024"), 75000.00},
+		{"marginal relief: 100.000 x 25% - 3/200 x (250.000 - 100.000)",
+			100000.00, dateOf("01-04-2023"), 22750.00},
+
+		// rates are not published yet, so the latest known are used
+		{"unknown future year", 40000.00, dateOf("01-04-2030"), 7600.00},
+
+		// a trading loss is not taxed, it is relieved in other periods
+		{"loss", -20000.00, dateOf("01-04-2024"), 0},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,140 @@
+package tax
+
+import (
+	"strconv"
+	"time"
+)
+
+type (
+	// CT600 is the company tax return of one accounting period, the comments are the boxes of the CT600 (2023)
+	// version 3. Other income, reliefs and deductions than the ones of a small trading company are not supported
+	CT600 struct {
+		PeriodStart              time.Time
+		PeriodEnd                time.Time            // the last day of the period
+		Turnover                 float64              // 145
+		TradingProfits           float64              // 155, zero if the trade made a loss
+		LossesBroughtForward     float64              // 160, earlier losses set against the trading profits
+		NetTradingProfits        float64              // 165
+		ProfitsBeforeDeductions  float64              // 235
+		LossesCarriedBack        float64              // 275, the loss of the next period set against the profits
+		ChargeableProfits        float64              // 315
+		ClaimsSmallProfitsRate   bool                 // 329, the small profits rate or the marginal relief
+		FinancialYears           []CT600FinancialYear // 330 - 345 and 380 - 395
+		CorporationTax           float64              // 430
+		MarginalRelief           float64              // 435
+		CorporationTaxChargeable float64              // 440, also the net liability of 475
+		S455Tax                  float64              // 480, from the supplementary page CT600A
+		TaxChargeable            float64              // 510, also the tax payable of 525
+		Allowances               CT600Allowances      // 690 - 710
+		LossArising              float64              // 780, the trading loss of this period
+		LossCarriedBack          float64              // 280, the part of the loss claimed against the previous period
+		LossCarriedForward       float64              // not a box, losses left for the next periods
+		Loans                    S455Charge           // CT600A, loans to the director
+	}
+
+	// CT600FinancialYear is the part of the chargeable profits which falls into one financial year
+	CT600FinancialYear struct {
+		Year   int     // 330 or 380, like 2024 for the year starting on 1 April 2024
+		Profit float64 // 335 or 385
+		Rate   float64 // 340 or 390
+		Tax    float64 // 345 or 395
+	}
+
+	// CT600Allowances are the capital allowances claimed and the balancing charges of the period
+	CT600Allowances struct {
+		AnnualInvestmentAllowance float64 // 690
+		SpecialRatePool           float64 // 695
+		MainPool                  float64 // 705, full expensing is claimed with the main pool allowances
+		BalancingCharges          float64 // 710
+	}
+)
+
+// CalculateCT600 fills in the return from the tax computation of the period, its line of the loss memorandum and
+// the s455 charge on the loans to the director. The chargeable profits are apportioned by days between the
+// financial years. Since 1 April 2023 the profits between the limits are taxed at the main rate and the marginal
+// relief is claimed separately, the way the form shows it.
+// Please refer to unit tests for examples
+func CalculateCT600(periodStart time.Time, turnover float64, computation TaxComputation, relief LossRelief,
+	allowances CT600Allowances, loans S455Charge) CT600 {
+
+	ct := CT600{
+		PeriodStart:          periodStart,
+		PeriodEnd:            periodStart.AddDate(1, 0, -1),
+		Turnover:             roundPennies(turnover),
+		LossesBroughtForward: relief.CarriedForwardUsed,
+		LossesCarriedBack:    relief.CarriedBackFrom,
+		LossCarriedBack:      relief.CarriedBack,
+		LossCarriedForward:   relief.CarriedForward,
+		Allowances:           allowances,
+		Loans:                loans,
+		S455Tax:              loans.Tax,
+	}
+
+	if computation.TaxableProfit > 0 {
+		ct.TradingProfits = computation.TaxableProfit
+	} else {
+		ct.LossArising = -computation.TaxableProfit
+	}
+	ct.NetTradingProfits = roundPennies(ct.TradingProfits - ct.LossesBroughtForward)
+	ct.ProfitsBeforeDeductions = ct.NetTradingProfits
+	ct.ChargeableProfits = roundPennies(ct.ProfitsBeforeDeductions - ct.LossesCarriedBack)
+
+	if ct.ChargeableProfits > 0 {
+		var marginalRelief float64
+		ct.FinancialYears, marginalRelief, ct.ClaimsSmallProfitsRate = splitByFinancialYears(periodStart, ct.ChargeableProfits)
+		for _, fy := range ct.FinancialYears {
+			ct.CorporationTax = ct.CorporationTax + fy.Tax
+		}
+		ct.CorporationTax = roundPennies(ct.CorporationTax)
+		ct.MarginalRelief = roundPennies(marginalRelief)
+	}
+
+	ct.CorporationTaxChargeable = roundPennies(ct.CorporationTax - ct.MarginalRelief)
+	ct.TaxChargeable = roundPennies(ct.CorporationTaxChargeable + ct.S455Tax)
+	return ct
+}
+
+// the profits of every financial year within the accounting period with its rate, the marginal relief of all the
+// years together and whether the small profits rate or the marginal relief is claimed
+func splitByFinancialYears(periodStart time.Time, profit float64) ([]CT600FinancialYear, float64, bool) {
+	type part struct {
+		finYear string
+		days    int
+	}
+	parts := []part{{GetFinYear(periodStart), 1}}
+	if !isMatchingFinYear(periodStart) {
+		prevPeriod, nextPeriod := getTwoPeriods(periodStart)
+		daysPrev, daysNext := getDaysForPeriods(periodStart)
+		parts = []part{{prevPeriod, daysPrev}, {nextPeriod, daysNext}}
+	}
+	var totalDays int
+	for _, p := range parts {
+		totalDays = totalDays + p.days
+	}
+
+	var years []CT600FinancialYear
+	var marginalRelief, apportioned float64
+	var claimsSmallProfitsRate bool
+	for i, p := range parts {
+		share := float64(p.days) / float64(totalDays)
+		fy := CT600FinancialYear{Profit: roundPennies(profit * share), Rate: getMainRate(p.finYear)}
+		if i == len(parts)-1 {
+			// the rounding difference goes to the last year
+			fy.Profit = roundPennies(profit - apportioned)
+		}
+		apportioned = apportioned + fy.Profit
+		fy.Year, _ = strconv.Atoi(p.finYear[:4])
+
+		if smallProfits, ok := getSmallProfits(p.finYear); ok && profit < smallProfits.UpperLimit {
+			claimsSmallProfitsRate = true
+			if profit <= smallProfits.LowerLimit {
+				fy.Rate = smallProfits.Rate
+			} else {
+				marginalRelief = marginalRelief + smallProfits.MarginalReliefFraction*(smallProfits.UpperLimit-profit)*share
+			}
+		}
+		fy.Tax = roundPennies(fy.Profit * fy.Rate)
+		years = append(years, fy)
+	}
+	return years, marginalRelief, claimsSmallProfitsRate
+}
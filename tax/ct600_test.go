@@ -0,0 +1,75 @@
+package tax
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CalculateCT600(t *testing.T) {
+	var tests = []struct {
+		name                   string
+		periodStart            time.Time
+		taxableProfit          float64
+		relief                 LossRelief
+		s455                   float64
+		expectedYears          []CT600FinancialYear
+		expectedMarginalRelief float64
+		expectedTaxChargeable  float64
+	}{
+		{"small profits rate", dateOf("01-04-2024"), 40000, LossRelief{}, 0,
+			[]CT600FinancialYear{{2024, 40000, 0.19, 7600}}, 0, 7600},
+
+		{"marginal relief", dateOf("01-04-2024"), 100000, LossRelief{}, 0,
+			[]CT600FinancialYear{{2024, 100000, 0.25, 25000}}, 2250, 22750},
+
+		{"main rate", dateOf("01-04-2024"), 300000, LossRelief{}, 0,
+			[]CT600FinancialYear{{2024, 300000, 0.25, 75000}}, 0, 75000},
+
+		{"two financial years", dateOf("01-01-2023"), 100000, LossRelief{}, 0,
+			[]CT600FinancialYear{{2022, 24657.53, 0.19, 4684.93}, {2023, 75342.47, 0.25, 18835.62}}, 1695.21, 21825.34},
+
+		{"losses brought forward", dateOf("01-04-2024"), 30000, LossRelief{CarriedForwardUsed: 10000}, 0,
+			[]CT600FinancialYear{{2024, 20000, 0.19, 3800}}, 0, 3800},
+
+		{"loss of the next period carried back", dateOf("01-04-2024"), 30000, LossRelief{CarriedBackFrom: 30000}, 0,
+			nil, 0, 0},
+
+		{"loss and s455", dateOf("01-04-2024"), -20000, LossRelief{CarriedBack: 5000, CarriedForward: 15000}, 3375,
+			nil, 0, 3375},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// When:
+			ct := CalculateCT600(tt.periodStart, 250000, TaxComputation{TaxableProfit: tt.taxableProfit}, tt.relief,
+				CT600Allowances{}, S455Charge{Tax: tt.s455})
+
+			// Then:
+			assert.Equal(t, tt.expectedYears, ct.FinancialYears)
+			assert.Equal(t, tt.expectedMarginalRelief, ct.MarginalRelief)
+			assert.Equal(t, tt.expectedTaxChargeable, ct.TaxChargeable)
+			assert.Equal(t, ct.ProfitsBeforeDeductions-ct.LossesCarriedBack, ct.ChargeableProfits)
+			if ct.S455Tax == 0 {
+				assert.Equal(t, CalculateCorporateTax(ct.ChargeableProfits, tt.periodStart), ct.CorporationTaxChargeable)
+			}
+		})
+	}
+}
+
+func Test_CalculateCT600Loss(t *testing.T) {
+
+	// When:
+	ct := CalculateCT600(dateOf("01-04-2024"), 5000, TaxComputation{TaxableProfit: -20000},
+		LossRelief{Profit: -20000, CarriedBack: 5000, CarriedForward: 15000}, CT600Allowances{}, S455Charge{})
+
+	// Then:
+	assert.Equal(t, 0.0, ct.TradingProfits)
+	assert.Equal(t, 20000.0, ct.LossArising)
+	assert.Equal(t, 5000.0, ct.LossCarriedBack)
+	assert.Equal(t, 15000.0, ct.LossCarriedForward)
+	assert.Equal(t, dateOf("31-03-2025"), ct.PeriodEnd)
+	assert.False(t, ct.ClaimsSmallProfitsRate)
+}
@@ -0,0 +1,155 @@
+package tax
+
+import (
+	"sort"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/conf"
+)
+
+const (
+	confirmationStatementDays = 14 // the statement is filed within 14 days after the review date
+	payePaymentDay            = 22 // paid electronically by the 22nd after the tax month ends
+)
+
+// DeadlineType is a statutory obligation of a company or its director
+type DeadlineType int
+
+const (
+	CorporationTaxPayment DeadlineType = 1 + iota
+	CorporationTaxReturn               // CT600
+	AnnualAccounts                     // filed at Companies House
+	ConfirmationStatement              // CS01, filed at Companies House
+	P11DReturn                         // P11D and P11D(b) for benefits in kind
+	Class1ANIPayment                   // National Insurance on benefits in kind
+	VATReturn
+	SelfAssessmentReturn // director's personal tax return
+	PAYEPayment
+)
+
+func (t DeadlineType) PrettyString() string {
+	switch t {
+	case CorporationTaxPayment:
+		return "Corporation tax payment"
+	case CorporationTaxReturn:
+		return "Company tax return (CT600)"
+	case AnnualAccounts:
+		return "Annual accounts to Companies House"
+	case ConfirmationStatement:
+		return "Confirmation statement"
+	case P11DReturn:
+		return "P11D and P11D(b)"
+	case Class1ANIPayment:
+		return "Class 1A NI payment"
+	case VATReturn:
+		return "VAT return and payment"
+	case SelfAssessmentReturn:
+		return "Self assessment return"
+	case PAYEPayment:
+		return "PAYE payment"
+	}
+	return ""
+}
+
+type (
+	// CompanyCalendar is what the deadlines depend on
+	CompanyCalendar struct {
+		AccountingPeriodStart time.Time  // the start of any accounting period
+		VAT                   *VATScheme // nil if the company is not registered for VAT
+		ConfirmationDate      time.Time  // any review date of the confirmation statement, zero if unknown
+		HasPayroll            bool
+	}
+
+	// Deadline is one statutory obligation
+	Deadline struct {
+		Type        DeadlineType
+		Date        time.Time
+		Description string    // which period it is for
+		PeriodEnd   time.Time // the last day of the period, the tax year or the tax month it is for
+	}
+)
+
+// GetDeadlines returns all statutory obligations of the company due since "from" (inclusive) until "until"
+// (exclusive) ordered by date. Please refer to unit tests for examples
+func GetDeadlines(c CompanyCalendar, from, until time.Time) []Deadline {
+	var deadlines []Deadline
+	add := func(t DeadlineType, date time.Time, description string, periodEnd time.Time) {
+		if !date.Before(from) && date.Before(until) {
+			deadlines = append(deadlines, Deadline{Type: t, Date: date, Description: description, PeriodEnd: periodEnd})
+		}
+	}
+
+	// obligations are due up to 2 years after the period they are for
+	since := from.AddDate(-2, 0, 0)
+
+	// accounting periods
+	start := c.AccountingPeriodStart
+	for start.After(since) {
+		start = start.AddDate(-1, 0, 0)
+	}
+	for ; start.Before(until); start = start.AddDate(1, 0, 0) {
+		end := start.AddDate(1, 0, -1)
+		period := "period ended " + end.Format("2 Jan 2006")
+		add(CorporationTaxPayment, monthsLater(end, 9).AddDate(0, 0, 1), period, end)
+		add(CorporationTaxReturn, monthsLater(end, 12), period, end)
+		add(AnnualAccounts, monthsLater(end, 9), period, end)
+	}
+
+	// confirmation statements
+	if !c.ConfirmationDate.IsZero() {
+		review := c.ConfirmationDate
+		for review.After(since) {
+			review = review.AddDate(-1, 0, 0)
+		}
+		for ; review.Before(until); review = review.AddDate(1, 0, 0) {
+			add(ConfirmationStatement, review.AddDate(0, 0, confirmationStatementDays), "review date "+review.Format("2 Jan 2006"), review)
+		}
+	}
+
+	// tax years, they end on the 5th of April
+	for year := since.Year(); year <= until.Year(); year++ {
+		taxYear := GetTaxYear(time.Date(year, time.April, 6, 0, 0, 0, 0, conf.GMT))
+		taxYearEnd := time.Date(year+1, time.April, 5, 0, 0, 0, 0, conf.GMT)
+		if c.HasPayroll {
+			// benefits in kind are reported by employers only
+			add(P11DReturn, time.Date(year+1, time.July, 6, 0, 0, 0, 0, conf.GMT), "tax year "+taxYear, taxYearEnd)
+			add(Class1ANIPayment, time.Date(year+1, time.July, 22, 0, 0, 0, 0, conf.GMT), "tax year "+taxYear, taxYearEnd)
+		}
+		add(SelfAssessmentReturn, time.Date(year+2, time.January, 31, 0, 0, 0, 0, conf.GMT), "tax year "+taxYear, taxYearEnd)
+	}
+
+	// VAT periods
+	if c.VAT != nil {
+		for p := c.VAT.GetVATPeriod(since); p.Start.Before(until); p = c.VAT.GetVATPeriod(p.End.AddDate(0, 0, 1)) {
+			add(VATReturn, p.ReturnDue, p.Start.Format("Jan 2006")+" - "+p.End.Format("Jan 2006"), p.End)
+		}
+	}
+
+	// PAYE, tax months end on the 5th
+	if c.HasPayroll {
+		month := time.Date(since.Year(), since.Month(), payePaymentDay, 0, 0, 0, 0, conf.GMT)
+		for ; month.Before(until); month = month.AddDate(0, 1, 0) {
+			taxMonthEnd := time.Date(month.Year(), month.Month(), 5, 0, 0, 0, 0, conf.GMT)
+			add(PAYEPayment, month, "tax month ended 5 "+month.Format("Jan 2006"), taxMonthEnd)
+		}
+	}
+
+	sort.SliceStable(deadlines, func(i, j int) bool {
+		if deadlines[i].Date.Equal(deadlines[j].Date) {
+			return deadlines[i].Type < deadlines[j].Type
+		}
+		return deadlines[i].Date.Before(deadlines[j].Date)
+	})
+	return deadlines
+}
+
+// the same day some months later. If the date is the last day of a month, or there is no such day in the later month,
+// it is the last day of the later month, as Companies House counts the filing deadlines
+func monthsLater(date time.Time, months int) time.Time {
+	first := time.Date(date.Year(), date.Month()+time.Month(months), 1, 0, 0, 0, 0, conf.GMT)
+	last := first.AddDate(0, 1, -1)
+	if date.AddDate(0, 0, 1).Day() == 1 || date.Day() > last.Day() {
+		return last
+	}
+	return time.Date(first.Year(), first.Month(), date.Day(), 0, 0, 0, 0, conf.GMT)
+}
@@ -0,0 +1,80 @@
+package tax
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetDeadlines(t *testing.T) {
+
+	// Given:
+	company := CompanyCalendar{
+		AccountingPeriodStart: dateOf("01-07-2023"),
+		VAT:                   &VATScheme{Period: QuarterlyReturns, PeriodEndMonth: time.March},
+		ConfirmationDate:      dateOf("10-02-2021"),
+	}
+
+	// When:
+	deadlines := GetDeadlines(company, dateOf("01-01-2025"), dateOf("01-07-2025"))
+
+	// Then:
+	assert.Equal(t, []Deadline{
+		{SelfAssessmentReturn, dateOf("31-01-2025"), "tax year 2023-2024", dateOf("05-04-2024")},
+		{VATReturn, dateOf("07-02-2025"), "Oct 2024 - Dec 2024", dateOf("31-12-2024")},
+		{ConfirmationStatement, dateOf("24-02-2025"), "review date 10 Feb 2025", dateOf("10-02-2025")},
+		{AnnualAccounts, dateOf("31-03-2025"), "period ended 30 Jun 2024", dateOf("30-06-2024")},
+		{CorporationTaxPayment, dateOf("01-04-2025"), "period ended 30 Jun 2024", dateOf("30-06-2024")},
+		{VATReturn, dateOf("07-05-2025"), "Jan 2025 - Mar 2025", dateOf("31-03-2025")},
+		{CorporationTaxReturn, dateOf("30-06-2025"), "period ended 30 Jun 2024", dateOf("30-06-2024")},
+	}, deadlines)
+}
+
+func Test_GetDeadlinesWithoutPayroll(t *testing.T) {
+
+	// Given:
+	company := CompanyCalendar{AccountingPeriodStart: dateOf("01-04-2024")}
+
+	// When:
+	deadlines := GetDeadlines(company, dateOf("01-07-2025"), dateOf("01-08-2025"))
+
+	// Then:
+	assert.Empty(t, deadlines)
+}
+
+func Test_GetDeadlinesPayrollAndBenefits(t *testing.T) {
+
+	// Given:
+	company := CompanyCalendar{AccountingPeriodStart: dateOf("01-04-2024"), HasPayroll: true}
+
+	// When:
+	deadlines := GetDeadlines(company, dateOf("01-07-2025"), dateOf("01-08-2025"))
+
+	// Then:
+	assert.Equal(t, []Deadline{
+		{P11DReturn, dateOf("06-07-2025"), "tax year 2024-2025", dateOf("05-04-2025")},
+		{Class1ANIPayment, dateOf("22-07-2025"), "tax year 2024-2025", dateOf("05-04-2025")},
+		{PAYEPayment, dateOf("22-07-2025"), "tax month ended 5 Jul 2025", dateOf("05-07-2025")},
+	}, deadlines)
+}
+
+func Test_monthsLater(t *testing.T) {
+	var tests = []struct {
+		date     string
+		months   int
+		expected string
+	}{
+		{"31-03-2025", 9, "31-12-2025"},
+		{"30-06-2024", 9, "31-03-2025"}, // the last day of the month
+		{"15-05-2024", 9, "15-02-2025"},
+		{"30-05-2024", 9, "28-02-2025"}, // there is no 30th of February
+		{"29-02-2024", 12, "28-02-2025"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			assert.Equal(t, dateOf(tt.expected), monthsLater(dateOf(tt.date), tt.months))
+		})
+	}
+}
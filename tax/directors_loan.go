@@ -0,0 +1,214 @@
+package tax
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/conf"
+)
+
+const (
+	bedAndBreakfastDays      = 30     // new loans within 30 days after a repayment
+	bedAndBreakfastMinimum   = 5000.0 // both the repayment and the new loans are at least £5,000
+	beneficialLoanThreshold  = 10000.0
+	daysInYearForLoanBenefit = 365
+)
+
+type (
+	// DirectorLoanEntry is one movement on the director's loan account
+	DirectorLoanEntry struct {
+		Date            time.Time
+		Amount          float64 // positive is money taken from the company, negative is a repayment
+		Balance         float64 // how much the director owes after this entry
+		BedAndBreakfast float64 // part of the repayment which doesn't count because of the 30-day rule
+	}
+
+	// S455Charge is the tax on the loan outstanding at the end of the accounting period
+	S455Charge struct {
+		PeriodEnd          time.Time
+		OutstandingAtEnd   float64 // balance at the end of the accounting period
+		LoansInPeriod      float64 // new loans taken within the accounting period
+		RepaidInNineMonths float64 // repayments after the period end that reduce the charge
+		BedAndBreakfast    float64 // repayments ignored by the 30-day rule
+		Chargeable         float64
+		Rate               float64
+		Tax                float64
+		RepayBy            time.Time // the last day when a repayment still reduces the charge
+		DueDate            time.Time // when s455 should be paid, together with the corporation tax
+	}
+
+	// BeneficialLoanInterest is the benefit in kind of a cheap or interest-free loan
+	BeneficialLoanInterest struct {
+		TaxYear      string
+		MaxBalance   float64
+		OfficialRate float64
+		Benefit      float64 // reported on P11D and taxed as director's income
+		Class1ANI    float64 // paid by the company on the benefit
+	}
+)
+
+// BuildDirectorLoanLedger sorts the entries by date and calculates the running balance across years.
+// A repayment of £5,000 or more is "bed and breakfasted" if new loans of £5,000 or more are taken within 30 days,
+// then the repayment is matched with the new loans and doesn't reduce the s455 charge
+// https://www.gov.uk/hmrc-internal-manuals/company-taxation-manual/ctm61615
+func BuildDirectorLoanLedger(entries []DirectorLoanEntry) []DirectorLoanEntry {
+	ledger := make([]DirectorLoanEntry, len(entries))
+	copy(ledger, entries)
+	sort.SliceStable(ledger, func(i, j int) bool {
+		return ledger[i].Date.Before(ledger[j].Date)
+	})
+
+	var balance float64
+	matched := make([]float64, len(ledger)) // how much of the new loan was matched with earlier repayments
+	for i := range ledger {
+		balance = balance + ledger[i].Amount
+		ledger[i].Balance = roundPennies(balance)
+
+		repaid := -ledger[i].Amount
+		if repaid < bedAndBreakfastMinimum {
+			continue
+		}
+
+		var newLoans float64
+		deadline := ledger[i].Date.AddDate(0, 0, bedAndBreakfastDays)
+		for j := i + 1; j < len(ledger) && !ledger[j].Date.After(deadline); j++ {
+			if ledger[j].Amount > 0 {
+				newLoans = newLoans + ledger[j].Amount - matched[j]
+			}
+		}
+		if newLoans < bedAndBreakfastMinimum {
+			continue
+		}
+
+		ledger[i].BedAndBreakfast = math.Min(repaid, newLoans)
+		left := ledger[i].BedAndBreakfast
+		for j := i + 1; j < len(ledger) && left > 0 && !ledger[j].Date.After(deadline); j++ {
+			if ledger[j].Amount > 0 {
+				used := math.Min(left, ledger[j].Amount-matched[j])
+				matched[j] = matched[j] + used
+				left = left - used
+			}
+		}
+	}
+
+	return ledger
+}
+
+// CalculateS455 calculates the s455 tax for the accounting period. The charge is on new loans of the period that are
+// still outstanding at the end of the period, minus repayments within 9 months after it. The tax is due 9 months
+// and 1 day after the period end, and it is refunded when the loan is repaid.
+// Please refer to unit tests for examples
+func CalculateS455(ledger []DirectorLoanEntry, periodStart, periodEnd time.Time) S455Charge {
+	repayBy := periodEnd.AddDate(0, 0, 1).AddDate(0, 9, -1)
+	charge := S455Charge{
+		PeriodEnd: periodEnd,
+		RepayBy:   repayBy,
+		DueDate:   periodEnd.AddDate(0, 0, 1).AddDate(0, 9, 0),
+		Rate:      getS455Rate(GetTaxYear(periodEnd)),
+	}
+
+	var reborrowed float64
+	for _, e := range ledger {
+		if !e.Date.After(periodEnd) {
+			charge.OutstandingAtEnd = e.Balance
+			if !e.Date.Before(periodStart) && e.Amount > 0 {
+				charge.LoansInPeriod = charge.LoansInPeriod + e.Amount
+			}
+			if !e.Date.Before(periodStart) && e.BedAndBreakfast > 0 {
+				// repaid before the period end, but borrowed again after it
+				borrowed := sumOfLoans(ledger, periodEnd, e.Date.AddDate(0, 0, bedAndBreakfastDays))
+				reborrowed = reborrowed + math.Min(e.BedAndBreakfast, borrowed)
+			}
+			continue
+		}
+
+		if !e.Date.After(repayBy) && e.Amount < 0 {
+			charge.RepaidInNineMonths = charge.RepaidInNineMonths - e.Amount - e.BedAndBreakfast
+			charge.BedAndBreakfast = charge.BedAndBreakfast + e.BedAndBreakfast
+		}
+	}
+
+	// older loans were already charged in the previous periods
+	charge.BedAndBreakfast = charge.BedAndBreakfast + reborrowed
+	outstanding := math.Min(charge.OutstandingAtEnd+reborrowed, charge.LoansInPeriod)
+	charge.Chargeable = math.Max(0, outstanding-charge.RepaidInNineMonths)
+	charge.Tax = roundPennies(charge.Chargeable * charge.Rate)
+	return charge
+}
+
+// CalculateBeneficialLoanInterest calculates the benefit in kind of an interest-free loan. There is no benefit if
+// the balance was £10,000 or less during the whole tax year, otherwise it is the official rate of interest
+// on the daily balance (the "precise" method).
+// Please refer to unit tests for examples
+func CalculateBeneficialLoanInterest(ledger []DirectorLoanEntry, taxYear string) BeneficialLoanInterest {
+	start := time.Date(taxYearStart(taxYear), time.April, 6, 0, 0, 0, 0, conf.GMT)
+	end := start.AddDate(1, 0, 0)
+
+	benefit := BeneficialLoanInterest{
+		TaxYear:      taxYear,
+		OfficialRate: getOfficialInterestRate(taxYear),
+	}
+
+	// the balance brought forward
+	var balance, balanceDays float64
+	for _, e := range ledger {
+		if e.Date.Before(start) {
+			balance = e.Balance
+		}
+	}
+	benefit.MaxBalance = balance
+
+	from := start
+	for _, e := range ledger {
+		if e.Date.Before(start) || !e.Date.Before(end) {
+			continue
+		}
+		balanceDays = balanceDays + math.Max(0, balance)*daysBetween(from, e.Date)
+		from = e.Date
+		balance = e.Balance
+		benefit.MaxBalance = math.Max(benefit.MaxBalance, balance)
+	}
+	balanceDays = balanceDays + math.Max(0, balance)*daysBetween(from, end)
+
+	if benefit.MaxBalance <= beneficialLoanThreshold {
+		return benefit
+	}
+
+	benefit.Benefit = roundPennies(balanceDays / daysInYearForLoanBenefit * benefit.OfficialRate)
+	benefit.Class1ANI = roundPennies(benefit.Benefit * getNationalInsuranceRates(taxYear).EmployerRate)
+	return benefit
+}
+
+// new loans taken after "since" until "until" inclusive
+func sumOfLoans(ledger []DirectorLoanEntry, since, until time.Time) float64 {
+	var sum float64
+	for _, e := range ledger {
+		if e.Amount > 0 && e.Date.After(since) && !e.Date.After(until) {
+			sum = sum + e.Amount
+		}
+	}
+	return sum
+}
+
+func daysBetween(from, to time.Time) float64 {
+	return math.Round(to.Sub(from).Hours() / 24)
+}
+
+// returns the s455 rate for the tax year, the closest known year is used if the year is not known
+func getS455Rate(taxYear string) float64 {
+	years := make([]string, 0, len(conf.S455Rates))
+	for y := range conf.S455Rates {
+		years = append(years, y)
+	}
+	return conf.S455Rates[findTaxYear(taxYear, years)]
+}
+
+// returns HMRC official rate of interest for the tax year, the closest known year is used if the year is not known
+func getOfficialInterestRate(taxYear string) float64 {
+	years := make([]string, 0, len(conf.OfficialInterestRates))
+	for y := range conf.OfficialInterestRates {
+		years = append(years, y)
+	}
+	return conf.OfficialInterestRates[findTaxYear(taxYear, years)]
+}
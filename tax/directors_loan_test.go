@@ -0,0 +1,166 @@
+package tax
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BuildDirectorLoanLedger(t *testing.T) {
+
+	// Given: entries are not sorted
+	entries := []DirectorLoanEntry{
+		{Date: dateOf("01-03-2021"), Amount: -500},
+		{Date: dateOf("01-01-2021"), Amount: 1000},
+		{Date: dateOf("01-02-2021"), Amount: 2000},
+		{Date: dateOf("01-05-2022"), Amount: 300},
+	}
+
+	// When:
+	ledger := BuildDirectorLoanLedger(entries)
+
+	// Then: the balance is accumulated across years
+	assert.Equal(t, 1000.0, ledger[0].Balance)
+	assert.Equal(t, 3000.0, ledger[1].Balance)
+	assert.Equal(t, 2500.0, ledger[2].Balance)
+	assert.Equal(t, 2800.0, ledger[3].Balance)
+	for _, e := range ledger {
+		assert.Equal(t, 0.0, e.BedAndBreakfast)
+	}
+}
+
+func Test_BuildDirectorLoanLedgerBedAndBreakfast(t *testing.T) {
+	var tests = []struct {
+		name                    string
+		entries                 []DirectorLoanEntry
+		expectedBedAndBreakfast float64
+	}{
+		{"borrowed again within 30 days", []DirectorLoanEntry{
+			{Date: dateOf("01-06-2024"), Amount: 20000},
+			{Date: dateOf("01-11-2025"), Amount: -20000},
+			{Date: dateOf("15-11-2025"), Amount: 8000},
+			{Date: dateOf("01-12-2025"), Amount: 4000},
+		}, 12000},
+		{"borrowed again after 30 days", []DirectorLoanEntry{
+			{Date: dateOf("01-06-2024"), Amount: 20000},
+			{Date: dateOf("01-11-2025"), Amount: -20000},
+			{Date: dateOf("02-12-2025"), Amount: 20000},
+		}, 0},
+		{"less than £5,000 borrowed again", []DirectorLoanEntry{
+			{Date: dateOf("01-06-2024"), Amount: 20000},
+			{Date: dateOf("01-11-2025"), Amount: -20000},
+			{Date: dateOf("15-11-2025"), Amount: 4999},
+		}, 0},
+		{"less than £5,000 repaid", []DirectorLoanEntry{
+			{Date: dateOf("01-06-2024"), Amount: 20000},
+			{Date: dateOf("01-11-2025"), Amount: -4000},
+			{Date: dateOf("15-11-2025"), Amount: 10000},
+		}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// When:
+			ledger := BuildDirectorLoanLedger(tt.entries)
+
+			// Then:
+			assert.Equal(t, tt.expectedBedAndBreakfast, ledger[1].BedAndBreakfast)
+		})
+	}
+}
+
+func Test_CalculateS455(t *testing.T) {
+	var tests = []struct {
+		name               string
+		entries            []DirectorLoanEntry
+		expectedChargeable float64
+		expectedTax        float64
+	}{
+		{"not repaid", []DirectorLoanEntry{
+			{Date: dateOf("01-06-2024"), Amount: 10000},
+		}, 10000, 3375},
+		{"partly repaid within 9 months", []DirectorLoanEntry{
+			{Date: dateOf("01-06-2024"), Amount: 10000},
+			{Date: dateOf("31-12-2025"), Amount: -4000},
+			{Date: dateOf("01-01-2026"), Amount: -1000}, // too late
+		}, 6000, 2025},
+		{"repaid before the year end", []DirectorLoanEntry{
+			{Date: dateOf("01-06-2024"), Amount: 10000},
+			{Date: dateOf("01-03-2025"), Amount: -10000},
+		}, 0, 0},
+		{"loans of previous years were charged before", []DirectorLoanEntry{
+			{Date: dateOf("01-06-2023"), Amount: 10000},
+			{Date: dateOf("01-06-2024"), Amount: 2000},
+		}, 2000, 675},
+		{"repaid after the year end, but borrowed again within 30 days", []DirectorLoanEntry{
+			{Date: dateOf("01-06-2024"), Amount: 20000},
+			{Date: dateOf("01-11-2025"), Amount: -20000},
+			{Date: dateOf("15-11-2025"), Amount: 20000},
+		}, 20000, 6750},
+		{"repaid before the year end, but borrowed again within 30 days", []DirectorLoanEntry{
+			{Date: dateOf("01-05-2024"), Amount: 20000},
+			{Date: dateOf("25-03-2025"), Amount: -15000},
+			{Date: dateOf("10-04-2025"), Amount: 15000},
+		}, 20000, 6750},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// Given:
+			ledger := BuildDirectorLoanLedger(tt.entries)
+
+			// When:
+			charge := CalculateS455(ledger, dateOf("01-04-2024"), dateOf("31-03-2025"))
+
+			// Then:
+			assert.Equal(t, tt.expectedChargeable, charge.Chargeable)
+			assert.Equal(t, tt.expectedTax, charge.Tax)
+			assert.Equal(t, 0.3375, charge.Rate)
+			assert.Equal(t, dateOf("31-12-2025"), charge.RepayBy)
+			assert.Equal(t, dateOf("01-01-2026"), charge.DueDate)
+		})
+	}
+}
+
+func Test_CalculateBeneficialLoanInterest(t *testing.T) {
+	var tests = []struct {
+		name              string
+		entries           []DirectorLoanEntry
+		expectedBenefit   float64
+		expectedClass1ANI float64
+	}{
+		// 182 days x 12000 / 365 x 2.25%, Class 1A NI 13.8%
+		{"repaid within the year", []DirectorLoanEntry{
+			{Date: dateOf("06-04-2024"), Amount: 12000},
+			{Date: dateOf("05-10-2024"), Amount: -12000},
+		}, 134.63, 18.58},
+		{"never more than £10,000", []DirectorLoanEntry{
+			{Date: dateOf("06-04-2024"), Amount: 10000},
+		}, 0, 0},
+		{"£10,000 or more was repaid before the tax year", []DirectorLoanEntry{
+			{Date: dateOf("01-01-2024"), Amount: 20000},
+			{Date: dateOf("01-02-2024"), Amount: -12000},
+		}, 0, 0},
+		// the whole year 365 days x 15000 / 365 x 2.25%
+		{"balance brought forward", []DirectorLoanEntry{
+			{Date: dateOf("01-01-2024"), Amount: 15000},
+		}, 337.5, 46.58},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// Given:
+			ledger := BuildDirectorLoanLedger(tt.entries)
+
+			// When:
+			benefit := CalculateBeneficialLoanInterest(ledger, "2024-2025")
+
+			// Then:
+			assert.Equal(t, tt.expectedBenefit, benefit.Benefit)
+			assert.Equal(t, tt.expectedClass1ANI, benefit.Class1ANI)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package tax
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/conf"
+)
+
+// GetTaxYear returns the personal tax year (6 April - 5 April) for the date, like "2020-2021"
+func GetTaxYear(date time.Time) string {
+	start, _, _ := GetTaxYearDates(date)
+	return strconv.Itoa(start.Year()) + "-" + strconv.Itoa(start.Year()+1)
+}
+
+// CalculateDirectorSelfAssessmentTax calculates the personal tax of a limited company director who
+// takes a salary and dividends. Unlike self-employed, a director doesn't pay Class 2 and Class 4 NI,
+// the Class 1 NI is paid through the payroll. Dividends are stacked on top of the salary, so they use
+// the rest of the personal allowance and the basic rate band first. Income tax on the salary depends on
+// the residency, but dividends are taxed with the same bands across the UK, even for Scottish taxpayers.
+// Returns income tax on the salary and tax on dividends
+func CalculateDirectorSelfAssessmentTax(salary, dividends float64, taxYear string, residency Residency) (float64, float64) {
+
+	allowance := getPersonalAllowance(salary+dividends, taxYear)
+
+	// salary (and any other non-savings income) uses the personal allowance first
+	taxableSalary := math.Max(0, salary-allowance)
+	incomeTax := taxOn(0, taxableSalary, getIncomeTaxBands(residency, taxYear))
+
+	// the rest of the personal allowance goes to dividends
+	allowanceLeft := math.Max(0, allowance-salary)
+	taxableDividends := math.Max(0, dividends-allowanceLeft)
+
+	// dividend allowance is taxed at 0%, but it still uses up the band where it falls
+	rates := getDividendTaxRates(taxYear)
+	dividendAllowance := math.Min(rates.Allowance, taxableDividends)
+	dividendTax := taxOn(taxableSalary+dividendAllowance, taxableSalary+taxableDividends, getDividendTaxBands(taxYear))
+
+	return math.Round(incomeTax*100) / 100, math.Round(dividendTax*100) / 100
+}
+
+// dividends use the basic, higher and additional rate bands of England, but with the dividend rates
+func getDividendTaxBands(taxYear string) []incomeTaxBand {
+	rates := getDividendTaxRates(taxYear)
+	bands := getIncomeTaxBands(EnglandAndNorthernIreland, taxYear)
+	bands[0].percent, bands[1].percent, bands[2].percent = rates.OrdinaryRate, rates.UpperRate, rates.AdditionalRate
+	return bands
+}
+
+// how much of the slice "from - to" falls into the band "bandStart - bandEnd"
+func sliceOf(from, to, bandStart, bandEnd float64) float64 {
+	return math.Max(0, math.Min(to, bandEnd)-math.Max(from, bandStart))
+}
+
+// returns rates for the tax year. If the year is not known yet, then the latest known rates are used
+func getDividendTaxRates(taxYear string) conf.DividendTax {
+	years := make([]string, 0, len(conf.DividendTaxRates))
+	for y := range conf.DividendTaxRates {
+		years = append(years, y)
+	}
+	return conf.DividendTaxRates[findTaxYear(taxYear, years)]
+}
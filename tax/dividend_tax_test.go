@@ -0,0 +1,84 @@
+package tax
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// for testing I used this calculator:
+// https://www.uktaxcalculators.co.uk/tax-calculators/dividend-tax-calculators/dividend-tax-calculator/
+func Test_CalculateDirectorSelfAssessmentTax(t *testing.T) {
+	var tests = []struct {
+		salary              float64
+		dividends           float64
+		taxYear             string
+		expectedIncomeTax   float64
+		expectedDividendTax float64
+	}{
+		// everything is covered by the personal allowance and the dividend allowance
+		{8788, 3000, "2020-2021", 0, 0},
+		{8788, 5712, "2020-2021", 0, 0},
+
+		// dividends fall within the basic rate band: (40000 - 3712 - 2000) x 7.5%
+		{8788, 40000, "2020-2021", 0, 2571.60},
+
+		// salary above the personal allowance
+		{20000, 10000, "2020-2021", 1500, 600},
+
+		// dividends cross into the higher rate band:
+		// taxable dividends 60000 - 3712 = 56288, the first 2000 is the allowance,
+		// basic band 37500 - 2000 = 35500 x 7.5% = 2662.50,
+		// higher band 56288 - 37500 = 18788 x 32.5% = 6106.10
+		{8788, 60000, "2020-2021", 0, 8768.60},
+
+		// new rates from April 2022 and reduced allowance from April 2023,
+		// the personal allowance is 12570 since April 2021: (40000 - 3782 - 2000) x 8.75%
+		{8788, 40000, "2022-2023", 0, 2994.08},
+		{8788, 40000, "2023-2024", 0, 3081.58},
+
+		// income above £125,000, no personal allowance at all:
+		// salary 10000 x 20% = 2000,
+		// dividends: allowance 2000, basic 37500 - 12000 = 25500 x 7.5% = 1912.50,
+		// higher 150000 - 37500 = 112500 x 32.5% = 36562.50, additional 10000 x 38.1% = 3810
+		{10000, 150000, "2020-2021", 2000, 42285},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("Salary £%.0f and dividends £%.0f in %s", tt.salary, tt.dividends, tt.taxYear),
+			func(t *testing.T) {
+
+				// When:
+				incomeTax, dividendTax := CalculateDirectorSelfAssessmentTax(tt.salary, tt.dividends, tt.taxYear, EnglandAndNorthernIreland)
+
+				// Then:
+				assert.Equal(t, tt.expectedIncomeTax, incomeTax)
+				assert.Equal(t, tt.expectedDividendTax, dividendTax)
+			},
+		)
+	}
+}
+
+func Test_CalculateDirectorSelfAssessmentTaxInScotland(t *testing.T) {
+
+	// When:
+	incomeTax, dividendTax := CalculateDirectorSelfAssessmentTax(40000, 10000, "2025-2026", Scotland)
+
+	// Then: taxable salary 27430 is taxed with Scottish bands:
+	// starter 2827 x 19% = 537.13, basic 12094 x 20% = 2418.80, intermediate 12509 x 21% = 2626.89
+	assert.Equal(t, 5582.82, incomeTax)
+
+	// but dividends use the UK bands: (10000 - 500) x 8.75% within the basic rate band
+	assert.Equal(t, 831.25, dividendTax)
+}
+
+func Test_GetTaxYear(t *testing.T) {
+	assert.Equal(t, "2019-2020", GetTaxYear(dateOf("05-04-2020")))
+	assert.Equal(t, "2020-2021", GetTaxYear(dateOf("06-04-2020")))
+	assert.Equal(t, "2020-2021", GetTaxYear(dateOf("31-12-2020")))
+}
+
+func Test_getDividendTaxRatesUnknownYear(t *testing.T) {
+	assert.Equal(t, 5000.0, getDividendTaxRates("2000-2001").Allowance)
+	assert.Equal(t, 500.0, getDividendTaxRates("2099-2100").Allowance)
+}
@@ -0,0 +1,140 @@
+package tax
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/w32blaster/tax-bookkeeper/conf"
+)
+
+// above this income the personal allowance goes down by £1 for every £2
+const personalAllowanceTaperStart = 100000.0
+
+// Residency is where the taxpayer lives, Scotland and Wales set their own income tax rates
+// https://www.gov.uk/scottish-income-tax
+// https://www.gov.uk/welsh-income-tax
+type Residency int
+
+const (
+	EnglandAndNorthernIreland Residency = 1 + iota
+	Wales
+	Scotland
+)
+
+func (r Residency) PrettyString() string {
+	switch r {
+	case EnglandAndNorthernIreland:
+		return "England and Northern Ireland"
+	case Wales:
+		return "Wales"
+	case Scotland:
+		return "Scotland"
+	}
+	return ""
+}
+
+// ParseResidency parses the value of a command line parameter, England is the default
+func ParseResidency(residency string) (Residency, error) {
+	switch strings.ToLower(residency) {
+	case "", "england", "ni", "northern-ireland":
+		return EnglandAndNorthernIreland, nil
+	case "wales":
+		return Wales, nil
+	case "scotland":
+		return Scotland, nil
+	}
+	return 0, errors.New("unknown residency '" + residency + "', it should be 'england', 'wales' or 'scotland'")
+}
+
+// one income tax band, from and to are the taxable income (above the personal allowance)
+type incomeTaxBand struct {
+	rate    Rate
+	percent float64
+	from    float64
+	to      float64
+}
+
+// rates of the bands in the same order as in the conf tables
+var (
+	ukRates       = []Rate{BasicRate, HigherRate, AdditionalRate}
+	scottishRates = []Rate{StarterRate, BasicRate, IntermediateRate, HigherRate, AdvancedRate, TopRate}
+)
+
+// returns the income tax bands for the residency and the tax year.
+// If the year is not known yet, then the closest known year is used
+func getIncomeTaxBands(residency Residency, taxYear string) []incomeTaxBand {
+	tables, rates := conf.IncomeTaxBands, ukRates
+	if residency == Scotland {
+		tables, rates = conf.ScottishIncomeTaxBands, scottishRates
+	}
+
+	years := make([]string, 0, len(tables))
+	for y := range tables {
+		years = append(years, y)
+	}
+
+	table := tables[findTaxYear(taxYear, years)]
+	bands := make([]incomeTaxBand, len(table))
+	from := 0.0
+	for i, b := range table {
+		to := b.UpTo
+		if to == 0 {
+			to = math.Inf(1)
+		}
+		bands[i] = incomeTaxBand{rate: rates[i], percent: b.Rate, from: from, to: to}
+		from = to
+	}
+	return bands
+}
+
+// Percent is the rate of the band in the tax year for the residency, like 0.42 for the Scottish higher rate
+func (r Rate) Percent(taxYear string, residency Residency) float64 {
+	for _, b := range getIncomeTaxBands(residency, taxYear) {
+		if b.rate == r {
+			return b.percent
+		}
+	}
+	return 0
+}
+
+// Label is the name of the rate with its percent, like "Higher Rate (40%)"
+func (r Rate) Label(taxYear string, residency Residency) string {
+	return r.PrettyString() + " (" + strconv.FormatFloat(r.Percent(taxYear, residency)*100, 'f', -1, 64) + "%)"
+}
+
+// calculates the tax for the slice of taxable income between "from" and "to"
+func taxOn(from, to float64, bands []incomeTaxBand) float64 {
+	var tax float64
+	for _, b := range bands {
+		tax = tax + sliceOf(from, to, b.from, b.to)*b.percent
+	}
+	return tax
+}
+
+// returns the personal allowance for the tax year, unless the income is more than £100,000.
+// If the year is not known yet, then the closest known year is used
+func getYearPersonalAllowance(taxYear string) float64 {
+	years := make([]string, 0, len(conf.PersonalAllowances))
+	for y := range conf.PersonalAllowances {
+		years = append(years, y)
+	}
+	return conf.PersonalAllowances[findTaxYear(taxYear, years)]
+}
+
+// the opposite of the personal allowance: what the total income should be to get this taxable income
+func incomeForTaxable(taxable, allowance float64) float64 {
+	income := taxable + allowance
+	if income <= personalAllowanceTaperStart {
+		return income
+	}
+
+	// within the taper: income - (allowance - (income - 100000) / 2) = taxable
+	income = (taxable + allowance + personalAllowanceTaperStart/2) / 1.5
+	if income >= personalAllowanceTaperStart+2*allowance {
+		// no personal allowance left at all
+		return taxable
+	}
+	return income
+}
@@ -0,0 +1,45 @@
+package tax
+
+import (
+	"math"
+
+	"github.com/w32blaster/tax-bookkeeper/conf"
+)
+
+// CalculateClass1NI calculates the yearly employee's and employer's Class 1 National Insurance for
+// the salary paid within the tax year. Directors have an annual earnings period, so
+// it is calculated on the whole year's salary rather than month by month.
+//
+//	Employee: rate on earnings between the primary threshold and the upper earnings limit,
+//	          upper rate above the upper earnings limit
+//	Employer: rate on earnings above the secondary threshold
+//
+// The Employment Allowance is not applied here, because it is per company rather than per employee
+// https://www.gov.uk/national-insurance-rates-letters
+func CalculateClass1NI(salary float64, taxYear string) (float64, float64) {
+	return calculateClass1NIForPeriod(salary, 1, getNationalInsuranceRates(taxYear))
+}
+
+// calculates Class 1 NI for one pay period, the yearly thresholds are divided by the number of periods in a year
+// and rounded to whole pounds, as HMRC does for the monthly and weekly thresholds
+func calculateClass1NIForPeriod(earnings float64, periodsInYear int, rates conf.NationalInsurance) (float64, float64) {
+	periods := float64(periodsInYear)
+	primaryThreshold := math.Round(rates.PrimaryThreshold / periods)
+	secondaryThreshold := math.Round(rates.SecondaryThreshold / periods)
+	upperEarningsLimit := math.Round(rates.UpperEarningsLimit / periods)
+
+	employee := sliceOf(0, earnings, primaryThreshold, upperEarningsLimit)*rates.EmployeeRate +
+		sliceOf(0, earnings, upperEarningsLimit, math.Inf(1))*rates.EmployeeUpperRate
+	employer := sliceOf(0, earnings, secondaryThreshold, math.Inf(1)) * rates.EmployerRate
+
+	return math.Round(employee*100) / 100, math.Round(employer*100) / 100
+}
+
+// returns NI rates for the tax year, the closest known year is used if the year is not known
+func getNationalInsuranceRates(taxYear string) conf.NationalInsurance {
+	years := make([]string, 0, len(conf.NationalInsuranceRates))
+	for y := range conf.NationalInsuranceRates {
+		years = append(years, y)
+	}
+	return conf.NationalInsuranceRates[findTaxYear(taxYear, years)]
+}
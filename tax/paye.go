@@ -0,0 +1,183 @@
+package tax
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// NICategory is the National Insurance category letter of an employee
+// https://www.gov.uk/national-insurance-rates-letters/category-letters
+type NICategory string
+
+const (
+	NICategoryA NICategory = "A" // standard
+	NICategoryC NICategory = "C" // over the State Pension age, no employee's NI
+	NICategoryH NICategory = "H" // apprentice under 25, no employer's NI up to the upper earnings limit
+	NICategoryM NICategory = "M" // under 21, no employer's NI up to the upper earnings limit
+	NICategoryX NICategory = "X" // doesn't pay NI, for example under 16
+)
+
+const monthsInYear = 12
+
+var taxCodeRegexp = regexp.MustCompile(`^(K)?([0-9]+)([LMNT])$`)
+
+// TaxCode is a parsed PAYE tax code, like "1257L", "BR", "S1257L" or "K100 M1"
+// https://www.gov.uk/tax-codes
+type TaxCode struct {
+	Code          string
+	Residency     Residency // "S" prefix is for Scotland and "C" for Wales
+	Allowance     float64   // yearly tax-free pay, negative for K codes
+	FlatRate      Rate      // BR and D codes tax everything at the rate of one band
+	NoTax         bool      // NT code
+	NonCumulative bool      // emergency "W1", "M1" or "X" codes
+}
+
+// ParseTaxCode parses the tax code. Suffixes W1, M1 and X mean the emergency non-cumulative basis
+func ParseTaxCode(code string) (TaxCode, error) {
+	normalised := strings.ToUpper(strings.TrimSpace(code))
+	taxCode := TaxCode{Code: normalised, Residency: EnglandAndNorthernIreland}
+
+	for _, suffix := range []string{" W1", " M1", " X", "W1", "M1", "X"} {
+		if strings.HasSuffix(normalised, suffix) {
+			taxCode.NonCumulative = true
+			normalised = strings.TrimSpace(strings.TrimSuffix(normalised, suffix))
+			break
+		}
+	}
+
+	if len(normalised) > 1 {
+		switch normalised[0] {
+		case 'S':
+			taxCode.Residency = Scotland
+			normalised = normalised[1:]
+		case 'C':
+			taxCode.Residency = Wales
+			normalised = normalised[1:]
+		}
+	}
+
+	// Scottish D codes follow the Scottish bands: SD0 is the intermediate rate, SD1 higher and so on
+	flatRates := map[string]Rate{"BR": BasicRate, "D0": HigherRate, "D1": AdditionalRate}
+	if taxCode.Residency == Scotland {
+		flatRates = map[string]Rate{"BR": BasicRate, "D0": IntermediateRate, "D1": HigherRate, "D2": AdvancedRate, "D3": TopRate}
+	}
+	if rate, ok := flatRates[normalised]; ok {
+		taxCode.FlatRate = rate
+		return taxCode, nil
+	}
+
+	switch normalised {
+	case "NT":
+		taxCode.NoTax = true
+		return taxCode, nil
+	case "0T":
+		return taxCode, nil
+	}
+
+	parts := taxCodeRegexp.FindStringSubmatch(normalised)
+	if parts == nil {
+		return TaxCode{}, errors.New("the tax code '" + code + "' is not valid")
+	}
+
+	number, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return TaxCode{}, err
+	}
+
+	if parts[1] == "K" {
+		// K codes add the amount to the taxable pay
+		taxCode.Allowance = -float64(number*10 + 9)
+	} else {
+		taxCode.Allowance = float64(number*10 + 9)
+	}
+	return taxCode, nil
+}
+
+// CalculatePAYE returns the income tax to deduct in the tax month (1 is 6 April - 5 May).
+// On the cumulative basis the free pay and the tax bands are accumulated since the beginning
+// of the tax year and the tax already paid is subtracted, so that an overpayment is refunded.
+// On the non-cumulative (week1/month1) basis each month is calculated on its own.
+// Please refer to unit tests for examples
+func CalculatePAYE(code TaxCode, taxYear string, taxMonth int, payThisMonth, payToDate, taxPaidToDate float64) float64 {
+	if code.NoTax {
+		return 0
+	}
+
+	if code.NonCumulative {
+		taxMonth, payToDate, taxPaidToDate = 1, payThisMonth, 0
+	} else {
+		payToDate = payToDate + payThisMonth
+	}
+
+	if code.FlatRate != 0 {
+		return roundPennies(math.Floor(payToDate)*flatRatePercent(code, taxYear) - taxPaidToDate)
+	}
+
+	share := float64(taxMonth) / monthsInYear
+	taxablePay := math.Floor(math.Max(0, payToDate-code.Allowance*share))
+
+	bands := getIncomeTaxBands(code.Residency, taxYear)
+	for i := range bands {
+		bands[i].from, bands[i].to = bands[i].from*share, bands[i].to*share
+	}
+	taxDue := taxOn(0, taxablePay, bands)
+
+	tax := taxDue - taxPaidToDate
+
+	// with a K code the tax can't be more than half of the pay (the overriding limit)
+	if code.Allowance < 0 {
+		tax = math.Min(tax, payThisMonth/2)
+	}
+
+	return roundPennies(tax)
+}
+
+// returns the percent of the flat rate code. A band that doesn't exist in the tax year is empty in the conf
+// tables, so the next band is taken: before 2024-2025 Scotland had no advanced rate and SD2 was the top rate
+func flatRatePercent(code TaxCode, taxYear string) float64 {
+	bands := getIncomeTaxBands(code.Residency, taxYear)
+	for i, b := range bands {
+		if b.rate != code.FlatRate {
+			continue
+		}
+		for i < len(bands)-1 && bands[i].to <= bands[i].from {
+			i++
+		}
+		return bands[i].percent
+	}
+	return 0
+}
+
+// CalculateMonthlyNI returns employee's and employer's Class 1 NI for one month of an employee.
+// Directors have the annual earnings period, so their NI is calculated on the pay since the beginning of the
+// tax year minus NI already paid
+func CalculateMonthlyNI(category NICategory, isDirector bool, taxYear string, payThisMonth, payToDate, employeeNIToDate, employerNIToDate float64) (float64, float64) {
+	rates := getNationalInsuranceRates(taxYear)
+
+	switch category {
+	case NICategoryX:
+		return 0, 0
+	case NICategoryC:
+		rates.EmployeeRate, rates.EmployeeUpperRate = 0, 0
+	case NICategoryH, NICategoryM:
+		// no employer's NI up to the upper secondary threshold, which is the same as the upper earnings limit
+		rates.SecondaryThreshold = rates.UpperEarningsLimit
+	}
+
+	if !isDirector {
+		return calculateClass1NIForPeriod(payThisMonth, monthsInYear, rates)
+	}
+
+	employee, employer := calculateClass1NIForPeriod(payToDate+payThisMonth, 1, rates)
+	return roundPennies(employee - employeeNIToDate), roundPennies(employer - employerNIToDate)
+}
+
+// EmploymentAllowanceLeft returns how much of the Employment Allowance can be used this month,
+// the allowance is used against the employer's NI until it is exhausted
+func EmploymentAllowanceLeft(taxYear string, usedSoFar, employerNIThisMonth float64) float64 {
+	left := math.Max(0, getNationalInsuranceRates(taxYear).EmploymentAllowance-usedSoFar)
+	return math.Min(left, employerNIThisMonth)
+}
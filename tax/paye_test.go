@@ -0,0 +1,170 @@
+package tax
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_ParseTaxCode(t *testing.T) {
+	var tests = []struct {
+		code            string
+		expected        TaxCode
+		isErrorExpected bool
+	}{
+		{"1257L", TaxCode{Code: "1257L", Residency: EnglandAndNorthernIreland, Allowance: 12579}, false},
+		{"1250l", TaxCode{Code: "1250L", Residency: EnglandAndNorthernIreland, Allowance: 12509}, false},
+		{"1257L M1", TaxCode{Code: "1257L M1", Residency: EnglandAndNorthernIreland, Allowance: 12579, NonCumulative: true}, false},
+		{"1257LX", TaxCode{Code: "1257LX", Residency: EnglandAndNorthernIreland, Allowance: 12579, NonCumulative: true}, false},
+		{"K100", TaxCode{}, true},
+		{"K100L", TaxCode{Code: "K100L", Residency: EnglandAndNorthernIreland, Allowance: -1009}, false},
+		{"BR", TaxCode{Code: "BR", Residency: EnglandAndNorthernIreland, FlatRate: BasicRate}, false},
+		{"D0", TaxCode{Code: "D0", Residency: EnglandAndNorthernIreland, FlatRate: HigherRate}, false},
+		{"D1", TaxCode{Code: "D1", Residency: EnglandAndNorthernIreland, FlatRate: AdditionalRate}, false},
+		{"D2", TaxCode{}, true},
+		{"NT", TaxCode{Code: "NT", Residency: EnglandAndNorthernIreland, NoTax: true}, false},
+		{"0T", TaxCode{Code: "0T", Residency: EnglandAndNorthernIreland}, false},
+		{"S1257L", TaxCode{Code: "S1257L", Residency: Scotland, Allowance: 12579}, false},
+		{"C1257L", TaxCode{Code: "C1257L", Residency: Wales, Allowance: 12579}, false},
+		{"SK100L", TaxCode{Code: "SK100L", Residency: Scotland, Allowance: -1009}, false},
+		{"SD2", TaxCode{Code: "SD2", Residency: Scotland, FlatRate: AdvancedRate}, false},
+		{"CBR", TaxCode{Code: "CBR", Residency: Wales, FlatRate: BasicRate}, false},
+		{"hello", TaxCode{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+
+			// When:
+			code, err := ParseTaxCode(tt.code)
+
+			// Then:
+			assert.Equal(t, tt.expected, code)
+			assert.Equal(t, tt.isErrorExpected, err != nil)
+		})
+	}
+}
+
+func Test_CalculatePAYE(t *testing.T) {
+	var tests = []struct {
+		name          string
+		code          string
+		taxMonth      int
+		payThisMonth  float64
+		payToDate     float64
+		taxPaidToDate float64
+		expectedTax   float64
+	}{
+		// monthly free pay for 1257L is 12579 / 12 = 1048.25
+		{"first month", "1257L", 1, 3000, 0, 0, 390.20},
+		{"second month, cumulative", "1257L", 2, 3000, 3000, 390.20, 390.40},
+		// basic rate band is 37700 / 12 = 3141.67 a month
+		{"higher rate within one month", "1257L", 1, 5000, 0, 0, 952.07},
+
+		// nothing paid for 5 months, so the tax free pay is accumulated
+		{"cumulative catches up the allowance", "1257L", 6, 3000, 0, 0, 0},
+		{"non-cumulative ignores previous months", "1257L M1", 6, 3000, 0, 0, 390.20},
+
+		// earned nothing in the second month, so a part of the tax paid before is refunded
+		{"refund", "1257L", 2, 0, 3000, 390.20, -209.60},
+
+		// Scottish starter, basic and intermediate rates: 235.58 x 19% + 1007.83 x 20% + 707.58 x 21%
+		{"Scottish code", "S1257L", 1, 3000, 0, 0, 394.92},
+		{"Welsh code", "C1257L", 1, 3000, 0, 0, 390.20},
+
+		{"basic rate on everything", "BR", 1, 1000, 0, 0, 200},
+		{"Scottish intermediate rate on everything", "SD0", 1, 1000, 0, 0, 210},
+		{"no tax", "NT", 1, 1000, 0, 0, 0},
+		{"no allowance", "0T", 1, 1000, 0, 0, 200},
+
+		// K code adds 1009 / 12 = 84.08 to the pay, and the tax can't be more than 50% of the pay
+		{"K code", "K100L", 1, 1000, 0, 0, 216.80},
+		{"K code overriding limit", "K1000L", 1, 100, 0, 0, 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// Given:
+			code, err := ParseTaxCode(tt.code)
+			assert.Nil(t, err)
+
+			// When:
+			tax := CalculatePAYE(code, "2025-2026", tt.taxMonth, tt.payThisMonth, tt.payToDate, tt.taxPaidToDate)
+
+			// Then:
+			assert.Equal(t, tt.expectedTax, tax)
+		})
+	}
+}
+
+func Test_CalculatePAYEScottishD2(t *testing.T) {
+	var tests = []struct {
+		taxYear     string
+		expectedTax float64
+	}{
+		// no advanced rate before 2024-2025, SD2 was the top rate
+		{"2023-2024", 470},
+		{"2024-2025", 450},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.taxYear, func(t *testing.T) {
+
+			// Given:
+			code, err := ParseTaxCode("SD2")
+			assert.Nil(t, err)
+
+			// When:
+			tax := CalculatePAYE(code, tt.taxYear, 1, 1000, 0, 0)
+
+			// Then:
+			assert.Equal(t, tt.expectedTax, tax)
+		})
+	}
+}
+
+func Test_CalculateMonthlyNI(t *testing.T) {
+	var tests = []struct {
+		category         NICategory
+		isDirector       bool
+		payThisMonth     float64
+		payToDate        float64
+		employeeNIToDate float64
+		employerNIToDate float64
+		expectedEmployee float64
+		expectedEmployer float64
+	}{
+		// 2025-2026 monthly thresholds: primary 1048, secondary 417, upper earnings limit 4189
+		{NICategoryA, false, 3000, 0, 0, 0, 156.16, 387.45},
+		{NICategoryC, false, 3000, 0, 0, 0, 0, 387.45},
+		{NICategoryM, false, 3000, 0, 0, 0, 156.16, 0},
+		{NICategoryX, false, 3000, 0, 0, 0, 0, 0},
+
+		// directors use the annual earnings period, so nothing is paid until the yearly threshold is reached
+		{NICategoryA, true, 3000, 0, 0, 0, 0, 0},
+		{NICategoryA, true, 3000, 9000, 0, 600, 0, 450},
+		{NICategoryA, true, 3000, 12000, 0, 1050, 194.40, 450},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("Category %s, director %t, pay £%.0f, to date £%.0f", tt.category, tt.isDirector, tt.payThisMonth, tt.payToDate),
+			func(t *testing.T) {
+
+				// When:
+				employee, employer := CalculateMonthlyNI(tt.category, tt.isDirector, "2025-2026",
+					tt.payThisMonth, tt.payToDate, tt.employeeNIToDate, tt.employerNIToDate)
+
+				// Then:
+				assert.Equal(t, tt.expectedEmployee, employee)
+				assert.Equal(t, tt.expectedEmployer, employer)
+			},
+		)
+	}
+}
+
+func Test_EmploymentAllowanceLeft(t *testing.T) {
+	assert.Equal(t, 387.45, EmploymentAllowanceLeft("2025-2026", 0, 387.45))
+	assert.Equal(t, 500.0, EmploymentAllowanceLeft("2025-2026", 10000, 800))
+	assert.Equal(t, 0.0, EmploymentAllowanceLeft("2025-2026", 10500, 800))
+}
@@ -0,0 +1,113 @@
+package tax
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/conf"
+)
+
+const (
+	paymentsOnAccountThreshold = 1000.0 // no payments on account if the self assessment bill is less than that
+	deductedAtSourceShare      = 0.8    // or if more than 80% of the tax was deducted at source (PAYE)
+)
+
+type SelfAssessmentPaymentType int
+
+const (
+	FirstPaymentOnAccount SelfAssessmentPaymentType = 1 + iota
+	SecondPaymentOnAccount
+	BalancingPayment
+)
+
+func (t SelfAssessmentPaymentType) PrettyString() string {
+	switch t {
+	case FirstPaymentOnAccount:
+		return "1st payment on account"
+	case SecondPaymentOnAccount:
+		return "2nd payment on account"
+	case BalancingPayment:
+		return "Balancing payment"
+	}
+	return ""
+}
+
+type (
+	// SelfAssessmentLiability is the personal tax for one tax year
+	SelfAssessmentLiability struct {
+		TaxYear          string  // like "2025-2026"
+		TotalTax         float64 // income tax and dividend tax for the whole year
+		DeductedAtSource float64 // income tax already paid through PAYE
+	}
+
+	// SelfAssessmentPayment is one instalment of the self assessment tax
+	SelfAssessmentPayment struct {
+		Type    SelfAssessmentPaymentType
+		TaxYear string // the tax year the payment is for
+		DueDate time.Time
+		Amount  float64 // a negative balancing payment is a refund
+	}
+)
+
+// Due is how much should be paid through the self assessment
+func (l SelfAssessmentLiability) Due() float64 {
+	return l.TotalTax - l.DeductedAtSource
+}
+
+// requiresPaymentsOnAccount tells whether the next year should be paid in advance. There are no payments on account
+// if the self assessment bill is less than £1000 or more than 80% of the tax was deducted at source
+// https://www.gov.uk/understand-self-assessment-bill/payments-on-account
+func (l SelfAssessmentLiability) requiresPaymentsOnAccount() bool {
+	if l.Due() < paymentsOnAccountThreshold {
+		return false
+	}
+	return l.DeductedAtSource < l.TotalTax*deductedAtSourceShare
+}
+
+// GetSelfAssessmentPaymentSchedule returns all the payments for the current tax year ordered by the due date:
+//
+//	31 January within the tax year    1st payment on account, half of the previous year's bill
+//	31 July after the tax year        2nd payment on account, the other half
+//	31 January after the tax year     balancing payment, the rest of the bill minus payments on account
+//	31 January after the tax year     1st payment on account for the next year, half of this year's bill
+//	31 July a year after              2nd payment on account for the next year
+//
+// Please refer to unit tests for examples
+func GetSelfAssessmentPaymentSchedule(previous, current SelfAssessmentLiability) []SelfAssessmentPayment {
+	startYear := taxYearStart(current.TaxYear)
+	nextTaxYear := strconv.Itoa(startYear+1) + "-" + strconv.Itoa(startYear+2)
+
+	var payments []SelfAssessmentPayment
+	var paidOnAccount float64
+	if previous.requiresPaymentsOnAccount() {
+		half := roundPennies(previous.Due() / 2)
+		paidOnAccount = half * 2
+		payments = append(payments,
+			SelfAssessmentPayment{FirstPaymentOnAccount, current.TaxYear, selfAssessmentDate(startYear+1, time.January), half},
+			SelfAssessmentPayment{SecondPaymentOnAccount, current.TaxYear, selfAssessmentDate(startYear+1, time.July), half})
+	}
+
+	payments = append(payments, SelfAssessmentPayment{BalancingPayment, current.TaxYear,
+		selfAssessmentDate(startYear+2, time.January), roundPennies(current.Due() - paidOnAccount)})
+
+	if current.requiresPaymentsOnAccount() {
+		half := roundPennies(current.Due() / 2)
+		payments = append(payments,
+			SelfAssessmentPayment{FirstPaymentOnAccount, nextTaxYear, selfAssessmentDate(startYear+2, time.January), half},
+			SelfAssessmentPayment{SecondPaymentOnAccount, nextTaxYear, selfAssessmentDate(startYear+2, time.July), half})
+	}
+
+	return payments
+}
+
+// the first year of the tax year, like 2025 for "2025-2026"
+func taxYearStart(taxYear string) int {
+	year, _ := strconv.Atoi(strings.Split(taxYear, "-")[0])
+	return year
+}
+
+// self assessment payments are due on the last day of January and July
+func selfAssessmentDate(year int, month time.Month) time.Time {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, conf.GMT)
+}
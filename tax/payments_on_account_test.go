@@ -0,0 +1,85 @@
+package tax
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetSelfAssessmentPaymentSchedule(t *testing.T) {
+
+	// Given:
+	previous := SelfAssessmentLiability{TaxYear: "2023-2024", TotalTax: 6000}
+	current := SelfAssessmentLiability{TaxYear: "2024-2025", TotalTax: 8000}
+
+	// When:
+	payments := GetSelfAssessmentPaymentSchedule(previous, current)
+
+	// Then:
+	assert.Equal(t, []SelfAssessmentPayment{
+		{FirstPaymentOnAccount, "2024-2025", dateOf("31-01-2025"), 3000},
+		{SecondPaymentOnAccount, "2024-2025", dateOf("31-07-2025"), 3000},
+		{BalancingPayment, "2024-2025", dateOf("31-01-2026"), 2000},
+		{FirstPaymentOnAccount, "2025-2026", dateOf("31-01-2026"), 4000},
+		{SecondPaymentOnAccount, "2025-2026", dateOf("31-07-2026"), 4000},
+	}, payments)
+}
+
+func Test_GetSelfAssessmentPaymentScheduleWithRefund(t *testing.T) {
+
+	// Given: the income went down, so payments on account were too big
+	previous := SelfAssessmentLiability{TaxYear: "2023-2024", TotalTax: 6000}
+	current := SelfAssessmentLiability{TaxYear: "2024-2025", TotalTax: 4000}
+
+	// When:
+	payments := GetSelfAssessmentPaymentSchedule(previous, current)
+
+	// Then:
+	assert.Len(t, payments, 5)
+	assert.Equal(t, -2000.0, payments[2].Amount)
+	assert.Equal(t, 2000.0, payments[3].Amount)
+}
+
+func Test_GetSelfAssessmentPaymentScheduleBelowThresholds(t *testing.T) {
+	var tests = []struct {
+		name             string
+		previous         SelfAssessmentLiability
+		current          SelfAssessmentLiability
+		expectedPayments []SelfAssessmentPayment
+	}{
+		{
+			"the bills are less than £1000",
+			SelfAssessmentLiability{TaxYear: "2024-2025", TotalTax: 999},
+			SelfAssessmentLiability{TaxYear: "2025-2026", TotalTax: 900},
+			[]SelfAssessmentPayment{{BalancingPayment, "2025-2026", dateOf("31-01-2027"), 900}},
+		},
+		{
+			// only 1500 is due through the self assessment, but more than 80% was paid through PAYE
+			"most of the tax was deducted at source",
+			SelfAssessmentLiability{TaxYear: "2024-2025", TotalTax: 10000, DeductedAtSource: 8500},
+			SelfAssessmentLiability{TaxYear: "2025-2026", TotalTax: 10000, DeductedAtSource: 8500},
+			[]SelfAssessmentPayment{{BalancingPayment, "2025-2026", dateOf("31-01-2027"), 1500}},
+		},
+		{
+			"payments on account for the next year only",
+			SelfAssessmentLiability{TaxYear: "2024-2025"},
+			SelfAssessmentLiability{TaxYear: "2025-2026", TotalTax: 5000.01, DeductedAtSource: 1000},
+			[]SelfAssessmentPayment{
+				{BalancingPayment, "2025-2026", dateOf("31-01-2027"), 4000.01},
+				{FirstPaymentOnAccount, "2026-2027", dateOf("31-01-2027"), 2000.01},
+				{SecondPaymentOnAccount, "2026-2027", dateOf("31-07-2027"), 2000.01},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// When:
+			payments := GetSelfAssessmentPaymentSchedule(tt.previous, tt.current)
+
+			// Then:
+			assert.Equal(t, tt.expectedPayments, payments)
+		})
+	}
+}
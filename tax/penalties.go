@@ -0,0 +1,321 @@
+package tax
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/conf"
+)
+
+const (
+	// company tax return (CT600)
+	// https://www.gov.uk/company-tax-returns/penalties-for-late-filing
+	ct600LatePenalty            = 100.0
+	ct600ThreeMonthsLatePenalty = 200.0
+	ct600RepeatedLatePenalty    = 500.0 // the third late return in a row
+	ct600RepeatedThreeMonths    = 1000.0
+	ct600TaxGearedRate          = 0.1 // of the unpaid tax, 18 and 24 months after the period end
+
+	// self assessment
+	// https://www.gov.uk/estimate-self-assessment-penalties
+	saLatePenalty      = 100.0
+	saDailyPenalty     = 10.0 // after 3 months late, for up to 90 days
+	saDailyPenaltyDays = 90
+	saTaxGearedRate    = 0.05 // of the tax due, when the return is 6 and 12 months late
+	saTaxGearedMinimum = 300.0
+	saLatePaymentRate  = 0.05 // of the tax unpaid after 30 days, 6 months and 12 months
+
+	// MTD VAT late submission points
+	// https://www.gov.uk/guidance/how-late-submission-penalties-work-if-you-submit-your-vat-return-late
+	vatLateSubmissionPenalty = 200.0
+	vatPointsExpiryMonths    = 24
+
+	daysInYearForInterest = 365
+)
+
+type (
+	// TaxPayment is a payment made to HMRC
+	TaxPayment struct {
+		Date   time.Time
+		Amount float64
+	}
+
+	// Obligation is a deadline together with what was actually done
+	Obligation struct {
+		Deadline Deadline
+		Amount   float64   // tax due by the deadline, or the tax of the period for returns
+		Filed    time.Time // when the return was filed, zero if it is not filed yet
+		Payments []TaxPayment
+	}
+
+	// PenaltyEstimate is the estimated penalties and interest for a missed deadline
+	PenaltyEstimate struct {
+		Deadline       Deadline
+		DaysLate       int
+		Unpaid         float64 // still unpaid now
+		FilingPenalty  float64
+		PaymentPenalty float64
+		Interest       float64
+		Points         int // VAT late submission points after this return
+	}
+)
+
+// EstimatePenalties estimates HMRC penalties and late payment interest for the obligations due before now.
+// Only obligations with penalties, interest or VAT points are returned.
+// VAT late submission points expire after 24 months, and at the threshold they are reset only after
+// a period of compliance (all the returns of the period submitted on time).
+// Please refer to unit tests for examples
+func EstimatePenalties(obligations []Obligation, vatPeriod VATReturnPeriod, now time.Time) []PenaltyEstimate {
+	sorted := make([]Obligation, len(obligations))
+	copy(sorted, obligations)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Deadline.Date.Before(sorted[j].Deadline.Date)
+	})
+
+	var estimates []PenaltyEstimate
+	var lateCT600InRow int
+	var vatPoints []time.Time // when the points were given
+	var lastLateVAT time.Time
+
+	for _, o := range sorted {
+		due := o.Deadline.Date
+		if !due.Before(now) {
+			continue
+		}
+
+		estimate := PenaltyEstimate{Deadline: o.Deadline, Unpaid: roundPennies(unpaidOn(o, now))}
+		filed := o.Filed
+		if filed.IsZero() {
+			filed = now
+		}
+
+		switch o.Deadline.Type {
+		case CorporationTaxReturn:
+			if filed.After(due) {
+				lateCT600InRow++
+				estimate.FilingPenalty = ct600FilingPenalty(o, filed, lateCT600InRow)
+			} else {
+				lateCT600InRow = 0
+			}
+
+		case SelfAssessmentReturn:
+			if filed.After(due) {
+				estimate.FilingPenalty = saFilingPenalty(o, filed)
+			}
+			estimate.PaymentPenalty = saPaymentPenalty(o, now)
+			estimate.Interest = lateInterest(o, now)
+
+		case VATReturn:
+			// the default surcharge before 2023 is not supported
+			if due.Before(conf.VATLatePaymentPenalties[0].Since) {
+				break
+			}
+
+			// points expire, unless the threshold is reached
+			threshold := vatPointsThreshold(vatPeriod)
+			if len(vatPoints) < threshold {
+				for len(vatPoints) > 0 && !vatPoints[0].AddDate(0, vatPointsExpiryMonths, 0).After(due) {
+					vatPoints = vatPoints[1:]
+				}
+			} else if lastLateVAT.AddDate(0, vatCompliancePeriodMonths(vatPeriod), 0).Before(due) {
+				vatPoints = nil
+			}
+
+			if filed.After(due) {
+				lastLateVAT = due
+				if len(vatPoints) >= threshold {
+					estimate.FilingPenalty = vatLateSubmissionPenalty
+				} else {
+					vatPoints = append(vatPoints, due)
+				}
+			}
+			estimate.Points = len(vatPoints)
+			estimate.PaymentPenalty = vatPaymentPenalty(o, now)
+			estimate.Interest = lateInterest(o, now)
+
+		case CorporationTaxPayment, PAYEPayment, Class1ANIPayment:
+			estimate.Interest = lateInterest(o, now)
+		}
+
+		// the return or the payment, whichever is later
+		var lateUntil time.Time
+		if o.Deadline.Type == CorporationTaxReturn || o.Deadline.Type == SelfAssessmentReturn || o.Deadline.Type == VATReturn {
+			lateUntil = filed
+		}
+		if paid := lastPaymentDate(o, now); o.Deadline.Type != CorporationTaxReturn && o.Amount > 0 && paid.After(lateUntil) {
+			lateUntil = paid
+		}
+		estimate.DaysLate = int(math.Max(0, daysBetween(due, lateUntil)))
+
+		if estimate.FilingPenalty > 0 || estimate.PaymentPenalty > 0 || estimate.Interest > 0 || estimate.Points > 0 {
+			estimates = append(estimates, estimate)
+		}
+	}
+
+	return estimates
+}
+
+// Total is the penalties and the interest together
+func (e PenaltyEstimate) Total() float64 {
+	return roundPennies(e.FilingPenalty + e.PaymentPenalty + e.Interest)
+}
+
+// £100 for a late return, £200 if it is 3 months late, £500 and £1000 for the third late return in a row.
+// If it is 6 months late, 10% of the unpaid tax, and another 10% if it is 12 months late
+func ct600FilingPenalty(o Obligation, filed time.Time, lateInRow int) float64 {
+	due := o.Deadline.Date
+
+	penalty, threeMonths := ct600LatePenalty, ct600ThreeMonthsLatePenalty
+	if lateInRow >= 3 {
+		penalty, threeMonths = ct600RepeatedLatePenalty, ct600RepeatedThreeMonths
+	}
+	if filed.After(due.AddDate(0, 3, 0)) {
+		penalty = threeMonths
+	}
+
+	for _, months := range []int{6, 12} {
+		if date := due.AddDate(0, months, 0); filed.After(date) {
+			penalty = penalty + ct600TaxGearedRate*unpaidOn(o, date)
+		}
+	}
+	return roundPennies(penalty)
+}
+
+// £100 for a late return, then £10 a day after 3 months, up to 90 days. 5% of the tax or £300 (whichever is
+// more) if it is 6 months late, and again if it is 12 months late
+func saFilingPenalty(o Obligation, filed time.Time) float64 {
+	due := o.Deadline.Date
+	penalty := saLatePenalty
+
+	if threeMonths := due.AddDate(0, 3, 0); filed.After(threeMonths) {
+		penalty = penalty + saDailyPenalty*math.Min(saDailyPenaltyDays, daysBetween(threeMonths, filed))
+	}
+	for _, months := range []int{6, 12} {
+		if filed.After(due.AddDate(0, months, 0)) {
+			penalty = penalty + math.Max(saTaxGearedMinimum, saTaxGearedRate*o.Amount)
+		}
+	}
+	return roundPennies(penalty)
+}
+
+// 5% of the tax still unpaid 30 days, 6 months and 12 months after the deadline
+func saPaymentPenalty(o Obligation, now time.Time) float64 {
+	due := o.Deadline.Date
+	var penalty float64
+	for _, date := range []time.Time{due.AddDate(0, 0, 30), due.AddDate(0, 6, 0), due.AddDate(0, 12, 0)} {
+		if date.Before(now) {
+			penalty = penalty + saLatePaymentRate*unpaidOn(o, date)
+		}
+	}
+	return roundPennies(penalty)
+}
+
+// the first penalty is a percent of the tax unpaid on day 15 and day 30, the second one is charged daily
+// from day 31 until the tax is paid
+// https://www.gov.uk/guidance/how-late-payment-penalties-work-if-you-pay-vat-late
+func vatPaymentPenalty(o Obligation, now time.Time) float64 {
+	due := o.Deadline.Date
+	rates := getVATLatePaymentPenalty(due)
+
+	var penalty float64
+	if day15 := due.AddDate(0, 0, 15); day15.Before(now) {
+		penalty = penalty + rates.Day15Rate*unpaidOn(o, day15)
+	}
+	if day30 := due.AddDate(0, 0, 30); day30.Before(now) {
+		penalty = penalty + rates.Day30Rate*unpaidOn(o, day30)
+	}
+	for day := due.AddDate(0, 0, 31); day.Before(now); day = day.AddDate(0, 0, 1) {
+		unpaid := unpaidOn(o, day)
+		if unpaid <= 0 {
+			break
+		}
+		penalty = penalty + unpaid*rates.YearlyRate/daysInYearForInterest
+	}
+	return roundPennies(penalty)
+}
+
+// simple interest on the unpaid tax for every day after the deadline, at the base rate plus the spread
+func lateInterest(o Obligation, now time.Time) float64 {
+	var interest float64
+	for day := o.Deadline.Date.AddDate(0, 0, 1); day.Before(now); day = day.AddDate(0, 0, 1) {
+		unpaid := unpaidOn(o, day.AddDate(0, 0, -1))
+		if unpaid <= 0 {
+			break
+		}
+		interest = interest + unpaid*GetLatePaymentInterestRate(day)/daysInYearForInterest
+	}
+	return roundPennies(interest)
+}
+
+// GetLatePaymentInterestRate returns HMRC late payment interest rate on the date
+func GetLatePaymentInterestRate(date time.Time) float64 {
+	return rateOn(conf.BankBaseRates, date) + rateOn(conf.LatePaymentInterestSpreads, date)
+}
+
+// the tax still unpaid at the end of the date
+func unpaidOn(o Obligation, date time.Time) float64 {
+	unpaid := o.Amount
+	for _, p := range o.Payments {
+		if !p.Date.After(date) {
+			unpaid = unpaid - p.Amount
+		}
+	}
+	return math.Max(0, unpaid)
+}
+
+// when the tax was paid in full, or now if it is still unpaid
+func lastPaymentDate(o Obligation, now time.Time) time.Time {
+	if unpaidOn(o, now) > 0 {
+		return now
+	}
+	var last time.Time
+	for _, p := range o.Payments {
+		if p.Date.After(last) {
+			last = p.Date
+		}
+	}
+	return last
+}
+
+func rateOn(changes []conf.RateChange, date time.Time) float64 {
+	var rate float64
+	for _, c := range changes {
+		if !c.Since.After(date) {
+			rate = c.Rate
+		}
+	}
+	return rate
+}
+
+func getVATLatePaymentPenalty(due time.Time) conf.VATLatePaymentPenalty {
+	penalty := conf.VATLatePaymentPenalties[0]
+	for _, p := range conf.VATLatePaymentPenalties {
+		if !p.Since.After(due) {
+			penalty = p
+		}
+	}
+	return penalty
+}
+
+// how many points lead to a penalty
+func vatPointsThreshold(period VATReturnPeriod) int {
+	switch period {
+	case MonthlyReturns:
+		return 5
+	case AnnualReturns:
+		return 2
+	}
+	return 4
+}
+
+// how long all the returns should be submitted on time to reset the points at the threshold
+func vatCompliancePeriodMonths(period VATReturnPeriod) int {
+	switch period {
+	case MonthlyReturns:
+		return 6
+	case AnnualReturns:
+		return 24
+	}
+	return 12
+}
@@ -0,0 +1,172 @@
+package tax
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_EstimatePenaltiesInterest(t *testing.T) {
+
+	// Given: paid 30 days late, the rate is 5.25% + 2.5%
+	obligations := []Obligation{{
+		Deadline: Deadline{Type: CorporationTaxPayment, Date: dateOf("01-01-2024")},
+		Amount:   10000,
+		Payments: []TaxPayment{{dateOf("31-01-2024"), 10000}},
+	}}
+
+	// When:
+	estimates := EstimatePenalties(obligations, QuarterlyReturns, dateOf("01-06-2024"))
+
+	// Then:
+	assert.Equal(t, []PenaltyEstimate{
+		{Deadline: obligations[0].Deadline, DaysLate: 30, Interest: 63.70},
+	}, estimates)
+}
+
+func Test_EstimatePenaltiesCT600(t *testing.T) {
+	var tests = []struct {
+		name            string
+		filed           string
+		expectedPenalty float64
+	}{
+		{"less than 3 months late", "15-05-2025", 100},
+		{"more than 3 months late", "15-08-2025", 200},
+		// £200 plus 10% of the unpaid tax
+		{"not filed for 7 months", "", 700},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// Given:
+			o := Obligation{Deadline: Deadline{Type: CorporationTaxReturn, Date: dateOf("31-03-2025")}, Amount: 5000}
+			if tt.filed != "" {
+				o.Filed = dateOf(tt.filed)
+			}
+
+			// When:
+			estimates := EstimatePenalties([]Obligation{o}, QuarterlyReturns, dateOf("01-11-2025"))
+
+			// Then:
+			assert.Len(t, estimates, 1)
+			assert.Equal(t, tt.expectedPenalty, estimates[0].FilingPenalty)
+		})
+	}
+}
+
+func Test_EstimatePenaltiesCT600ThirdLateInRow(t *testing.T) {
+
+	// Given:
+	var obligations []Obligation
+	for _, due := range []string{"31-03-2023", "31-03-2024", "31-03-2025"} {
+		obligations = append(obligations, Obligation{
+			Deadline: Deadline{Type: CorporationTaxReturn, Date: dateOf(due)},
+			Filed:    dateOf(due).AddDate(0, 0, 1),
+		})
+	}
+
+	// When:
+	estimates := EstimatePenalties(obligations, QuarterlyReturns, dateOf("01-11-2025"))
+
+	// Then:
+	assert.Len(t, estimates, 3)
+	assert.Equal(t, 100.0, estimates[0].FilingPenalty)
+	assert.Equal(t, 100.0, estimates[1].FilingPenalty)
+	assert.Equal(t, 500.0, estimates[2].FilingPenalty)
+}
+
+func Test_EstimatePenaltiesSelfAssessment(t *testing.T) {
+
+	// Given: filed and paid 10 days late
+	o := Obligation{
+		Deadline: Deadline{Type: SelfAssessmentReturn, Date: dateOf("31-01-2025")},
+		Amount:   2000,
+		Filed:    dateOf("10-02-2025"),
+		Payments: []TaxPayment{{dateOf("10-02-2025"), 2000}},
+	}
+
+	// When:
+	estimates := EstimatePenalties([]Obligation{o}, QuarterlyReturns, dateOf("01-06-2025"))
+
+	// Then: the base rate went down from 4.75% to 4.5% on 6 February
+	assert.Equal(t, []PenaltyEstimate{
+		{Deadline: o.Deadline, DaysLate: 10, FilingPenalty: 100, Interest: 3.90},
+	}, estimates)
+}
+
+func Test_EstimatePenaltiesSelfAssessmentNotFiled(t *testing.T) {
+
+	// Given:
+	o := Obligation{
+		Deadline: Deadline{Type: SelfAssessmentReturn, Date: dateOf("31-01-2025")},
+		Amount:   2000,
+	}
+
+	// When:
+	estimates := EstimatePenalties([]Obligation{o}, QuarterlyReturns, dateOf("01-09-2025"))
+
+	// Then: £100, 90 days x £10 and £300, then 5% of the unpaid tax after 30 days and 6 months
+	assert.Len(t, estimates, 1)
+	assert.Equal(t, 1300.0, estimates[0].FilingPenalty)
+	assert.Equal(t, 200.0, estimates[0].PaymentPenalty)
+	assert.Equal(t, 2000.0, estimates[0].Unpaid)
+	assert.True(t, estimates[0].Interest > 0)
+}
+
+func Test_EstimatePenaltiesVATPoints(t *testing.T) {
+
+	// Given: five quarterly returns submitted a day late
+	var obligations []Obligation
+	for _, due := range []string{"07-05-2023", "07-08-2023", "07-11-2023", "07-02-2024", "07-05-2024"} {
+		obligations = append(obligations, Obligation{
+			Deadline: Deadline{Type: VATReturn, Date: dateOf(due)},
+			Filed:    dateOf(due).AddDate(0, 0, 1),
+		})
+	}
+
+	// When:
+	estimates := EstimatePenalties(obligations, QuarterlyReturns, dateOf("01-06-2024"))
+
+	// Then: the threshold is 4 points, then each late return is £200
+	assert.Len(t, estimates, 5)
+	for i, expectedPoints := range []int{1, 2, 3, 4, 4} {
+		assert.Equal(t, expectedPoints, estimates[i].Points)
+	}
+	assert.Equal(t, 0.0, estimates[3].FilingPenalty)
+	assert.Equal(t, 200.0, estimates[4].FilingPenalty)
+}
+
+func Test_EstimatePenaltiesVATPointsExpire(t *testing.T) {
+
+	// Given: the first late return was more than 24 months ago
+	obligations := []Obligation{
+		{Deadline: Deadline{Type: VATReturn, Date: dateOf("07-05-2023")}, Filed: dateOf("08-05-2023")},
+		{Deadline: Deadline{Type: VATReturn, Date: dateOf("07-08-2025")}, Filed: dateOf("08-08-2025")},
+	}
+
+	// When:
+	estimates := EstimatePenalties(obligations, QuarterlyReturns, dateOf("01-10-2025"))
+
+	// Then:
+	assert.Equal(t, 1, estimates[1].Points)
+}
+
+func Test_EstimatePenaltiesVATLatePayment(t *testing.T) {
+
+	// Given: filed on time, but paid 20 days late
+	o := Obligation{
+		Deadline: Deadline{Type: VATReturn, Date: dateOf("07-05-2024")},
+		Amount:   10000,
+		Filed:    dateOf("01-05-2024"),
+		Payments: []TaxPayment{{dateOf("27-05-2024"), 10000}},
+	}
+
+	// When:
+	estimates := EstimatePenalties([]Obligation{o}, QuarterlyReturns, dateOf("01-10-2024"))
+
+	// Then: 2% of the tax unpaid on day 15
+	assert.Equal(t, []PenaltyEstimate{
+		{Deadline: o.Deadline, DaysLate: 20, PaymentPenalty: 200, Interest: 42.47},
+	}, estimates)
+}
@@ -0,0 +1,117 @@
+package tax
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+const defaultPlannerStep = 100.0
+
+type (
+	// SalaryPlannerInput is what we know in April, before the tax year begins
+	SalaryPlannerInput struct {
+		CompanyProfit         float64 // expected company profit before the director's salary
+		OtherIncome           float64 // director's other taxable non-dividend income (employment elsewhere, rent)
+		TaxYear               string  // personal tax year, like "2025-2026"
+		Residency             Residency
+		AccountingPeriodStart time.Time
+		EmploymentAllowance   bool    // can the company claim the Employment Allowance (not for sole director companies)
+		Step                  float64 // how precise is the search, £100 by default
+	}
+
+	// SalaryDividendSplit is one combination of salary and dividends with all the taxes paid
+	SalaryDividendSplit struct {
+		Salary         float64
+		Dividends      float64
+		CorporationTax float64
+		EmployerNI     float64
+		EmployeeNI     float64
+		IncomeTax      float64
+		DividendTax    float64
+		TotalTax       float64
+		TakeHome       float64 // what director has after all the taxes
+	}
+)
+
+// PlanSalaryAndDividends searches salary and dividends combinations and returns the one with the lowest
+// combined tax, as well as all the checked combinations sorted by salary. For each salary, the company pays
+// employer's NI, then the corporation tax on what's left, and the rest of the profit is paid out as dividends.
+// Please refer to unit tests for examples
+func PlanSalaryAndDividends(in SalaryPlannerInput) (SalaryDividendSplit, []SalaryDividendSplit) {
+
+	step := in.Step
+	if step <= 0 {
+		step = defaultPlannerStep
+	}
+
+	// notable salaries: NI thresholds and personal allowance
+	rates := getNationalInsuranceRates(in.TaxYear)
+	candidates := map[float64]bool{
+		rates.SecondaryThreshold:             true,
+		rates.PrimaryThreshold:               true,
+		getYearPersonalAllowance(in.TaxYear): true,
+	}
+	for salary := 0.0; salary <= in.CompanyProfit; salary = salary + step {
+		candidates[salary] = true
+	}
+
+	var splits []SalaryDividendSplit
+	for salary := range candidates {
+		if salary > in.CompanyProfit {
+			continue
+		}
+		if split, ok := calculateSplit(salary, in); ok {
+			splits = append(splits, split)
+		}
+	}
+
+	sort.Slice(splits, func(i, j int) bool {
+		return splits[i].Salary < splits[j].Salary
+	})
+
+	var best SalaryDividendSplit
+	for i, split := range splits {
+		if i == 0 || split.TotalTax < best.TotalTax {
+			best = split
+		}
+	}
+
+	return best, splits
+}
+
+// calculates all the taxes for the salary. Returns false if the company can't afford the salary
+func calculateSplit(salary float64, in SalaryPlannerInput) (SalaryDividendSplit, bool) {
+	employeeNI, employerNI := CalculateClass1NI(salary, in.TaxYear)
+	if in.EmploymentAllowance {
+		employerNI = math.Max(0, employerNI-getNationalInsuranceRates(in.TaxYear).EmploymentAllowance)
+	}
+
+	// salary and employer's NI are deductible for corporation tax
+	profit := in.CompanyProfit - salary - employerNI
+	if profit < 0 {
+		return SalaryDividendSplit{}, false
+	}
+
+	corporationTax := math.Round(CalculateCorporateTax(profit, in.AccountingPeriodStart)*100) / 100
+	dividends := math.Round((profit-corporationTax)*100) / 100
+
+	incomeTax, dividendTax := CalculateDirectorSelfAssessmentTax(salary+in.OtherIncome, dividends, in.TaxYear, in.Residency)
+
+	// income tax on the other income would be paid anyway
+	otherIncomeTax, _ := CalculateDirectorSelfAssessmentTax(in.OtherIncome, 0, in.TaxYear, in.Residency)
+	incomeTax = incomeTax - otherIncomeTax
+
+	totalTax := corporationTax + employerNI + employeeNI + incomeTax + dividendTax
+	return SalaryDividendSplit{
+		Salary:         salary,
+		Dividends:      dividends,
+		CorporationTax: corporationTax,
+		EmployerNI:     employerNI,
+		EmployeeNI:     employeeNI,
+		IncomeTax:      incomeTax,
+		DividendTax:    dividendTax,
+		TotalTax:       math.Round(totalTax*100) / 100,
+		TakeHome:       math.Round((salary+dividends-employeeNI-incomeTax-dividendTax)*100) / 100,
+	}, true
+}
@@ -0,0 +1,96 @@
+package tax
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_PlanSalaryAndDividends(t *testing.T) {
+	var tests = []struct {
+		profit              float64
+		taxYear             string
+		accPeriodStart      string
+		employmentAllowance bool
+		expectedSalary      float64
+	}{
+		// sole director: salary above the secondary threshold costs 15% employer's NI,
+		// but it is still cheaper than the corporation tax and dividend tax until the personal allowance
+		{60000, "2025-2026", "01-04-2025", false, 12570},
+
+		// with the Employment Allowance there is no employer's NI at all
+		{60000, "2025-2026", "01-04-2025", true, 12570},
+
+		// back in 2020 the employee's NI made any salary above the primary threshold more expensive
+		{60000, "2020-2021", "01-04-2020", false, 9500},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("Expected salary £%.0f for profit £%.0f in %s", tt.expectedSalary, tt.profit, tt.taxYear),
+			func(t *testing.T) {
+
+				// When:
+				best, all := PlanSalaryAndDividends(SalaryPlannerInput{
+					CompanyProfit:         tt.profit,
+					TaxYear:               tt.taxYear,
+					AccountingPeriodStart: dateOf(tt.accPeriodStart),
+					EmploymentAllowance:   tt.employmentAllowance,
+				})
+
+				// Then:
+				assert.Equal(t, tt.expectedSalary, best.Salary)
+				for _, split := range all {
+					assert.True(t, best.TotalTax <= split.TotalTax)
+				}
+			},
+		)
+	}
+}
+
+func Test_PlanSalaryAndDividendsTaxesAddUp(t *testing.T) {
+
+	// When:
+	best, _ := PlanSalaryAndDividends(SalaryPlannerInput{
+		CompanyProfit:         30000,
+		TaxYear:               "2025-2026",
+		AccountingPeriodStart: dateOf("01-04-2025"),
+		EmploymentAllowance:   true,
+	})
+
+	// Then: all the profit is either taxed or taken home
+	assert.InDelta(t, 30000.0, best.TotalTax+best.TakeHome, 0.01)
+	assert.Equal(t, 12570.0, best.Salary)
+	assert.Equal(t, 3311.70, best.CorporationTax)
+	assert.Equal(t, 14118.30, best.Dividends)
+}
+
+func Test_CalculateClass1NI(t *testing.T) {
+	var tests = []struct {
+		salary             float64
+		taxYear            string
+		expectedEmployee   float64
+		expectedEmployerNI float64
+	}{
+		{8000, "2025-2026", 0, 450},
+		{12570, "2025-2026", 0, 1135.50},
+		{30000, "2025-2026", 1394.40, 3750},
+
+		// above the upper earnings limit: (50270 - 12570) x 8% + (60000 - 50270) x 2%
+		{60000, "2025-2026", 3210.60, 8250},
+
+		{9500, "2020-2021", 0, 98.26},
+		{20000, "2020-2021", 1260, 1547.26},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("Salary £%.0f in %s", tt.salary, tt.taxYear), func(t *testing.T) {
+
+			// When:
+			employee, employer := CalculateClass1NI(tt.salary, tt.taxYear)
+
+			// Then:
+			assert.Equal(t, tt.expectedEmployee, employee)
+			assert.Equal(t, tt.expectedEmployerNI, employer)
+		})
+	}
+}
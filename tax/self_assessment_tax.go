@@ -6,10 +6,7 @@ import (
 	"time"
 )
 
-const (
-	personalAllowance = 12500.0
-	weeksInAYear      = 52
-)
+const weeksInAYear = 52
 
 type Rate int
 
@@ -18,18 +15,32 @@ const (
 	BasicRate
 	HigherRate
 	AdditionalRate
+
+	// Scottish rates
+	StarterRate
+	IntermediateRate
+	AdvancedRate
+	TopRate
 )
 
 func (r Rate) PrettyString() string {
 	switch r {
 	case PersonalAllowance:
-		return "Personal Allowance (0%)"
+		return "Personal Allowance"
+	case StarterRate:
+		return "Starter Rate"
 	case BasicRate:
-		return "Basic Rate (20%)"
+		return "Basic Rate"
+	case IntermediateRate:
+		return "Intermediate Rate"
 	case HigherRate:
-		return "Higher Rate (40%)"
+		return "Higher Rate"
+	case AdvancedRate:
+		return "Advanced Rate"
 	case AdditionalRate:
-		return "Additional Rate (45%)"
+		return "Additional Rate"
+	case TopRate:
+		return "Top Rate"
 	}
 	return ""
 }
@@ -54,49 +65,32 @@ func _generateTaxYearDatesForGivenYear(year int) (time.Time, time.Time, time.Tim
 
 // Tax Year is from 6 April to 5 April
 // https://www.gov.uk/income-tax-rates
-func CalculateSelfAssessmentTax(income, costs float64) float64 {
+func CalculateSelfAssessmentTax(income, costs float64, taxYear string, residency Residency) float64 {
 
 	profitBeforeTaxes := income - costs
 
-	personalTax := getPersonalTaxFrom(profitBeforeTaxes)
+	personalTax := getPersonalTaxFrom(profitBeforeTaxes, taxYear, residency)
 
 	class2NITax, class4NITax := getNITax(profitBeforeTaxes)
 
 	return personalTax + class2NITax + class4NITax
 }
 
-//    Band                    Taxable income         Tax rate
+//    Band                    Taxable income         Tax rate (2020-2021, England)
 //    -------------           --------------         ---------
 //    Personal Allowance      Up to £12,500          0%
 //    Basic rate              £12,501 to £50,000     20%
 //    Higher rate             £50,001 to £150,000    40%
 //    Additional rate         over £150,000          45% (rich bastard!)
 //
+// Bands are different for every year, and Scottish taxpayers have their own bands and rates,
+// see conf.IncomeTaxBands and conf.ScottishIncomeTaxBands
+//
 // please refer to unit tests for examples
 //
-func getPersonalTaxFrom(profitBeforeTaxes float64) float64 {
-
-	// personalAllowance IS DIFFERENT FOR YEARS!!!
-	// https://www.gov.uk/government/publications/rates-and-allowances-income-tax/income-tax-rates-and-allowances-current-and-past#tax-rates-and-bands
-	if profitBeforeTaxes <= personalAllowance {
-		return 0
-	}
-
-	allowance := getPersonalAllowance(profitBeforeTaxes)
-
-	// Basic rate (£12,501 to £50,000) - 20%
-	taxableProfit := profitBeforeTaxes - allowance
-	if profitBeforeTaxes <= 50000 {
-		return taxableProfit * 0.2
-	}
-
-	// Higher rate (£50,001 to £150,000) - 40%
-	if profitBeforeTaxes <= 150000 {
-		return 37500*0.2 + (taxableProfit-37500)*0.4
-	}
-
-	// Additional rate (over £150,000) - 45%
-	return 37500*0.2 + (100000+12500)*0.4 + (profitBeforeTaxes-150000)*0.45
+func getPersonalTaxFrom(profitBeforeTaxes float64, taxYear string, residency Residency) float64 {
+	taxableProfit := math.Max(0, profitBeforeTaxes-getPersonalAllowance(profitBeforeTaxes, taxYear))
+	return taxOn(0, taxableProfit, getIncomeTaxBands(residency, taxYear))
 }
 
 // Anyone earning more than £100,000 per year will have their personal
@@ -105,17 +99,14 @@ func getPersonalTaxFrom(profitBeforeTaxes float64) float64 {
 //
 // The Personal Allowance goes down by £1 for every £2 of
 // income above the £100,000 limit. It can go down to zero.
-// You do not get a Personal Allowance on taxable income over £125,000.
 //
 // https://www.gov.uk/government/publications/rates-and-allowances-income-tax/income-tax-rates-and-allowances-current-and-past#personal-allowances
-func getPersonalAllowance(profitBeforeTaxes float64) float64 {
-	if profitBeforeTaxes < 100000 {
+func getPersonalAllowance(profitBeforeTaxes float64, taxYear string) float64 {
+	personalAllowance := getYearPersonalAllowance(taxYear)
+	if profitBeforeTaxes < personalAllowanceTaperStart {
 		return personalAllowance
 	}
-	if profitBeforeTaxes > 125000 {
-		return 0
-	}
-	return personalAllowance - (profitBeforeTaxes-100000)/2
+	return math.Max(0, personalAllowance-(profitBeforeTaxes-personalAllowanceTaperStart)/2)
 }
 
 // Class 	Rate for tax year 2020 to 2021
@@ -145,28 +136,27 @@ func getNITax(profitBeforeTaxes float64) (float64, float64) {
 	return math.Round(class2), math.Round(class4)
 }
 
-// returns current rate, how much before next threshold, and is it warning (when less than 20% left) or not
-func HowMuchBeforeNextThreshold(personalIncome float64) (Rate, float64, bool) {
+// returns current rate, how much before next threshold, and is it warning (when less than 20% left) or not.
+// Thresholds are the total income, when the next band starts for the residency in the tax year
+func HowMuchBeforeNextThreshold(personalIncome float64, taxYear string, residency Residency) (Rate, float64, bool) {
 	const percentToWarning = 0.2
-	left := 0.0
-	isWarning := false
+	personalAllowance := getYearPersonalAllowance(taxYear)
 	if personalIncome < personalAllowance {
-		left = personalAllowance - personalIncome
-		isWarning = (left / personalIncome) <= percentToWarning
-		return PersonalAllowance, left, isWarning
-	}
-
-	if personalIncome < 50000 {
-		left = 50000 - personalIncome
-		isWarning = (left / 50000) <= percentToWarning
-		return BasicRate, left, isWarning
+		left := personalAllowance - personalIncome
+		return PersonalAllowance, left, (left / personalIncome) <= percentToWarning
 	}
 
-	if personalIncome < 150000 {
-		left = 150000 - personalIncome
-		isWarning = (left / 150000) <= percentToWarning
-		return HigherRate, left, isWarning
+	bands := getIncomeTaxBands(residency, taxYear)
+	for _, band := range bands {
+		if band.from == band.to || math.IsInf(band.to, 1) {
+			continue
+		}
+		threshold := incomeForTaxable(band.to, personalAllowance)
+		if personalIncome < threshold {
+			left := threshold - personalIncome
+			return band.rate, left, (left / threshold) <= percentToWarning
+		}
 	}
 
-	return AdditionalRate, 0, true
+	return bands[len(bands)-1].rate, 0, true
 }
@@ -22,7 +22,7 @@ func Test_CalculateSelfAssessmentTax(t *testing.T) {
 			func(t *testing.T) {
 
 				// When:
-				selfAssessmentTax := CalculateSelfAssessmentTax(tt.income, tt.costs)
+				selfAssessmentTax := CalculateSelfAssessmentTax(tt.income, tt.costs, "2020-2021", EnglandAndNorthernIreland)
 
 				// Then:
 				assert.Equal(t, tt.expectedTax, selfAssessmentTax)
@@ -63,7 +63,7 @@ func Test_getPersonalTaxFrom(t *testing.T) {
 			func(t *testing.T) {
 
 				// When:
-				tax := getPersonalTaxFrom(tt.profitBeforeTaxes)
+				tax := getPersonalTaxFrom(tt.profitBeforeTaxes, "2020-2021", EnglandAndNorthernIreland)
 
 				// Then:
 				assert.Equal(t, tt.expectedTax, tax)
@@ -72,7 +72,40 @@ func Test_getPersonalTaxFrom(t *testing.T) {
 	}
 }
 
+func Test_getPersonalTaxFromByResidency(t *testing.T) {
+	var tests = []struct {
+		profitBeforeTaxes float64
+		residency         Residency
+		expectedTax       float64
+	}{
+		// starter 2827 x 19% + basic 12094 x 20% + intermediate 2509 x 21%
+		{30000, Scotland, 3482.82},
+		{30000, EnglandAndNorthernIreland, 3486},
+
+		// starter, basic, intermediate 16171 x 21%, higher 31338 x 42% and advanced 5000 x 45%
+		{80000, Scotland, 21763.80},
+
+		// basic 37700 x 20% + higher 29730 x 40%, Welsh rates are the same as in England
+		{80000, EnglandAndNorthernIreland, 19432},
+		{80000, Wales, 19432},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("Expected £%.2f tax from £%.0f income in %s", tt.expectedTax, tt.profitBeforeTaxes, tt.residency.PrettyString()),
+			func(t *testing.T) {
+
+				// When:
+				tax := getPersonalTaxFrom(tt.profitBeforeTaxes, "2025-2026", tt.residency)
+
+				// Then:
+				assert.InDelta(t, tt.expectedTax, tax, 0.001)
+			},
+		)
+	}
+}
+
 func Test_getPersonalAllowanceForRich(t *testing.T) {
+	const personalAllowance = 12500.0
 
 	var tests = []struct {
 		profitBeforeTaxes float64
@@ -106,7 +139,7 @@ func Test_getPersonalAllowanceForRich(t *testing.T) {
 			func(t *testing.T) {
 
 				// When:
-				allowance := getPersonalAllowance(tt.profitBeforeTaxes)
+				allowance := getPersonalAllowance(tt.profitBeforeTaxes, "2020-2021")
 
 				// Then:
 				assert.Equal(t, tt.expectedAllowance, allowance)
@@ -183,7 +216,7 @@ func Test_HowMuchBeforeNextThreshold(t *testing.T) {
 			func(t *testing.T) {
 
 				// When:
-				rate, leftBeforeNextThreshold, isWarning := HowMuchBeforeNextThreshold(tt.income)
+				rate, leftBeforeNextThreshold, isWarning := HowMuchBeforeNextThreshold(tt.income, "2020-2021", EnglandAndNorthernIreland)
 
 				// Then:
 				assert.Equal(t, tt.expectedRate, rate)
@@ -194,6 +227,87 @@ func Test_HowMuchBeforeNextThreshold(t *testing.T) {
 	}
 }
 
+func Test_HowMuchBeforeNextThresholdByResidency(t *testing.T) {
+
+	var tests = []struct {
+		income            float64
+		residency         Residency
+		expectedRate      Rate
+		expectedMoneyLeft float64
+		expectedIsWarning bool
+	}{
+		// Scottish thresholds are the personal allowance 12570 plus the band
+		{14000, Scotland, StarterRate, 1397.00, true},
+		{20000, Scotland, BasicRate, 7491.00, false},
+		{40000, Scotland, IntermediateRate, 3662.00, true},
+		{70000, Scotland, HigherRate, 5000.00, true},
+
+		// no personal allowance left above £125,140
+		{100000, Scotland, AdvancedRate, 25140.00, false},
+		{130000, Scotland, TopRate, 0.00, true},
+
+		{40000, EnglandAndNorthernIreland, BasicRate, 10270.00, false},
+		{60000, EnglandAndNorthernIreland, HigherRate, 65140.00, false},
+		{130000, Wales, AdditionalRate, 0.00, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("Expected %s when income is %.02f in %s",
+			tt.expectedRate.PrettyString(), tt.income, tt.residency.PrettyString()),
+			func(t *testing.T) {
+
+				// When:
+				rate, leftBeforeNextThreshold, isWarning := HowMuchBeforeNextThreshold(tt.income, "2025-2026", tt.residency)
+
+				// Then:
+				assert.Equal(t, tt.expectedRate, rate)
+				assert.InDelta(t, tt.expectedMoneyLeft, leftBeforeNextThreshold, 0.001)
+				assert.Equal(t, tt.expectedIsWarning, isWarning)
+			},
+		)
+	}
+}
+
+func Test_ParseResidency(t *testing.T) {
+	residency, err := ParseResidency("Scotland")
+	assert.Nil(t, err)
+	assert.Equal(t, Scotland, residency)
+
+	residency, err = ParseResidency("")
+	assert.Nil(t, err)
+	assert.Equal(t, EnglandAndNorthernIreland, residency)
+
+	_, err = ParseResidency("france")
+	assert.NotNil(t, err)
+}
+
+func Test_RateLabel(t *testing.T) {
+	var tests = []struct {
+		rate      Rate
+		residency Residency
+		expected  string
+	}{
+		{PersonalAllowance, EnglandAndNorthernIreland, "Personal Allowance (0%)"},
+		{BasicRate, EnglandAndNorthernIreland, "Basic Rate (20%)"},
+		{HigherRate, Wales, "Higher Rate (40%)"},
+		{AdditionalRate, EnglandAndNorthernIreland, "Additional Rate (45%)"},
+		{StarterRate, Scotland, "Starter Rate (19%)"},
+		{HigherRate, Scotland, "Higher Rate (42%)"},
+		{TopRate, Scotland, "Top Rate (48%)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+
+			// When:
+			label := tt.rate.Label("2025-2026", tt.residency)
+
+			// Then:
+			assert.Equal(t, tt.expected, label)
+		})
+	}
+}
+
 func Test_GetTaxYearDatesNowIsAfterApril(t *testing.T) {
 
 	// Given:
@@ -0,0 +1,44 @@
+package tax
+
+type (
+	// TaxAdjustment is a line of the corporation tax computation, like depreciation or capital allowances
+	TaxAdjustment struct {
+		Description string
+		Amount      float64
+	}
+
+	// TaxComputation turns the profit in the accounts into the taxable profit
+	TaxComputation struct {
+		AccountingProfit float64
+		AddBacks         []TaxAdjustment // not deductible for corporation tax, like depreciation or penalties
+		Deductions       []TaxAdjustment // deductible, but not in the accounts, like capital allowances
+		TaxableProfit    float64         // before the loss relief
+	}
+)
+
+// ComputeTaxableProfit starts from the accounting profit, adds back disallowable items and subtracts deductions.
+// Adjustments of zero are left out
+func ComputeTaxableProfit(accountingProfit float64, addBacks []TaxAdjustment, deductions []TaxAdjustment) TaxComputation {
+	computation := TaxComputation{
+		AccountingProfit: roundPennies(accountingProfit),
+		AddBacks:         []TaxAdjustment{},
+		Deductions:       []TaxAdjustment{},
+	}
+
+	taxable := accountingProfit
+	for _, a := range addBacks {
+		if a.Amount != 0 {
+			computation.AddBacks = append(computation.AddBacks, a)
+			taxable = taxable + a.Amount
+		}
+	}
+	for _, d := range deductions {
+		if d.Amount != 0 {
+			computation.Deductions = append(computation.Deductions, d)
+			taxable = taxable - d.Amount
+		}
+	}
+
+	computation.TaxableProfit = roundPennies(taxable)
+	return computation
+}
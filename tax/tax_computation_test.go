@@ -0,0 +1,31 @@
+package tax
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ComputeTaxableProfit(t *testing.T) {
+
+	// Given:
+	addBacks := []TaxAdjustment{
+		{"Depreciation", 1200},
+		{"Penalties and fines", 100},
+		{"Client entertaining", 0},
+	}
+	deductions := []TaxAdjustment{
+		{"Capital allowances", 3000},
+	}
+
+	// When:
+	computation := ComputeTaxableProfit(20000, addBacks, deductions)
+
+	// Then:
+	assert.Equal(t, TaxComputation{
+		AccountingProfit: 20000,
+		AddBacks:         []TaxAdjustment{{"Depreciation", 1200}, {"Penalties and fines", 100}},
+		Deductions:       []TaxAdjustment{{"Capital allowances", 3000}},
+		TaxableProfit:    18300,
+	}, computation)
+}
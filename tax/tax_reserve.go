@@ -0,0 +1,25 @@
+package tax
+
+import "math"
+
+// TaxReserve is the part of the bank balance which belongs to HMRC: the taxes accrued but not paid yet.
+// Every liability is never negative, a repayment due from HMRC is not counted until it arrives
+type TaxReserve struct {
+	BankBalance    float64 // of the latest imported transaction
+	CorporationTax float64 // of the finished periods not due yet and the current period so far
+	VAT            float64 // of the finished periods not due yet and the current period so far
+	PAYE           float64 // payroll months not paid to HMRC
+	S455           float64 // on the director's loan, it is refunded when the loan is repaid
+	Buffer         float64 // kept on the account anyway
+}
+
+// Total is how much should stay on the bank account
+func (r TaxReserve) Total() float64 {
+	return roundPennies(r.CorporationTax + r.VAT + r.PAYE + r.S455 + r.Buffer)
+}
+
+// SafeToWithdraw is how much can be taken out of the company today without going short for the taxes
+// Please refer to unit tests for examples
+func (r TaxReserve) SafeToWithdraw() float64 {
+	return math.Max(0, roundPennies(r.BankBalance-r.Total()))
+}
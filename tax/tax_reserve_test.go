@@ -0,0 +1,35 @@
+package tax
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaxReserveSafeToWithdraw(t *testing.T) {
+	var tests = []struct {
+		name                   string
+		reserve                TaxReserve
+		expectedTotal          float64
+		expectedSafeToWithdraw float64
+	}{
+		{"no taxes", TaxReserve{BankBalance: 10000}, 0, 10000},
+		{"all the taxes and the buffer", TaxReserve{BankBalance: 30000, CorporationTax: 9500.55, VAT: 3200.10,
+			PAYE: 850.20, S455: 3375, Buffer: 2000}, 18925.85, 11074.15},
+		{"taxes are more than the bank balance", TaxReserve{BankBalance: 5000, CorporationTax: 7000}, 7000, 0},
+		{"overdrawn", TaxReserve{BankBalance: -100, Buffer: 500}, 500, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// When:
+			total := tt.reserve.Total()
+			safe := tt.reserve.SafeToWithdraw()
+
+			// Then:
+			assert.Equal(t, tt.expectedTotal, total)
+			assert.Equal(t, tt.expectedSafeToWithdraw, safe)
+		})
+	}
+}
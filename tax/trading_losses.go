@@ -0,0 +1,89 @@
+package tax
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// carried forward losses are set against the first £5m of profits in full, but only against 50% of the rest
+	// https://www.gov.uk/hmrc-internal-manuals/company-taxation-manual/ctm05010
+	lossDeductionAllowance    = 5000000.0
+	lossRestrictionPercentage = 0.5
+)
+
+type (
+	// TradingPeriod is the taxable result of an accounting period before any loss relief
+	TradingPeriod struct {
+		PeriodStart time.Time
+		Profit      float64 // negative is a loss
+		CarryBack   bool    // claim to set the loss against the profits of the previous 12 months
+	}
+
+	// LossRelief is a line of the loss memorandum, one per accounting period
+	LossRelief struct {
+		PeriodStart        time.Time
+		Profit             float64 // before the relief, negative is a loss
+		BroughtForward     float64 // losses of earlier periods available at the start of the period
+		CarriedForwardUsed float64 // earlier losses set against the profit of this period
+		CarriedBackFrom    float64 // loss of the next period set against the profit of this period
+		CarriedBack        float64 // loss of this period set against the profit of the previous period
+		TaxableProfit      float64
+		Tax                float64
+		Repayment          float64 // corporation tax repaid for the previous period because of the carry back
+		CarriedForward     float64 // losses left at the end of the period
+	}
+)
+
+// ApplyTradingLosses builds the loss memorandum. A loss is carried forward automatically and set against
+// later profits within the deduction allowance, or carried back to the previous 12 months if it was claimed.
+// Losses carried forward from earlier periods are used before the carry back.
+// Please refer to unit tests for examples
+func ApplyTradingLosses(periods []TradingPeriod) []LossRelief {
+	sorted := make([]TradingPeriod, len(periods))
+	copy(sorted, periods)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].PeriodStart.Before(sorted[j].PeriodStart)
+	})
+
+	reliefs := make([]LossRelief, len(sorted))
+	var lossPool float64
+	for i, p := range sorted {
+		relief := LossRelief{PeriodStart: p.PeriodStart, Profit: p.Profit, BroughtForward: lossPool}
+
+		if p.Profit > 0 {
+			relief.CarriedForwardUsed = math.Min(lossPool, lossDeductionLimit(p.Profit))
+			relief.TaxableProfit = roundPennies(p.Profit - relief.CarriedForwardUsed)
+			lossPool = roundPennies(lossPool - relief.CarriedForwardUsed)
+		} else {
+			loss := -p.Profit
+			if p.CarryBack && i > 0 && sorted[i-1].PeriodStart.AddDate(1, 0, 0).Equal(p.PeriodStart) {
+				previous := &reliefs[i-1]
+				relief.CarriedBack = math.Min(loss, previous.TaxableProfit)
+				previous.CarriedBackFrom = relief.CarriedBack
+				previous.TaxableProfit = roundPennies(previous.TaxableProfit - relief.CarriedBack)
+
+				taxBefore := previous.Tax
+				previous.Tax = roundPennies(CalculateCorporateTax(previous.TaxableProfit, previous.PeriodStart))
+				relief.Repayment = roundPennies(taxBefore - previous.Tax)
+				loss = loss - relief.CarriedBack
+			}
+			lossPool = roundPennies(lossPool + loss)
+		}
+
+		relief.Tax = roundPennies(CalculateCorporateTax(relief.TaxableProfit, p.PeriodStart))
+		relief.CarriedForward = lossPool
+		reliefs[i] = relief
+	}
+
+	return reliefs
+}
+
+// the maximum of the profit that could be covered by losses carried forward
+func lossDeductionLimit(profit float64) float64 {
+	if profit <= lossDeductionAllowance {
+		return profit
+	}
+	return lossDeductionAllowance + (profit-lossDeductionAllowance)*lossRestrictionPercentage
+}
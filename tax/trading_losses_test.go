@@ -0,0 +1,79 @@
+package tax
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ApplyTradingLossesCarryForward(t *testing.T) {
+
+	// Given:
+	periods := []TradingPeriod{
+		{PeriodStart: dateOf("01-04-2024"), Profit: 40000},
+		{PeriodStart: dateOf("01-04-2022"), Profit: -30000},
+		{PeriodStart: dateOf("01-04-2023"), Profit: 20000},
+	}
+
+	// When:
+	reliefs := ApplyTradingLosses(periods)
+
+	// Then:
+	assert.Equal(t, []LossRelief{
+		{PeriodStart: dateOf("01-04-2022"), Profit: -30000, CarriedForward: 30000},
+		{PeriodStart: dateOf("01-04-2023"), Profit: 20000, BroughtForward: 30000, CarriedForwardUsed: 20000, CarriedForward: 10000},
+		{PeriodStart: dateOf("01-04-2024"), Profit: 40000, BroughtForward: 10000, CarriedForwardUsed: 10000,
+			TaxableProfit: 30000, Tax: 5700},
+	}, reliefs)
+}
+
+func Test_ApplyTradingLossesDeductionAllowance(t *testing.T) {
+
+	// Given: only £5m + 50% of the rest could be relieved
+	periods := []TradingPeriod{
+		{PeriodStart: dateOf("01-04-2023"), Profit: -8000000},
+		{PeriodStart: dateOf("01-04-2024"), Profit: 10000000},
+	}
+
+	// When:
+	reliefs := ApplyTradingLosses(periods)
+
+	// Then:
+	assert.Equal(t, 7500000.0, reliefs[1].CarriedForwardUsed)
+	assert.Equal(t, 2500000.0, reliefs[1].TaxableProfit)
+	assert.Equal(t, 625000.0, reliefs[1].Tax)
+	assert.Equal(t, 500000.0, reliefs[1].CarriedForward)
+}
+
+func Test_ApplyTradingLossesCarryBack(t *testing.T) {
+	var tests = []struct {
+		name               string
+		carryBack          bool
+		expectedRepayment  float64
+		expectedPrevTax    float64
+		expectedCarriedFwd float64
+	}{
+		{"loss is carried back", true, 7600, 0, 10000},
+		{"carry back is not claimed", false, 0, 7600, 50000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// Given:
+			periods := []TradingPeriod{
+				{PeriodStart: dateOf("01-04-2023"), Profit: 40000},
+				{PeriodStart: dateOf("01-04-2024"), Profit: -50000, CarryBack: tt.carryBack},
+			}
+
+			// When:
+			reliefs := ApplyTradingLosses(periods)
+
+			// Then:
+			assert.Equal(t, tt.expectedPrevTax, reliefs[0].Tax)
+			assert.Equal(t, tt.expectedRepayment, reliefs[1].Repayment)
+			assert.Equal(t, 0.0, reliefs[1].Tax)
+			assert.Equal(t, tt.expectedCarriedFwd, reliefs[1].CarriedForward)
+		})
+	}
+}
@@ -1,50 +1,47 @@
 package tax
 
 import (
-	"github.com/w32blaster/tax-bookkeeper/conf"
+	"errors"
+	"strings"
 	"time"
 )
 
-// Quarterly VAT return dates are due for submission 1 month and 7 days after
-// the of a VAT quarter. For example, a VAT return for the quarter-end
-// June 2019 would be due by 7 August 2019.
-// please refer to unit tests
-func GetNextReturnDate(vatRegisteredMonth time.Month, now time.Time) (time.Month, time.Time) {
-	closestMonth := getClosestSubmittingMonth(vatRegisteredMonth, now.Month())
-	year := now.Year()
-	if closestMonth < now.Month() {
-		year = year + 1
-	}
-	return closestMonth, time.Date(year, closestMonth+2, 7, 0, 0, 0, 0, conf.GMT)
-}
-
-// check the unit test for examples
-func getClosestSubmittingMonth(vat time.Month, current time.Month) time.Month {
-
-	if vat == current {
-		return current
-	}
+// VATAccountingBasis defines when VAT falls due, it depends on the scheme your company uses
+// https://www.gov.uk/vat-cash-accounting-scheme
+type VATAccountingBasis int
 
-	if vat > current {
-		vat = vat - 12
-	}
+const (
+	CashAccounting    VATAccountingBasis = 1 + iota // tax point is the date of the bank transaction
+	InvoiceAccounting                               // standard accounting, tax point is the invoice date
+)
 
-	for i := vat; ; i = i + 3 {
-		if i >= current {
-			if i > 12 {
-				return i - 12
-			}
-			return i
-		}
+func (b VATAccountingBasis) PrettyString() string {
+	switch b {
+	case CashAccounting:
+		return "Cash accounting"
+	case InvoiceAccounting:
+		return "Invoice accounting"
 	}
+	return ""
 }
 
-// safely subtracts 3 months, considering the year beginning
-func GetBeginningOfPreviousPeriod(closestMonth time.Month, year int) time.Time {
-	month := closestMonth - 2
-	if month < 1 {
-		return time.Date(year-1, 12-(-month), 1, 0, 0, 0, 0, conf.GMT)
+// ParseVATAccountingBasis converts a command line value ("cash" or "invoice") to the basis
+func ParseVATAccountingBasis(basis string) (VATAccountingBasis, error) {
+	switch strings.ToLower(basis) {
+	case "", "cash":
+		return CashAccounting, nil
+	case "invoice", "standard":
+		return InvoiceAccounting, nil
 	}
+	return 0, errors.New("unknown VAT accounting basis '" + basis + "', it should be 'cash' or 'invoice'")
+}
 
-	return time.Date(year, month, 1, 0, 0, 0, 0, conf.GMT)
+// Quarterly VAT return dates are due for submission 1 month and 7 days after
+// the of a VAT quarter. For example, a VAT return for the quarter-end
+// June 2019 would be due by 7 August 2019.
+// For monthly and annual returns please use VATScheme.GetVATPeriod
+// please refer to unit tests
+func GetNextReturnDate(vatRegisteredMonth time.Month, now time.Time) (time.Month, time.Time) {
+	period := VATScheme{Period: QuarterlyReturns, PeriodEndMonth: vatRegisteredMonth}.GetVATPeriod(now)
+	return period.EndingMonth, period.ReturnDue
 }
@@ -0,0 +1,180 @@
+package tax
+
+import (
+	"errors"
+	"github.com/w32blaster/tax-bookkeeper/conf"
+	"math"
+	"strings"
+	"time"
+)
+
+// VATReturnPeriod is how often a company submits VAT returns
+// https://www.gov.uk/vat-returns
+type VATReturnPeriod int
+
+const (
+	MonthlyReturns   VATReturnPeriod = 1 + iota // usually repayment traders
+	QuarterlyReturns                            // the default, one of three stagger groups
+	AnnualReturns                               // Annual Accounting Scheme with interim payments
+)
+
+func (p VATReturnPeriod) PrettyString() string {
+	switch p {
+	case MonthlyReturns:
+		return "Monthly"
+	case QuarterlyReturns:
+		return "Quarterly"
+	case AnnualReturns:
+		return "Annual"
+	}
+	return ""
+}
+
+// how many months are in one VAT period
+func (p VATReturnPeriod) months() int {
+	switch p {
+	case MonthlyReturns:
+		return 1
+	case AnnualReturns:
+		return 12
+	}
+	return 3
+}
+
+// ParseVATReturnPeriod converts a command line value ("monthly", "quarterly" or "annual") to the period
+func ParseVATReturnPeriod(period string) (VATReturnPeriod, error) {
+	switch strings.ToLower(period) {
+	case "", "quarterly":
+		return QuarterlyReturns, nil
+	case "monthly":
+		return MonthlyReturns, nil
+	case "annual":
+		return AnnualReturns, nil
+	}
+	return 0, errors.New("unknown VAT return period '" + period + "', it should be 'monthly', 'quarterly' or 'annual'")
+}
+
+type (
+	// VATScheme describes how a company accounts for VAT
+	VATScheme struct {
+		Period VATReturnPeriod
+		// any month when a VAT period ends. For quarterly returns it defines the stagger group,
+		// for the Annual Accounting Scheme it is the last month of the VAT year
+		PeriodEndMonth time.Month
+		Basis          VATAccountingBasis
+		// under the Annual Accounting Scheme you can choose 3 quarterly interim payments instead of 9 monthly ones
+		QuarterlyInterimPayments bool
+	}
+
+	// VATPeriod is one period covered by a single VAT return
+	VATPeriod struct {
+		Start       time.Time // first day of the period
+		End         time.Time // last day of the period
+		ReturnDue   time.Time
+		PaymentDue  time.Time
+		EndingMonth time.Month
+	}
+
+	// VATInterimPayment is a payment on account under the Annual Accounting Scheme
+	VATInterimPayment struct {
+		DueDate     time.Time
+		Amount      float64
+		IsBalancing bool // the final payment made together with the annual return
+	}
+)
+
+// Stagger returns the stagger group for quarterly returns:
+//
+//	Stagger 1 - quarters end in March, June, September and December
+//	Stagger 2 - quarters end in April, July, October and January
+//	Stagger 3 - quarters end in May, August, November and February
+//
+// https://www.gov.uk/government/publications/vat-notice-70012-filling-in-your-vat-return/vat-notice-70012-filling-in-your-vat-return#stagger
+func (s VATScheme) Stagger() int {
+	switch s.PeriodEndMonth % 3 {
+	case 0:
+		return 1
+	case 1:
+		return 2
+	}
+	return 3
+}
+
+// GetVATPeriod returns the VAT period that contains the given date.
+//
+// Monthly and quarterly returns (and the payment) are due 1 month and 7 days after the end of the period.
+// Under the Annual Accounting Scheme the return and the balancing payment are due 2 months after
+// the end of the VAT year. Please refer to unit tests for examples
+func (s VATScheme) GetVATPeriod(date time.Time) VATPeriod {
+	length := s.Period.months()
+
+	// count months from the year zero, so that it is easy to align the period ends
+	current := date.Year()*12 + int(date.Month()) - 1
+	periodEndMonth := int(s.PeriodEndMonth) - 1
+	if s.Period == MonthlyReturns {
+		periodEndMonth = 0
+	}
+
+	offset := ((periodEndMonth-current)%length + length) % length
+	end := current + offset
+	start := end - length + 1
+
+	startDate := time.Date(start/12, time.Month(start%12+1), 1, 0, 0, 0, 0, conf.GMT)
+	nextAfterEnd := time.Date(end/12, time.Month(end%12+1), 1, 0, 0, 0, 0, conf.GMT).AddDate(0, 1, 0)
+	endDate := nextAfterEnd.AddDate(0, 0, -1)
+
+	var due time.Time
+	if s.Period == AnnualReturns {
+		// the last day of the second month after the year end
+		due = nextAfterEnd.AddDate(0, 2, -1)
+	} else {
+		due = nextAfterEnd.AddDate(0, 1, 6)
+	}
+
+	return VATPeriod{
+		Start:       startDate,
+		End:         endDate,
+		ReturnDue:   due,
+		PaymentDue:  due,
+		EndingMonth: endDate.Month(),
+	}
+}
+
+// GetPreviousVATPeriod returns the period right before the given one
+func (s VATScheme) GetPreviousVATPeriod(period VATPeriod) VATPeriod {
+	return s.GetVATPeriod(period.Start.AddDate(0, 0, -1))
+}
+
+// GetAnnualAccountingPayments returns the payments on account for the Annual Accounting Scheme.
+// Interim payments are based on the previous year's VAT liability: either nine monthly payments
+// of 10% due at the end of months 4 to 12 of the VAT year, or three quarterly payments of 25% due at
+// the end of months 4, 7 and 10. The balancing payment is due together with the annual return.
+// https://www.gov.uk/vat-annual-accounting-scheme/how-it-works
+func GetAnnualAccountingPayments(year VATPeriod, previousYearLiability, currentYearLiability float64, quarterly bool) []VATInterimPayment {
+
+	share, step := 0.1, 1
+	if quarterly {
+		share, step = 0.25, 3
+	}
+
+	var payments []VATInterimPayment
+	var paid float64
+	for month := 4; month <= 12; month = month + step {
+		amount := roundPennies(previousYearLiability * share)
+		paid = paid + amount
+		payments = append(payments, VATInterimPayment{
+			DueDate: year.Start.AddDate(0, month, -1), // the last day of the month
+			Amount:  amount,
+		})
+	}
+
+	return append(payments, VATInterimPayment{
+		DueDate:     year.PaymentDue,
+		Amount:      roundPennies(currentYearLiability - paid),
+		IsBalancing: true,
+	})
+}
+
+func roundPennies(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
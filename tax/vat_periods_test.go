@@ -0,0 +1,146 @@
+package tax
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestGetVATPeriod(t *testing.T) {
+	var tests = []struct {
+		scheme          VATScheme
+		now             time.Time
+		expectedStart   time.Time
+		expectedEnd     time.Time
+		expectedDueDate time.Time
+	}{
+		// monthly returns, due 1 month and 7 days after the end of a month
+		{VATScheme{Period: MonthlyReturns}, dateOf("15-01-2020"), dateOf("01-01-2020"), dateOf("31-01-2020"), dateOf("07-03-2020")},
+		{VATScheme{Period: MonthlyReturns}, dateOf("29-02-2020"), dateOf("01-02-2020"), dateOf("29-02-2020"), dateOf("07-04-2020")},
+		{VATScheme{Period: MonthlyReturns}, dateOf("10-12-2020"), dateOf("01-12-2020"), dateOf("31-12-2020"), dateOf("07-02-2021")},
+
+		// quarterly returns, stagger 1 (March, June, September and December)
+		{VATScheme{Period: QuarterlyReturns, PeriodEndMonth: time.March}, dateOf("15-01-2020"), dateOf("01-01-2020"), dateOf("31-03-2020"), dateOf("07-05-2020")},
+		{VATScheme{Period: QuarterlyReturns, PeriodEndMonth: time.December}, dateOf("31-12-2020"), dateOf("01-10-2020"), dateOf("31-12-2020"), dateOf("07-02-2021")},
+
+		// quarterly returns, stagger 2 (April, July, October and January)
+		{VATScheme{Period: QuarterlyReturns, PeriodEndMonth: time.January}, dateOf("15-11-2019"), dateOf("01-11-2019"), dateOf("31-01-2020"), dateOf("07-03-2020")},
+
+		// quarterly returns, stagger 3 (May, August, November and February)
+		{VATScheme{Period: QuarterlyReturns, PeriodEndMonth: time.November}, dateOf("15-12-2018"), dateOf("01-12-2018"), dateOf("28-02-2019"), dateOf("07-04-2019")},
+
+		// Annual Accounting Scheme, the return is due 2 months after the end of the VAT year
+		{VATScheme{Period: AnnualReturns, PeriodEndMonth: time.March}, dateOf("15-06-2020"), dateOf("01-04-2020"), dateOf("31-03-2021"), dateOf("31-05-2021")},
+		{VATScheme{Period: AnnualReturns, PeriodEndMonth: time.December}, dateOf("01-12-2020"), dateOf("01-01-2020"), dateOf("31-12-2020"), dateOf("28-02-2021")},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s returns on %s", tt.scheme.Period.PrettyString(), tt.now.Format("02 Jan 06")),
+			func(t *testing.T) {
+
+				// When:
+				period := tt.scheme.GetVATPeriod(tt.now)
+
+				// Then:
+				assert.Equal(t, tt.expectedStart, period.Start)
+				assert.Equal(t, tt.expectedEnd, period.End)
+				assert.Equal(t, tt.expectedDueDate, period.ReturnDue)
+				assert.Equal(t, tt.expectedEnd.Month(), period.EndingMonth)
+			},
+		)
+	}
+}
+
+func TestGetPreviousVATPeriod(t *testing.T) {
+
+	// Given:
+	scheme := VATScheme{Period: QuarterlyReturns, PeriodEndMonth: time.November}
+	current := scheme.GetVATPeriod(dateOf("15-01-2019"))
+
+	// When:
+	previous := scheme.GetPreviousVATPeriod(current)
+
+	// Then:
+	assert.Equal(t, dateOf("01-09-2018"), previous.Start)
+	assert.Equal(t, dateOf("30-11-2018"), previous.End)
+}
+
+func TestStagger(t *testing.T) {
+	var tests = []struct {
+		month    time.Month
+		expected int
+	}{
+		{time.March, 1}, {time.June, 1}, {time.September, 1}, {time.December, 1},
+		{time.January, 2}, {time.April, 2}, {time.July, 2}, {time.October, 2},
+		{time.February, 3}, {time.May, 3}, {time.August, 3}, {time.November, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.month.String(), func(t *testing.T) {
+			assert.Equal(t, tt.expected, VATScheme{Period: QuarterlyReturns, PeriodEndMonth: tt.month}.Stagger())
+		})
+	}
+}
+
+func TestGetAnnualAccountingPaymentsMonthly(t *testing.T) {
+
+	// Given:
+	year := VATScheme{Period: AnnualReturns, PeriodEndMonth: time.December}.GetVATPeriod(dateOf("01-06-2020"))
+
+	// When:
+	payments := GetAnnualAccountingPayments(year, 10000, 12000, false)
+
+	// Then: nine payments of 10% at the end of months 4 to 12 plus balancing payment
+	assert.Len(t, payments, 10)
+	assert.Equal(t, dateOf("30-04-2020"), payments[0].DueDate)
+	assert.Equal(t, 1000.0, payments[0].Amount)
+	assert.Equal(t, dateOf("31-12-2020"), payments[8].DueDate)
+	assert.True(t, payments[9].IsBalancing)
+	assert.Equal(t, dateOf("28-02-2021"), payments[9].DueDate)
+	assert.Equal(t, 3000.0, payments[9].Amount)
+}
+
+func TestGetAnnualAccountingPaymentsQuarterly(t *testing.T) {
+
+	// Given:
+	year := VATScheme{Period: AnnualReturns, PeriodEndMonth: time.March}.GetVATPeriod(dateOf("01-06-2020"))
+
+	// When:
+	payments := GetAnnualAccountingPayments(year, 10000, 9000, true)
+
+	// Then: three payments of 25% at the end of months 4, 7 and 10 plus balancing payment
+	assert.Len(t, payments, 4)
+	assert.Equal(t, dateOf("31-07-2020"), payments[0].DueDate)
+	assert.Equal(t, dateOf("31-10-2020"), payments[1].DueDate)
+	assert.Equal(t, dateOf("31-01-2021"), payments[2].DueDate)
+	assert.Equal(t, 2500.0, payments[2].Amount)
+	assert.Equal(t, dateOf("31-05-2021"), payments[3].DueDate)
+	assert.Equal(t, 1500.0, payments[3].Amount)
+}
+
+func TestParseVATReturnPeriod(t *testing.T) {
+	var tests = []struct {
+		value           string
+		expected        VATReturnPeriod
+		isErrorExpected bool
+	}{
+		{"", QuarterlyReturns, false},
+		{"quarterly", QuarterlyReturns, false},
+		{"monthly", MonthlyReturns, false},
+		{"Annual", AnnualReturns, false},
+		{"weekly", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+
+			// When:
+			period, err := ParseVATReturnPeriod(tt.value)
+
+			// Then:
+			assert.Equal(t, tt.expected, period)
+			assert.Equal(t, tt.isErrorExpected, err != nil)
+		})
+	}
+}
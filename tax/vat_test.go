@@ -51,98 +51,29 @@ func TestGetNextReturnDate(t *testing.T) {
 	}
 }
 
-func TestFindClosestSubmittingMonth(t *testing.T) {
+func TestParseVATAccountingBasis(t *testing.T) {
 	var tests = []struct {
-		VATMonth time.Month
-		now      time.Month
-		expected time.Month
+		value           string
+		expected        VATAccountingBasis
+		isErrorExpected bool
 	}{
-		// if you were registered in November, then VAT Return dates are (when you submit return):
-		// May, August, November and February
-		{time.November, time.December, time.February},
-		{time.November, time.January, time.February},
-		{time.November, time.February, time.February},
-
-		{time.November, time.March, time.May},
-		{time.November, time.April, time.May},
-		{time.November, time.May, time.May},
-
-		{time.November, time.June, time.August},
-		{time.November, time.July, time.August},
-		{time.November, time.August, time.August},
-
-		{time.November, time.September, time.November},
-		{time.November, time.October, time.November},
-		{time.November, time.November, time.November},
-
-		// if VAT was registered in January, then VAT Return dates are:
-		// Jan, Apr, Jul and Oct
-		{time.January, time.February, time.April},
-		{time.January, time.March, time.April},
-		{time.January, time.April, time.April},
-
-		{time.January, time.May, time.July},
-		{time.January, time.June, time.July},
-		{time.January, time.July, time.July},
-
-		{time.January, time.August, time.October},
-		{time.January, time.September, time.October},
-		{time.January, time.October, time.October},
-
-		{time.January, time.November, time.January},
-		{time.January, time.December, time.January},
-		{time.January, time.January, time.January},
-	}
-
-	for _, tt := range tests {
-		t.Run(fmt.Sprintf("Expected submitting month %s and the VAT submittion deadline %s for the %s",
-			tt.expected.String(), tt.VATMonth.String(), tt.now.String()),
-			func(t *testing.T) {
-
-				// When:
-				closestSubmittingMonth := getClosestSubmittingMonth(tt.VATMonth, tt.now)
-
-				// Then:
-				assert.Equal(t, tt.expected, closestSubmittingMonth)
-			},
-		)
-	}
-}
-
-func TestGetBeginningOfPreviousPeriod(t *testing.T) {
-	var tests = []struct {
-		month    time.Month
-		year     int
-		expected time.Time
-	}{
-		// returns previous year!
-		{time.January, 2020, dateOf("01-11-2019")},
-		{time.February, 2020, dateOf("01-12-2019")},
-
-		// the same year
-		{time.March, 2020, dateOf("01-01-2020")},
-		{time.April, 2020, dateOf("01-02-2020")},
-		{time.May, 2020, dateOf("01-03-2020")},
-		{time.June, 2020, dateOf("01-04-2020")},
-		{time.July, 2020, dateOf("01-05-2020")},
-		{time.August, 2020, dateOf("01-06-2020")},
-		{time.September, 2020, dateOf("01-07-2020")},
-		{time.October, 2020, dateOf("01-08-2020")},
-		{time.November, 2020, dateOf("01-09-2020")},
-		{time.December, 2020, dateOf("01-10-2020")},
+		{"", CashAccounting, false},
+		{"cash", CashAccounting, false},
+		{"Cash", CashAccounting, false},
+		{"invoice", InvoiceAccounting, false},
+		{"standard", InvoiceAccounting, false},
+		{"accrual", 0, true},
 	}
 
 	for _, tt := range tests {
-		t.Run(fmt.Sprintf("Expected getting %s when the end of period is  %s",
-			tt.expected.String(), tt.month.String()),
-			func(t *testing.T) {
+		t.Run(tt.value, func(t *testing.T) {
 
-				// When:
-				beginning := GetBeginningOfPreviousPeriod(tt.month, tt.year)
+			// When:
+			basis, err := ParseVATAccountingBasis(tt.value)
 
-				// Then:
-				assert.Equal(t, tt.expected.Format("02 Jan 06 "), beginning.Format("02 Jan 06 "))
-			},
-		)
+			// Then:
+			assert.Equal(t, tt.expected, basis)
+			assert.Equal(t, tt.isErrorExpected, err != nil)
+		})
 	}
 }
@@ -0,0 +1,316 @@
+package ui
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/ledger"
+	"github.com/w32blaster/tax-bookkeeper/tax"
+)
+
+type (
+	// BalanceSheet is the balance sheet as at the end of the day. Liabilities and equity are positive
+	BalanceSheet struct {
+		Date                    time.Time           `json:"date"`
+		FixedAssets             float64             `json:"fixedAssets"` // the net book value
+		CurrentAssets           []ledger.ReportLine `json:"currentAssets"`
+		TotalCurrentAssets      float64             `json:"totalCurrentAssets"`
+		CurrentLiabilities      []ledger.ReportLine `json:"currentLiabilities"`
+		TotalCurrentLiabilities float64             `json:"totalCurrentLiabilities"`
+		NetCurrentAssets        float64             `json:"netCurrentAssets"`
+		NetAssets               float64             `json:"netAssets"`
+		ShareCapital            float64             `json:"shareCapital"`
+		OtherReserves           []ledger.ReportLine `json:"otherReserves,omitempty"`
+		RetainedEarnings        RetainedEarnings    `json:"retainedEarnings"`
+		TotalEquity             float64             `json:"totalEquity"`
+		// net assets not explained by the equity, like the opening bank balance which was never imported
+		Difference           float64           `json:"difference"`
+		StatementBankBalance float64           `json:"statementBankBalance"` // of the last imported transaction
+		DividendWarnings     []DividendWarning `json:"dividendWarnings,omitempty"`
+	}
+
+	// RetainedEarnings are profits after tax of the previous accounting periods and the current one so far,
+	// minus dividends. They are the distributable reserves
+	RetainedEarnings struct {
+		BroughtForward float64 `json:"broughtForward"`
+		Profit         float64 `json:"profit"` // after tax
+		Dividends      float64 `json:"dividends"`
+		Total          float64 `json:"total"`
+	}
+
+	// BalanceSheetReport compares the balance sheet with the one of the year before
+	BalanceSheetReport struct {
+		Current  BalanceSheet `json:"current"`
+		Previous BalanceSheet `json:"previous"`
+	}
+
+	// DividendWarning is a dividend which exceeds the distributable reserves on the day it was paid
+	DividendWarning struct {
+		Date                  time.Time `json:"date"`
+		Description           string    `json:"description"`
+		Amount                float64   `json:"amount"`
+		DistributableReserves float64   `json:"distributableReserves"`
+	}
+)
+
+// CollectBalanceSheet returns the balance sheet as at the end of the day. The corporation tax of the periods
+// without the year-end accrual is estimated, so the taxes owed to HMRC are netted with the payments made
+func CollectBalanceSheet(d *db.Database, calendar tax.CompanyCalendar, date time.Time) (BalanceSheet, error) {
+	until := date.AddDate(0, 0, 1)
+	// retained earnings are rolled forward from the profits, so the closing entries are not needed
+	l, err := CollectLedger(d, calendar.VAT, calendar.AccountingPeriodStart, until)
+	if err != nil {
+		return BalanceSheet{}, err
+	}
+	l = l.WithoutClosingEntries()
+
+	bs := BalanceSheet{Date: date}
+	for code, balance := range l.Balances(time.Time{}, until) {
+		a, ok := l.Account(code)
+		if !ok || balance == 0 {
+			continue
+		}
+
+		line := ledger.ReportLine{Code: a.Code, Name: a.Name, Amount: balance}
+		switch {
+		case a.Type == db.AssetAccount && a.Parent == ledger.FixedAssets:
+			bs.FixedAssets = round(bs.FixedAssets + balance)
+		case a.Code == ledger.DirectorsLoan && balance < 0:
+			line.Amount = -balance
+			bs.CurrentLiabilities = append(bs.CurrentLiabilities, line)
+		case a.Type == db.AssetAccount:
+			bs.CurrentAssets = append(bs.CurrentAssets, line)
+		case a.Type == db.LiabilityAccount && balance > 0:
+			// overpaid, like the VAT repayment or the corporation tax paid before the loss was carried back
+			bs.CurrentAssets = append(bs.CurrentAssets, line)
+		case a.Type == db.LiabilityAccount:
+			line.Amount = -balance
+			bs.CurrentLiabilities = append(bs.CurrentLiabilities, line)
+		case a.Code == ledger.ShareCapital:
+			bs.ShareCapital = -balance
+		case a.Type == db.EquityAccount && a.Code != ledger.Dividends && a.Code != ledger.RetainedEarnings:
+			line.Amount = -balance
+			bs.OtherReserves = append(bs.OtherReserves, line)
+		}
+	}
+
+	for _, lines := range [][]ledger.ReportLine{bs.CurrentAssets, bs.CurrentLiabilities, bs.OtherReserves} {
+		sort.Slice(lines, func(i, j int) bool {
+			return lines[i].Code < lines[j].Code
+		})
+	}
+
+	bs.RetainedEarnings = collectRetainedEarnings(l, calendar.AccountingPeriodStart, until)
+	bs.DividendWarnings = checkDividends(l, calendar.AccountingPeriodStart, until)
+	if bs.StatementBankBalance, err = statementBankBalance(d, until); err != nil {
+		return BalanceSheet{}, err
+	}
+
+	bs.TotalCurrentAssets = sumLines(bs.CurrentAssets)
+	bs.TotalCurrentLiabilities = sumLines(bs.CurrentLiabilities)
+	bs.NetCurrentAssets = round(bs.TotalCurrentAssets - bs.TotalCurrentLiabilities)
+	bs.NetAssets = round(bs.FixedAssets + bs.NetCurrentAssets)
+	bs.TotalEquity = round(bs.ShareCapital + sumLines(bs.OtherReserves) + bs.RetainedEarnings.Total)
+	bs.Difference = round(bs.NetAssets - bs.TotalEquity)
+	return bs, nil
+}
+
+// CollectBalanceSheetReport returns the balance sheet as at the end of the day and the one of the year before
+func CollectBalanceSheetReport(d *db.Database, calendar tax.CompanyCalendar, date time.Time) (BalanceSheetReport, error) {
+	current, err := CollectBalanceSheet(d, calendar, date)
+	if err != nil {
+		return BalanceSheetReport{}, err
+	}
+	previous, err := CollectBalanceSheet(d, calendar, date.AddDate(-1, 0, 0))
+	if err != nil {
+		return BalanceSheetReport{}, err
+	}
+	return BalanceSheetReport{Current: current, Previous: previous}, nil
+}
+
+// Rows returns the balance sheet line by line, the difference is shown only when it is not balanced
+func (r BalanceSheetReport) Rows() []ReportRow {
+	cur, prev := r.Current, r.Previous
+	rows := []ReportRow{{Label: "Fixed assets", Current: cur.FixedAssets, Previous: prev.FixedAssets}}
+
+	rows = append(rows, ReportRow{Label: "Current assets", IsHeader: true})
+	rows = append(rows, compareLines(cur.CurrentAssets, prev.CurrentAssets)...)
+	rows = append(rows,
+		ReportRow{Label: "Total current assets", Current: cur.TotalCurrentAssets, Previous: prev.TotalCurrentAssets, IsTotal: true},
+		ReportRow{Label: "Creditors due within one year", IsHeader: true})
+	rows = append(rows, compareLines(cur.CurrentLiabilities, prev.CurrentLiabilities)...)
+	rows = append(rows,
+		ReportRow{Label: "Total creditors", Current: cur.TotalCurrentLiabilities, Previous: prev.TotalCurrentLiabilities, IsTotal: true},
+		ReportRow{Label: "Net current assets", Current: cur.NetCurrentAssets, Previous: prev.NetCurrentAssets, IsTotal: true},
+		ReportRow{Label: "Net assets", Current: cur.NetAssets, Previous: prev.NetAssets, IsTotal: true},
+		ReportRow{Label: "Capital and reserves", IsHeader: true},
+		ReportRow{Label: "  Share capital", Current: cur.ShareCapital, Previous: prev.ShareCapital})
+	rows = append(rows, compareLines(cur.OtherReserves, prev.OtherReserves)...)
+
+	re, prevRE := cur.RetainedEarnings, prev.RetainedEarnings
+	rows = append(rows,
+		ReportRow{Label: "  Retained earnings brought forward", Current: re.BroughtForward, Previous: prevRE.BroughtForward},
+		ReportRow{Label: "  Profit after tax of the period", Current: re.Profit, Previous: prevRE.Profit},
+		// 0 - x is not a negative zero when there are no dividends
+		ReportRow{Label: "  Dividends of the period", Current: 0 - re.Dividends, Previous: 0 - prevRE.Dividends},
+		ReportRow{Label: "Retained earnings", Current: re.Total, Previous: prevRE.Total, IsTotal: true},
+		ReportRow{Label: "Total equity", Current: cur.TotalEquity, Previous: prev.TotalEquity, IsTotal: true})
+
+	if cur.Difference != 0 || prev.Difference != 0 {
+		rows = append(rows, ReportRow{Label: "Not reconciled", Current: cur.Difference, Previous: prev.Difference})
+	}
+	return rows
+}
+
+// lines of both dates, those from the ledger are ordered by code and the calculated ones go last
+func compareLines(current, previous []ledger.ReportLine) []ReportRow {
+	var keys []ledger.ReportLine
+	amounts := map[string][2]float64{}
+	for i, lines := range [][]ledger.ReportLine{current, previous} {
+		for _, line := range lines {
+			key := line.Code + line.Name
+			a, ok := amounts[key]
+			if !ok {
+				keys = append(keys, line)
+			}
+			a[i] = line.Amount
+			amounts[key] = a
+		}
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		if (keys[i].Code == "") != (keys[j].Code == "") {
+			return keys[j].Code == ""
+		}
+		return keys[i].Code < keys[j].Code
+	})
+
+	rows := make([]ReportRow, len(keys))
+	for i, line := range keys {
+		a := amounts[line.Code+line.Name]
+		rows[i] = ReportRow{Label: "  " + line.Name, Current: a[0], Previous: a[1]}
+	}
+	return rows
+}
+
+// retained earnings rolled forward from the first accounting period with any entries until the date (exclusive).
+// The profits are after the corporation tax charge of the ledger. Manual entries to the retained earnings account,
+// like the opening balance, are brought forward too
+func collectRetainedEarnings(l ledger.Ledger, accountingDateStart, until time.Time) RetainedEarnings {
+	var re RetainedEarnings
+	if len(l.Entries) == 0 {
+		return re
+	}
+
+	current := accountingPeriodOf(accountingDateStart, until.AddDate(0, 0, -1))
+	for start := accountingPeriodOf(accountingDateStart, l.Entries[0].Date); !start.After(current); start = start.AddDate(1, 0, 0) {
+		end := start.AddDate(1, 0, 0)
+		if end.After(until) {
+			end = until
+		}
+
+		profit := l.ProfitAndLoss(start, end).OperatingProfit - l.Balance(start, end, ledger.CorporationTaxCharge)
+		dividends := l.Balance(start, end, ledger.Dividends)
+
+		if start.Equal(current) {
+			re.Profit, re.Dividends = round(profit), dividends
+		} else {
+			re.BroughtForward = round(re.BroughtForward + profit - dividends)
+		}
+	}
+
+	re.BroughtForward = round(re.BroughtForward - l.Balance(time.Time{}, until, ledger.RetainedEarnings))
+	re.Total = round(re.BroughtForward + re.Profit - re.Dividends)
+	return re
+}
+
+// dividends which exceeded the retained earnings of the day before they were paid. The reserves brought forward
+// and the tax rate are taken once per accounting period, the tax is charged at the end of the period, so the
+// profit so far is taxed at the effective rate of the whole period
+func checkDividends(l ledger.Ledger, accountingDateStart, until time.Time) []DividendWarning {
+	var warnings []DividendWarning
+	var periodStart time.Time
+	var broughtForward, taxRate float64
+	for _, e := range l.Entries {
+		if !e.Date.Before(until) {
+			break
+		}
+
+		var amount float64
+		for _, line := range e.Lines {
+			if line.Account == ledger.Dividends {
+				amount = amount + line.Debit - line.Credit
+			}
+		}
+		if amount <= 0 {
+			continue
+		}
+
+		if start := accountingPeriodOf(accountingDateStart, e.Date); !start.Equal(periodStart) {
+			periodStart = start
+			broughtForward = collectRetainedEarnings(l, accountingDateStart, start).Total
+			end := start.AddDate(1, 0, 0)
+			if end.After(until) {
+				end = until
+			}
+			taxRate = 0
+			if profit := l.ProfitAndLoss(start, end).OperatingProfit; profit > 0 {
+				taxRate = l.Balance(start, end, ledger.CorporationTaxCharge) / profit
+			}
+		}
+
+		profit := l.ProfitAndLoss(periodStart, e.Date).OperatingProfit
+		if profit > 0 {
+			profit = profit * (1 - taxRate)
+		}
+		reserves := round(broughtForward + profit - l.Balance(periodStart, e.Date, ledger.Dividends) -
+			l.Balance(periodStart, e.Date, ledger.RetainedEarnings))
+		if amount > reserves {
+			warnings = append(warnings, DividendWarning{Date: e.Date, Description: e.Description, Amount: amount,
+				DistributableReserves: reserves})
+		}
+	}
+	return warnings
+}
+
+// the balance of the last imported transaction before the date
+func statementBankBalance(d *db.Database, until time.Time) (float64, error) {
+	transactions, err := d.GetAll(0, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	var balance float64
+	var last time.Time
+	for _, t := range transactions {
+		if t.Date.Before(until) && !t.Date.Before(last) {
+			balance, last = t.Balance, t.Date
+		}
+	}
+	return balance, nil
+}
+
+// the start of the accounting period the date belongs to, accountingDateStart is the start of any period
+func accountingPeriodOf(accountingDateStart, date time.Time) time.Time {
+	start := accountingDateStart
+	for start.After(date) {
+		start = start.AddDate(-1, 0, 0)
+	}
+	for !start.AddDate(1, 0, 0).After(date) {
+		start = start.AddDate(1, 0, 0)
+	}
+	return start
+}
+
+func sumLines(lines []ledger.ReportLine) float64 {
+	var total float64
+	for _, line := range lines {
+		total = total + line.Amount
+	}
+	return round(total)
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
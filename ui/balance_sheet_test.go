@@ -0,0 +1,136 @@
+package ui
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/ledger"
+	"github.com/w32blaster/tax-bookkeeper/tax"
+)
+
+func TestCollectBalanceSheet(t *testing.T) {
+
+	// create real DB
+	const dbFile = "/tmp/tax-bookkeeper-ui-balance-sheet.db"
+	d := db.Init(dbFile)
+	defer func() {
+		d.Close()
+		os.Remove(dbFile)
+	}()
+
+	// Populate with data: the second dividend exceeds the profit after tax
+	_, err := d.ImportTransactions([]db.Transaction{
+		{Date: dateOf("10-05-2024"), Type: db.Credit, Description: "ACME", Credit: 50000, Category: db.Income},
+		{Date: dateOf("20-05-2024"), Type: db.Debit, Description: "Office fit-out", Debit: 10000, Category: db.Office},
+		{Date: dateOf("10-06-2024"), Type: db.Debit, Description: "Interim dividend", Debit: 30000, Category: db.Dividend},
+		{Date: dateOf("01-03-2025"), Type: db.Debit, Description: "Final dividend", Debit: 5000, Category: db.Dividend},
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, ledger.InitChartOfAccounts(d))
+
+	// When:
+	calendar := tax.CompanyCalendar{AccountingPeriodStart: dateOf("01-04-2024")}
+	bs, err := CollectBalanceSheet(d, calendar, dateOf("31-03-2025"))
+
+	// Then: the corporation tax is owed to HMRC and it is charged before the retained earnings
+	assert.Nil(t, err)
+	assert.Equal(t, []ledger.ReportLine{{Code: ledger.BankAccount, Name: "Bank current account", Amount: 5000}}, bs.CurrentAssets)
+	assert.Equal(t, []ledger.ReportLine{{Code: ledger.TaxLiabilities, Name: "HMRC (VAT, PAYE, corporation tax)", Amount: 7600}}, bs.CurrentLiabilities)
+	assert.Equal(t, -2600.0, bs.NetAssets)
+	assert.Equal(t, RetainedEarnings{Profit: 32400, Dividends: 35000, Total: -2600}, bs.RetainedEarnings)
+	assert.Equal(t, 0.0, bs.Difference)
+	assert.Len(t, bs.DividendWarnings, 1)
+	assert.True(t, dateOf("01-03-2025").Equal(bs.DividendWarnings[0].Date))
+	assert.Equal(t, 5000.0, bs.DividendWarnings[0].Amount)
+	assert.Equal(t, 2400.0, bs.DividendWarnings[0].DistributableReserves)
+}
+
+func TestCollectRetainedEarnings(t *testing.T) {
+
+	// Given: the opening balance was posted to the retained earnings
+	l := ledger.Ledger{Accounts: ledger.DefaultChart, Entries: []db.JournalEntry{
+		{Date: dateOf("01-04-2023"), Description: "Opening balance",
+			Lines: []db.JournalLine{{Account: ledger.BankAccount, Debit: 1000}, {Account: ledger.RetainedEarnings, Credit: 1000}}},
+		ledger.PostTransaction(db.Transaction{Date: dateOf("10-05-2023"), Type: db.Credit, Credit: 20000, Account: ledger.Sales}),
+		ledger.PostTransaction(db.Transaction{Date: dateOf("10-06-2023"), Type: db.Debit, Debit: 5000, Account: ledger.Dividends}),
+		ledger.PostCorporationTax(dateOf("01-04-2023"), 3800),
+		ledger.PostTransaction(db.Transaction{Date: dateOf("10-05-2024"), Type: db.Credit, Credit: 8000, Account: ledger.Sales}),
+		ledger.PostTransaction(db.Transaction{Date: dateOf("10-06-2024"), Type: db.Debit, Debit: 2000, Account: ledger.Dividends}),
+		ledger.PostTransaction(db.Transaction{Date: dateOf("10-05-2025"), Type: db.Credit, Credit: 9000, Account: ledger.Sales}),
+	}}
+
+	// When:
+	re := collectRetainedEarnings(l, dateOf("01-04-2025"), dateOf("01-04-2025"))
+
+	// Then: the profit of the first year is after tax
+	assert.Equal(t, RetainedEarnings{BroughtForward: 12200, Profit: 8000, Dividends: 2000, Total: 18200}, re)
+}
+
+func TestCheckDividends(t *testing.T) {
+
+	// Given: 19% of the profit of the period is the corporation tax
+	l := ledger.Ledger{Accounts: ledger.DefaultChart, Entries: []db.JournalEntry{
+		ledger.PostTransaction(db.Transaction{Date: dateOf("10-05-2024"), Type: db.Credit, Credit: 10000, Account: ledger.Sales}),
+		ledger.PostTransaction(db.Transaction{Date: dateOf("10-06-2024"), Type: db.Debit, Debit: 8100, Description: "Interim", Account: ledger.Dividends}),
+		ledger.PostTransaction(db.Transaction{Date: dateOf("10-07-2024"), Type: db.Debit, Debit: 100, Description: "Second", Account: ledger.Dividends}),
+		ledger.PostCorporationTax(dateOf("01-04-2024"), 1900),
+		ledger.PostTransaction(db.Transaction{Date: dateOf("10-05-2025"), Type: db.Credit, Credit: 500, Account: ledger.Sales}),
+		ledger.PostTransaction(db.Transaction{Date: dateOf("10-06-2025"), Type: db.Debit, Debit: 400, Description: "Next year", Account: ledger.Dividends}),
+	}}
+
+	// When:
+	warnings := checkDividends(l, dateOf("01-04-2024"), dateOf("01-04-2026"))
+
+	// Then: nothing is left after the interim dividend, the overdrawn reserves are brought forward
+	assert.Equal(t, []DividendWarning{{Date: dateOf("10-07-2024"), Description: "Second", Amount: 100,
+		DistributableReserves: 0}}, warnings)
+}
+
+func TestAccountingPeriodOf(t *testing.T) {
+	var tests = []struct {
+		date     time.Time
+		expected time.Time
+	}{
+		{dateOf("01-04-2025"), dateOf("01-04-2025")},
+		{dateOf("31-03-2025"), dateOf("01-04-2024")},
+		{dateOf("15-08-2027"), dateOf("01-04-2027")},
+		{dateOf("01-01-2020"), dateOf("01-04-2019")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.date.String(), func(t *testing.T) {
+
+			// When:
+			start := accountingPeriodOf(dateOf("01-04-2025"), tt.date)
+
+			// Then:
+			assert.Equal(t, tt.expected, start)
+		})
+	}
+}
+
+func TestCompareLines(t *testing.T) {
+
+	// Given:
+	current := []ledger.ReportLine{
+		{Code: "1200", Name: "Bank", Amount: 100},
+		{Name: "Corporation tax", Amount: 19},
+	}
+	previous := []ledger.ReportLine{
+		{Code: "1100", Name: "Debtors", Amount: 50},
+		{Code: "1200", Name: "Bank", Amount: 70},
+	}
+
+	// When:
+	rows := compareLines(current, previous)
+
+	// Then: the calculated lines go after the accounts
+	assert.Equal(t, []ReportRow{
+		{Label: "  Debtors", Current: 0, Previous: 50},
+		{Label: "  Bank", Current: 100, Previous: 70},
+		{Label: "  Corporation tax", Current: 19, Previous: 0},
+	}, rows)
+}
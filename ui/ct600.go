@@ -0,0 +1,41 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/assets"
+	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/ledger"
+	"github.com/w32blaster/tax-bookkeeper/tax"
+)
+
+// CollectCT600 returns the boxes of the company tax return of the accounting period starting on the date
+func CollectCT600(d *db.Database, vat *tax.VATScheme, accountingDateStart time.Time) (tax.CT600, error) {
+	end := accountingDateStart.AddDate(1, 0, 0)
+	ct, err := collectSummaryCorporateTax(d, vat, accountingDateStart, end)
+	if err != nil {
+		return tax.CT600{}, err
+	}
+	register, err := assets.GetRegister(d)
+	if err != nil {
+		return tax.CT600{}, err
+	}
+	allowances := assets.CalculateCapitalAllowances(register, accountingDateStart)
+
+	var s455 tax.S455Charge
+	l, err := ledger.Build(d, vat, end)
+	if err != nil {
+		return tax.CT600{}, err
+	}
+	if loans := toDirectorLoanEntriesOfLedger(l); len(loans) > 0 {
+		s455 = tax.CalculateS455(tax.BuildDirectorLoanLedger(loans), accountingDateStart, end.AddDate(0, 0, -1))
+	}
+
+	return tax.CalculateCT600(accountingDateStart, ct.EarnedAccountingPeriod, ct.Computation, ct.LossRelief,
+		tax.CT600Allowances{
+			AnnualInvestmentAllowance: allowances.AnnualInvestmentAllowance,
+			SpecialRatePool:           allowances.SpecialRateAllowance,
+			MainPool:                  round(allowances.MainPoolAllowance + allowances.FullExpensing),
+			BalancingCharges:          allowances.BalancingCharges,
+		}, s455), nil
+}
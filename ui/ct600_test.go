@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/ledger"
+	"github.com/w32blaster/tax-bookkeeper/tax"
+)
+
+func TestCollectCT600(t *testing.T) {
+
+	// create real DB
+	const dbFile = "/tmp/tax-bookkeeper-ui-ct600.db"
+	d := db.Init(dbFile)
+	defer func() {
+		d.Close()
+		os.Remove(dbFile)
+	}()
+
+	// Populate with data: the loss of the first year is carried forward, both include VAT
+	_, err := d.ImportTransactions([]db.Transaction{
+		{Date: dateOf("10-05-2023"), Type: db.Debit, Description: "Office fit-out", Debit: 20000, Category: db.Office},
+		{Date: dateOf("10-05-2024"), Type: db.Credit, Description: "ACME", Credit: 60000, Category: db.Income},
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, ledger.InitChartOfAccounts(d))
+
+	transactions, err := d.GetAll(0, 0)
+	assert.Nil(t, err)
+	invoice := db.Invoice{Type: db.SalesInvoice, Number: "INV-1", IssueDate: dateOf("01-05-2024"), Net: 50000, VAT: 10000}
+	assert.Nil(t, d.SaveInvoice(&invoice))
+	for _, tx := range transactions {
+		if tx.Description == "ACME" {
+			assert.Nil(t, d.LinkInvoice(invoice.Pk, tx.Pk))
+		}
+	}
+	vat := &tax.VATScheme{Period: tax.QuarterlyReturns, PeriodEndMonth: time.March, Basis: tax.CashAccounting}
+
+	// When:
+	ct600, err := CollectCT600(d, vat, dateOf("01-04-2024"))
+
+	// Then:
+	assert.Nil(t, err)
+	assert.Equal(t, 50000.0, ct600.Turnover)
+	assert.Equal(t, 16666.67, ct600.LossesBroughtForward) // the office VAT is reclaimed
+	assert.Equal(t, 33333.33, ct600.NetTradingProfits)
+}
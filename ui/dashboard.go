@@ -5,48 +5,73 @@ import (
 	"sort"
 	"time"
 
+	"github.com/w32blaster/tax-bookkeeper/assets"
 	"github.com/w32blaster/tax-bookkeeper/conf"
 	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/ledger"
 	"github.com/w32blaster/tax-bookkeeper/tax"
 )
 
-// CollectDataForDashboard accountingDateStart is only day and month, like 01-11
-func CollectDataForDashboard(d *db.Database, accountingDateStart time.Time, vatMonth time.Month) (*DashboardData, error) {
+// how far ahead the dashboard shows deadlines
+const upcomingDeadlinesMonths = 3
+
+// CollectDataForDashboard accountingDateStart is only day and month, like 01-11. The cash buffer is kept on the
+// bank account on top of the taxes when the safe to withdraw amount is calculated
+func CollectDataForDashboard(d *db.Database, accountingDateStart time.Time, vatScheme tax.VATScheme, residency tax.Residency,
+	calendar tax.CompanyCalendar, cashBuffer float64) (*DashboardData, error) {
 
 	now := time.Now().In(conf.GMT)
 
-	// get the profit for the current accounting period since accountingDateStart until now
-	currentCorporateTax, err := collectSummaryCorporateTax(d, accountingDateStart, now)
+	// the corporation tax of both periods and of the periods whose losses they relieve comes from one ledger
+	l, err := buildCorporateTaxLedger(d, calendar.VAT, accountingDateStart.AddDate(-1, 0, 0), now)
 	if err != nil {
 		return nil, err
 	}
 
-	previousCorporateTax, err := collectSummaryCorporateTax(d,
+	// the previous year goes first, because its loss is carried forward to the current one
+	previousCorporateTax, err := collectSummaryCorporateTaxOfLedger(d, l,
 		accountingDateStart.AddDate(-1, 0, 0),
 		accountingDateStart)
 	if err != nil {
 		return nil, err
 	}
 
+	// get the profit for the current accounting period since accountingDateStart until now
+	currentCorporateTax, err := collectSummaryCorporateTaxOfLedger(d, l, accountingDateStart, now)
+	if err != nil {
+		return nil, err
+	}
+
 	// Self-assessment tax
-	currentSelfAssessmentTax, err := collectSummarySelfAssessmentTax(d, now)
+	currentSelfAssessmentTax, err := collectSummarySelfAssessmentTax(d, now, residency)
+	if err != nil {
+		return nil, err
+	}
+
+	previousSelfAssessmentTax, err := collectSummarySelfAssessmentTax(d, now.AddDate(-1, 0, 0), residency)
 	if err != nil {
 		return nil, err
 	}
 
-	previousSelfAssessmentTax, err := collectSummarySelfAssessmentTax(d, now.AddDate(-1, 0, 0))
+	currentVATPeriod := vatScheme.GetVATPeriod(now)
+	previousVATPeriod := vatScheme.GetPreviousVATPeriod(currentVATPeriod)
+
+	previousVAT, err := collectSummaryVAT(d, vatScheme, previousVATPeriod, previousVATPeriod.End.AddDate(0, 0, 1), nil)
 	if err != nil {
 		return nil, err
 	}
 
-	currentVAT, err := collectSummaryVAT(d, vatMonth, now)
-	previousVAT, err := collectSummaryVAT(d, vatMonth, now.AddDate(0, -3, 0))
+	currentVAT, err := collectSummaryVAT(d, vatScheme, currentVATPeriod, now, &previousVAT)
+	if err != nil {
+		return nil, err
+	}
 
+	loans, err := collectSummaryDirectorLoans(d, accountingDateStart, now)
 	if err != nil {
 		return nil, err
 	}
 
-	loans, err := collectSummaryDirectorLoans(d, accountingDateStart)
+	penalties, err := CollectPenalties(d, calendar, residency, now)
 	if err != nil {
 		return nil, err
 	}
@@ -56,6 +81,29 @@ func CollectDataForDashboard(d *db.Database, accountingDateStart time.Time, vatM
 		return nil, err
 	}
 
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, conf.GMT)
+
+	cashFlow, err := CollectForecast(d, calendar, residency, today, forecastMonths)
+	if err != nil {
+		return nil, err
+	}
+
+	var vats []VAT
+	if calendar.VAT != nil {
+		vats = []VAT{previousVAT, currentVAT}
+	}
+	// the corporation tax of the current period is due up to 21 months later
+	obligations, err := collectObligations(d, calendar, residency, today.AddDate(-penaltiesLookBackYears, 0, 0),
+		today.AddDate(2, 0, 0), today.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	reserve, err := collectTaxReserve(d, today, cashBuffer, []CorporateTax{previousCorporateTax, currentCorporateTax},
+		vats, loans, obligations)
+	if err != nil {
+		return nil, err
+	}
+
 	return &DashboardData{
 		TotalTransactionsCnt: cnt,
 		GetTransactions: func(limit, page int) []db.Transaction {
@@ -73,93 +121,256 @@ func CollectDataForDashboard(d *db.Database, accountingDateStart time.Time, vatM
 		CurrentVAT:  currentVAT,
 
 		Loans: loans,
+
+		Deadlines: tax.GetDeadlines(calendar, today, today.AddDate(0, upcomingDeadlinesMonths, 0)),
+		Penalties: penalties,
+		Forecast:  cashFlow,
+		Reserve:   reserve,
 	}, nil
 }
 
-func collectSummaryDirectorLoans(d *db.Database, accountingDateStart time.Time) (DirectorLoans, error) {
-	transactions, err := d.GetTransactionsByCategories(db.Loan, db.LoansReturn)
+func collectSummaryDirectorLoans(d *db.Database, accountingDateStart, now time.Time) (DirectorLoans, error) {
+	// VAT is never posted to the director's loan account
+	l, err := ledger.Build(d, nil, now)
 	if err != nil {
 		return DirectorLoans{}, err
 	}
 
-	if len(transactions) == 0 {
+	entries := toDirectorLoanEntriesOfLedger(l)
+	if len(entries) == 0 {
 		return DirectorLoans{}, nil
 	}
 
-	sort.Slice(transactions, func(i, j int) bool {
-		return transactions[i].Date.Before(transactions[j].Date)
-	})
+	ledger := tax.BuildDirectorLoanLedger(entries)
+	previousS455 := tax.CalculateS455(ledger, accountingDateStart.AddDate(-1, 0, 0), accountingDateStart.AddDate(0, 0, -1))
+	currentS455 := tax.CalculateS455(ledger, accountingDateStart, accountingDateStart.AddDate(1, 0, -1))
 
 	// A director’s loan must be repaid within nine
 	// months and one day of the company’s year-end, or you will face a heavy tax penalty.
 	return DirectorLoans{
-		Transactions:       transactions,
-		LeftForActiveLoan:  getActiveLoan(transactions),
-		LoanMustBeReturnBy: accountingDateStart.AddDate(1, 9, 1),
+		Ledger:             ledger,
+		LeftForActiveLoan:  ledger[len(ledger)-1].Balance,
+		LoanMustBeReturnBy: currentS455.RepayBy,
+		PreviousS455:       previousS455,
+		CurrentS455:        currentS455,
+		BeneficialLoan:     tax.CalculateBeneficialLoanInterest(ledger, tax.GetTaxYear(now)),
 	}, nil
 }
 
+// movements of the director's loan account, so that the loans written off or set against dividends by a journal
+// are counted too. A debit is money taken by the director, a credit is a repayment
+func toDirectorLoanEntriesOfLedger(l ledger.Ledger) []tax.DirectorLoanEntry {
+	var entries []tax.DirectorLoanEntry
+	for _, e := range l.Entries {
+		var amount float64
+		for _, line := range e.Lines {
+			if line.Account == ledger.DirectorsLoan {
+				amount = amount + line.Debit - line.Credit
+			}
+		}
+		if amount != 0 {
+			entries = append(entries, tax.DirectorLoanEntry{Date: e.Date, Amount: round(amount)})
+		}
+	}
+	return entries
+}
+
 func getActiveLoan(tx []db.Transaction) float64 {
 
 	if len(tx) == 0 {
 		return 0.0
 	}
 
-	sort.Slice(tx, func(i, j int) bool {
-		return tx[i].Date.Before(tx[j].Date)
-	})
+	ledger := tax.BuildDirectorLoanLedger(toDirectorLoanEntries(tx))
+	return ledger[len(ledger)-1].Balance
+}
 
-	var accumulator float64
+// NB! Credit is a loan return, Debit is a loan take away. Debits can be negative in the bank statement
+func toDirectorLoanEntries(tx []db.Transaction) []tax.DirectorLoanEntry {
+	entries := make([]tax.DirectorLoanEntry, 0, len(tx))
 	for _, t := range tx {
 		if t.Category == db.Loan {
-			accumulator = t.Debit
+			entries = append(entries, tax.DirectorLoanEntry{Date: t.Date, Amount: math.Abs(t.Debit)})
 		} else {
-			accumulator = accumulator - t.Credit
+			entries = append(entries, tax.DirectorLoanEntry{Date: t.Date, Amount: -math.Abs(t.Credit)})
 		}
 	}
-
-	return accumulator
+	return entries
 }
 
-func collectSummarySelfAssessmentTax(d *db.Database, now time.Time) (SelfAssessmentTax, error) {
+func collectSummarySelfAssessmentTax(d *db.Database, now time.Time, residency tax.Residency) (SelfAssessmentTax, error) {
 
 	startDate, endDate, paymentDate := tax.GetTaxYearDates(now)
 
-	movedOut, _ := d.GetMovedOut(startDate, endDate)
-	selfAssessmentTax := tax.CalculateSelfAssessmentTax(movedOut, 0)
-	rate, leftBeforeThreshold, isWarning := tax.HowMuchBeforeNextThreshold(math.Abs(movedOut))
+	salary, dividends, err := collectDirectorIncome(d, startDate, endDate)
+	if err != nil {
+		return SelfAssessmentTax{}, err
+	}
+
+	taxYear := tax.GetTaxYear(startDate)
+	incomeTax, dividendTax := tax.CalculateDirectorSelfAssessmentTax(salary, dividends, taxYear, residency)
+	rate, leftBeforeThreshold, isWarning := tax.HowMuchBeforeNextThreshold(salary+dividends, taxYear, residency)
+
+	// payments on account of this year depend on the previous year's bill
+	previousStartDate, previousEndDate, _ := tax.GetTaxYearDates(startDate.AddDate(-1, 0, 0))
+	previousSalary, previousDividends, err := collectDirectorIncome(d, previousStartDate, previousEndDate)
+	if err != nil {
+		return SelfAssessmentTax{}, err
+	}
+
+	previousTaxYear := tax.GetTaxYear(previousStartDate)
+	previousIncomeTax, previousDividendTax := tax.CalculateDirectorSelfAssessmentTax(previousSalary, previousDividends,
+		previousTaxYear, residency)
+
+	previous, err := collectSelfAssessmentLiability(d, previousTaxYear, previousIncomeTax+previousDividendTax)
+	if err != nil {
+		return SelfAssessmentTax{}, err
+	}
+
+	current, err := collectSelfAssessmentLiability(d, taxYear, incomeTax+dividendTax)
+	if err != nil {
+		return SelfAssessmentTax{}, err
+	}
 
 	return SelfAssessmentTax{
 		StartingDate:               startDate,
 		EndingDate:                 endDate,
 		NextPaymentDate:            paymentDate,
-		MovedOutFromCompanyTotal:   movedOut,
-		SelfAssessmentTaxSoFar:     selfAssessmentTax,
+		Payments:                   tax.GetSelfAssessmentPaymentSchedule(previous, current),
+		Residency:                  residency,
+		MovedOutFromCompanyTotal:   salary + dividends,
+		Salary:                     salary,
+		Dividends:                  dividends,
+		IncomeTax:                  incomeTax,
+		DividendTax:                dividendTax,
+		SelfAssessmentTaxSoFar:     incomeTax + dividendTax,
 		TaxRate:                    rate,
 		HowMuchBeforeNextThreshold: leftBeforeThreshold,
 		IsWarning:                  isWarning,
 	}, nil
 }
 
-func collectSummaryCorporateTax(d *db.Database, accountingDateStart time.Time, accountingDateEnd time.Time) (CorporateTax, error) {
+// returns director's gross salary and dividends within the tax year, the dividends declared by a journal are
+// counted on the date of the journal
+func collectDirectorIncome(d *db.Database, startDate, endDate time.Time) (float64, float64, error) {
+	// the tax year ends on 5 April inclusive. VAT is never posted to the salaries or the dividends
+	until := endDate.AddDate(0, 0, 1)
+	l, err := ledger.Build(d, nil, until)
+	if err != nil {
+		return 0, 0, err
+	}
+	return l.Balance(startDate, until, ledger.DirectorsSalaries), l.Balance(startDate, until, ledger.Dividends), nil
+}
 
-	var revenue, expenses, pension float64
-	var err error
+// income tax on the salary paid through the payroll is not paid again with the self assessment
+func collectSelfAssessmentLiability(d *db.Database, taxYear string, totalTax float64) (tax.SelfAssessmentLiability, error) {
+	paye, err := d.GetDirectorsPAYE(taxYear)
+	if err != nil {
+		return tax.SelfAssessmentLiability{}, err
+	}
+
+	return tax.SelfAssessmentLiability{
+		TaxYear:          taxYear,
+		TotalTax:         totalTax,
+		DeductedAtSource: paye,
+	}, nil
+}
 
-	if revenue, _ = d.GetRevenueSince(accountingDateStart, accountingDateEnd); err != nil {
+func collectSummaryCorporateTax(d *db.Database, vat *tax.VATScheme, accountingDateStart time.Time, accountingDateEnd time.Time) (CorporateTax, error) {
+	l, err := buildCorporateTaxLedger(d, vat, accountingDateStart, accountingDateEnd)
+	if err != nil {
 		return CorporateTax{}, err
 	}
-	if expenses, err = d.GetExpensesSince(accountingDateStart, accountingDateEnd); err != nil {
+	return collectSummaryCorporateTaxOfLedger(d, l, accountingDateStart, accountingDateEnd)
+}
+
+// the ledger must be built until the end of the period at least, and until the end of the later periods which
+// carry their losses back
+func collectSummaryCorporateTaxOfLedger(d *db.Database, l ledger.Ledger, accountingDateStart time.Time, accountingDateEnd time.Time) (CorporateTax, error) {
+	ct, err := collectTaxComputation(d, l, accountingDateStart, accountingDateEnd)
+	if err != nil {
 		return CorporateTax{}, err
 	}
-	if pension, err = d.GetPensionSince(accountingDateStart, accountingDateEnd); err != nil {
+
+	// Corporate Tax, after losses of other years
+	relief, err := collectLossRelief(d, l, accountingDateStart, ct.Computation.TaxableProfit)
+	if err != nil {
 		return CorporateTax{}, err
 	}
 
-	profit := revenue - expenses - pension
+	ct.CorporateTaxSoFar = relief.Tax
+	ct.LossesRelieved = relief.CarriedForwardUsed + relief.CarriedBackFrom
+	ct.LossCarriedForward = relief.CarriedForward
+	ct.LossRepayment = relief.Repayment
+	ct.LossRelief = relief
+	return ct, nil
+}
+
+// builds the ledger for the corporation tax of the period starting on the date, it covers the later periods which
+// carry their losses back to it too
+func buildCorporateTaxLedger(d *db.Database, vat *tax.VATScheme, accountingDateStart time.Time, accountingDateEnd time.Time) (ledger.Ledger, error) {
+	memoranda, err := d.GetLossMemoranda()
+	if err != nil {
+		return ledger.Ledger{}, err
+	}
+
+	until := accountingDateEnd
+	for _, m := range memoranda {
+		if end := m.PeriodStart.AddDate(1, 0, 0); m.CarryBack && m.PeriodStart.After(accountingDateStart) && end.After(until) {
+			until = end
+		}
+	}
+	return ledger.Build(d, vat, until)
+}
+
+// the accounting profit of the period and its tax computation before the losses of other periods are relieved.
+// The ledger is built until the end of the period at least, the later entries are not taken
+func collectTaxComputation(d *db.Database, l ledger.Ledger, accountingDateStart time.Time, accountingDateEnd time.Time) (CorporateTax, error) {
+
+	var register []db.FixedAsset
+	var disallowable map[string]float64
+	var err error
+
+	if disallowable, err = d.GetDisallowableSince(accountingDateStart, accountingDateEnd); err != nil {
+		return CorporateTax{}, err
+	}
+	if register, err = assets.GetRegister(d); err != nil {
+		return CorporateTax{}, err
+	}
 
-	// Corporate Tax
-	corpTax := tax.CalculateCorporateTax(profit, accountingDateStart)
+	// the figures of closed periods are taken before the closing entries. Income has a credit balance, expenses
+	// have a debit one. Dividends and the corporation tax itself are paid from the profit after tax, so they
+	// are not expenses
+	balances := l.WithoutClosingEntries().Balances(accountingDateStart, accountingDateEnd)
+	var revenue, expenses float64
+	for code, balance := range balances {
+		a, _ := l.Account(code)
+		switch a.Type {
+		case db.IncomeAccount:
+			revenue = revenue - balance
+		case db.ExpenseAccount:
+			expenses = expenses + balance
+		}
+	}
+	salaries := round(balances[ledger.DirectorsSalaries] + balances[ledger.Wages])
+	pension := balances[ledger.Pension]
+	depreciation := balances[ledger.Depreciation]
+	disposalGains := -balances[ledger.DisposalLoss]
+	charge := balances[ledger.CorporationTaxCharge]
+	revenue = round(revenue)
+	expenses = round(expenses) - charge - salaries - pension - depreciation + disposalGains
+	accountingProfit := revenue - expenses - pension - salaries - depreciation + disposalGains
+
+	// the depreciation and disposal gains or losses are added back, the capital allowances are deducted instead
+	allowances := assets.CalculateCapitalAllowances(register, accountingDateStart)
+	computation := tax.ComputeTaxableProfit(accountingProfit,
+		append(disallowableAddBacks(disallowable, l),
+			tax.TaxAdjustment{Description: "Depreciation", Amount: depreciation},
+			tax.TaxAdjustment{Description: "Loss on disposals", Amount: math.Max(0, -disposalGains)}),
+		[]tax.TaxAdjustment{
+			{Description: "Gain on disposals", Amount: math.Max(0, disposalGains)},
+			{Description: "Capital allowances", Amount: allowances.Total},
+		})
 
 	// You must pay your Corporation Tax 9 months and 1 day after the end
 	// of your accounting period
@@ -171,28 +382,183 @@ func collectSummaryCorporateTax(d *db.Database, accountingDateStart time.Time, a
 		StartingDate:             accountingDateStart,
 		EndingDate:               accountingDateStart.AddDate(1, 0, -1),
 		NextPaymentDate:          paymentDate,
-		CorporateTaxSoFar:        corpTax,
 		EarnedAccountingPeriod:   revenue,
 		ExpensesAccountingPeriod: expenses,
 		PensionAccountingPeriod:  pension,
+		SalaryAccountingPeriod:   salaries,
+		Depreciation:             depreciation,
+		DisposalGains:            disposalGains,
+		AccountingProfit:         accountingProfit,
+		Computation:              computation,
 	}, nil
 }
 
-func collectSummaryVAT(d *db.Database, vatMonth time.Month, until time.Time) (VAT, error) {
+// CollectCorporateTax returns the corporation tax of the accounting period together with its tax computation
+func CollectCorporateTax(d *db.Database, vat *tax.VATScheme, accountingDateStart time.Time, accountingDateEnd time.Time) (CorporateTax, error) {
+	return collectSummaryCorporateTax(d, vat, accountingDateStart, accountingDateEnd)
+}
+
+// disallowable expenses ordered by category
+func disallowableAddBacks(disallowable map[string]float64, l ledger.Ledger) []tax.TaxAdjustment {
+	codes := make([]string, 0, len(disallowable))
+	for code := range disallowable {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	addBacks := make([]tax.TaxAdjustment, len(codes))
+	for i, code := range codes {
+		name := code
+		if a, ok := l.Account(code); ok {
+			name = a.Name
+		}
+		addBacks[i] = tax.TaxAdjustment{Description: "Disallowable: " + name, Amount: math.Round(disallowable[code]*100) / 100}
+	}
+	return addBacks
+}
+
+// applies the trading losses of other periods to the taxable profit of the period
+func collectLossRelief(d *db.Database, l ledger.Ledger, accountingDateStart time.Time, taxableProfit float64) (tax.LossRelief, error) {
+	periods, err := collectTradingPeriods(d, l, accountingDateStart, taxableProfit)
+	if err != nil {
+		return tax.LossRelief{}, err
+	}
+
+	for _, relief := range tax.ApplyTradingLosses(periods) {
+		if relief.PeriodStart.Equal(accountingDateStart) {
+			return relief, nil
+		}
+	}
+	return tax.LossRelief{}, nil
+}
+
+// taxable results of the accounting periods since the first transaction until the period starting on the date,
+// whose result is given, and of the later periods which claimed to carry their loss back. Results of the closed
+// periods are taken from the loss memorandum, the open ones are calculated from the ledger, so nothing is saved here
+func collectTradingPeriods(d *db.Database, l ledger.Ledger, accountingDateStart time.Time, taxableProfit float64) ([]tax.TradingPeriod, error) {
+	first, err := d.GetFirstTransactionDate()
+	if err != nil {
+		return nil, err
+	}
+	memoranda, err := d.GetLossMemoranda()
+	if err != nil {
+		return nil, err
+	}
+	locks, err := d.GetLockedPeriods()
+	if err != nil {
+		return nil, err
+	}
+
+	closed := map[time.Time]bool{}
+	for _, lock := range locks {
+		closed[lock.Start] = true
+	}
+	saved := map[time.Time]db.LossMemorandum{}
+	for _, m := range memoranda {
+		saved[m.PeriodStart] = m
+	}
+
+	var starts []time.Time
+	start := accountingDateStart
+	for !first.IsZero() && start.After(first) {
+		start = start.AddDate(-1, 0, 0)
+	}
+	for ; !start.After(accountingDateStart); start = start.AddDate(1, 0, 0) {
+		starts = append(starts, start)
+	}
+	for _, m := range memoranda {
+		if m.PeriodStart.After(accountingDateStart) && m.CarryBack {
+			starts = append(starts, m.PeriodStart)
+		}
+	}
+
+	periods := make([]tax.TradingPeriod, len(starts))
+	for i, start := range starts {
+		m, ok := saved[start]
+		periods[i] = tax.TradingPeriod{PeriodStart: start, Profit: m.Profit, CarryBack: m.CarryBack}
+		switch {
+		case start.Equal(accountingDateStart):
+			periods[i].Profit = taxableProfit
+		case !ok || !closed[start]:
+			ct, err := collectTaxComputation(d, l, start, start.AddDate(1, 0, 0))
+			if err != nil {
+				return nil, err
+			}
+			periods[i].Profit = ct.Computation.TaxableProfit
+		}
+	}
+	return periods, nil
+}
+
+// CollectLossMemorandum calculates results of all the accounting periods since the first transaction until now
+// and returns the loss memorandum
+func CollectLossMemorandum(d *db.Database, vat *tax.VATScheme, accountingDateStart time.Time, now time.Time) ([]tax.LossRelief, error) {
+	periods, err := collectCurrentTradingPeriods(d, vat, accountingDateStart, now)
+	if err != nil {
+		return nil, err
+	}
+	return tax.ApplyTradingLosses(periods), nil
+}
 
-	submitMonth, payDeadline := tax.GetNextReturnDate(vatMonth, until)
+// SaveLossMemorandum saves the taxable results of the accounting periods since the first transaction until now,
+// so that the loss of a period can be carried back and the results of the closed periods are kept as they were
+// filed. The carry back claims are kept
+func SaveLossMemorandum(d *db.Database, vat *tax.VATScheme, accountingDateStart time.Time, now time.Time) error {
+	periods, err := collectCurrentTradingPeriods(d, vat, accountingDateStart, now)
+	if err != nil {
+		return err
+	}
+	for _, p := range periods {
+		if err := d.SaveLossMemorandum(p.PeriodStart, p.Profit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	beginningVATPeriod := tax.GetBeginningOfPreviousPeriod(submitMonth, until.Year())
-	vatExpenses, err := d.GetExpensesSince(beginningVATPeriod, until)
+// the trading periods until the one starting on the date, its result is taken so far
+func collectCurrentTradingPeriods(d *db.Database, vat *tax.VATScheme, accountingDateStart time.Time, now time.Time) ([]tax.TradingPeriod, error) {
+	end := accountingDateStart.AddDate(1, 0, 0)
+	if end.After(now) {
+		end = now
+	}
+	l, err := buildCorporateTaxLedger(d, vat, accountingDateStart, end)
+	if err != nil {
+		return nil, err
+	}
+	ct, err := collectTaxComputation(d, l, accountingDateStart, end)
+	if err != nil {
+		return nil, err
+	}
+	return collectTradingPeriods(d, l, accountingDateStart, ct.Computation.TaxableProfit)
+}
+
+func collectSummaryVAT(d *db.Database, scheme tax.VATScheme, period tax.VATPeriod, until time.Time, previous *VAT) (VAT, error) {
+
+	// VAT is posted to the VAT control account on the tax point of the scheme: the invoice date under the invoice
+	// accounting, the date of the bank transaction under the cash accounting. The VAT of the finished periods
+	// moved to HMRC is not taken off
+	l, err := ledger.Build(d, &scheme, until)
 	if err != nil {
 		return VAT{}, err
 	}
+	vatSoFar := -l.Without(db.VATReturnPosting).Balance(period.Start, until, ledger.VATControl)
+
+	// under the Annual Accounting Scheme the VAT is paid in advance,
+	// based on the liability of the previous VAT year
+	var interimPayments []tax.VATInterimPayment
+	if scheme.Period == tax.AnnualReturns && previous != nil {
+		interimPayments = tax.GetAnnualAccountingPayments(period, previous.NextVATToBePaidSoFar, vatSoFar, scheme.QuarterlyInterimPayments)
+	}
 
 	return VAT{
-		Since:                   beginningVATPeriod,
-		Until:                   beginningVATPeriod.AddDate(0, 3, -1),
-		NextVATToBePaidSoFar:    vatExpenses * 0.2,
-		NextDateYouShouldPayFor: payDeadline,
-		NextMonthSubmit:         submitMonth.String(),
+		Basis:                   scheme.Basis,
+		Period:                  scheme.Period,
+		Since:                   period.Start,
+		Until:                   period.End,
+		NextVATToBePaidSoFar:    vatSoFar,
+		NextDateYouShouldPayFor: period.PaymentDue,
+		NextMonthSubmit:         period.EndingMonth.String(),
+		InterimPayments:         interimPayments,
 	}, nil
 }
@@ -4,6 +4,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/w32blaster/tax-bookkeeper/conf"
 	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/ledger"
+	"github.com/w32blaster/tax-bookkeeper/tax"
+	"os"
 	"strconv"
 	"strings"
 	"testing"
@@ -65,6 +68,22 @@ func TestActiveLoanIsNotPaidTwo(t *testing.T) {
 	assert.Equal(t, 40.0, left)
 }
 
+func TestActiveLoanAccumulates(t *testing.T) {
+
+	// Given: debits are negative in the bank statement
+	tx := []db.Transaction{
+		{Date: dateOf("01-02-2020"), Category: db.Loan, Debit: -200.0},
+		{Date: dateOf("01-01-2020"), Category: db.Loan, Debit: -100.0},
+		{Date: dateOf("03-02-2020"), Category: db.LoansReturn, Credit: 50.0},
+	}
+
+	// When:
+	left := getActiveLoan(tx)
+
+	// Then:
+	assert.Equal(t, 250.0, left)
+}
+
 func TestActiveLoanNoTransactions(t *testing.T) {
 
 	// Given:
@@ -77,6 +96,166 @@ func TestActiveLoanNoTransactions(t *testing.T) {
 	assert.Equal(t, 0.0, left)
 }
 
+func TestCollectDirectorIncome(t *testing.T) {
+
+	// create real DB
+	const dbFile = "/tmp/tax-bookkeeper-ui-director-income.db"
+	d := db.Init(dbFile)
+	defer func() {
+		d.Close()
+		os.Remove(dbFile)
+	}()
+
+	// Populate with data: old transactions of the Personal category are told apart by the description
+	_, err := d.ImportTransactions([]db.Transaction{
+		{Date: dateOf("30-04-2020"), Type: db.Debit, Category: db.Personal, Debit: 732.0, Description: "Director Salary April"},
+		{Date: dateOf("15-05-2020"), Type: db.Debit, Category: db.Personal, Debit: 5000.0, Description: "Dividend Q1"},
+		{Date: dateOf("30-06-2020"), Type: db.Debit, Category: db.Salary, Debit: 732.0, Description: "Payroll"},
+		{Date: dateOf("30-06-2020"), Type: db.Debit, Category: db.Dividend, Debit: 2000.0, Description: "Transfer"},
+		{Date: dateOf("06-04-2021"), Type: db.Debit, Category: db.Dividend, Debit: 3000.0, Description: "Next tax year"},
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, ledger.InitChartOfAccounts(d))
+
+	// the dividend declared on the last day of the tax year is credited to the director's loan account
+	assert.Nil(t, ledger.AddJournal(d, &db.JournalEntry{Date: dateOf("05-04-2021"), Description: "Final dividend",
+		Lines: []db.JournalLine{{Account: ledger.Dividends, Debit: 1000}, {Account: ledger.DirectorsLoan, Credit: 1000}}}))
+
+	// When:
+	salary, dividends, err := collectDirectorIncome(d, dateOf("06-04-2020"), dateOf("05-04-2021"))
+
+	// Then:
+	assert.Nil(t, err)
+	assert.Equal(t, 1464.0, salary)
+	assert.Equal(t, 8000.0, dividends)
+}
+
+func TestCollectLossRelief(t *testing.T) {
+
+	// create real DB
+	const dbFile = "/tmp/tax-bookkeeper-ui-losses.db"
+	d := db.Init(dbFile)
+	defer func() {
+		d.Close()
+		os.Remove(dbFile)
+	}()
+
+	// Populate with data: the loss of the first year is carried forward to the second one
+	_, err := d.ImportTransactions([]db.Transaction{
+		{Date: dateOf("10-05-2023"), Type: db.Debit, Description: "Office fit-out", Debit: 20000, Category: db.Office},
+		{Date: dateOf("10-05-2024"), Type: db.Credit, Description: "ACME", Credit: 50000, Category: db.Income},
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, ledger.InitChartOfAccounts(d))
+
+	// When:
+	ct, err := collectSummaryCorporateTax(d, nil, dateOf("01-04-2024"), dateOf("01-04-2025"))
+
+	// Then: the loss of the open period is calculated, nothing is saved
+	assert.Nil(t, err)
+	assert.Equal(t, 20000.0, ct.LossesRelieved)
+	memoranda, err := d.GetLossMemoranda()
+	assert.Nil(t, err)
+	assert.Empty(t, memoranda)
+
+	// and When: the memorandum is saved explicitly
+	assert.Nil(t, SaveLossMemorandum(d, nil, dateOf("01-04-2024"), dateOf("01-04-2025")))
+
+	// Then:
+	memoranda, err = d.GetLossMemoranda()
+	assert.Nil(t, err)
+	assert.Len(t, memoranda, 2)
+	assert.Equal(t, -20000.0, memoranda[0].Profit)
+	assert.Equal(t, 50000.0, memoranda[1].Profit)
+}
+
+func TestAllocateHMRCPayments(t *testing.T) {
+
+	// Given:
+	obligations := []tax.Obligation{
+		{Deadline: tax.Deadline{Type: tax.VATReturn, Date: dateOf("07-05-2025"), PeriodEnd: dateOf("31-03-2025")}, Amount: 1000},
+		{Deadline: tax.Deadline{Type: tax.SelfAssessmentReturn, Date: dateOf("31-01-2026"), PeriodEnd: dateOf("05-04-2025")}, Amount: 500},
+		{Deadline: tax.Deadline{Type: tax.VATReturn, Date: dateOf("07-08-2025"), PeriodEnd: dateOf("30-06-2025")}, Amount: 800},
+	}
+	payments := []tax.TaxPayment{
+		{Date: dateOf("05-05-2025"), Amount: 1200}, // overpaid the first return
+		{Date: dateOf("20-08-2025"), Amount: 600},
+	}
+
+	// When:
+	allocateHMRCPayments(obligations, payments)
+
+	// Then: the overpayment is not taken by the period that had not ended yet, the personal tax is skipped
+	assert.Equal(t, []tax.TaxPayment{{Date: dateOf("05-05-2025"), Amount: 1000}}, obligations[0].Payments)
+	assert.Empty(t, obligations[1].Payments)
+	assert.Equal(t, []tax.TaxPayment{{Date: dateOf("20-08-2025"), Amount: 600}}, obligations[2].Payments)
+}
+
+func TestCollectSummaryVAT(t *testing.T) {
+
+	// create real DB
+	const dbFile = "/tmp/tax-bookkeeper-ui-vat.db"
+	d := db.Init(dbFile)
+	defer func() {
+		d.Close()
+		os.Remove(dbFile)
+	}()
+
+	// Populate with data: INV-1 was issued in the previous quarter and paid in this one, INV-2 is not paid yet
+	_, err := d.ImportTransactions([]db.Transaction{
+		{Date: dateOf("05-02-2021"), Type: db.Debit, Description: "Stationery", Debit: 120, Category: db.Office},
+		{Date: dateOf("08-02-2021"), Type: db.Debit, Description: "Laptop", Debit: 600, Category: db.EquipmentExpenses},
+		{Date: dateOf("10-02-2021"), Type: db.Credit, Description: "ACME", Credit: 1200, Category: db.Income},
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, ledger.InitChartOfAccounts(d))
+
+	paidBy := map[string]int{}
+	transactions, err := d.GetAll(0, 0)
+	assert.Nil(t, err)
+	for _, tx := range transactions {
+		paidBy[tx.Description] = tx.Pk
+	}
+
+	invoices := []db.Invoice{
+		{Type: db.SalesInvoice, Number: "INV-1", IssueDate: dateOf("20-12-2020"), Net: 1000, VAT: 200},
+		{Type: db.SalesInvoice, Number: "INV-2", IssueDate: dateOf("15-03-2021"), Net: 2000, VAT: 400},
+		{Type: db.SupplierBill, Number: "B-1", IssueDate: dateOf("01-02-2021"), Net: 550, VAT: 50},
+	}
+	for i := range invoices {
+		assert.Nil(t, d.SaveInvoice(&invoices[i]))
+	}
+	assert.Nil(t, d.LinkInvoice(invoices[0].Pk, paidBy["ACME"]))
+	assert.Nil(t, d.LinkInvoice(invoices[2].Pk, paidBy["Laptop"]))
+
+	var tests = []struct {
+		basis       tax.VATAccountingBasis
+		expectedVAT float64
+	}{
+		// INV-2 minus the bill B-1
+		{tax.InvoiceAccounting, 350},
+
+		// paid INV-1 minus the paid bill B-1 and the VAT fraction of the stationery 120 x 0.2 / 1.2
+		{tax.CashAccounting, 130},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.basis.PrettyString(), func(t *testing.T) {
+
+			// Given:
+			scheme := tax.VATScheme{Period: tax.QuarterlyReturns, PeriodEndMonth: time.March, Basis: tt.basis}
+			period := scheme.GetVATPeriod(dateOf("15-02-2021"))
+
+			// When:
+			vat, err := collectSummaryVAT(d, scheme, period, dateOf("01-04-2021"), nil)
+
+			// Then:
+			assert.Nil(t, err)
+			assert.InDelta(t, tt.expectedVAT, vat.NextVATToBePaidSoFar, 0.001)
+		})
+	}
+}
+
 // shorthand for the date creation, like "01-03-2021"
 func dateOf(date string) time.Time {
 	parts := strings.Split(date, "-")
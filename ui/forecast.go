@@ -0,0 +1,198 @@
+package ui
+
+import (
+	"math"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/forecast"
+	"github.com/w32blaster/tax-bookkeeper/tax"
+)
+
+// how far ahead the dashboard forecasts the bank balance
+const forecastMonths = 12
+
+// CollectForecast projects the bank balance since today for the number of months. It starts with the balance of
+// the latest imported transaction, repeats the monthly incomes and expenses found in the bank history and
+// takes away every tax liability on its due date
+func CollectForecast(d *db.Database, calendar tax.CompanyCalendar, residency tax.Residency, today time.Time, months int) (forecast.Forecast, error) {
+	until := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location()).AddDate(0, months, 0)
+
+	opening, err := statementBankBalance(d, today.AddDate(0, 0, 1))
+	if err != nil {
+		return forecast.Forecast{}, err
+	}
+
+	history, err := d.GetAll(0, 0)
+	if err != nil {
+		return forecast.Forecast{}, err
+	}
+
+	var flows []forecast.Flow
+	for _, p := range forecast.DetectPatterns(history, today.AddDate(0, 0, 1)) {
+		flows = append(flows, p.Flows(today, until)...)
+	}
+
+	taxes, err := collectTaxFlows(d, calendar, residency, today, until)
+	if err != nil {
+		return forecast.Forecast{}, err
+	}
+
+	return forecast.Project(opening, today, months, append(flows, taxes...)), nil
+}
+
+// every tax liability due since today until the date, the ones of the periods not finished yet are estimated.
+// Corporation tax and VAT paid before the due date are taken off, the payments to HMRC are allocated to the
+// obligations as for the penalties
+func collectTaxFlows(d *db.Database, calendar tax.CompanyCalendar, residency tax.Residency, today, until time.Time) ([]forecast.Flow, error) {
+	var flows []forecast.Flow
+	add := func(date time.Time, description string, amount float64) {
+		flows = append(flows, forecast.Flow{Date: date, Description: description, Amount: -amount, Type: forecast.Tax})
+	}
+
+	obligations, err := collectObligations(d, calendar, residency, today.AddDate(-penaltiesLookBackYears, 0, 0), until,
+		today.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	// Corporation tax of the previous period and the current one, which is extended to the whole period
+	current := accountingPeriodOf(calendar.AccountingPeriodStart, today)
+	l, err := buildCorporateTaxLedger(d, calendar.VAT, current.AddDate(-1, 0, 0), today)
+	if err != nil {
+		return nil, err
+	}
+	for _, start := range []time.Time{current.AddDate(-1, 0, 0), current} {
+		end := start.AddDate(1, 0, 0)
+		if end.After(today) {
+			end = today
+		}
+		ct, err := collectSummaryCorporateTaxOfLedger(d, l, start, end)
+		if err != nil {
+			return nil, err
+		}
+
+		amount := ct.CorporateTaxSoFar
+		if elapsed := end.Sub(start).Hours(); end.Equal(today) && elapsed > 0 {
+			amount = round(amount * start.AddDate(1, 0, 0).Sub(start).Hours() / elapsed)
+		}
+		paid := paidTowards(obligations, tax.CorporationTaxPayment, ct.EndingDate)
+		add(ct.NextPaymentDate, "Corporation tax "+ct.Period, math.Max(0, amount-paid))
+	}
+
+	// s455 on the loans to the director which are not repaid in time
+	loans, err := collectSummaryDirectorLoans(d, current, today)
+	if err != nil {
+		return nil, err
+	}
+	for _, s455 := range []tax.S455Charge{loans.PreviousS455, loans.CurrentS455} {
+		add(s455.DueDate, "s455 on the director's loan", s455.Tax)
+	}
+
+	if calendar.VAT != nil {
+		vat, err := collectVATFlows(d, *calendar.VAT, obligations, today, until)
+		if err != nil {
+			return nil, err
+		}
+		flows = append(flows, vat...)
+	}
+
+	if calendar.HasPayroll {
+		paye, err := collectPAYEFlows(d, today, until)
+		if err != nil {
+			return nil, err
+		}
+		flows = append(flows, paye...)
+	}
+
+	// the director pays the self assessment with the money taken out of the company. The schedule of the previous
+	// tax year is needed for its balancing payment, its payments on account for this year are the same
+	previousSA, err := collectSummarySelfAssessmentTax(d, today.AddDate(-1, 0, 0), residency)
+	if err != nil {
+		return nil, err
+	}
+	currentSA, err := collectSummarySelfAssessmentTax(d, today, residency)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range previousSA.Payments {
+		if p.TaxYear == tax.GetTaxYear(previousSA.StartingDate) {
+			add(p.DueDate, "Self assessment "+p.TaxYear+", "+p.Type.PrettyString(), p.Amount)
+		}
+	}
+	for _, p := range currentSA.Payments {
+		add(p.DueDate, "Self assessment "+p.TaxYear+", "+p.Type.PrettyString(), p.Amount)
+	}
+
+	// only what is due within the forecast
+	due := flows[:0]
+	for _, f := range flows {
+		if f.Amount != 0 && !f.Date.Before(today) && f.Date.Before(until) {
+			due = append(due, f)
+		}
+	}
+	return due, nil
+}
+
+// VAT of the finished periods is known, the current one is extended to the whole period and the next ones
+// repeat the last finished period. What was paid for the finished periods is taken off. Interim payments of the
+// Annual Accounting Scheme are not forecast separately
+func collectVATFlows(d *db.Database, scheme tax.VATScheme, obligations []tax.Obligation, today, until time.Time) ([]forecast.Flow, error) {
+	var flows []forecast.Flow
+	var lastFinished float64
+	period := scheme.GetPreviousVATPeriod(scheme.GetPreviousVATPeriod(scheme.GetVATPeriod(today)))
+	for ; period.Start.Before(until); period = scheme.GetVATPeriod(period.End.AddDate(0, 0, 1)) {
+		var amount float64
+		switch {
+		case period.End.Before(today):
+			vat, err := collectSummaryVAT(d, scheme, period, period.End.AddDate(0, 0, 1), nil)
+			if err != nil {
+				return nil, err
+			}
+			lastFinished = vat.NextVATToBePaidSoFar
+			amount = math.Max(0, vat.NextVATToBePaidSoFar-paidTowards(obligations, tax.VATReturn, period.End))
+		case period.Start.After(today):
+			amount = lastFinished
+		default:
+			vat, err := collectSummaryVAT(d, scheme, period, today, nil)
+			if err != nil {
+				return nil, err
+			}
+			if elapsed := today.Sub(period.Start).Hours(); elapsed > 0 {
+				amount = vat.NextVATToBePaidSoFar * period.End.AddDate(0, 0, 1).Sub(period.Start).Hours() / elapsed
+			}
+		}
+		flows = append(flows, forecast.Flow{Date: period.PaymentDue, Description: "VAT for " + period.EndingMonth.String(),
+			Amount: -round(amount), Type: forecast.Tax})
+	}
+	return flows, nil
+}
+
+// PAYE of the payroll months already run, the next months repeat the latest one
+func collectPAYEFlows(d *db.Database, today, until time.Time) ([]forecast.Flow, error) {
+	var flows []forecast.Flow
+	var latest db.PAYEPayment
+	for _, taxYear := range []string{tax.GetTaxYear(today.AddDate(-1, 0, 0)), tax.GetTaxYear(today)} {
+		payments, err := d.GetPAYEPayments(taxYear)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range payments {
+			if p.TransactionPk == 0 {
+				flows = append(flows, forecast.Flow{Date: p.DueDate, Description: "PAYE " + p.TaxYear, Amount: -p.Total, Type: forecast.Tax})
+			}
+			if p.DueDate.After(latest.DueDate) {
+				latest = p
+			}
+		}
+	}
+
+	if latest.Total == 0 {
+		return flows, nil
+	}
+	for due := latest.DueDate.AddDate(0, 1, 0); due.Before(until); due = due.AddDate(0, 1, 0) {
+		taxYear := tax.GetTaxYear(due.AddDate(0, -1, 0))
+		flows = append(flows, forecast.Flow{Date: due, Description: "PAYE " + taxYear + " (estimated)", Amount: -latest.Total, Type: forecast.Tax})
+	}
+	return flows, nil
+}
@@ -0,0 +1,161 @@
+package ui
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/w32blaster/tax-bookkeeper/conf"
+	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/forecast"
+	"github.com/w32blaster/tax-bookkeeper/ledger"
+	"github.com/w32blaster/tax-bookkeeper/tax"
+)
+
+func TestCollectTaxFlows(t *testing.T) {
+
+	// create real DB
+	const dbFile = "/tmp/tax-bookkeeper-ui-tax-flows.db"
+	d := db.Init(dbFile)
+	defer func() {
+		d.Close()
+		os.Remove(dbFile)
+	}()
+
+	// Populate with data: a part of the corporation tax of the previous period is paid early
+	_, err := d.ImportTransactions([]db.Transaction{
+		{Date: dateOf("10-05-2024"), Type: db.Credit, Description: "ACME", Credit: 10000, Category: db.Income},
+		{Date: dateOf("10-06-2024"), Type: db.Debit, Description: "Dividend", Debit: 30000, Category: db.Dividend},
+		{Date: dateOf("10-05-2025"), Type: db.Credit, Description: "ACME", Credit: 5000, Category: db.Income},
+		{Date: dateOf("10-06-2025"), Type: db.Debit, Description: "HMRC", Debit: 1000, Category: db.HMRC},
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, ledger.InitChartOfAccounts(d))
+	calendar := tax.CompanyCalendar{AccountingPeriodStart: dateOf("01-04-2025")}
+
+	flow := func(date, description string, amount float64) forecast.Flow {
+		return forecast.Flow{Date: dateOf(date), Description: description, Amount: amount, Type: forecast.Tax}
+	}
+	var tests = []struct {
+		name     string
+		until    time.Time
+		expected []forecast.Flow
+	}{
+		{"the current period is extended to the whole year, the balancing payment of this year is a refund",
+			dateOf("01-04-2027"), []forecast.Flow{
+				flow("02-01-2026", "Corporation tax 2024-2025", -900),
+				flow("02-01-2027", "Corporation tax 2025-2026", -1894.81),
+				flow("31-01-2026", "Self assessment 2024-2025, Balancing payment", -1481.38),
+				flow("31-01-2026", "Self assessment 2025-2026, 1st payment on account", -740.69),
+				flow("31-07-2026", "Self assessment 2025-2026, 2nd payment on account", -740.69),
+				flow("31-01-2027", "Self assessment 2025-2026, Balancing payment", 1481.38),
+			}},
+		{"only what is due within the forecast", dateOf("01-07-2026"), []forecast.Flow{
+			flow("02-01-2026", "Corporation tax 2024-2025", -900),
+			flow("31-01-2026", "Self assessment 2024-2025, Balancing payment", -1481.38),
+			flow("31-01-2026", "Self assessment 2025-2026, 1st payment on account", -740.69),
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// When:
+			flows, err := collectTaxFlows(d, calendar, tax.EnglandAndNorthernIreland, dateOf("01-10-2025"), tt.until)
+
+			// Then:
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, flows)
+		})
+	}
+}
+
+func TestCollectVATFlows(t *testing.T) {
+
+	// create real DB
+	const dbFile = "/tmp/tax-bookkeeper-ui-vat-flows.db"
+	d := db.Init(dbFile)
+	defer func() {
+		d.Close()
+		os.Remove(dbFile)
+	}()
+
+	// Populate with data: the first quarter is paid in full, the second one in part before its due date
+	_, err := d.ImportTransactions([]db.Transaction{
+		{Date: dateOf("10-05-2025"), Type: db.Credit, Description: "INV-1", Credit: 1200, Category: db.Income},
+		{Date: dateOf("01-08-2025"), Type: db.Debit, Description: "HMRC", Debit: 200, Category: db.HMRC},
+		{Date: dateOf("10-08-2025"), Type: db.Credit, Description: "INV-2", Credit: 2400, Category: db.Income},
+		{Date: dateOf("01-10-2025"), Type: db.Debit, Description: "HMRC", Debit: 150, Category: db.HMRC},
+		{Date: dateOf("10-10-2025"), Type: db.Credit, Description: "INV-3", Credit: 600, Category: db.Income},
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, ledger.InitChartOfAccounts(d))
+
+	transactions, err := d.GetAll(0, 0)
+	assert.Nil(t, err)
+	for _, tx := range transactions {
+		if tx.Type != db.Credit {
+			continue
+		}
+		invoice := db.Invoice{Type: db.SalesInvoice, Number: tx.Description, IssueDate: tx.Date,
+			Net: round(tx.Credit / 1.2), VAT: round(tx.Credit / 6)}
+		assert.Nil(t, d.SaveInvoice(&invoice))
+		assert.Nil(t, d.LinkInvoice(invoice.Pk, tx.Pk))
+	}
+
+	scheme := tax.VATScheme{Period: tax.QuarterlyReturns, PeriodEndMonth: time.March, Basis: tax.CashAccounting}
+	calendar := tax.CompanyCalendar{AccountingPeriodStart: dateOf("01-04-2025"), VAT: &scheme}
+	today := dateOf("01-11-2025")
+	obligations, err := collectObligations(d, calendar, tax.EnglandAndNorthernIreland, today.AddDate(-3, 0, 0),
+		dateOf("01-05-2026"), today)
+	assert.Nil(t, err)
+
+	// When:
+	flows, err := collectVATFlows(d, scheme, obligations, today, dateOf("01-05-2026"))
+
+	// Then: the current quarter is extended from 31 days to 92, the next ones repeat the last finished quarter
+	assert.Nil(t, err)
+	assert.Equal(t, []forecast.Flow{
+		{Date: dateOf("07-08-2025"), Description: "VAT for June", Amount: 0, Type: forecast.Tax},
+		{Date: dateOf("07-11-2025"), Description: "VAT for September", Amount: -250, Type: forecast.Tax},
+		{Date: dateOf("07-02-2026"), Description: "VAT for December", Amount: -296.77, Type: forecast.Tax},
+		{Date: dateOf("07-05-2026"), Description: "VAT for March", Amount: -400, Type: forecast.Tax},
+		{Date: dateOf("07-08-2026"), Description: "VAT for June", Amount: -400, Type: forecast.Tax},
+	}, flows)
+}
+
+func TestCollectPAYEFlows(t *testing.T) {
+
+	// create real DB
+	const dbFile = "/tmp/tax-bookkeeper-ui-paye-flows.db"
+	d := db.Init(dbFile)
+	defer func() {
+		d.Close()
+		os.Remove(dbFile)
+	}()
+
+	// Populate with data: the first month of the tax year is paid and linked to the bank transaction
+	for _, p := range []db.PAYEPayment{
+		{TaxYear: "2024-2025", TaxMonth: 12, Total: 400, DueDate: dateOf("22-04-2025")},
+		{TaxYear: "2025-2026", TaxMonth: 1, Total: 500, DueDate: dateOf("22-05-2025"), TransactionPk: 7},
+		{TaxYear: "2025-2026", TaxMonth: 2, Total: 600, DueDate: dateOf("22-06-2025")},
+	} {
+		assert.Nil(t, d.SavePayroll(nil, &p))
+	}
+
+	// When:
+	flows, err := collectPAYEFlows(d, dateOf("15-06-2025"), dateOf("01-09-2025"))
+
+	// Then: the next months repeat the latest one
+	assert.Nil(t, err)
+	for i := range flows {
+		flows[i].Date = flows[i].Date.In(conf.GMT) // as they are read from the DB
+	}
+	assert.Equal(t, []forecast.Flow{
+		{Date: dateOf("22-04-2025"), Description: "PAYE 2024-2025", Amount: -400, Type: forecast.Tax},
+		{Date: dateOf("22-06-2025"), Description: "PAYE 2025-2026", Amount: -600, Type: forecast.Tax},
+		{Date: dateOf("22-07-2025"), Description: "PAYE 2025-2026 (estimated)", Amount: -600, Type: forecast.Tax},
+		{Date: dateOf("22-08-2025"), Description: "PAYE 2025-2026 (estimated)", Amount: -600, Type: forecast.Tax},
+	}, flows)
+}
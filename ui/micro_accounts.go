@@ -0,0 +1,130 @@
+package ui
+
+import (
+	"math"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/ledger"
+	"github.com/w32blaster/tax-bookkeeper/statutory"
+	"github.com/w32blaster/tax-bookkeeper/tax"
+)
+
+// CollectMicroEntityAccounts returns the figures of the micro-entity accounts of the accounting period starting
+// on the date. The company details, the director and the approval date are filled in by the caller. Directors
+// are employees too, whether they are on the payroll or not
+func CollectMicroEntityAccounts(d *db.Database, calendar tax.CompanyCalendar, periodStart time.Time, directors int) (statutory.MicroEntityAccounts, error) {
+	end := periodStart.AddDate(1, 0, 0)
+	accounts := statutory.MicroEntityAccounts{PeriodStart: periodStart, PeriodEnd: end.AddDate(0, 0, -1)}
+
+	for _, bs := range []struct {
+		sheet *statutory.BalanceSheet
+		date  time.Time
+	}{{&accounts.Current, accounts.PeriodEnd}, {&accounts.Previous, periodStart.AddDate(0, 0, -1)}} {
+		collected, err := CollectBalanceSheet(d, calendar, bs.date)
+		if err != nil {
+			return statutory.MicroEntityAccounts{}, err
+		}
+		*bs.sheet = statutory.NewBalanceSheet(collected.FixedAssets, collected.TotalCurrentAssets,
+			collected.TotalCurrentLiabilities, collected.TotalEquity)
+	}
+
+	l, err := ledger.Build(d, calendar.VAT, end)
+	if err != nil {
+		return statutory.MicroEntityAccounts{}, err
+	}
+	accounts.FirstPeriod = len(l.Entries) == 0 || !l.Entries[0].Date.Before(periodStart)
+	accounts.DirectorAdvances = collectDirectorAdvances(l, periodStart, end)
+
+	if accounts.AverageEmployees, err = averageEmployees(d, periodStart, end, directors); err != nil {
+		return statutory.MicroEntityAccounts{}, err
+	}
+	if accounts.PreviousAverageEmployees, err = averageEmployees(d, periodStart.AddDate(-1, 0, 0), periodStart, directors); err != nil {
+		return statutory.MicroEntityAccounts{}, err
+	}
+	return accounts, nil
+}
+
+// movements of the director's loan account, nil if it was never overdrawn during the period
+func collectDirectorAdvances(l ledger.Ledger, from, until time.Time) *statutory.DirectorAdvances {
+	advances := statutory.DirectorAdvances{BroughtForward: math.Round(l.Balance(time.Time{}, from, ledger.DirectorsLoan))}
+
+	balance := advances.BroughtForward
+	isOverdrawn := balance > 0
+	for _, e := range l.Entries {
+		if e.Date.Before(from) || !e.Date.Before(until) {
+			continue
+		}
+		for _, line := range e.Lines {
+			if line.Account != ledger.DirectorsLoan {
+				continue
+			}
+			advances.Advanced = advances.Advanced + line.Debit
+			advances.Repaid = advances.Repaid + line.Credit
+			balance = balance + line.Debit - line.Credit
+			isOverdrawn = isOverdrawn || balance > 0.005
+		}
+	}
+	if !isOverdrawn {
+		return nil
+	}
+
+	advances.Advanced = math.Round(advances.Advanced)
+	advances.Repaid = math.Round(advances.Repaid)
+	advances.CarriedForward = advances.BroughtForward + advances.Advanced - advances.Repaid
+	return &advances
+}
+
+// the number of employees paid through the payroll in every month of the period, averaged over the period.
+// The directors who were not paid that month are counted too
+func averageEmployees(d *db.Database, from, until time.Time, directors int) (int, error) {
+	employees, err := d.GetAllEmployees()
+	if err != nil {
+		return 0, err
+	}
+	isDirector := map[int]bool{}
+	for _, e := range employees {
+		isDirector[e.Pk] = e.IsDirector
+	}
+
+	taxYears := map[string]bool{}
+	for date := from; date.Before(until); date = date.AddDate(0, 1, 0) {
+		taxYears[tax.GetTaxYear(date)] = true
+	}
+
+	paid := map[int]map[int]bool{} // employees by the month since "from"
+	for taxYear := range taxYears {
+		payslips, err := d.GetPayslips(taxYear)
+		if err != nil {
+			return 0, err
+		}
+		for _, p := range payslips {
+			if p.PayDate.Before(from) || !p.PayDate.Before(until) {
+				continue
+			}
+			month := (p.PayDate.Year()-from.Year())*12 + int(p.PayDate.Month()-from.Month())
+			if paid[month] == nil {
+				paid[month] = map[int]bool{}
+			}
+			paid[month][p.EmployeePk] = true
+		}
+	}
+
+	months := (until.Year()-from.Year())*12 + int(until.Month()-from.Month())
+	var total int
+	for month := 0; month < months; month++ {
+		var paidDirectors int
+		for pk := range paid[month] {
+			if isDirector[pk] {
+				paidDirectors++
+			}
+		}
+		total = total + len(paid[month]) - paidDirectors
+		if paidDirectors > directors {
+			total = total + paidDirectors
+		} else {
+			total = total + directors
+		}
+	}
+	return int(math.Round(float64(total) / float64(months))), nil
+}
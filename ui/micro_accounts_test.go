@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/ledger"
+	"github.com/w32blaster/tax-bookkeeper/tax"
+)
+
+func TestCollectMicroEntityAccounts(t *testing.T) {
+
+	// create real DB
+	const dbFile = "/tmp/tax-bookkeeper-ui-micro-accounts.db"
+	d := db.Init(dbFile)
+	defer func() {
+		d.Close()
+		os.Remove(dbFile)
+	}()
+
+	// Populate with data: one of two directors and the assistant are on the payroll from the second year
+	_, err := d.ImportTransactions([]db.Transaction{
+		{Date: dateOf("10-05-2023"), Type: db.Credit, Description: "ACME", Credit: 20000, Category: db.Income},
+		{Date: dateOf("10-05-2024"), Type: db.Credit, Description: "ACME", Credit: 30000, Category: db.Income},
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, ledger.InitChartOfAccounts(d))
+
+	director := db.Employee{Name: "Director", IsDirector: true}
+	assistant := db.Employee{Name: "Assistant"}
+	assert.Nil(t, d.SaveEmployee(&director))
+	assert.Nil(t, d.SaveEmployee(&assistant))
+	for month := 1; month <= 12; month++ {
+		payDate := dateOf("25-04-2024").AddDate(0, month-1, 0)
+		assert.Nil(t, d.SavePayroll([]db.Payslip{
+			{EmployeePk: director.Pk, TaxYear: "2024-2025", TaxMonth: month, PayDate: payDate, IncomeTax: 100},
+			{EmployeePk: assistant.Pk, TaxYear: "2024-2025", TaxMonth: month, PayDate: payDate, IncomeTax: 50},
+		}, &db.PAYEPayment{TaxYear: "2024-2025", TaxMonth: month, IncomeTax: 150, Total: 150,
+			DueDate: payDate.AddDate(0, 0, 27)}))
+	}
+
+	// When:
+	calendar := tax.CompanyCalendar{AccountingPeriodStart: dateOf("01-04-2024")}
+	accounts, err := CollectMicroEntityAccounts(d, calendar, dateOf("01-04-2024"), 2)
+
+	// Then: the unpaid director is counted as well
+	assert.Nil(t, err)
+	assert.Equal(t, 3, accounts.AverageEmployees)
+	assert.Equal(t, 2, accounts.PreviousAverageEmployees)
+
+	// and Then: the taxes owed are in the creditors, so the balance sheet balances
+	assert.Equal(t, accounts.Current.NetAssets, accounts.Current.CapitalAndReserves)
+	assert.Equal(t, accounts.Previous.NetAssets, accounts.Previous.CapitalAndReserves)
+	assert.False(t, accounts.FirstPeriod)
+}
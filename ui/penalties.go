@@ -0,0 +1,232 @@
+package ui
+
+import (
+	"math"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/tax"
+)
+
+// how far back we look for missed deadlines
+const penaltiesLookBackYears = 3
+
+// CollectPenalties estimates penalties and late payment interest for the deadlines missed within the last few years.
+// Returns without a filing record are treated as filed on time, and payments are taken from the HMRC transactions
+func CollectPenalties(d *db.Database, calendar tax.CompanyCalendar, residency tax.Residency, now time.Time) ([]tax.PenaltyEstimate, error) {
+	obligations, err := collectObligations(d, calendar, residency, now.AddDate(-penaltiesLookBackYears, 0, 0), now, now)
+	if err != nil || len(obligations) == 0 {
+		return nil, err
+	}
+
+	// the tax-geared CT600 penalties depend on the tax still unpaid
+	for i := range obligations {
+		if obligations[i].Deadline.Type != tax.CorporationTaxReturn {
+			continue
+		}
+		for _, o := range obligations {
+			if o.Deadline.Type == tax.CorporationTaxPayment && o.Deadline.PeriodEnd.Equal(obligations[i].Deadline.PeriodEnd) {
+				obligations[i].Payments = o.Payments
+			}
+		}
+	}
+
+	var vatPeriod tax.VATReturnPeriod
+	if calendar.VAT != nil {
+		vatPeriod = calendar.VAT.Period
+	}
+	return tax.EstimatePenalties(obligations, vatPeriod, now), nil
+}
+
+// obligations with the deadlines since "from" until "until" and the payments made to HMRC until now. Payments
+// linked to the payroll go to their month, the other ones are allocated to the earliest unpaid obligations
+func collectObligations(d *db.Database, calendar tax.CompanyCalendar, residency tax.Residency, from, until, now time.Time) ([]tax.Obligation, error) {
+	deadlines := tax.GetDeadlines(calendar, from, until)
+
+	records, err := d.GetFilingRecords()
+	if err != nil {
+		return nil, err
+	}
+
+	reliefs, err := CollectLossMemorandum(d, calendar.VAT, calendar.AccountingPeriodStart, now)
+	if err != nil {
+		return nil, err
+	}
+
+	linked := map[int]bool{} // HMRC transactions linked to the payroll
+	payePayments := map[string][]db.PAYEPayment{}
+
+	var obligations []tax.Obligation
+	for _, dl := range deadlines {
+		o := tax.Obligation{Deadline: dl, Filed: dl.Date}
+
+		switch dl.Type {
+		case tax.CorporationTaxPayment:
+			o.Amount = corporationTaxOfPeriod(reliefs, dl.PeriodEnd)
+
+		case tax.CorporationTaxReturn:
+			o.Amount = corporationTaxOfPeriod(reliefs, dl.PeriodEnd)
+			o.Filed = filingDate(records, db.CT600Return, dl)
+
+		case tax.VATReturn:
+			period := calendar.VAT.GetVATPeriod(dl.PeriodEnd)
+			vat, err := collectSummaryVAT(d, *calendar.VAT, period, period.End.AddDate(0, 0, 1), nil)
+			if err != nil {
+				return nil, err
+			}
+			o.Amount = vat.NextVATToBePaidSoFar
+			o.Filed = filingDate(records, db.VATReturn, dl)
+
+		case tax.SelfAssessmentReturn:
+			if o.Amount, err = collectSelfAssessmentBill(d, dl.PeriodEnd, residency); err != nil {
+				return nil, err
+			}
+			o.Filed = filingDate(records, db.SelfAssessmentReturn, dl)
+			o.Payments = []tax.TaxPayment{{Date: selfAssessmentPaymentDate(records, dl), Amount: o.Amount}}
+
+		case tax.PAYEPayment:
+			taxYear := tax.GetTaxYear(dl.PeriodEnd)
+			if _, ok := payePayments[taxYear]; !ok {
+				if payePayments[taxYear], err = d.GetPAYEPayments(taxYear); err != nil {
+					return nil, err
+				}
+			}
+			for _, p := range payePayments[taxYear] {
+				if !p.DueDate.Equal(dl.Date) {
+					continue
+				}
+				o.Amount = p.Total
+				if p.TransactionPk != 0 {
+					t, err := d.GetTransaction(p.TransactionPk)
+					if err != nil {
+						return nil, err
+					}
+					linked[t.Pk] = true
+					o.Payments = []tax.TaxPayment{{Date: t.Date, Amount: math.Abs(t.Debit)}}
+				}
+			}
+
+		default:
+			continue
+		}
+		obligations = append(obligations, o)
+	}
+	if len(obligations) == 0 {
+		return nil, nil
+	}
+
+	since := now
+	for _, o := range obligations {
+		if o.Deadline.PeriodEnd.Before(since) {
+			since = o.Deadline.PeriodEnd
+		}
+	}
+	transactions, err := d.GetDebitTransactionsSince(since, now, db.HMRC)
+	if err != nil {
+		return nil, err
+	}
+	var payments []tax.TaxPayment
+	for _, t := range transactions {
+		if !linked[t.Pk] {
+			payments = append(payments, tax.TaxPayment{Date: t.Date, Amount: math.Abs(t.Debit)})
+		}
+	}
+	allocateHMRCPayments(obligations, payments)
+	return obligations, nil
+}
+
+// the payments allocated to the obligation of the type for the period ending on the date
+func paidTowards(obligations []tax.Obligation, deadlineType tax.DeadlineType, periodEnd time.Time) float64 {
+	var paid float64
+	for _, o := range obligations {
+		if o.Deadline.Type != deadlineType || !o.Deadline.PeriodEnd.Equal(periodEnd) {
+			continue
+		}
+		for _, p := range o.Payments {
+			paid = paid + p.Amount
+		}
+	}
+	return round(paid)
+}
+
+// payments to HMRC can't be told apart, so every payment goes to the earliest unpaid obligation
+// whose period has already ended. The obligations are ordered by the deadline
+func allocateHMRCPayments(obligations []tax.Obligation, payments []tax.TaxPayment) {
+	for _, p := range payments {
+		left := p.Amount
+		for i := range obligations {
+			o := &obligations[i]
+			if left <= 0 {
+				break
+			}
+
+			switch o.Deadline.Type {
+			case tax.CorporationTaxPayment, tax.VATReturn, tax.PAYEPayment:
+			default:
+				continue
+			}
+			if o.Deadline.PeriodEnd.After(p.Date) {
+				continue
+			}
+
+			unpaid := o.Amount
+			for _, paid := range o.Payments {
+				unpaid = unpaid - paid.Amount
+			}
+			if unpaid <= 0 {
+				continue
+			}
+
+			amount := math.Min(unpaid, left)
+			o.Payments = append(o.Payments, tax.TaxPayment{Date: p.Date, Amount: amount})
+			left = left - amount
+		}
+	}
+}
+
+// corporation tax after the loss relief of the accounting period ending on the date
+func corporationTaxOfPeriod(reliefs []tax.LossRelief, periodEnd time.Time) float64 {
+	for _, r := range reliefs {
+		if r.PeriodStart.AddDate(1, 0, -1).Equal(periodEnd) {
+			return r.Tax
+		}
+	}
+	return 0
+}
+
+// the director's tax paid with the self assessment for the tax year ending on the date
+func collectSelfAssessmentBill(d *db.Database, taxYearEnd time.Time, residency tax.Residency) (float64, error) {
+	startDate, endDate, _ := tax.GetTaxYearDates(taxYearEnd)
+	salary, dividends, err := collectDirectorIncome(d, startDate, endDate)
+	if err != nil {
+		return 0, err
+	}
+
+	taxYear := tax.GetTaxYear(startDate)
+	incomeTax, dividendTax := tax.CalculateDirectorSelfAssessmentTax(salary, dividends, taxYear, residency)
+	liability, err := collectSelfAssessmentLiability(d, taxYear, incomeTax+dividendTax)
+	if err != nil {
+		return 0, err
+	}
+	return math.Max(0, liability.TotalTax-liability.DeductedAtSource), nil
+}
+
+// when the return was filed, on the deadline if there is no record
+func filingDate(records []db.FilingRecord, returnType db.ReturnType, dl tax.Deadline) time.Time {
+	for _, r := range records {
+		if r.Return == returnType && r.PeriodEnd.Equal(dl.PeriodEnd) {
+			return r.Filed
+		}
+	}
+	return dl.Date
+}
+
+// when the personal tax was paid, on the deadline if there is no record
+func selfAssessmentPaymentDate(records []db.FilingRecord, dl tax.Deadline) time.Time {
+	for _, r := range records {
+		if r.Return == db.SelfAssessmentReturn && r.PeriodEnd.Equal(dl.PeriodEnd) && !r.Paid.IsZero() {
+			return r.Paid
+		}
+	}
+	return dl.Date
+}
@@ -0,0 +1,249 @@
+package ui
+
+import (
+	"sort"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/ledger"
+	"github.com/w32blaster/tax-bookkeeper/tax"
+)
+
+// ReportDateFormat is how dates are shown in the headers of reports
+const ReportDateFormat = "02 Jan 2006"
+
+type (
+	// ProfitAndLoss is the profit and loss statement of the date range with the corporation tax charge
+	ProfitAndLoss struct {
+		ledger.ProfitAndLoss
+		CorporationTax float64 `json:"corporationTax"`
+		ProfitAfterTax float64 `json:"profitAfterTax"`
+	}
+
+	// ProfitAndLossReport compares the statement with the previous period of the same length
+	ProfitAndLossReport struct {
+		Current  ProfitAndLoss `json:"current"`
+		Previous ProfitAndLoss `json:"previous"`
+	}
+
+	// ReportRow is one row of a report with the comparison column
+	ReportRow struct {
+		Label    string
+		Current  float64
+		Previous float64
+		IsHeader bool // a group name without amounts
+		IsTotal  bool
+	}
+)
+
+// CollectProfitAndLoss returns the profit and loss statement since "from" until "until" (exclusive) and the one
+// of the previous period. accountingDateStart is the start of any accounting period of the company
+func CollectProfitAndLoss(d *db.Database, vat *tax.VATScheme, accountingDateStart, from, until, now time.Time) (ProfitAndLossReport, error) {
+	l, err := ledger.Build(d, vat, until)
+	if err != nil {
+		return ProfitAndLossReport{}, err
+	}
+	l = l.WithoutClosingEntries()
+
+	var report ProfitAndLossReport
+	previousFrom, previousUntil := PreviousPeriod(from, until)
+	for _, p := range []struct {
+		pnl         *ProfitAndLoss
+		from, until time.Time
+	}{{&report.Current, from, until}, {&report.Previous, previousFrom, previousUntil}} {
+
+		p.pnl.ProfitAndLoss = l.ProfitAndLoss(p.from, p.until)
+		if p.pnl.CorporationTax, err = collectCorporationTaxCharge(d, vat, accountingDateStart, p.from, p.until, now); err != nil {
+			return ProfitAndLossReport{}, err
+		}
+		p.pnl.ProfitAfterTax = round(p.pnl.OperatingProfit - p.pnl.CorporationTax)
+	}
+	return report, nil
+}
+
+// PreviousPeriod returns the period of the same length right before the given one. Whole months are
+// moved by months, so that the previous period of a year is the previous year
+func PreviousPeriod(from, until time.Time) (time.Time, time.Time) {
+	months := (until.Year()-from.Year())*12 + int(until.Month()-from.Month())
+	if months > 0 && from.AddDate(0, months, 0).Equal(until) {
+		return from.AddDate(0, -months, 0), from
+	}
+	return from.Add(-until.Sub(from)), from
+}
+
+// corporation tax of every accounting period within the date range, apportioned by days when the range covers
+// only a part of the period. The tax of the current period is estimated from the profit so far
+func collectCorporationTaxCharge(d *db.Database, vat *tax.VATScheme, accountingDateStart, from, until, now time.Time) (float64, error) {
+	var charge float64
+	for start := accountingPeriodOf(accountingDateStart, from); start.Before(until) && start.Before(now); start = start.AddDate(1, 0, 0) {
+		end := start.AddDate(1, 0, 0)
+		if end.After(now) {
+			end = now
+		}
+
+		overlapFrom, overlapUntil := start, end
+		if from.After(overlapFrom) {
+			overlapFrom = from
+		}
+		if until.Before(overlapUntil) {
+			overlapUntil = until
+		}
+		if !overlapUntil.After(overlapFrom) {
+			continue
+		}
+
+		ct, err := collectSummaryCorporateTax(d, vat, start, end)
+		if err != nil {
+			return 0, err
+		}
+		charge = charge + ct.CorporateTaxSoFar*overlapUntil.Sub(overlapFrom).Hours()/end.Sub(start).Hours()
+	}
+	return round(charge), nil
+}
+
+// CollectLedger builds the general ledger until the date (exclusive) with the corporation tax accrued at the end of
+// every accounting period, unless it was accrued at the year-end already. The tax of the current period is
+// estimated from the profit so far and accrued on the day before the date
+func CollectLedger(d *db.Database, vat *tax.VATScheme, accountingDateStart, until time.Time) (ledger.Ledger, error) {
+	l, err := ledger.Build(d, vat, until)
+	if err != nil || len(l.Entries) == 0 {
+		return l, err
+	}
+
+	accrued := map[time.Time]bool{}
+	for _, e := range l.Entries {
+		if e.Source == db.TaxAccrual {
+			accrued[accountingPeriodOf(accountingDateStart, e.Date)] = true
+		}
+	}
+
+	for start := accountingPeriodOf(accountingDateStart, l.Entries[0].Date); start.Before(until); start = start.AddDate(1, 0, 0) {
+		if accrued[start] {
+			continue
+		}
+		end := start.AddDate(1, 0, 0)
+		if end.After(until) {
+			end = until
+		}
+
+		ct, err := collectCorporationTaxCharge(d, vat, accountingDateStart, start, end, end)
+		if err != nil {
+			return ledger.Ledger{}, err
+		}
+		if ct != 0 {
+			entry := ledger.PostCorporationTax(start, ct)
+			entry.Date = end.AddDate(0, 0, -1)
+			l.Entries = append(l.Entries, entry)
+		}
+	}
+
+	sort.SliceStable(l.Entries, func(i, j int) bool {
+		return l.Entries[i].Date.Before(l.Entries[j].Date)
+	})
+	return l, nil
+}
+
+// Rows returns the statement line by line, overheads are grouped by their parent account
+func (r ProfitAndLossReport) Rows() []ReportRow {
+	cur, prev := r.Current, r.Previous
+	rows := []ReportRow{
+		{Label: "Turnover", Current: cur.Turnover, Previous: prev.Turnover},
+		{Label: "Cost of sales", Current: cur.CostOfSales, Previous: prev.CostOfSales},
+		{Label: "Gross profit", Current: cur.GrossProfit, Previous: prev.GrossProfit, IsTotal: true},
+	}
+
+	// accounts used in any of the periods
+	overheads := map[string]ledger.ReportLine{}
+	amounts := map[string][2]float64{}
+	for i, lines := range [][]ledger.ReportLine{cur.Overheads, prev.Overheads} {
+		for _, line := range lines {
+			overheads[line.Code] = line
+			a := amounts[line.Code]
+			a[i] = line.Amount
+			amounts[line.Code] = a
+		}
+	}
+	codes := make([]string, 0, len(overheads))
+	for code := range overheads {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	group := ""
+	for _, code := range codes {
+		line := overheads[code]
+		if line.Group != group && line.Group != "" {
+			rows = append(rows, ReportRow{Label: line.Group, IsHeader: true})
+		}
+		group = line.Group
+
+		label := line.Name
+		if group != "" {
+			label = "  " + label
+		}
+		rows = append(rows, ReportRow{Label: label, Current: amounts[code][0], Previous: amounts[code][1]})
+	}
+
+	return append(rows,
+		ReportRow{Label: "Total overheads", Current: cur.TotalOverheads, Previous: prev.TotalOverheads, IsTotal: true},
+		ReportRow{Label: "Operating profit", Current: cur.OperatingProfit, Previous: prev.OperatingProfit, IsTotal: true},
+		ReportRow{Label: "Corporation tax", Current: cur.CorporationTax, Previous: prev.CorporationTax},
+		ReportRow{Label: "Profit after tax", Current: cur.ProfitAfterTax, Previous: prev.ProfitAfterTax, IsTotal: true},
+	)
+}
+
+// PeriodLabel returns the date range with the inclusive end date, like "01 Apr 2025 - 31 Mar 2026"
+func PeriodLabel(from, until time.Time) string {
+	return from.Format(ReportDateFormat) + " - " + until.AddDate(0, 0, -1).Format(ReportDateFormat)
+}
+
+// DrawReport shows the rows of a report with the comparison column, warnings are shown above the table
+func (t *TerminalUI) DrawReport(title string, headers []string, rows []ReportRow, warnings []string) {
+	table := tview.NewTable().SetBorders(false).SetFixed(1, 0).SetSelectable(true, false)
+
+	var uLine tcell.Style
+	uLine = uLine.Underline(true)
+
+	for c, header := range headers {
+		table.SetCell(0, c,
+			tview.NewTableCell(header).
+				SetStyle(uLine).
+				SetTextColor(tcell.ColorWhite).
+				SetAlign(tview.AlignRight))
+	}
+
+	for r, row := range rows {
+		color := tcell.ColorGrey
+		if row.IsTotal {
+			color = tcell.ColorWhite
+		}
+		table.SetCell(r+1, 0, tview.NewTableCell(row.Label).SetTextColor(color))
+		if row.IsHeader {
+			continue
+		}
+
+		for c, amount := range []float64{row.Current, row.Previous} {
+			amountColor := color
+			if row.IsTotal && amount < 0 {
+				amountColor = tcell.ColorRed
+			}
+			table.SetCell(r+1, c+1,
+				tview.NewTableCell("£"+floatToString(amount)).
+					SetTextColor(amountColor).
+					SetAlign(tview.AlignRight))
+		}
+	}
+
+	flex := tview.NewFlex().SetDirection(tview.FlexRow)
+	flex.SetBorder(true).SetTitle(" "+title+" ").SetBorderPadding(1, 1, 1, 1)
+	for _, warning := range warnings {
+		flex.AddItem(tview.NewTextView().SetText(warning).SetTextColor(tcell.ColorRed), 1, 0, false)
+	}
+	flex.AddItem(table, 0, 1, true)
+
+	if err := t.app.SetRoot(flex, true).EnableMouse(true).SetFocus(table).Run(); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,32 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreviousPeriod(t *testing.T) {
+	var tests = []struct {
+		from, until                 time.Time
+		expectedFrom, expectedUntil time.Time
+	}{
+		{dateOf("01-04-2025"), dateOf("01-04-2026"), dateOf("01-04-2024"), dateOf("01-04-2025")},
+		{dateOf("01-01-2025"), dateOf("01-04-2025"), dateOf("01-10-2024"), dateOf("01-01-2025")},
+		{dateOf("01-03-2025"), dateOf("01-04-2025"), dateOf("01-02-2025"), dateOf("01-03-2025")},
+		{dateOf("10-03-2025"), dateOf("20-03-2025"), dateOf("28-02-2025"), dateOf("10-03-2025")},
+	}
+
+	for _, tt := range tests {
+		t.Run(PeriodLabel(tt.from, tt.until), func(t *testing.T) {
+
+			// When:
+			from, until := PreviousPeriod(tt.from, tt.until)
+
+			// Then:
+			assert.Equal(t, tt.expectedFrom, from)
+			assert.Equal(t, tt.expectedUntil, until)
+		})
+	}
+}
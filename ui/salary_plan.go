@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/w32blaster/tax-bookkeeper/tax"
+)
+
+var salaryPlanColumns = []string{"Salary", "Dividends", "Corp. tax", "Employer NI", "Employee NI", "Income tax", "Dividend tax", "Total tax", "Take home"}
+
+// DrawSalaryPlan shows all the checked salary and dividends combinations, the recommended one is highlighted
+func (t *TerminalUI) DrawSalaryPlan(best tax.SalaryDividendSplit, splits []tax.SalaryDividendSplit) {
+
+	table := tview.NewTable().SetBorders(false).SetFixed(1, 0).SetSelectable(true, false)
+
+	var uLine tcell.Style
+	uLine = uLine.Underline(true)
+
+	for c, header := range salaryPlanColumns {
+		table.SetCell(0, c,
+			tview.NewTableCell(header).
+				SetStyle(uLine).
+				SetTextColor(tcell.ColorWhite).
+				SetAlign(tview.AlignRight))
+	}
+
+	bestRow := 1
+	for r, split := range splits {
+		color := tcell.ColorGrey
+		if split.Salary == best.Salary {
+			color = tcell.ColorGreen
+			bestRow = r + 1
+		}
+
+		amounts := []float64{split.Salary, split.Dividends, split.CorporationTax, split.EmployerNI,
+			split.EmployeeNI, split.IncomeTax, split.DividendTax, split.TotalTax, split.TakeHome}
+		for c, amount := range amounts {
+			table.SetCell(r+1, c,
+				tview.NewTableCell("£"+floatToString(amount)).
+					SetTextColor(color).
+					SetAlign(tview.AlignRight))
+		}
+	}
+	table.Select(bestRow, 0)
+
+	recommendation := fmt.Sprintf("Recommended: salary £%s and dividends £%s, total tax £%s, you take home £%s",
+		floatToString(best.Salary), floatToString(best.Dividends), floatToString(best.TotalTax), floatToString(best.TakeHome))
+
+	flex := tview.NewFlex().SetDirection(tview.FlexRow)
+	flex.SetBorder(true).SetTitle(" Salary and dividends planner ").SetBorderPadding(1, 1, 1, 1)
+	flex.AddItem(tview.NewTextView().SetText(recommendation).SetTextColor(tcell.ColorGreen), 2, 0, false)
+	flex.AddItem(table, 0, 1, true)
+
+	if err := t.app.SetRoot(flex, true).EnableMouse(true).SetFocus(table).Run(); err != nil {
+		panic(err)
+	}
+}
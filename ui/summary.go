@@ -0,0 +1,64 @@
+package ui
+
+import "time"
+
+type (
+	// Summary is the dashboard without the user interface, for scripts and notifications
+	Summary struct {
+		Date                  time.Time         `json:"date"`
+		BankBalance           float64           `json:"bankBalance"` // of the last imported transaction
+		Reserve               SummaryReserve    `json:"reserve"`
+		SafeToWithdraw        float64           `json:"safeToWithdraw"`
+		CorporationTaxSoFar   float64           `json:"corporationTaxSoFar"` // of the current accounting period
+		SelfAssessmentSoFar   float64           `json:"selfAssessmentSoFar"` // of the current tax year
+		DirectorsLoan         float64           `json:"directorsLoan"`       // what the director owes the company
+		LowestForecastBalance float64           `json:"lowestForecastBalance"`
+		LowestForecastDate    time.Time         `json:"lowestForecastDate"`
+		Deadlines             []SummaryDeadline `json:"deadlines"`
+	}
+
+	// SummaryReserve is the money kept on the bank account for the taxes
+	SummaryReserve struct {
+		CorporationTax float64 `json:"corporationTax"`
+		VAT            float64 `json:"vat"`
+		PAYE           float64 `json:"paye"`
+		S455           float64 `json:"s455"`
+		Buffer         float64 `json:"buffer"`
+		Total          float64 `json:"total"`
+	}
+
+	// SummaryDeadline is an upcoming filing or payment
+	SummaryDeadline struct {
+		Date        time.Time `json:"date"`
+		Type        string    `json:"type"`
+		Description string    `json:"description"`
+	}
+)
+
+// NewSummary takes the headline figures of the dashboard
+func NewSummary(data *DashboardData, today time.Time) Summary {
+	deadlines := make([]SummaryDeadline, len(data.Deadlines))
+	for i, dl := range data.Deadlines {
+		deadlines[i] = SummaryDeadline{Date: dl.Date, Type: dl.Type.PrettyString(), Description: dl.Description}
+	}
+
+	return Summary{
+		Date:        today,
+		BankBalance: data.Reserve.BankBalance,
+		Reserve: SummaryReserve{
+			CorporationTax: data.Reserve.CorporationTax,
+			VAT:            data.Reserve.VAT,
+			PAYE:           data.Reserve.PAYE,
+			S455:           data.Reserve.S455,
+			Buffer:         data.Reserve.Buffer,
+			Total:          data.Reserve.Total(),
+		},
+		SafeToWithdraw:        data.Reserve.SafeToWithdraw(),
+		CorporationTaxSoFar:   round(data.CurrentPeriod.CorporateTaxSoFar),
+		SelfAssessmentSoFar:   round(data.CurrentSelfAssessmentPeriod.SelfAssessmentTaxSoFar),
+		DirectorsLoan:         round(data.Loans.LeftForActiveLoan),
+		LowestForecastBalance: data.Forecast.LowestBalance,
+		LowestForecastDate:    data.Forecast.LowestDate,
+		Deadlines:             deadlines,
+	}
+}
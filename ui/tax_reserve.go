@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"math"
+	"time"
+
+	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/tax"
+)
+
+// takes the taxes not due yet from the bank balance of the latest imported transaction. Corporation tax and VAT
+// paid earlier than their due dates are taken off, the payments to HMRC are allocated to the obligations
+func collectTaxReserve(d *db.Database, today time.Time, buffer float64, corporateTaxes []CorporateTax, vats []VAT,
+	loans DirectorLoans, obligations []tax.Obligation) (tax.TaxReserve, error) {
+
+	bank, err := statementBankBalance(d, today.AddDate(0, 0, 1))
+	if err != nil {
+		return tax.TaxReserve{}, err
+	}
+	reserve := tax.TaxReserve{BankBalance: bank, Buffer: buffer}
+
+	for _, ct := range corporateTaxes {
+		if !ct.NextPaymentDate.Before(today) {
+			paid := paidTowards(obligations, tax.CorporationTaxPayment, ct.EndingDate)
+			reserve.CorporationTax = reserve.CorporationTax + math.Max(0, ct.CorporateTaxSoFar-paid)
+		}
+	}
+
+	// under the Annual Accounting Scheme the interim payments already made are taken off
+	for _, vat := range vats {
+		if vat.NextDateYouShouldPayFor.Before(today) {
+			continue
+		}
+		owed := vat.NextVATToBePaidSoFar - paidTowards(obligations, tax.VATReturn, vat.Until)
+		for _, p := range vat.InterimPayments {
+			if !p.IsBalancing && p.DueDate.Before(today) {
+				owed = owed - p.Amount
+			}
+		}
+		reserve.VAT = reserve.VAT + math.Max(0, owed)
+	}
+
+	for _, taxYear := range []string{tax.GetTaxYear(today.AddDate(-1, 0, 0)), tax.GetTaxYear(today)} {
+		payments, err := d.GetPAYEPayments(taxYear)
+		if err != nil {
+			return tax.TaxReserve{}, err
+		}
+		for _, p := range payments {
+			if p.TransactionPk == 0 {
+				reserve.PAYE = reserve.PAYE + p.Total
+			}
+		}
+	}
+
+	for _, s455 := range []tax.S455Charge{loans.PreviousS455, loans.CurrentS455} {
+		if !s455.DueDate.Before(today) {
+			reserve.S455 = reserve.S455 + s455.Tax
+		}
+	}
+
+	reserve.CorporationTax = round(reserve.CorporationTax)
+	reserve.VAT = round(reserve.VAT)
+	reserve.PAYE = round(reserve.PAYE)
+	reserve.S455 = round(reserve.S455)
+	return reserve, nil
+}
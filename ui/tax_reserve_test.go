@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/ledger"
+	"github.com/w32blaster/tax-bookkeeper/tax"
+)
+
+func TestCollectTaxReserve(t *testing.T) {
+
+	// create real DB
+	const dbFile = "/tmp/tax-bookkeeper-ui-tax-reserve.db"
+	d := db.Init(dbFile)
+	defer func() {
+		d.Close()
+		os.Remove(dbFile)
+	}()
+
+	// Populate with data: the corporation tax of the previous period and the VAT of the last quarter are paid
+	// in part before they are due
+	_, err := d.ImportTransactions([]db.Transaction{
+		{Date: dateOf("10-05-2024"), Type: db.Credit, Description: "ACME", Credit: 12000, Category: db.Income},
+		{Date: dateOf("01-08-2024"), Type: db.Debit, Description: "HMRC", Debit: 2000, Category: db.HMRC},
+		{Date: dateOf("10-06-2025"), Type: db.Debit, Description: "HMRC", Debit: 1000, Category: db.HMRC},
+		{Date: dateOf("10-08-2025"), Type: db.Credit, Description: "INV-1", Credit: 2400, Category: db.Income},
+		{Date: dateOf("01-10-2025"), Type: db.Debit, Description: "HMRC", Debit: 150, Category: db.HMRC, Balance: 9000},
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, ledger.InitChartOfAccounts(d))
+
+	transactions, err := d.GetAll(0, 0)
+	assert.Nil(t, err)
+	for _, tx := range transactions {
+		if tx.Description == "INV-1" {
+			invoice := db.Invoice{Type: db.SalesInvoice, Number: "INV-1", IssueDate: tx.Date, Net: 2000, VAT: 400}
+			assert.Nil(t, d.SaveInvoice(&invoice))
+			assert.Nil(t, d.LinkInvoice(invoice.Pk, tx.Pk))
+		}
+	}
+	assert.Nil(t, d.SavePayroll(nil, &db.PAYEPayment{TaxYear: "2025-2026", TaxMonth: 6, Total: 300, DueDate: dateOf("22-10-2025")}))
+
+	today := dateOf("01-10-2025")
+	scheme := tax.VATScheme{Period: tax.QuarterlyReturns, PeriodEndMonth: time.March, Basis: tax.CashAccounting}
+	calendar := tax.CompanyCalendar{AccountingPeriodStart: dateOf("01-04-2025"), VAT: &scheme}
+
+	previousCT, err := collectSummaryCorporateTax(d, &scheme, dateOf("01-04-2024"), dateOf("01-04-2025"))
+	assert.Nil(t, err)
+	currentCT, err := collectSummaryCorporateTax(d, &scheme, dateOf("01-04-2025"), today)
+	assert.Nil(t, err)
+	previousPeriod := scheme.GetPreviousVATPeriod(scheme.GetVATPeriod(today))
+	previousVAT, err := collectSummaryVAT(d, scheme, previousPeriod, previousPeriod.End.AddDate(0, 0, 1), nil)
+	assert.Nil(t, err)
+	obligations, err := collectObligations(d, calendar, tax.EnglandAndNorthernIreland, today.AddDate(-3, 0, 0),
+		today.AddDate(2, 0, 0), today.AddDate(0, 0, 1))
+	assert.Nil(t, err)
+
+	// When:
+	reserve, err := collectTaxReserve(d, today, 500, []CorporateTax{previousCT, currentCT}, []VAT{previousVAT},
+		DirectorLoans{}, obligations)
+
+	// Then: 1900 - 1000 of the previous period and 19% of the profit so far, 400 - 150 of VAT
+	assert.Nil(t, err)
+	assert.Equal(t, tax.TaxReserve{BankBalance: 9000, CorporationTax: 900 + 380, VAT: 250, PAYE: 300, Buffer: 500}, reserve)
+}
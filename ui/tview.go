@@ -4,11 +4,16 @@ import (
 	"fmt"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
+	"github.com/w32blaster/tax-bookkeeper/conf"
 	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/forecast"
+	"github.com/w32blaster/tax-bookkeeper/tax"
 	"log"
+	"math"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type TerminalUI struct {
@@ -65,10 +70,14 @@ func (t *TerminalUI) DrawDashboard(data *DashboardData) {
 	currentVatTable := buildVatReportWidget(&data.CurrentVAT, true)
 
 	vatFlex := buildTwoColumnsWithDescription(" VAT ", previousVatTable, currentVatTable,
-		"Quarterly VAT return dates are due for submission 1 month and 7 days after the of a VAT quarter")
+		getVATDescription(data.CurrentVAT.Period))
 
-	// Director loans
-	loanFlex := renderLoans(data.Loans)
+	// Safe to withdraw on top, then director loans, deadlines and the cash flow forecast
+	loanFlex := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(renderSafeToWithdraw(data.Reserve), 9, 0, false).
+		AddItem(renderLoans(data.Loans), 0, 2, false).
+		AddItem(renderDeadlines(data.Deadlines, data.Penalties), 0, 1, false).
+		AddItem(renderForecast(data.Forecast), 0, 2, false)
 
 	renderRootElementToApl(infoFlex, cpFlex, saFlex, vatFlex, loanFlex, transactionsTable, t)
 }
@@ -84,51 +93,153 @@ func renderLoans(loans DirectorLoans) *tview.Flex {
 	cpFlex := tview.NewFlex().SetDirection(tview.FlexRow)
 	cpFlex.SetBorder(true).SetTitle(" Director's Loans ").SetBorderPadding(1, 1, 1, 1)
 
-	table := buildLoanTable(loans.Transactions)
+	table := buildLoanTable(loans.Ledger)
 	cpFlex.AddItem(table, 0, 1, false)
 
-	if loans.LeftForActiveLoan != 0.0 {
-
-		lbl := fmt.Sprintf("NB!\nRepay the mount £%0.2f\nby %s",
-			-loans.LeftForActiveLoan,
+	var lbl string
+	if loans.LeftForActiveLoan > 0.0 {
+		lbl = fmt.Sprintf("NB!\nRepay the amount £%0.2f\nby %s\n",
+			loans.LeftForActiveLoan,
 			loans.LoanMustBeReturnBy.Format("2 Jan 2006"))
+	}
+
+	for _, charge := range []tax.S455Charge{loans.PreviousS455, loans.CurrentS455} {
+		if charge.Tax > 0 {
+			lbl = lbl + fmt.Sprintf("\ns455 tax £%0.2f (%.2f%% of £%0.2f) for the year ended %s, due %s\n",
+				charge.Tax, charge.Rate*100, charge.Chargeable,
+				charge.PeriodEnd.Format("2 Jan 2006"), charge.DueDate.Format("2 Jan 2006"))
+		}
+		if charge.BedAndBreakfast > 0 {
+			lbl = lbl + fmt.Sprintf("£%0.2f was borrowed again within 30 days and doesn't count as repaid\n",
+				charge.BedAndBreakfast)
+		}
+	}
+
+	if loans.BeneficialLoan.Benefit > 0 {
+		lbl = lbl + fmt.Sprintf("\nBenefit in kind £%0.2f for %s (%.2f%% official rate), Class 1A NI £%0.2f\n",
+			loans.BeneficialLoan.Benefit, loans.BeneficialLoan.TaxYear,
+			loans.BeneficialLoan.OfficialRate*100, loans.BeneficialLoan.Class1ANI)
+	}
 
+	if lbl != "" {
 		cpFlex.AddItem(
 			tview.NewTextView().
 				SetText(lbl).
+				SetWordWrap(true).
 				SetTextColor(tcell.ColorRed), 0, 1, false)
-
 	}
 
 	return cpFlex
 }
 
-func buildLoanTable(tx []db.Transaction) *tview.Table {
-	table := tview.NewTable().SetBorders(true)
-	for i, t := range tx {
+func renderDeadlines(deadlines []tax.Deadline, penalties []tax.PenaltyEstimate) *tview.Flex {
+	flex := tview.NewFlex().SetDirection(tview.FlexRow)
+	flex.SetBorder(true).SetTitle(" Upcoming deadlines ").SetBorderPadding(1, 1, 1, 1)
+
+	table := tview.NewTable().SetBorders(false)
+	soon := time.Now().In(conf.GMT).AddDate(0, 0, 14)
+	for i, dl := range deadlines {
 
 		color := tcell.ColorWhite
-		if t.Type == db.Debit {
-			color = tcell.ColorGrey
+		if dl.Date.Before(soon) {
+			color = tcell.ColorRed
 		}
 
 		table.SetCell(i, 0,
-			tview.NewTableCell(t.Date.Format("2 Jan 06")).
+			tview.NewTableCell(dl.Date.Format("2 Jan 06")).
 				SetTextColor(color).
 				SetAlign(tview.AlignLeft))
 
-		var amount string
-		var label string
-		if t.Type == db.Credit {
-			amount = fmt.Sprintf("£%.02f", t.Credit)
-			label = "Loan return"
-		} else {
-			amount = fmt.Sprintf("£%.02f", t.Debit)
+		table.SetCell(i, 1,
+			tview.NewTableCell(dl.Type.PrettyString()+", "+dl.Description).
+				SetTextColor(color).
+				SetAlign(tview.AlignLeft))
+	}
+
+	// missed deadlines go after the upcoming ones
+	for i, p := range penalties {
+		row := len(deadlines) + i
+		table.SetCell(row, 0,
+			tview.NewTableCell(fmt.Sprintf("£%.2f", p.Total())).
+				SetTextColor(tcell.ColorRed).
+				SetAlign(tview.AlignLeft))
+
+		table.SetCell(row, 1,
+			tview.NewTableCell(fmt.Sprintf("%d days late: %s, %s", p.DaysLate, p.Deadline.Type.PrettyString(), p.Deadline.Description)).
+				SetTextColor(tcell.ColorRed).
+				SetAlign(tview.AlignLeft))
+	}
+
+	flex.AddItem(table, 0, 1, false)
+	return flex
+}
+
+func renderSafeToWithdraw(reserve tax.TaxReserve) *tview.TextView {
+	color := tcell.ColorGreen
+	if reserve.SafeToWithdraw() == 0 {
+		color = tcell.ColorRed
+	}
+
+	text := tview.NewTextView().SetTextColor(color).SetWordWrap(true).
+		SetText(fmt.Sprintf("£%0.2f\n\nBank £%0.2f minus corporation tax £%0.2f, VAT £%0.2f, PAYE £%0.2f, "+
+			"s455 £%0.2f and buffer £%0.2f",
+			reserve.SafeToWithdraw(), reserve.BankBalance, reserve.CorporationTax, reserve.VAT, reserve.PAYE,
+			reserve.S455, reserve.Buffer))
+	text.SetBorder(true).SetTitle(" Safe to withdraw today ").SetBorderPadding(1, 1, 1, 1)
+	return text
+}
+
+func renderForecast(f forecast.Forecast) *tview.Flex {
+	flex := tview.NewFlex().SetDirection(tview.FlexRow)
+	flex.SetBorder(true).SetTitle(" Cash flow forecast ").SetBorderPadding(1, 1, 1, 1)
+
+	// the lowest point goes first, this is what the forecast is for
+	color := tcell.ColorGreen
+	lbl := fmt.Sprintf("Lowest balance £%0.2f on %s", f.LowestBalance, f.LowestDate.Format("2 Jan 2006"))
+	if f.IsShort(0) {
+		color = tcell.ColorRed
+		lbl = "NB! " + lbl + ", the bank account goes short"
+	}
+	flex.AddItem(tview.NewTextView().SetText(lbl).SetWordWrap(true).SetTextColor(color), 2, 0, false)
+
+	table := tview.NewTable().SetBorders(false)
+	for i, header := range []string{"Month", "Taxes", "Closing"} {
+		table.SetCell(0, i, tview.NewTableCell(header).SetTextColor(tcell.ColorYellow).SetAlign(tview.AlignLeft))
+	}
+	for i, m := range f.Months {
+		color := tcell.ColorWhite
+		if m.Lowest < 0 {
+			color = tcell.ColorRed
+		}
+		table.SetCell(i+1, 0, tview.NewTableCell(m.Start.Format("Jan 06")).SetTextColor(color).SetAlign(tview.AlignLeft))
+		table.SetCell(i+1, 1, tview.NewTableCell(floatToString(m.Taxes)).SetTextColor(color).SetAlign(tview.AlignRight))
+		table.SetCell(i+1, 2, tview.NewTableCell(floatToString(m.Closing)).SetTextColor(color).SetAlign(tview.AlignRight))
+	}
+
+	flex.AddItem(table, 0, 1, false)
+	return flex
+}
+
+func buildLoanTable(ledger []tax.DirectorLoanEntry) *tview.Table {
+	table := tview.NewTable().SetBorders(true)
+	for i, e := range ledger {
+
+		color := tcell.ColorWhite
+		label := "Loan return"
+		if e.Amount > 0 {
+			color = tcell.ColorGrey
 			label = "Loan take away"
+		} else if e.BedAndBreakfast > 0 {
+			label = "Loan return (30-day rule)"
 		}
 
+		table.SetCell(i, 0,
+			tview.NewTableCell(e.Date.Format("2 Jan 06")).
+				SetTextColor(color).
+				SetAlign(tview.AlignLeft))
+
 		table.SetCell(i, 1,
-			tview.NewTableCell(amount).
+			tview.NewTableCell(fmt.Sprintf("£%.02f", math.Abs(e.Amount))).
 				SetTextColor(color).
 				SetAlign(tview.AlignLeft))
 
@@ -136,6 +247,11 @@ func buildLoanTable(tx []db.Transaction) *tview.Table {
 			tview.NewTableCell(label).
 				SetTextColor(color).
 				SetAlign(tview.AlignLeft))
+
+		table.SetCell(i, 3,
+			tview.NewTableCell(fmt.Sprintf("£%.02f", e.Balance)).
+				SetTextColor(color).
+				SetAlign(tview.AlignLeft))
 	}
 	return table
 }
@@ -197,9 +313,27 @@ func buildCorporationTaxReportWidget(data *CorporateTax, isFuture bool) *tview.T
 		{"Earned: ", "£" + floatToString(data.EarnedAccountingPeriod), color},
 		{"Expenses: ", "£" + floatToString(data.ExpensesAccountingPeriod), color},
 		{"Pension: ", "£" + floatToString(data.PensionAccountingPeriod), color},
-		{cpLabel, "£" + floatToString(data.CorporateTaxSoFar), "green"},
+		{"Salaries: ", "£" + floatToString(data.SalaryAccountingPeriod), color},
+		{"Depreciation: ", "£" + floatToString(data.Depreciation), color},
+		{"Gain on disposals: ", "£" + floatToString(data.DisposalGains), color},
+		{"Profit before tax: ", "£" + floatToString(data.AccountingProfit), color},
 	}
 
+	// the tax computation
+	for _, a := range data.Computation.AddBacks {
+		labels = append(labels, []string{"  add " + a.Description + ": ", "£" + floatToString(a.Amount), color})
+	}
+	for _, a := range data.Computation.Deductions {
+		labels = append(labels, []string{"  less " + a.Description + ": ", "£" + floatToString(a.Amount), color})
+	}
+	labels = append(labels,
+		[]string{"Taxable profit: ", "£" + floatToString(data.Computation.TaxableProfit), color},
+		[]string{"Losses relieved: ", "£" + floatToString(data.LossesRelieved), color},
+		[]string{"Loss carried forward: ", "£" + floatToString(data.LossCarriedForward), color},
+		[]string{"Carry back repayment: ", "£" + floatToString(data.LossRepayment), color},
+		[]string{cpLabel, "£" + floatToString(data.CorporateTaxSoFar), "green"},
+	)
+
 	cpHeader := "Previous Year Corporate tax"
 	if isFuture {
 		cpHeader = "Current year Corporate tax (not finished) "
@@ -256,12 +390,26 @@ func buildSelfAssessmentTaxReportWidget(data *SelfAssessmentTax, isFuture bool)
 		{"Start dat: ", data.StartingDate.Format("02 January 2006"), color},
 		{"End day: ", data.EndingDate.Format("02 January 2006"), color},
 		{"Payment day: ", data.NextPaymentDate.Format("02 January 2006"), "red"},
+		{"Tax residency: ", data.Residency.PrettyString(), color},
 		{"Moved out from company: ", "£" + floatToString(data.MovedOutFromCompanyTotal), color},
+		{"   salary: ", "£" + floatToString(data.Salary), color},
+		{"   dividends: ", "£" + floatToString(data.Dividends), color},
+		{"Income tax: ", "£" + floatToString(data.IncomeTax), color},
+		{"Dividend tax: ", "£" + floatToString(data.DividendTax), color},
 		{cpLabel, "£" + floatToString(data.SelfAssessmentTaxSoFar), "green"},
-		{"Current tax rate: ", data.TaxRate.PrettyString(), color},
+		{"Current tax rate: ", data.TaxRate.Label(tax.GetTaxYear(data.StartingDate), data.Residency), color},
 		{"Left before the following threshold: ", "£" + floatToString(data.HowMuchBeforeNextThreshold), colorWarning},
 	}
 
+	if len(data.Payments) > 0 {
+		labels = append(labels, []string{"Payments: ", "", color})
+	}
+	for _, p := range data.Payments {
+		labels = append(labels, []string{
+			"   " + p.DueDate.Format("02 Jan 2006") + " " + strings.ToLower(p.Type.PrettyString()) + " " + p.TaxYear + ": ",
+			"£" + floatToString(p.Amount), color})
+	}
+
 	table := tview.NewTable().SetBorders(false)
 
 	var uLine tcell.Style
@@ -299,6 +447,17 @@ func buildSelfAssessmentTaxReportWidget(data *SelfAssessmentTax, isFuture bool)
 	return table
 }
 
+func getVATDescription(period tax.VATReturnPeriod) string {
+	switch period {
+	case tax.MonthlyReturns:
+		return "Monthly VAT returns are due for submission 1 month and 7 days after the end of a month"
+	case tax.AnnualReturns:
+		return "Under the Annual Accounting Scheme you make interim payments during the year, " +
+			"the return and the balancing payment are due 2 months after the end of the VAT year"
+	}
+	return "Quarterly VAT return dates are due for submission 1 month and 7 days after the of a VAT quarter"
+}
+
 func buildVatReportWidget(data *VAT, isFuture bool) *tview.Table {
 
 	color := "grey"
@@ -314,6 +473,8 @@ func buildVatReportWidget(data *VAT, isFuture bool) *tview.Table {
 	}
 
 	labels := [][]string{
+		{"Return period: ", data.Period.PrettyString(), color},
+		{"Accounting basis: ", data.Basis.PrettyString(), color},
 		{"VAT since: ", data.Since.Format("02 January 2006"), color},
 		{"VAT until: ", data.Until.Format("02 January 2006"), color},
 		{submitBy, data.NextMonthSubmit, color},
@@ -321,12 +482,20 @@ func buildVatReportWidget(data *VAT, isFuture bool) *tview.Table {
 		{"Payment deadline: ", data.NextDateYouShouldPayFor.Format("02 January 2006"), "red"},
 	}
 
+	for _, p := range data.InterimPayments {
+		paymentLabel := "Interim payment: "
+		if p.IsBalancing {
+			paymentLabel = "Balancing payment: "
+		}
+		labels = append(labels, []string{paymentLabel, p.DueDate.Format("02 Jan 2006") + " £" + floatToString(p.Amount), color})
+	}
+
 	table := tview.NewTable().SetBorders(false)
 
 	var uLine tcell.Style
 	uLine = uLine.Underline(true)
 
-	cpHeader := "Previous 3 months VAT tax"
+	cpHeader := "Previous period VAT tax"
 	if isFuture {
 		cpHeader = "Current VAT (not finished) "
 	}
@@ -360,45 +529,69 @@ func buildVatReportWidget(data *VAT, isFuture bool) *tview.Table {
 
 // here we attempt to guess and prefill category dropdown list by some words in description,
 // add here as many "common" words so it could be easily to pre-fill dropdown list
-func getInitialOptionByDescription(tx db.Transaction) int {
+func guessCategoryByDescription(tx db.Transaction) db.TransactionCategory {
 	descr := strings.ToLower(tx.Description)
 	if tx.Type == db.Credit {
 		if strings.Contains(descr, "loan") {
-			return db.CreditTransactionUI.GetPositionFor(db.LoansReturn)
+			return db.Loan
 		}
-		return db.CreditTransactionUI.GetPositionFor(db.Income)
+		return db.Income
 
 	} else {
 
-		if strings.Contains(descr, "dividend") {
-			return db.DebitTransactionUI.GetPositionFor(db.Personal)
-		} else if strings.Contains(descr, "salary") {
-			return db.DebitTransactionUI.GetPositionFor(db.Personal)
+		if cat := db.GuessPersonalCategory(descr); cat != db.Personal {
+			return cat
 		} else if strings.Contains(descr, "amznmktplace" /* amazon payment */) {
-			return db.DebitTransactionUI.GetPositionFor(db.EquipmentExpenses)
+			return db.EquipmentExpenses
 		} else if strings.Contains(descr, "amazon" /* amazon payment */) {
-			return db.DebitTransactionUI.GetPositionFor(db.EquipmentExpenses)
+			return db.EquipmentExpenses
 		} else if strings.Contains(descr, "energy") {
-			return db.DebitTransactionUI.GetPositionFor(db.Premises)
+			return db.Premises
 		} else if strings.Contains(descr, "water") {
-			return db.DebitTransactionUI.GetPositionFor(db.Premises)
+			return db.Premises
 		} else if strings.Contains(descr, "forx") {
-			return db.DebitTransactionUI.GetPositionFor(db.BankCharges)
+			return db.BankCharges
 		} else if strings.Contains(descr, "fee") {
-			return db.DebitTransactionUI.GetPositionFor(db.BankCharges)
+			return db.BankCharges
 		} else if strings.Contains(descr, "loan") {
-			return db.DebitTransactionUI.GetPositionFor(db.Loan)
+			return db.Loan
 		} else if strings.Contains(descr, "hmrc") {
-			return db.DebitTransactionUI.GetPositionFor(db.HMRC)
+			return db.HMRC
 		} else if strings.Contains(descr, "pension") {
-			return db.DebitTransactionUI.GetPositionFor(db.Pension)
+			return db.Pension
+		}
+
+		return db.EquipmentExpenses
+	}
+}
+
+// accounts transactions could be allocated to, the groups are left out
+func getPostableAccounts(chart []db.Account) []db.Account {
+	parents := map[string]bool{}
+	for _, a := range chart {
+		parents[a.Parent] = true
+	}
+
+	var accounts []db.Account
+	for _, a := range chart {
+		if !parents[a.Code] {
+			accounts = append(accounts, a)
 		}
+	}
+	return accounts
+}
 
-		return db.DebitTransactionUI.GetPositionFor(db.EquipmentExpenses)
+func getInitialOptionByDescription(tx db.Transaction, accounts []db.Account) int {
+	category := guessCategoryByDescription(tx)
+	for i, a := range accounts {
+		if a.Category == category {
+			return i
+		}
 	}
+	return 0
 }
 
-func (t *TerminalUI) BeginDialogToAllocateTransactions(unallocatedTxs []db.Transaction, fnAllocate FuncAllocateTransactions) {
+func (t *TerminalUI) BeginDialogToAllocateTransactions(unallocatedTxs []db.Transaction, chart []db.Account, fnAllocate FuncAllocateTransactions) {
 
 	sort.Slice(unallocatedTxs, func(i, j int) bool {
 		return unallocatedTxs[i].Date.After(unallocatedTxs[j].Date)
@@ -406,22 +599,24 @@ func (t *TerminalUI) BeginDialogToAllocateTransactions(unallocatedTxs []db.Trans
 
 	form := tview.NewForm()
 
+	accounts := getPostableAccounts(chart)
+	labels := make([]string, len(accounts))
+	for i, a := range accounts {
+		labels[i] = a.Code + " " + a.Name
+	}
+
 	// populate dropdown list
-	mapSelectedOptions := make(map[int]db.TransactionCategory)
+	mapSelectedOptions := make(map[int]string)
 	for idx, tx := range unallocatedTxs {
+		tx := tx
+		amount := tx.Debit
 		if tx.Type == db.Credit {
-			rowText := fmt.Sprintf("%d) %.2f (%s) - %s", idx, tx.Credit, tx.Date.Format("02 Jan 06"), tx.Description)
-			form.AddDropDown(rowText, db.CreditTransactionUI.GetLabels(), getInitialOptionByDescription(tx),
-				func(option string, optionIndex int) {
-					mapSelectedOptions[tx.Pk] = db.TransactionCreditLabelMap[option]
-				},
-			)
-		} else {
-			rowText := fmt.Sprintf("%d) %.2f (%s) - %s", idx, tx.Debit, tx.Date.Format("02 Jan 06"), tx.Description)
-			form.AddDropDown(rowText, db.DebitTransactionUI.GetLabels(), getInitialOptionByDescription(tx), func(option string, optionIndex int) {
-				mapSelectedOptions[tx.Pk] = db.TransactionDebitLabelMap[option]
-			})
+			amount = tx.Credit
 		}
+		rowText := fmt.Sprintf("%d) %.2f (%s) - %s", idx, amount, tx.Date.Format("02 Jan 06"), tx.Description)
+		form.AddDropDown(rowText, labels, getInitialOptionByDescription(tx, accounts), func(option string, optionIndex int) {
+			mapSelectedOptions[tx.Pk] = accounts[optionIndex].Code
+		})
 	}
 
 	// button "Save" with callback
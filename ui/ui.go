@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/w32blaster/tax-bookkeeper/db"
+	"github.com/w32blaster/tax-bookkeeper/forecast"
 	"github.com/w32blaster/tax-bookkeeper/tax"
 )
 
@@ -18,14 +19,28 @@ type (
 		EarnedAccountingPeriod   float64
 		ExpensesAccountingPeriod float64
 		PensionAccountingPeriod  float64
+		SalaryAccountingPeriod   float64
+		Depreciation             float64
+		DisposalGains            float64 // negative is a loss
+		AccountingProfit         float64 // profit before tax in the accounts
+		Computation              tax.TaxComputation
+		LossesRelieved           float64 // trading losses of other years set against this profit
+		LossCarriedForward       float64
+		LossRepayment            float64 // tax repaid for the previous year when the loss is carried back
+		LossRelief               tax.LossRelief
 	}
 
-	// TODO: Salary, dividends?
 	SelfAssessmentTax struct {
 		StartingDate             time.Time
 		EndingDate               time.Time
 		NextPaymentDate          time.Time
+		Residency                tax.Residency
+		Payments                 []tax.SelfAssessmentPayment
 		MovedOutFromCompanyTotal float64
+		Salary                   float64
+		Dividends                float64
+		IncomeTax                float64
+		DividendTax              float64
 		SelfAssessmentTaxSoFar   float64
 		TaxRate                  tax.Rate
 		// warning:
@@ -35,17 +50,23 @@ type (
 	}
 
 	VAT struct {
+		Basis                   tax.VATAccountingBasis
+		Period                  tax.VATReturnPeriod
 		Since                   time.Time
 		Until                   time.Time
 		NextVATToBePaidSoFar    float64
 		NextDateYouShouldPayFor time.Time
 		NextMonthSubmit         string
+		InterimPayments         []tax.VATInterimPayment // only for the Annual Accounting Scheme
 	}
 
 	DirectorLoans struct {
-		Transactions       []db.Transaction
+		Ledger             []tax.DirectorLoanEntry
 		LeftForActiveLoan  float64
 		LoanMustBeReturnBy time.Time
+		PreviousS455       tax.S455Charge
+		CurrentS455        tax.S455Charge
+		BeneficialLoan     tax.BeneficialLoanInterest
 	}
 
 	FnLoadTransactions func(limit, page int) []db.Transaction
@@ -60,17 +81,21 @@ type (
 		PreviousVAT                  VAT
 		CurrentVAT                   VAT
 		Loans                        DirectorLoans
+		Deadlines                    []tax.Deadline        // upcoming in the next few months
+		Penalties                    []tax.PenaltyEstimate // for the missed deadlines
+		Forecast                     forecast.Forecast     // the bank balance in the next months
+		Reserve                      tax.TaxReserve        // taxes to keep on the bank account, and what is safe to withdraw
 	}
 )
 
-// callback function that will be fired on the Save button clicking
-type FuncAllocateTransactions func(txToAllocate map[int]db.TransactionCategory) error
+// callback function that will be fired on the Save button clicking, it gets account codes by transaction ID
+type FuncAllocateTransactions func(txToAllocate map[int]string) error
 
 // UI is a common interface for an GUI. At this moment we have only terminal UI,
 // but if in the future we will need to do another UI, it would be easy possible
 // to do by implementing this interface
 type UI interface {
 	Start()
-	BeginDialogToAllocateTransactions(unallocatedTxs []db.Transaction, fnAllocate FuncAllocateTransactions)
+	BeginDialogToAllocateTransactions(unallocatedTxs []db.Transaction, chart []db.Account, fnAllocate FuncAllocateTransactions)
 	ShowDashboard(data DashboardData)
 }